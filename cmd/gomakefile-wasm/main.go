@@ -0,0 +1,199 @@
+// Copyright (c) 2023 Tiago Melo. All rights reserved.
+// Use of this source code is governed by the MIT License that can be found in
+// the LICENSE file.
+
+//go:build js && wasm
+
+// Command gomakefile-wasm exposes the generator to JavaScript when built to
+// WebAssembly, so that browser-based playgrounds and developer-platform
+// portals can run it client-side:
+//
+//	GOOS=js GOARCH=wasm go build -o gomakefile.wasm ./cmd/gomakefile-wasm
+//
+// Once loaded with the wasm_exec.js of the Go distribution, it sets the
+// gomakefile global, whose functions take and return the objects the
+// endpoints of the HTTP API of gomakefile serve take and return, working
+// on the Makefile they hold, in memory:
+//
+//	gomakefile.generate({spec: {flavor: "full", presets: ["docker"]}})
+//	gomakefile.addTarget({makefile: "...", target: "deploy", content: "..."})
+//	gomakefile.lint({makefile: "...", dialect: "gnu"})
+//	gomakefile.export({makefile: "...", format: "taskfile"})
+//
+// Failures are returned as {error: "..."}, rather than thrown.
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+	"syscall/js"
+
+	"github.com/tiagomelo/go-makefile-gen/mfile"
+)
+
+func main() {
+	js.Global().Set("gomakefile", js.ValueOf(map[string]any{
+		"generate":  binding(generate),
+		"addTarget": binding(addTarget),
+		"lint":      binding(lint),
+		"export":    binding(export),
+	}))
+	// The functions are called from JavaScript once main returned, so it
+	// must not.
+	select {}
+}
+
+// binding returns the JavaScript function decoding the request object it
+// is called with and calling call with it. The result of call is returned
+// as an object, and its error as an errorResult.
+func binding[Request any](call func(req *Request) (any, error)) js.Func {
+	return js.FuncOf(func(this js.Value, args []js.Value) any {
+		var res any
+		req, err := decodeRequest[Request](args)
+		if err == nil {
+			res, err = call(req)
+		}
+		if err != nil {
+			res = errorResult{Error: err.Error()}
+		}
+		out, err := json.Marshal(res)
+		if err != nil {
+			out, _ = json.Marshal(errorResult{Error: err.Error()})
+		}
+		return js.Global().Get("JSON").Call("parse", string(out))
+	})
+}
+
+// decodeRequest decodes the request object the given arguments start with.
+func decodeRequest[Request any](args []js.Value) (*Request, error) {
+	if len(args) == 0 || args[0].Type() != js.TypeObject {
+		return nil, errors.New("decoding request: the request must be an object")
+	}
+	req := new(Request)
+	dec := json.NewDecoder(strings.NewReader(js.Global().Get("JSON").Call("stringify", args[0]).String()))
+	dec.DisallowUnknownFields()
+	if err := dec.Decode(req); err != nil {
+		return nil, fmt.Errorf("decoding request: %w", err)
+	}
+	return req, nil
+}
+
+// errorResult is the result of a failed call.
+type errorResult struct {
+	Error string `json:"error"`
+}
+
+// makefileResult is the result of the functions changing a Makefile.
+type makefileResult struct {
+	Makefile string `json:"makefile"`
+}
+
+// generateRequest is the request of gomakefile.generate.
+type generateRequest struct {
+	// Spec declares how the Makefile is generated, with the keys of the
+	// spec files, like {"flavor": "full", "presets": ["docker"]}.
+	Spec json.RawMessage `json:"spec"`
+}
+
+// generate generates a Makefile from the spec of the given request.
+func generate(req *generateRequest) (any, error) {
+	var spec mfile.Spec
+	if len(req.Spec) > 0 {
+		s, err := mfile.ParseSpec(req.Spec)
+		if err != nil {
+			return nil, err
+		}
+		spec = *s
+	}
+	content, err := mfile.GenerateContent(spec.Options()...)
+	if err != nil {
+		return nil, err
+	}
+	return makefileResult{Makefile: content}, nil
+}
+
+// addTargetRequest is the request of gomakefile.addTarget.
+type addTargetRequest struct {
+	Makefile     string   `json:"makefile"`
+	Target       string   `json:"target"`
+	Content      string   `json:"content"`
+	Dependencies []string `json:"dependencies"`
+	Aliases      []string `json:"aliases"`
+	Namespace    string   `json:"namespace"`
+	Replace      bool     `json:"replace"`
+}
+
+// addTarget adds the target of the given request to its Makefile.
+func addTarget(req *addTargetRequest) (any, error) {
+	policy := mfile.ErrorIfExists
+	if req.Replace {
+		policy = mfile.ReplaceIfExists
+	}
+	content, err := mfile.AddTargetContent(req.Makefile, req.Target,
+		mfile.WithContent(req.Content),
+		mfile.WithDependencies(req.Dependencies...),
+		mfile.WithAliases(req.Aliases...),
+		mfile.WithNamespace(req.Namespace),
+		mfile.WithExistsPolicy(policy),
+	)
+	if err != nil {
+		return nil, err
+	}
+	return makefileResult{Makefile: content}, nil
+}
+
+// lintRequest is the request of gomakefile.lint.
+type lintRequest struct {
+	Makefile string `json:"makefile"`
+	Dialect  string `json:"dialect"` // Defaults to gnu.
+}
+
+// lintResult is the result of gomakefile.lint.
+type lintResult struct {
+	Issues       []string `json:"issues"`
+	RequiredMake string   `json:"requiredMake,omitempty"`
+}
+
+// lint checks the Makefile of the given request for common mistakes.
+func lint(req *lintRequest) (any, error) {
+	if req.Dialect == "" {
+		req.Dialect = mfile.DialectGNU
+	}
+	issues, err := mfile.LintContent(req.Makefile, req.Dialect)
+	if err != nil {
+		return nil, err
+	}
+	r := lintResult{Issues: append([]string{}, issues...)}
+	if req.Dialect == mfile.DialectGNU {
+		requirements, err := mfile.MakeRequirementsContent(req.Makefile)
+		if err != nil {
+			return nil, err
+		}
+		r.RequiredMake = mfile.RequiredMakeVersion(requirements)
+	}
+	return r, nil
+}
+
+// exportRequest is the request of gomakefile.export.
+type exportRequest struct {
+	Makefile string `json:"makefile"`
+	Format   string `json:"format"`
+}
+
+// exportResult is the result of gomakefile.export.
+type exportResult struct {
+	Format  string `json:"format"`
+	Content string `json:"content"`
+}
+
+// export converts the Makefile of the given request into the format it
+// selects.
+func export(req *exportRequest) (any, error) {
+	content, err := mfile.ExportContent(req.Makefile, req.Format)
+	if err != nil {
+		return nil, err
+	}
+	return exportResult{Format: req.Format, Content: string(content)}, nil
+}
@@ -0,0 +1,69 @@
+// Copyright (c) 2023 Tiago Melo. All rights reserved.
+// Use of this source code is governed by the MIT License that can be found in
+// the LICENSE file.
+
+package main
+
+import (
+	"os"
+	"os/user"
+	"strconv"
+	"strings"
+
+	"github.com/jessevdk/go-flags"
+	"github.com/tiagomelo/go-makefile-gen/mfile"
+)
+
+// executeAudited executes the given command and, if it modifies the
+// Makefile, records the change in the audit log next to it, see
+// mfile.RecordChange. Changes are recorded with audit, which creates the
+// log, or once the log exists. The undo command records itself, see
+// mfile.Undo.
+func executeAudited(command flags.Commander, args []string, audit bool) error {
+	if _, ok := command.(*UndoCommand); ok {
+		return command.Execute(args)
+	}
+	modifier, ok := command.(makefileModifier)
+	if !ok || modifier.makefilePath() == "" {
+		if audit {
+			return &flags.Error{Type: flags.ErrInvalidChoice, Message: "--audit is only supported by the commands modifying the Makefile"}
+		}
+		return command.Execute(args)
+	}
+	makefile, err := makefileFile(modifier.makefilePath())
+	if err != nil {
+		return err
+	}
+	if !audit && !mfile.HistoryEnabled(makefile) {
+		return command.Execute(args)
+	}
+	before, err := os.ReadFile(makefile)
+	if err != nil && !os.IsNotExist(err) {
+		return err
+	}
+	if err := command.Execute(args); err != nil {
+		return err
+	}
+	return mfile.RecordChange(makefile, before, mfile.HistoryEntry{Command: commandLine(), User: userName()})
+}
+
+// commandLine returns the command line gomakefile was run with, its
+// arguments holding spaces being quoted.
+func commandLine() string {
+	words := []string{"gomakefile"}
+	for _, arg := range os.Args[1:] {
+		if arg == "" || strings.ContainsAny(arg, " \t\n\"'") {
+			arg = strconv.Quote(arg)
+		}
+		words = append(words, arg)
+	}
+	return strings.Join(words, " ")
+}
+
+// userName returns the name of the user running gomakefile, if known.
+func userName() string {
+	if u, err := user.Current(); err == nil {
+		return u.Username
+	}
+	return os.Getenv("USER")
+}
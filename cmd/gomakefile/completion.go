@@ -0,0 +1,64 @@
+// Copyright (c) 2023 Tiago Melo. All rights reserved.
+// Use of this source code is governed by the MIT License that can be found in
+// the LICENSE file.
+
+package main
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/tiagomelo/go-makefile-gen/mfile"
+)
+
+// CompletionCommand is used to generate a shell completion script for make targets
+type CompletionCommand struct {
+	Shell        string `short:"s" long:"shell" description:"Shell to generate the completion script for" choice:"bash" choice:"zsh" default:"bash"`
+	OutputFile   string `short:"f" long:"file" description:"Write the completion script to this file instead of stdout"`
+	MakefilePath string `short:"p" long:"path" description:"Path to the Makefile" default:"."`
+	AddTarget    bool   `long:"add-target" description:"Add a completions target to the Makefile instead, regenerating the bash and zsh scripts into $(COMPLETIONS_DIR)"`
+}
+
+// Execute is the method invoked for the completion command
+func (c *CompletionCommand) Execute(args []string) error {
+	if c.AddTarget {
+		if err := mfile.AddCompletionsTarget(c.MakefilePath); err != nil {
+			return err
+		}
+		makefile, err := makefileFile(c.MakefilePath)
+		if err != nil {
+			return err
+		}
+		return report(addTargetResult{Target: "completions", Path: makefile})
+	}
+	script, err := mfile.Completion(c.MakefilePath, c.Shell)
+	if err != nil {
+		return err
+	}
+	if c.OutputFile == "" {
+		if opts.Output == outputJSON {
+			return report(completionResult{Shell: c.Shell, Script: script})
+		}
+		fmt.Print(script)
+		return nil
+	}
+	if err := os.WriteFile(c.OutputFile, []byte(script), 0644); err != nil {
+		return err
+	}
+	absPath, err := absPath(c.OutputFile)
+	if err != nil {
+		return err
+	}
+	return report(completionResult{Shell: c.Shell, Path: absPath})
+}
+
+// completionResult is the outcome of the completion command.
+type completionResult struct {
+	Shell  string `json:"shell"`
+	Path   string `json:"path,omitempty"`
+	Script string `json:"script,omitempty"`
+}
+
+func (r completionResult) text() string {
+	return fmt.Sprintf("Completion script was generated successfully at %s", r.Path)
+}
@@ -0,0 +1,57 @@
+// Copyright (c) 2023 Tiago Melo. All rights reserved.
+// Use of this source code is governed by the MIT License that can be found in
+// the LICENSE file.
+
+package main
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/tiagomelo/go-makefile-gen/mfile"
+)
+
+// DedupeCommand is used to remove the repeated declarations of a Makefile
+type DedupeCommand struct {
+	MakefilePath string `short:"p" long:"path" description:"Path to the Makefile" default:"."`
+}
+
+// Execute is the method invoked for the dedupe command
+func (d *DedupeCommand) Execute(args []string) error {
+	duplicates, err := mfile.Dedupe(d.MakefilePath)
+	if err != nil {
+		return err
+	}
+	r := dedupeResult{Removed: make([]duplicate, len(duplicates))}
+	for i, dup := range duplicates {
+		r.Removed[i] = duplicate{Target: dup.Target, Phony: dup.Phony, Line: dup.Line}
+	}
+	return show(r)
+}
+
+// duplicate is a removed declaration.
+type duplicate struct {
+	Target string `json:"target"`
+	Phony  bool   `json:"phony"`
+	Line   int    `json:"line"`
+}
+
+// dedupeResult is the outcome of the dedupe command.
+type dedupeResult struct {
+	Removed []duplicate `json:"removed"`
+}
+
+func (r dedupeResult) text() string {
+	if len(r.Removed) == 0 {
+		return "no duplicate found"
+	}
+	lines := make([]string, len(r.Removed))
+	for i, d := range r.Removed {
+		if d.Phony {
+			lines[i] = fmt.Sprintf("line %d: removed repeated .PHONY declaration of %s", d.Line, d.Target)
+		} else {
+			lines[i] = fmt.Sprintf("line %d: removed duplicate rule of %s", d.Line, d.Target)
+		}
+	}
+	return strings.Join(lines, "\n")
+}
@@ -0,0 +1,47 @@
+// Copyright (c) 2023 Tiago Melo. All rights reserved.
+// Use of this source code is governed by the MIT License that can be found in
+// the LICENSE file.
+
+package main
+
+import (
+	"fmt"
+
+	"github.com/tiagomelo/go-makefile-gen/mfile"
+)
+
+// DescribeCommand is used to set the description of a target
+type DescribeCommand struct {
+	MakefilePath string `short:"p" long:"path" description:"Path to the Makefile" default:"."`
+	Args         struct {
+		Target      string `positional-arg-name:"target" description:"Target to describe"`
+		Description string `positional-arg-name:"description" description:"Description listed by help"`
+	} `positional-args:"yes" required:"yes"`
+}
+
+// Execute is the method invoked for the describe command
+func (d *DescribeCommand) Execute(args []string) error {
+	if err := mfile.SetTargetDescription(d.MakefilePath, d.Args.Target, d.Args.Description); err != nil {
+		return err
+	}
+	makefile, err := makefileFile(d.MakefilePath)
+	if err != nil {
+		return err
+	}
+	return report(describeResult{
+		Target:      d.Args.Target,
+		Description: d.Args.Description,
+		Path:        makefile,
+	})
+}
+
+// describeResult is the outcome of the describe command.
+type describeResult struct {
+	Target      string `json:"target"`
+	Description string `json:"description"`
+	Path        string `json:"path"`
+}
+
+func (r describeResult) text() string {
+	return fmt.Sprintf("Description of target %s was successfully set in %s", r.Target, r.Path)
+}
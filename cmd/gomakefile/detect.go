@@ -0,0 +1,57 @@
+// Copyright (c) 2023 Tiago Melo. All rights reserved.
+// Use of this source code is governed by the MIT License that can be found in
+// the LICENSE file.
+
+package main
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/tiagomelo/go-makefile-gen/mfile"
+)
+
+// DetectCommand is used to propose presets for a project
+type DetectCommand struct {
+	ProjectPath string `short:"p" long:"path" description:"Path to the project" default:"."`
+}
+
+// Execute is the method invoked for the detect command
+func (d *DetectCommand) Execute(args []string) error {
+	detections, err := mfile.Detect(d.ProjectPath)
+	if err != nil {
+		return err
+	}
+	r := detectResult{Detections: []detectionResult{}}
+	for _, det := range detections {
+		r.Detections = append(r.Detections, detectionResult(det))
+	}
+	return show(r)
+}
+
+// detectionResult describes a preset proposed by the detect command.
+type detectionResult struct {
+	Preset     string            `json:"preset"`
+	Reason     string            `json:"reason"`
+	Parameters map[string]string `json:"parameters,omitempty"`
+}
+
+// detectResult is the outcome of the detect command.
+type detectResult struct {
+	Detections []detectionResult `json:"detections"`
+}
+
+func (r detectResult) text() string {
+	if len(r.Detections) == 0 {
+		return "no preset detected"
+	}
+	var sb strings.Builder
+	for _, d := range r.Detections {
+		fmt.Fprintf(&sb, "%-12s %s", d.Preset, d.Reason)
+		for name, value := range d.Parameters {
+			fmt.Fprintf(&sb, " (--param %s=%s)", name, value)
+		}
+		sb.WriteString("\n")
+	}
+	return strings.TrimSuffix(sb.String(), "\n")
+}
@@ -0,0 +1,186 @@
+// Copyright (c) 2023 Tiago Melo. All rights reserved.
+// Use of this source code is governed by the MIT License that can be found in
+// the LICENSE file.
+
+package main
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/tiagomelo/go-makefile-gen/mfile"
+)
+
+// DiffCommand is used to compare the targets and variables of two Makefiles
+type DiffCommand struct {
+	Args struct {
+		Old string `positional-arg-name:"old" description:"Path to the old Makefile"`
+		New string `positional-arg-name:"new" description:"Path to the new Makefile"`
+	} `positional-args:"yes" required:"yes"`
+}
+
+// Execute is the method invoked for the diff command
+func (d *DiffCommand) Execute(args []string) error {
+	diff, err := mfile.Diff(d.Args.Old, d.Args.New)
+	if err != nil {
+		return err
+	}
+	r := diffResult{
+		AddedTargets:     []targetResult{},
+		RemovedTargets:   []targetResult{},
+		ChangedTargets:   []targetChangeResult{},
+		AddedVariables:   []variableResult{},
+		RemovedVariables: []variableResult{},
+		ChangedVariables: []variableChangeResult{},
+	}
+	for _, t := range diff.AddedTargets {
+		r.AddedTargets = append(r.AddedTargets, newTargetResult(t))
+	}
+	for _, t := range diff.RemovedTargets {
+		r.RemovedTargets = append(r.RemovedTargets, newTargetResult(t))
+	}
+	for _, c := range diff.ChangedTargets {
+		tc := targetChangeResult{
+			Name:                c.New.Name,
+			AddedDependencies:   c.AddedDependencies,
+			RemovedDependencies: c.RemovedDependencies,
+		}
+		if c.DescriptionChanged {
+			tc.Description = &change{Old: c.Old.Description, New: c.New.Description}
+		}
+		if c.RecipeChanged {
+			tc.Recipe = &change{Old: strings.Join(c.Old.Recipe, "\n"), New: strings.Join(c.New.Recipe, "\n")}
+		}
+		if c.PhonyChanged {
+			tc.Phony = &c.New.Phony
+		}
+		r.ChangedTargets = append(r.ChangedTargets, tc)
+	}
+	for _, v := range diff.AddedVariables {
+		r.AddedVariables = append(r.AddedVariables, newVariableResult(v))
+	}
+	for _, v := range diff.RemovedVariables {
+		r.RemovedVariables = append(r.RemovedVariables, newVariableResult(v))
+	}
+	for _, c := range diff.ChangedVariables {
+		r.ChangedVariables = append(r.ChangedVariables, variableChangeResult{Name: c.New.Name, Old: newVariableResult(c.Old), New: newVariableResult(c.New)})
+	}
+	if err := show(r); err != nil {
+		return err
+	}
+	if !diff.Empty() {
+		return errors.New("Makefiles differ")
+	}
+	return nil
+}
+
+// targetResult is a target added or removed.
+type targetResult struct {
+	Name         string   `json:"name"`
+	Description  string   `json:"description,omitempty"`
+	Dependencies []string `json:"dependencies,omitempty"`
+}
+
+func newTargetResult(t mfile.Target) targetResult {
+	return targetResult{Name: t.Name, Description: t.Description, Dependencies: t.Dependencies}
+}
+
+// change is the old and new values of a changed field.
+type change struct {
+	Old string `json:"old"`
+	New string `json:"new"`
+}
+
+// targetChangeResult is a target declared differently.
+type targetChangeResult struct {
+	Name                string   `json:"name"`
+	AddedDependencies   []string `json:"addedDependencies,omitempty"`
+	RemovedDependencies []string `json:"removedDependencies,omitempty"`
+	Description         *change  `json:"description,omitempty"`
+	Recipe              *change  `json:"recipe,omitempty"`
+	Phony               *bool    `json:"phony,omitempty"`
+}
+
+// variableResult is a variable assignment.
+type variableResult struct {
+	Name     string `json:"name"`
+	Operator string `json:"operator"`
+	Value    string `json:"value"`
+	Export   bool   `json:"export,omitempty"`
+}
+
+func newVariableResult(v mfile.Variable) variableResult {
+	return variableResult{Name: v.Name, Operator: v.Operator, Value: v.Value, Export: v.Export}
+}
+
+func (v variableResult) String() string {
+	s := v.Name + " " + v.Operator + " " + v.Value
+	if v.Export {
+		s = "export " + s
+	}
+	return strings.TrimSpace(s)
+}
+
+// variableChangeResult is a variable assigned differently.
+type variableChangeResult struct {
+	Name string         `json:"name"`
+	Old  variableResult `json:"old"`
+	New  variableResult `json:"new"`
+}
+
+// diffResult is the outcome of the diff command.
+type diffResult struct {
+	AddedTargets     []targetResult         `json:"addedTargets"`
+	RemovedTargets   []targetResult         `json:"removedTargets"`
+	ChangedTargets   []targetChangeResult   `json:"changedTargets"`
+	AddedVariables   []variableResult       `json:"addedVariables"`
+	RemovedVariables []variableResult       `json:"removedVariables"`
+	ChangedVariables []variableChangeResult `json:"changedVariables"`
+}
+
+func (r diffResult) text() string {
+	var lines []string
+	for _, v := range r.RemovedVariables {
+		lines = append(lines, "- variable "+v.String())
+	}
+	for _, v := range r.AddedVariables {
+		lines = append(lines, "+ variable "+v.String())
+	}
+	for _, c := range r.ChangedVariables {
+		lines = append(lines, fmt.Sprintf("~ variable %s: %s -> %s", c.Name, c.Old, c.New))
+	}
+	for _, t := range r.RemovedTargets {
+		lines = append(lines, "- target "+t.Name)
+	}
+	for _, t := range r.AddedTargets {
+		lines = append(lines, "+ target "+t.Name)
+	}
+	for _, c := range r.ChangedTargets {
+		var changes []string
+		if len(c.AddedDependencies) > 0 || len(c.RemovedDependencies) > 0 {
+			var deps []string
+			for _, d := range c.AddedDependencies {
+				deps = append(deps, "+"+d)
+			}
+			for _, d := range c.RemovedDependencies {
+				deps = append(deps, "-"+d)
+			}
+			changes = append(changes, "dependencies "+strings.Join(deps, " "))
+		}
+		if c.Description != nil {
+			changes = append(changes, fmt.Sprintf("description %q -> %q", c.Description.Old, c.Description.New))
+		}
+		if c.Recipe != nil {
+			changes = append(changes, "recipe changed")
+		}
+		if c.Phony != nil {
+			changes = append(changes, fmt.Sprintf("phony -> %t", *c.Phony))
+		}
+		lines = append(lines, fmt.Sprintf("~ target %s: %s", c.Name, strings.Join(changes, "; ")))
+	}
+	if len(lines) == 0 {
+		return "no difference found"
+	}
+	return strings.Join(lines, "\n")
+}
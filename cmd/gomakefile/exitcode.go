@@ -0,0 +1,61 @@
+// Copyright (c) 2023 Tiago Melo. All rights reserved.
+// Use of this source code is governed by the MIT License that can be found in
+// the LICENSE file.
+
+package main
+
+import (
+	"errors"
+
+	"github.com/jessevdk/go-flags"
+	"github.com/tiagomelo/go-makefile-gen/mfile"
+)
+
+// Exit codes returned by gomakefile, so that scripts can branch
+// on failure causes.
+const (
+	exitOK                = 0
+	exitFailure           = 1 // Any failure not listed below.
+	exitUsage             = 2 // Invalid command-line usage.
+	exitMakefileNotFound  = 3
+	exitTargetExists      = 4
+	exitInvalidTargetName = 5
+	exitNotAMakefile      = 6
+	exitSnippetNotFound   = 7
+	exitTargetNotFound    = 8
+	exitReservedTarget    = 9
+)
+
+// exitCodes maps the mfile sentinel errors to exit codes.
+var exitCodes = []struct {
+	err  error
+	code int
+}{
+	{mfile.ErrMakefileNotFound, exitMakefileNotFound},
+	{mfile.ErrTargetExists, exitTargetExists},
+	{mfile.ErrInvalidTargetName, exitInvalidTargetName},
+	{mfile.ErrNotAMakefile, exitNotAMakefile},
+	{mfile.ErrSnippetNotFound, exitSnippetNotFound},
+	{mfile.ErrTargetNotFound, exitTargetNotFound},
+	{mfile.ErrReservedTarget, exitReservedTarget},
+}
+
+// exitCode returns the exit code for the given error.
+func exitCode(err error) int {
+	if err == nil {
+		return exitOK
+	}
+	var flagsErr *flags.Error
+	if errors.As(err, &flagsErr) {
+		if flagsErr.Type == flags.ErrHelp {
+			return exitOK
+		}
+		return exitUsage
+	}
+	for _, ec := range exitCodes {
+		if errors.Is(err, ec.err) {
+			return ec.code
+		}
+	}
+	return exitFailure
+}
@@ -0,0 +1,128 @@
+// Copyright (c) 2023 Tiago Melo. All rights reserved.
+// Use of this source code is governed by the MIT License that can be found in
+// the LICENSE file.
+
+package main
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/tiagomelo/go-makefile-gen/mfile"
+	"github.com/tiagomelo/go-makefile-gen/mfile/run"
+)
+
+// ExplainCommand is used to explain what running a target does
+type ExplainCommand struct {
+	MakefilePath string `short:"p" long:"path" description:"Path to the Makefile" default:"."`
+	DryRun       bool   `short:"n" long:"dry-run" description:"List the commands printed by make -n, with variables expanded, instead of the ones read from the Makefile"`
+	Args         struct {
+		Target string `positional-arg-name:"target" description:"Target to explain"`
+	} `positional-args:"yes" required:"yes"`
+}
+
+// Execute is the method invoked for the explain command
+func (e *ExplainCommand) Execute(args []string) error {
+	explanation, err := mfile.Explain(e.MakefilePath, e.Args.Target)
+	if err != nil {
+		return err
+	}
+	commands := explanation.Commands()
+	if e.DryRun {
+		if commands, err = dryRun(e.MakefilePath, e.Args.Target); err != nil {
+			return err
+		}
+	}
+	return show(explainResult{
+		Target:       e.Args.Target,
+		Description:  explanation.Description,
+		Dependencies: dependencyNodes(explanation.Dependencies),
+		Commands:     append([]string{}, commands...),
+	})
+}
+
+// dryRun returns the commands make -n prints for the given target.
+func dryRun(makefilePath, target string) ([]string, error) {
+	result, err := run.Target(context.Background(), makefilePath, target, run.WithArgs("-n"))
+	if err != nil {
+		return nil, err
+	}
+	if result.ExitCode != 0 {
+		return nil, fmt.Errorf("make -n %s failed: %s", target, strings.TrimSpace(string(result.Stderr)))
+	}
+	var commands []string
+	for _, line := range strings.Split(string(result.Stdout), "\n") {
+		if strings.TrimSpace(line) != "" {
+			commands = append(commands, line)
+		}
+	}
+	return commands, nil
+}
+
+// dependencyNode is a prerequisite in the dependency chain of the
+// explained target.
+type dependencyNode struct {
+	Name         string           `json:"name"`
+	Description  string           `json:"description,omitempty"`
+	File         bool             `json:"file,omitempty"`
+	Dependencies []dependencyNode `json:"dependencies,omitempty"`
+}
+
+// dependencyNodes returns the nodes of the given explanations.
+func dependencyNodes(explanations []*mfile.Explanation) []dependencyNode {
+	var nodes []dependencyNode
+	for _, e := range explanations {
+		nodes = append(nodes, dependencyNode{
+			Name:         e.Name,
+			Description:  e.Description,
+			File:         !e.Declared,
+			Dependencies: dependencyNodes(e.Dependencies),
+		})
+	}
+	return nodes
+}
+
+// explainResult is the outcome of the explain command.
+type explainResult struct {
+	Target       string           `json:"target"`
+	Description  string           `json:"description,omitempty"`
+	Dependencies []dependencyNode `json:"dependencies"`
+	Commands     []string         `json:"commands"`
+}
+
+func (r explainResult) text() string {
+	var sb strings.Builder
+	sb.WriteString(r.Target)
+	if r.Description != "" {
+		sb.WriteString(": " + r.Description)
+	}
+	sb.WriteString("\n\nDependencies:\n")
+	if len(r.Dependencies) == 0 {
+		sb.WriteString("  none\n")
+	}
+	writeDependencies(&sb, r.Dependencies, "  ")
+	sb.WriteString("\nCommands:\n")
+	if len(r.Commands) == 0 {
+		sb.WriteString("  none\n")
+	}
+	for _, c := range r.Commands {
+		sb.WriteString("  " + c + "\n")
+	}
+	return strings.TrimSuffix(sb.String(), "\n")
+}
+
+// writeDependencies writes the given dependency tree, indented.
+func writeDependencies(sb *strings.Builder, nodes []dependencyNode, indent string) {
+	for _, n := range nodes {
+		sb.WriteString(indent + n.Name)
+		switch {
+		case n.File:
+			sb.WriteString(" (file)")
+		case n.Description != "":
+			sb.WriteString(": " + n.Description)
+		}
+		sb.WriteString("\n")
+		writeDependencies(sb, n.Dependencies, indent+"  ")
+	}
+}
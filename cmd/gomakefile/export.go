@@ -0,0 +1,65 @@
+// Copyright (c) 2023 Tiago Melo. All rights reserved.
+// Use of this source code is governed by the MIT License that can be found in
+// the LICENSE file.
+
+package main
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/jessevdk/go-flags"
+	"github.com/tiagomelo/go-makefile-gen/mfile"
+)
+
+// ExportCommand is used to convert the Makefile into the file of another tool
+type ExportCommand struct {
+	Format       string `long:"format" description:"Format to export the Makefile to: taskfile (Taskfile.yml of Task), gha (GitHub Actions workflow), gitlab-ci (.gitlab-ci.yml) or vscode (.vscode/tasks.json)" choice:"taskfile" choice:"gha" choice:"gitlab-ci" choice:"vscode"`
+	To           string `long:"to" description:"Deprecated alias of --format" choice:"taskfile" choice:"gha" choice:"gitlab-ci" choice:"vscode" hidden:"yes"`
+	OutputFile   string `short:"f" long:"file" description:"Write the exported file to this file instead of stdout"`
+	MakefilePath string `short:"p" long:"path" description:"Path to the Makefile" default:"."`
+}
+
+// Execute is the method invoked for the export command
+func (e *ExportCommand) Execute(args []string) error {
+	if e.Format == "" {
+		e.Format = e.To
+	}
+	if e.Format == "" {
+		return &flags.Error{Type: flags.ErrRequired, Message: "the required flag `--format' was not specified"}
+	}
+	content, err := mfile.Export(e.MakefilePath, e.Format)
+	if err != nil {
+		return err
+	}
+	if e.OutputFile == "" {
+		if opts.Output == outputJSON {
+			return report(exportResult{Format: e.Format, Content: string(content)})
+		}
+		fmt.Print(string(content))
+		return nil
+	}
+	if err := os.MkdirAll(filepath.Dir(e.OutputFile), 0755); err != nil {
+		return err
+	}
+	if err := os.WriteFile(e.OutputFile, content, 0644); err != nil {
+		return err
+	}
+	absPath, err := absPath(e.OutputFile)
+	if err != nil {
+		return err
+	}
+	return report(exportResult{Format: e.Format, Path: absPath})
+}
+
+// exportResult is the outcome of the export command.
+type exportResult struct {
+	Format  string `json:"format"`
+	Path    string `json:"path,omitempty"`
+	Content string `json:"content,omitempty"`
+}
+
+func (r exportResult) text() string {
+	return fmt.Sprintf("Makefile was exported successfully to %s", r.Path)
+}
@@ -0,0 +1,158 @@
+// Copyright (c) 2023 Tiago Melo. All rights reserved.
+// Use of this source code is governed by the MIT License that can be found in
+// the LICENSE file.
+
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"os/exec"
+	"path/filepath"
+	"strings"
+
+	"github.com/jessevdk/go-flags"
+)
+
+// makefileModifier is implemented by the commands modifying the Makefile,
+// whose changes can be committed with --git-commit.
+type makefileModifier interface {
+	// makefilePath returns the path of the Makefile the command modifies,
+	// or an empty string if it does not modify it with the given options.
+	makefilePath() string
+}
+
+func (g *GenerateCommand) makefilePath() string { return g.MakefilePath }
+
+func (a *AddTargetCommand) makefilePath() string { return a.MakefilePath }
+
+func (i *ImportCommand) makefilePath() string { return i.MakefilePath }
+
+func (m *MergeCommand) makefilePath() string { return m.OutputFile }
+
+func (d *DedupeCommand) makefilePath() string { return d.MakefilePath }
+
+func (d *DescribeCommand) makefilePath() string { return d.MakefilePath }
+
+func (s *SyncCommand) makefilePath() string { return s.MakefilePath }
+
+func (u *UndoCommand) makefilePath() string {
+	if u.List {
+		return ""
+	}
+	return u.MakefilePath
+}
+
+func (u *UpgradeCommand) makefilePath() string {
+	if u.DryRun {
+		return ""
+	}
+	return u.MakefilePath
+}
+
+func (c *CompletionCommand) makefilePath() string {
+	if !c.AddTarget {
+		return ""
+	}
+	return c.MakefilePath
+}
+
+func (v *VerifyCommand) makefilePath() string {
+	if !v.Fix {
+		return ""
+	}
+	return v.MakefilePath
+}
+
+// executeAndCommit executes the given command with execute and, if it
+// modifies the Makefile, stages and commits the files it changed with the
+// given message. It fails before executing the command if other changes
+// are staged, so that they don't end up in the commit.
+func executeAndCommit(command flags.Commander, execute func() error, message string) error {
+	modifier, ok := command.(makefileModifier)
+	if !ok || modifier.makefilePath() == "" {
+		return &flags.Error{Type: flags.ErrInvalidChoice, Message: "--git-commit is only supported by the commands modifying the Makefile"}
+	}
+	makefile, err := makefileFile(modifier.makefilePath())
+	if err != nil {
+		return err
+	}
+	root, err := git(filepath.Dir(makefile), "rev-parse", "--show-toplevel")
+	if err != nil {
+		return err
+	}
+	root = strings.TrimSpace(root)
+	staged, err := git(root, "diff", "--cached", "--name-only", "-z")
+	if err != nil {
+		return err
+	}
+	if staged != "" {
+		return fmt.Errorf("refusing to commit with --git-commit: changes are already staged in %s (%s)", root, strings.Join(strings.Split(strings.TrimSuffix(staged, "\x00"), "\x00"), ", "))
+	}
+	before, err := gitStatus(root)
+	if err != nil {
+		return err
+	}
+	if err := execute(); err != nil {
+		return err
+	}
+	after, err := gitStatus(root)
+	if err != nil {
+		return err
+	}
+	var changed []string
+	for path, status := range after {
+		if before[path] != status {
+			changed = append(changed, path)
+		}
+	}
+	if rel, err := filepath.Rel(root, makefile); err == nil {
+		changed = append(changed, rel)
+	}
+	if _, err := git(root, append([]string{"add", "--"}, changed...)...); err != nil {
+		return err
+	}
+	staged, err = git(root, "diff", "--cached", "--name-only")
+	if err != nil {
+		return err
+	}
+	if staged == "" {
+		logger.Info("nothing to commit, the Makefile is unchanged")
+		return nil
+	}
+	if _, err := git(root, "commit", "--quiet", "-m", message); err != nil {
+		return err
+	}
+	logger.Info(fmt.Sprintf("Committed %s", strings.Join(strings.Fields(staged), ", ")))
+	return nil
+}
+
+// gitStatus returns the status of the changed and untracked files of the
+// git repository at the given root, by path.
+func gitStatus(root string) (map[string]string, error) {
+	out, err := git(root, "status", "--porcelain", "-z", "--untracked-files=all")
+	if err != nil {
+		return nil, err
+	}
+	status := make(map[string]string)
+	for _, entry := range strings.Split(out, "\x00") {
+		if len(entry) > 3 {
+			status[entry[3:]] = entry[:2]
+		}
+	}
+	return status, nil
+}
+
+// git runs git with the given arguments in the given directory, and
+// returns its output.
+func git(dir string, args ...string) (string, error) {
+	cmd := exec.Command("git", args...)
+	cmd.Dir = dir
+	var stderr bytes.Buffer
+	cmd.Stderr = &stderr
+	out, err := cmd.Output()
+	if err != nil {
+		return "", fmt.Errorf("git %s: %s", args[0], strings.TrimSpace(stderr.String()+" "+err.Error()))
+	}
+	return string(out), nil
+}
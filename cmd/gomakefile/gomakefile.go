@@ -8,6 +8,8 @@ import (
 	"fmt"
 	"os"
 	"path/filepath"
+	"runtime"
+	"strings"
 
 	"github.com/jessevdk/go-flags"
 	"github.com/tiagomelo/go-makefile-gen/mfile"
@@ -15,67 +17,323 @@ import (
 
 // GenerateCommand is used to generate a Makefile
 type GenerateCommand struct {
-	MakefilePath              string `short:"p" long:"path" description:"Path to the Makefile" default:"."`
-	OverwriteExistingMakefile bool   `short:"o" long:"overwrite" description:"Overwrite existing Makefile"`
+	MakefilePath              string   `short:"p" long:"path" description:"Path to the Makefile" default:"."`
+	OverwriteExistingMakefile bool     `short:"o" long:"overwrite" description:"Overwrite existing Makefile"`
+	Flavor                    string   `long:"flavor" description:"Base skeleton of the Makefile, the presets are added to: minimal (help only), standard (help, test and coverage; the default), library (standard, plus vet, fmt and tidy) or full (standard, plus build, lint and clean)" choice:"minimal" choice:"standard" choice:"library" choice:"full"`
+	HelpStyle                 string   `long:"help-style" description:"Implementation of the help target: awk (the default), sed (with column) or info (pure make, listing the targets known at generation time)" choice:"awk" choice:"sed" choice:"info"`
+	Windows                   bool     `long:"windows" description:"Make the Makefile work under Windows too; the default when running on Windows"`
+	Dialect                   string   `long:"dialect" description:"Dialect of make the Makefile targets: gnu (the default), posix, for BSD and Solaris build hosts, or bmake, for FreeBSD and NetBSD" choice:"gnu" choice:"posix" choice:"bmake"`
+	RecipePrefix              string   `long:"recipe-prefix" description:"Character starting the recipe lines instead of a tab, like >, declared with .RECIPEPREFIX (GNU make only)"`
+	Presets                   []string `short:"s" long:"preset" description:"Presets to generate the Makefile from; comma-separated, may be repeated (see the presets command)"`
+	Parameters                []string `long:"param" description:"Preset parameter, as name=value; may be repeated"`
+	Auto                      bool     `long:"auto" description:"Add the presets detected from the project (see the detect command)"`
+	Recursive                 bool     `short:"r" long:"recursive" description:"Generate a Makefile in each module found under the path, and a root Makefile delegating to them"`
+	Template                  string   `long:"template" description:"Generate the Makefile from this template: a file, a directory, a URL, a git repository directory like github.com/org/repo//dir?ref=v1.0.0, or a registered template name"`
+	Values                    string   `long:"values" description:"YAML file holding values the template can use as {{ .Values.<key> }}"`
+	Fragments                 string   `long:"fragments" description:"Write each preset to its own fragment file in this directory, included by the Makefile" optional:"yes" optional-value:"make"`
+	EnvFile                   string   `long:"env-file" description:"Load this environment file, if it exists, and export its variables to the recipes; the ENV_FILE variable selects another one" optional:"yes" optional-value:".env"`
+	Parallel                  string   `long:"parallel" description:"Run the targets in parallel by default, with this number of jobs, or one per CPU; the JOBS variable overrides it (GNU make only)" optional:"yes" optional-value:"auto" value-name:"JOBS"`
+	NotParallel               bool     `long:"not-parallel" description:"Declare .NOTPARALLEL, so that the targets run one at a time even with make -j"`
+	Strict                    bool     `long:"strict" description:"Start the Makefile with a strict prologue: recipes run with bash -eu -o pipefail, and make warns about undefined variables and drops its builtin rules (GNU make only)"`
+	Shell                     string   `long:"shell" description:"Shell the recipes run with, declared as SHELL, like /bin/bash (GNU make only)"`
+	ShellFlags                string   `long:"shell-flags" description:"Flags of the shell the recipes run with, declared as .SHELLFLAGS, like '-eu -o pipefail -c' (GNU make only)"`
+	MakeFlags                 []string `long:"make-flag" description:"Flag added to MAKEFLAGS, like --make-flag=--no-builtin-rules; may be repeated (GNU make only)"`
+	Managed                   bool     `long:"managed" description:"Wrap the generated content in a managed block, which the upgrade command regenerates with the templates of later releases"`
 }
 
 // Execute is the method invoked for the generate command
 func (g *GenerateCommand) Execute(args []string) error {
-	if err := mfile.GenerateMakefile(g.MakefilePath, g.OverwriteExistingMakefile); err != nil {
+	generateOpts, err := g.options()
+	if err != nil {
+		return err
+	}
+	if err := mfile.Generate(g.MakefilePath, generateOpts...); err != nil {
 		return err
 	}
-	absPath, err := absPath(g.MakefilePath)
+	makefile, err := makefileFile(g.MakefilePath)
 	if err != nil {
 		return err
 	}
-	fmt.Printf("Makefile was generated successfully at %s\n", absPath)
-	return nil
+	r := generateResult{Path: makefile, Presets: g.Presets}
+	if g.Recursive {
+		if r.Modules, err = mfile.Modules(filepath.Dir(makefile)); err != nil {
+			return err
+		}
+	}
+	return report(r)
+}
+
+// options returns the options the Makefile is generated with.
+func (g *GenerateCommand) options() ([]mfile.GenerateOption, error) {
+	generateOpts := []mfile.GenerateOption{
+		mfile.WithOverwrite(g.OverwriteExistingMakefile),
+		mfile.WithFlavor(g.Flavor),
+		mfile.WithHelpStyle(g.HelpStyle),
+		mfile.WithWindows(g.Windows || runtime.GOOS == "windows" && (g.Dialect == "" || g.Dialect == mfile.DialectGNU)),
+		mfile.WithDialect(g.Dialect),
+		mfile.WithRecipePrefix(g.RecipePrefix),
+		mfile.WithPresets(g.Presets...),
+		mfile.WithAutoDetect(g.Auto),
+		mfile.WithRecursive(g.Recursive),
+		mfile.WithFragments(g.Fragments),
+		mfile.WithEnvFile(g.EnvFile),
+		mfile.WithTemplate(g.Template),
+		mfile.WithParallel(g.Parallel),
+		mfile.WithNotParallel(g.NotParallel),
+		mfile.WithStrict(g.Strict),
+		mfile.WithShell(g.Shell),
+		mfile.WithShellFlags(g.ShellFlags),
+		mfile.WithMakeFlags(g.MakeFlags...),
+		mfile.WithManaged(g.Managed),
+	}
+	for _, p := range g.Parameters {
+		name, value, ok := strings.Cut(p, "=")
+		if !ok {
+			return nil, fmt.Errorf("invalid parameter %q, expected name=value", p)
+		}
+		generateOpts = append(generateOpts, mfile.WithParameter(name, value))
+	}
+	if g.Values != "" {
+		values, err := mfile.ReadValues(g.Values)
+		if err != nil {
+			return nil, err
+		}
+		generateOpts = append(generateOpts, mfile.WithValues(values))
+	}
+	return generateOpts, nil
+}
+
+// generateResult is the outcome of the generate command.
+type generateResult struct {
+	Path    string   `json:"path"`
+	Presets []string `json:"presets,omitempty"`
+	Modules []string `json:"modules,omitempty"`
+}
+
+func (r generateResult) text() string {
+	if len(r.Modules) > 0 {
+		return fmt.Sprintf("Makefiles were generated successfully at %s and in %s", r.Path, strings.Join(r.Modules, ", "))
+	}
+	return fmt.Sprintf("Makefile was generated successfully at %s", r.Path)
 }
 
 // AddTargetCommand is used to add a target to the Makefile
 type AddTargetCommand struct {
-	TargetName         string   `short:"t" long:"target" description:"Name of the target" required:"true"`
+	TargetName         string   `short:"t" long:"target" description:"Name of the target"`
+	TargetTemplate     string   `long:"target-template" description:"File holding the template of the target block; defaults to .gomakefile/target.tmpl next to the Makefile or in the home directory, if any"`
+	FromSnippet        string   `long:"from-snippet" description:"Add the targets of this snippet, from .makefile-snippets/ or ~/.gomakefile/snippets/"`
 	TargetContent      string   `short:"c" long:"targetContent" description:"Content of the target"`
 	TargetDependencies []string `short:"d" long:"targetDependencies" description:"Target dependencies"`
+	Aliases            []string `long:"alias" description:"Add a short alias target depending on the target, listed as an alias by help; can be repeated"`
+	Namespace          string   `long:"namespace" description:"Namespace of the target, like docker for docker/build; namespaced targets are grouped by help"`
+	Replace            bool     `long:"replace" description:"Replace the target, and its aliases, if already declared, instead of failing"`
+	PatternRule        bool     `long:"pattern-rule" description:"Add a pattern rule, like guard-%, whose name contains a single %"`
+	Force              bool     `long:"force" description:"Add, or replace, a target managed by the generator, like help, test and coverage, or a special target of make"`
+	SanitizeName       bool     `long:"sanitize-name" description:"Convert the target name, taken as a free-form label like \"Run Integration Tests\", into a kebab-case name"`
+	Stamp              bool     `long:"stamp" description:"Skip the content when its sources haven't changed: it builds a .<target>.stamp file, touched once it succeeds, the target depends on"`
+	StampSources       []string `long:"stamp-source" description:"Source the stamp file depends on, like Dockerfile or $(shell find . -name '*.go'); may be repeated, implies --stamp"`
 	MakefilePath       string   `short:"p" long:"path" description:"Path to the Makefile" default:"."`
 }
 
 // Execute is the method invoked for the addtarget command
 func (a *AddTargetCommand) Execute(args []string) error {
-	if a.TargetContent != "" && len(a.TargetDependencies) > 0 {
-		if err := mfile.AddTargetWithContentAndDependenciesToMakefile(a.MakefilePath, a.TargetName, a.TargetContent, a.TargetDependencies); err != nil {
-			return err
-		}
-		return nil
+	template, err := a.targetTemplate()
+	if err != nil {
+		return err
 	}
-	if a.TargetContent != "" {
-		if err := mfile.AddTargetWithContentToMakefile(a.MakefilePath, a.TargetName, a.TargetContent); err != nil {
-			return err
+	switch {
+	case a.FromSnippet != "" && a.Replace:
+		return &flags.Error{Type: flags.ErrInvalidChoice, Message: "`--replace' is not supported with `--from-snippet'"}
+	case a.FromSnippet != "":
+		err = mfile.AddSnippetToMakefile(a.MakefilePath, a.FromSnippet)
+	case a.TargetName == "":
+		return &flags.Error{Type: flags.ErrRequired, Message: "the required flag `-t, --target' or `--from-snippet' was not specified"}
+	default:
+		policy := mfile.ErrorIfExists
+		if a.Replace {
+			policy = mfile.ReplaceIfExists
 		}
-		return nil
-	}
-	if len(a.TargetDependencies) > 0 {
-		if err := mfile.AddTargetWithDependenciesToMakefile(a.MakefilePath, a.TargetName, a.TargetDependencies); err != nil {
-			return err
+		opts := []mfile.TargetOption{
+			mfile.WithContent(a.TargetContent),
+			mfile.WithDependencies(a.TargetDependencies...),
+			mfile.WithAliases(a.Aliases...),
+			mfile.WithNamespace(a.Namespace),
+			mfile.WithExistsPolicy(policy),
 		}
-		return nil
+		if a.PatternRule {
+			opts = append(opts, mfile.WithPatternRule())
+		}
+		if a.SanitizeName {
+			opts = append(opts, mfile.WithSanitizedName())
+		}
+		if a.Force {
+			opts = append(opts, mfile.WithForce())
+		}
+		if a.Stamp || len(a.StampSources) > 0 {
+			opts = append(opts, mfile.WithStamp(a.StampSources...))
+		}
+		if template != "" {
+			opts = append(opts, mfile.WithTargetTemplate(template))
+		}
+		err = mfile.AddTarget(a.MakefilePath, a.TargetName, opts...)
 	}
-	if err := mfile.AddTargetToMakefile(a.MakefilePath, a.TargetName); err != nil {
+	if err != nil {
 		return err
 	}
-	absPath, err := absPath(a.MakefilePath)
+	makefile, err := makefileFile(a.MakefilePath)
 	if err != nil {
 		return err
 	}
-	makeFilePath := fmt.Sprintf("%s/%s", absPath, "Makefile")
-	fmt.Printf("Target %s was generated successfully added to %s\n", a.TargetName, makeFilePath)
-	return nil
+	if a.FromSnippet != "" {
+		return report(addSnippetResult{Snippet: a.FromSnippet, Path: makefile})
+	}
+	target := a.TargetName
+	if a.SanitizeName {
+		target = mfile.SanitizeTargetName(target)
+	}
+	if a.Namespace != "" {
+		target = a.Namespace + "/" + target
+	}
+	r := addTargetResult{
+		Target:       target,
+		Dependencies: a.TargetDependencies,
+		Content:      a.TargetContent,
+		Aliases:      a.Aliases,
+		Path:         makefile,
+	}
+	if a.Stamp || len(a.StampSources) > 0 {
+		r.StampFile = mfile.StampFile(target)
+	}
+	return report(r)
+}
+
+// targetTemplateFile is the file holding the target template, looked up
+// next to the Makefile, then in the home directory.
+const targetTemplateFile = ".gomakefile/target.tmpl"
+
+// targetTemplate returns the target template read from the
+// --target-template file or, if not given, from the target template file,
+// if any.
+func (a *AddTargetCommand) targetTemplate() (string, error) {
+	files := []string{a.TargetTemplate}
+	if a.TargetTemplate == "" {
+		makefile, err := makefileFile(a.MakefilePath)
+		if err != nil {
+			return "", err
+		}
+		files = []string{filepath.Join(filepath.Dir(makefile), targetTemplateFile)}
+		if home, err := os.UserHomeDir(); err == nil {
+			files = append(files, filepath.Join(home, targetTemplateFile))
+		}
+	}
+	for _, file := range files {
+		text, err := os.ReadFile(file)
+		if err != nil {
+			if a.TargetTemplate == "" && os.IsNotExist(err) {
+				continue
+			}
+			return "", err
+		}
+		logger.Debug("using target template", "path", file)
+		return string(text), nil
+	}
+	return "", nil
+}
+
+// addTargetResult is the outcome of the addtarget command.
+type addTargetResult struct {
+	Target       string   `json:"target"`
+	Dependencies []string `json:"dependencies,omitempty"`
+	Content      string   `json:"content,omitempty"`
+	Aliases      []string `json:"aliases,omitempty"`
+	StampFile    string   `json:"stampFile,omitempty"`
+	Path         string   `json:"path"`
+}
+
+func (r addTargetResult) text() string {
+	return fmt.Sprintf("Target %s was generated successfully added to %s", r.Target, r.Path)
+}
+
+// addSnippetResult is the outcome of the addtarget command with a snippet.
+type addSnippetResult struct {
+	Snippet string `json:"snippet"`
+	Path    string `json:"path"`
+}
+
+func (r addSnippetResult) text() string {
+	return fmt.Sprintf("Snippet %s was successfully added to %s", r.Snippet, r.Path)
 }
 
 // Options holds the command-line options
 type Options struct {
-	Generate  GenerateCommand  `command:"generate" description:"Generate a basic Makefile"`
-	AddTarget AddTargetCommand `command:"addtarget" description:"Add a target to the Makefile"`
+	Output    string `long:"output" description:"Output format" choice:"text" choice:"json" default:"text"`
+	Verbose   bool   `short:"v" long:"verbose" description:"Show debug messages"`
+	Quiet     bool   `short:"q" long:"quiet" description:"Show errors only"`
+	GitCommit string `long:"git-commit" description:"Stage and commit the changes made to the Makefile with this message; fails if other changes are staged" value-name:"MESSAGE"`
+	Audit     bool   `long:"audit" description:"Record the changes made to the Makefile in .gomakefile/history.jsonl, next to it; once the log exists, they are recorded without --audit"`
+	Makefile  string `long:"makefile" description:"Makefile to work on, overriding -p, --path: a directory holding a Makefile, or the Makefile itself, like Makefile.ci" value-name:"NAME-OR-PATH"`
+
+	Generate   GenerateCommand   `command:"generate" description:"Generate a basic Makefile"`
+	Init       InitCommand       `command:"init" description:"Generate a Makefile along with the supporting files of a project"`
+	AddTarget  AddTargetCommand  `command:"addtarget" description:"Add a target to the Makefile"`
+	Completion CompletionCommand `command:"completion" description:"Generate a shell completion script for the Makefile targets"`
+	Man        ManCommand        `command:"man" description:"Generate a man page for gomakefile"`
+	Presets    PresetsCommand    `command:"presets" description:"List the presets available to the generate command"`
+	Detect     DetectCommand     `command:"detect" description:"Detect the presets matching a project"`
+	Template   TemplateCommand   `command:"template" description:"Manage the local cache of named templates"`
+	Lint       LintCommand       `command:"lint" description:"Check a Makefile for common mistakes"`
+	Import     ImportCommand     `command:"import" description:"Convert the tasks of another tool into Makefile targets"`
+	Export     ExportCommand     `command:"export" description:"Convert the Makefile into the file of another tool"`
+	Explain    ExplainCommand    `command:"explain" description:"Show the description, dependency chain and commands of a target"`
+	Watch      WatchCommand      `command:"watch" description:"Regenerate the Makefile from a spec whenever it or the project changes"`
+	Verify     VerifyCommand     `command:"verify" description:"Check that the Makefile is the one its spec generates"`
+	Hooks      HooksCommand      `command:"hooks" description:"Manage the git hooks checking the Makefile"`
+	Diff       DiffCommand       `command:"diff" description:"Compare the targets and variables of two Makefiles"`
+	Merge      MergeCommand      `command:"merge" description:"Combine the variables and targets of several Makefiles"`
+	Dedupe     DedupeCommand     `command:"dedupe" description:"Remove the duplicate rules and .PHONY declarations of a Makefile"`
+	Stats      StatsCommand      `command:"stats" description:"Report the size and complexity of a Makefile"`
+	Grep       GrepCommand       `command:"grep" description:"Search the recipes of a Makefile, reporting the targets running the matching lines"`
+	Describe   DescribeCommand   `command:"describe" description:"Set the description of a target listed by help"`
+	Sync       SyncCommand       `command:"sync" description:"Add and remove the targets of the Makefile as the project changes"`
+	Undo       UndoCommand       `command:"undo" description:"Revert the last operations recorded in the audit log of the Makefile"`
+	Upgrade    UpgradeCommand    `command:"upgrade" description:"Regenerate the managed block of the Makefile with the templates of this release"`
+	SelfUpdate SelfUpdateCommand `command:"self-update" description:"Replace gomakefile with the latest release, or the given one"`
+	Serve      ServeCommand      `command:"serve" description:"Serve an HTTP API generating, linting and exporting Makefiles"`
+	Spec       SpecCommand       `command:"spec" description:"Print the JSON Schema of the spec files, or check a spec file against it"`
+}
+
+var (
+	opts   Options
+	parser = newParser(&opts)
+)
+
+// newParser creates the command-line parser for the given options.
+func newParser(opts *Options) *flags.Parser {
+	p := flags.NewParser(opts, flags.Default)
+	p.Name = "gomakefile"
+	p.ShortDescription = "Makefile generator for Go projects"
+	p.LongDescription = "gomakefile generates a Makefile for your Go project and adds targets to existing Makefiles."
+	p.CommandHandler = func(command flags.Commander, args []string) error {
+		setupLogging()
+		if command == nil {
+			return nil
+		}
+		if opts.Makefile != "" {
+			c, ok := command.(makefileCommand)
+			if !ok {
+				return &flags.Error{Type: flags.ErrInvalidChoice, Message: "--makefile is only supported by the commands working on a Makefile"}
+			}
+			c.setMakefilePath(opts.Makefile)
+		}
+		execute := func() error {
+			return executeAudited(command, args, opts.Audit)
+		}
+		if opts.GitCommit != "" {
+			return executeAndCommit(command, execute, opts.GitCommit)
+		}
+		return execute()
+	}
+	return p
 }
 
 // absPath converts a relative file path to an absolute path.
@@ -84,19 +342,11 @@ func absPath(path string) (string, error) {
 }
 
 func main() {
-	var opts Options
-	parser := flags.NewParser(&opts, flags.Default)
 	if _, err := parser.Parse(); err != nil {
-		switch flagsErr := err.(type) {
-		case flags.ErrorType:
-			if flagsErr == flags.ErrHelp {
-				fmt.Println(err)
-				os.Exit(0)
-			}
-			fmt.Println(err)
-			os.Exit(1)
-		default:
-			os.Exit(1)
+		code := exitCode(err)
+		if code != exitOK && opts.Output == outputJSON {
+			report(errorResult{Error: err.Error()})
 		}
+		os.Exit(code)
 	}
 }
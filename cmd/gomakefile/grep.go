@@ -0,0 +1,70 @@
+// Copyright (c) 2023 Tiago Melo. All rights reserved.
+// Use of this source code is governed by the MIT License that can be found in
+// the LICENSE file.
+
+package main
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+
+	"github.com/tiagomelo/go-makefile-gen/mfile"
+)
+
+// GrepCommand is used to search the recipes of a Makefile
+type GrepCommand struct {
+	MakefilePath string `short:"p" long:"path" description:"Path to the Makefile" default:"."`
+	FixedStrings bool   `short:"F" long:"fixed-strings" description:"Search for the pattern as a plain string instead of a regular expression"`
+	IgnoreCase   bool   `short:"i" long:"ignore-case" description:"Ignore case when matching the pattern"`
+	Args         struct {
+		Pattern string `positional-arg-name:"pattern" description:"Regular expression the recipe lines are matched against"`
+	} `positional-args:"yes" required:"yes"`
+}
+
+// Execute is the method invoked for the grep command
+func (g *GrepCommand) Execute(args []string) error {
+	expr := g.Args.Pattern
+	if g.FixedStrings {
+		expr = regexp.QuoteMeta(expr)
+	}
+	if g.IgnoreCase {
+		expr = "(?i)" + expr
+	}
+	pattern, err := regexp.Compile(expr)
+	if err != nil {
+		return fmt.Errorf("invalid pattern: %w", err)
+	}
+	matches, err := mfile.Grep(g.MakefilePath, pattern)
+	if err != nil {
+		return err
+	}
+	if len(matches) == 0 {
+		return fmt.Errorf("no recipe line matches %q", g.Args.Pattern)
+	}
+	r := grepResult{Matches: make([]recipeMatch, len(matches))}
+	for i, m := range matches {
+		r.Matches[i] = recipeMatch{Targets: m.Targets, Line: m.Line, Text: m.Text}
+	}
+	return show(r)
+}
+
+// recipeMatch is a recipe line matching the pattern.
+type recipeMatch struct {
+	Targets []string `json:"targets"`
+	Line    int      `json:"line"`
+	Text    string   `json:"text"`
+}
+
+// grepResult is the outcome of the grep command.
+type grepResult struct {
+	Matches []recipeMatch `json:"matches"`
+}
+
+func (r grepResult) text() string {
+	lines := make([]string, len(r.Matches))
+	for i, m := range r.Matches {
+		lines[i] = fmt.Sprintf("%d: %s: %s", m.Line, strings.Join(m.Targets, " "), m.Text)
+	}
+	return strings.Join(lines, "\n")
+}
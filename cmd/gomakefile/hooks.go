@@ -0,0 +1,44 @@
+// Copyright (c) 2023 Tiago Melo. All rights reserved.
+// Use of this source code is governed by the MIT License that can be found in
+// the LICENSE file.
+
+package main
+
+import (
+	"fmt"
+
+	"github.com/tiagomelo/go-makefile-gen/mfile"
+)
+
+// HooksCommand is used to manage the git hooks checking the Makefile
+type HooksCommand struct {
+	Install HooksInstallCommand `command:"install" description:"Install a pre-commit hook linting and verifying the staged Makefiles"`
+}
+
+// HooksInstallCommand is used to install the pre-commit hook
+type HooksInstallCommand struct {
+	Path  string `short:"p" long:"path" description:"Path within the git repository" default:"."`
+	Force bool   `short:"f" long:"force" description:"Replace an existing pre-commit hook not installed by gomakefile"`
+}
+
+// Execute is the method invoked for the hooks install command
+func (h *HooksInstallCommand) Execute(args []string) error {
+	hookPath, err := mfile.InstallHook(h.Path, h.Force)
+	if err != nil {
+		return err
+	}
+	absPath, err := absPath(hookPath)
+	if err != nil {
+		return err
+	}
+	return report(hookResult{Path: absPath})
+}
+
+// hookResult is the outcome of the hooks install command.
+type hookResult struct {
+	Path string `json:"path"`
+}
+
+func (r hookResult) text() string {
+	return fmt.Sprintf("pre-commit hook was installed successfully at %s", r.Path)
+}
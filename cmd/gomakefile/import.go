@@ -0,0 +1,51 @@
+// Copyright (c) 2023 Tiago Melo. All rights reserved.
+// Use of this source code is governed by the MIT License that can be found in
+// the LICENSE file.
+
+package main
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/tiagomelo/go-makefile-gen/mfile"
+)
+
+// ImportCommand is used to convert the tasks of another tool into Makefile targets
+type ImportCommand struct {
+	From         string `long:"from" description:"Format of the file to import: taskfile (Taskfile.yml of Task), just (justfile), npm (scripts of package.json) or gha (run steps of a GitHub Actions workflow)" choice:"taskfile" choice:"just" choice:"npm" choice:"gha" required:"yes"`
+	OnConflict   string `long:"on-conflict" description:"What to do with the imported targets already declared in the Makefile: fail, skip them or rename them with the format as prefix, like npm-build" choice:"error" choice:"skip" choice:"rename" default:"error"`
+	Inline       bool   `long:"inline" description:"Run the commands of the imported npm scripts instead of npm run"`
+	MakefilePath string `short:"p" long:"path" description:"Path to the Makefile" default:"."`
+	Args         struct {
+		File string `positional-arg-name:"file" description:"File to import, like Taskfile.yml"`
+	} `positional-args:"yes" required:"yes"`
+}
+
+// Execute is the method invoked for the import command
+func (i *ImportCommand) Execute(args []string) error {
+	targets, err := mfile.Import(i.MakefilePath, i.From, i.Args.File, mfile.WithConflict(i.OnConflict), mfile.WithInline(i.Inline))
+	if err != nil {
+		return err
+	}
+	makefile, err := makefileFile(i.MakefilePath)
+	if err != nil {
+		return err
+	}
+	r := importResult{File: i.Args.File, Path: makefile, Targets: []string{}}
+	for _, t := range targets {
+		r.Targets = append(r.Targets, t.Name)
+	}
+	return report(r)
+}
+
+// importResult is the outcome of the import command.
+type importResult struct {
+	File    string   `json:"file"`
+	Path    string   `json:"path"`
+	Targets []string `json:"targets"`
+}
+
+func (r importResult) text() string {
+	return fmt.Sprintf("Imported %s into %s: %s", r.File, r.Path, strings.Join(r.Targets, ", "))
+}
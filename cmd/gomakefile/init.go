@@ -0,0 +1,69 @@
+// Copyright (c) 2023 Tiago Melo. All rights reserved.
+// Use of this source code is governed by the MIT License that can be found in
+// the LICENSE file.
+
+package main
+
+import (
+	"fmt"
+	"path/filepath"
+	"strings"
+
+	"github.com/tiagomelo/go-makefile-gen/mfile"
+)
+
+// InitCommand is used to bootstrap a project: its Makefile, generated
+// like with the generate command, and its supporting files
+type InitCommand struct {
+	GenerateCommand
+	Gitignore bool `long:"gitignore" description:"Add the artifacts of the generated targets, like bin, to .gitignore"`
+	Tools     bool `long:"tools" description:"Write a tools.go stub tracking the tools the module depends on"`
+	Golangci  bool `long:"golangci" description:"Write a .golangci.yml stub and add the lint target running golangci-lint with it"`
+}
+
+// Execute is the method invoked for the init command
+func (i *InitCommand) Execute(args []string) error {
+	generateOpts, err := i.options()
+	if err != nil {
+		return err
+	}
+	initOpts := []mfile.InitOption{mfile.WithGenerateOptions(generateOpts...)}
+	if i.Gitignore {
+		initOpts = append(initOpts, mfile.WithGitignore())
+	}
+	if i.Tools {
+		initOpts = append(initOpts, mfile.WithToolsFile())
+	}
+	if i.Golangci {
+		initOpts = append(initOpts, mfile.WithGolangciConfig())
+	}
+	written, err := mfile.Init(i.MakefilePath, initOpts...)
+	if err != nil {
+		return err
+	}
+	r := initResult{}
+	for _, path := range written {
+		absPath, err := absPath(path)
+		if err != nil {
+			return err
+		}
+		r.Files = append(r.Files, absPath)
+	}
+	return report(r)
+}
+
+// initResult is the outcome of the init command.
+type initResult struct {
+	Files []string `json:"files"`
+}
+
+func (r initResult) text() string {
+	names := make([]string, 0, len(r.Files)-1)
+	for _, f := range r.Files[1:] {
+		names = append(names, filepath.Base(f))
+	}
+	if len(names) == 0 {
+		return fmt.Sprintf("Makefile was generated successfully at %s", r.Files[0])
+	}
+	return fmt.Sprintf("Makefile was generated successfully at %s, along with %s", r.Files[0], strings.Join(names, ", "))
+}
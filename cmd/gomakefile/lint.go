@@ -0,0 +1,78 @@
+// Copyright (c) 2023 Tiago Melo. All rights reserved.
+// Use of this source code is governed by the MIT License that can be found in
+// the LICENSE file.
+
+package main
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/tiagomelo/go-makefile-gen/mfile"
+	"github.com/tiagomelo/go-makefile-gen/mfile/run"
+)
+
+// LintCommand is used to check a Makefile for common mistakes
+type LintCommand struct {
+	MakefilePath string `short:"p" long:"path" description:"Path to the Makefile" default:"."`
+	Dialect      string `long:"dialect" description:"Dialect of make the Makefile targets; posix and bmake also report the GNU-only constructs they don't support" choice:"gnu" choice:"posix" choice:"bmake" default:"gnu"`
+	Make         string `long:"make" description:"GNU make the Makefile is checked against, reporting the constructs it is too old for" default:"make"`
+}
+
+// Execute is the method invoked for the lint command
+func (l *LintCommand) Execute(args []string) error {
+	issues, err := mfile.LintMakefile(l.MakefilePath, l.Dialect)
+	if err != nil {
+		return err
+	}
+	r := lintResult{Issues: append([]string{}, issues...)}
+	if l.Dialect == mfile.DialectGNU {
+		requirements, err := mfile.MakeRequirements(l.MakefilePath)
+		if err != nil {
+			return err
+		}
+		r.RequiredMake = mfile.RequiredMakeVersion(requirements)
+		r.Issues = append(r.Issues, l.makeVersionIssues(requirements)...)
+	}
+	issues = r.Issues
+	if err := show(r); err != nil {
+		return err
+	}
+	if len(issues) > 0 {
+		return fmt.Errorf("%d issue(s) found", len(issues))
+	}
+	return nil
+}
+
+// makeVersionIssues returns the issues of the given requirements the
+// installed make is too old for. Nothing is reported when it can't be
+// run or is not GNU make.
+func (l *LintCommand) makeVersionIssues(requirements []mfile.MakeRequirement) []string {
+	if len(requirements) == 0 {
+		return nil
+	}
+	version, err := run.MakeVersion(context.Background(), run.WithMake(l.Make))
+	if err != nil {
+		logger.Debug("skipped the make version check", "error", err)
+		return nil
+	}
+	var issues []string
+	for _, r := range mfile.UnsupportedBy(version, requirements) {
+		issues = append(issues, fmt.Sprintf("line %d: %s: GNU make %s required, %s is %s", r.Line, r.Construct, r.Version, l.Make, version))
+	}
+	return issues
+}
+
+// lintResult is the outcome of the lint command.
+type lintResult struct {
+	Issues       []string `json:"issues"`
+	RequiredMake string   `json:"requiredMake,omitempty"`
+}
+
+func (r lintResult) text() string {
+	if len(r.Issues) == 0 {
+		return "no issue found"
+	}
+	return strings.Join(r.Issues, "\n")
+}
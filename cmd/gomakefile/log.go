@@ -0,0 +1,85 @@
+// Copyright (c) 2023 Tiago Melo. All rights reserved.
+// Use of this source code is governed by the MIT License that can be found in
+// the LICENSE file.
+
+package main
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"log/slog"
+	"os"
+	"strings"
+	"sync"
+
+	"github.com/tiagomelo/go-makefile-gen/mfile"
+)
+
+// logger is the CLI logger. It is configured from the global
+// --verbose and --quiet flags before any command runs.
+var logger = slog.New(newCLIHandler(os.Stderr, slog.LevelInfo))
+
+// setupLogging configures the CLI and library loggers from the global flags.
+func setupLogging() {
+	level := slog.LevelInfo
+	switch {
+	case opts.Quiet:
+		level = slog.LevelError
+	case opts.Verbose:
+		level = slog.LevelDebug
+	}
+	logger = slog.New(newCLIHandler(os.Stderr, level))
+	mfile.SetLogger(logger)
+}
+
+// cliHandler is a slog.Handler that writes plain, human readable lines:
+// the message followed by its attributes. Non-info records are
+// prefixed with their level.
+type cliHandler struct {
+	mu    *sync.Mutex
+	w     io.Writer
+	level slog.Level
+	attrs []slog.Attr
+}
+
+func newCLIHandler(w io.Writer, level slog.Level) *cliHandler {
+	return &cliHandler{mu: new(sync.Mutex), w: w, level: level}
+}
+
+func (h *cliHandler) Enabled(_ context.Context, level slog.Level) bool {
+	return level >= h.level
+}
+
+func (h *cliHandler) Handle(_ context.Context, r slog.Record) error {
+	var sb strings.Builder
+	if r.Level != slog.LevelInfo {
+		sb.WriteString(r.Level.String())
+		sb.WriteString(": ")
+	}
+	sb.WriteString(r.Message)
+	writeAttr := func(a slog.Attr) bool {
+		fmt.Fprintf(&sb, " %s=%v", a.Key, a.Value)
+		return true
+	}
+	for _, a := range h.attrs {
+		writeAttr(a)
+	}
+	r.Attrs(writeAttr)
+	sb.WriteString("\n")
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	_, err := io.WriteString(h.w, sb.String())
+	return err
+}
+
+func (h *cliHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	nh := *h
+	nh.attrs = append(append([]slog.Attr{}, h.attrs...), attrs...)
+	return &nh
+}
+
+// WithGroup is not supported; groups are flattened.
+func (h *cliHandler) WithGroup(name string) slog.Handler {
+	return h
+}
@@ -0,0 +1,67 @@
+// Copyright (c) 2023 Tiago Melo. All rights reserved.
+// Use of this source code is governed by the MIT License that can be found in
+// the LICENSE file.
+
+package main
+
+import (
+	"os"
+	"path/filepath"
+)
+
+// makefileName is the name of the Makefile looked up in a directory.
+const makefileName = "Makefile"
+
+// makefileCommand is implemented by the commands working on a Makefile,
+// whose path --makefile sets.
+type makefileCommand interface {
+	// setMakefilePath sets the path of the Makefile the command works
+	// on, a directory holding a Makefile or the Makefile itself.
+	setMakefilePath(path string)
+}
+
+func (g *GenerateCommand) setMakefilePath(path string) { g.MakefilePath = path }
+
+func (a *AddTargetCommand) setMakefilePath(path string) { a.MakefilePath = path }
+
+func (c *CompletionCommand) setMakefilePath(path string) { c.MakefilePath = path }
+
+func (l *LintCommand) setMakefilePath(path string) { l.MakefilePath = path }
+
+func (i *ImportCommand) setMakefilePath(path string) { i.MakefilePath = path }
+
+func (e *ExportCommand) setMakefilePath(path string) { e.MakefilePath = path }
+
+func (e *ExplainCommand) setMakefilePath(path string) { e.MakefilePath = path }
+
+func (w *WatchCommand) setMakefilePath(path string) { w.MakefilePath = path }
+
+func (v *VerifyCommand) setMakefilePath(path string) { v.MakefilePath = path }
+
+func (d *DedupeCommand) setMakefilePath(path string) { d.MakefilePath = path }
+
+func (s *StatsCommand) setMakefilePath(path string) { s.MakefilePath = path }
+
+func (g *GrepCommand) setMakefilePath(path string) { g.MakefilePath = path }
+
+func (d *DescribeCommand) setMakefilePath(path string) { d.MakefilePath = path }
+
+func (s *SyncCommand) setMakefilePath(path string) { s.MakefilePath = path }
+
+func (u *UndoCommand) setMakefilePath(path string) { u.MakefilePath = path }
+
+func (u *UpgradeCommand) setMakefilePath(path string) { u.MakefilePath = path }
+
+// makefileFile returns the absolute path of the Makefile at the given
+// path, which is either a directory holding a Makefile or the Makefile
+// itself, like Makefile.ci.
+func makefileFile(path string) (string, error) {
+	absPath, err := absPath(path)
+	if err != nil {
+		return "", err
+	}
+	if fi, err := os.Stat(absPath); err == nil && fi.IsDir() {
+		absPath = filepath.Join(absPath, makefileName)
+	}
+	return absPath, nil
+}
@@ -0,0 +1,47 @@
+// Copyright (c) 2023 Tiago Melo. All rights reserved.
+// Use of this source code is governed by the MIT License that can be found in
+// the LICENSE file.
+
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+)
+
+// ManCommand is used to generate a roff man page from the command definitions
+type ManCommand struct {
+	OutputFile string `short:"f" long:"file" description:"Write the man page to this file instead of stdout"`
+}
+
+// Execute is the method invoked for the man command
+func (m *ManCommand) Execute(args []string) error {
+	var buf bytes.Buffer
+	parser.WriteManPage(&buf)
+	if m.OutputFile == "" {
+		if opts.Output == outputJSON {
+			return report(manResult{Page: buf.String()})
+		}
+		fmt.Print(buf.String())
+		return nil
+	}
+	if err := os.WriteFile(m.OutputFile, buf.Bytes(), 0644); err != nil {
+		return err
+	}
+	absPath, err := absPath(m.OutputFile)
+	if err != nil {
+		return err
+	}
+	return report(manResult{Path: absPath})
+}
+
+// manResult is the outcome of the man command.
+type manResult struct {
+	Path string `json:"path,omitempty"`
+	Page string `json:"page,omitempty"`
+}
+
+func (r manResult) text() string {
+	return fmt.Sprintf("Man page was generated successfully at %s", r.Path)
+}
@@ -0,0 +1,78 @@
+// Copyright (c) 2023 Tiago Melo. All rights reserved.
+// Use of this source code is governed by the MIT License that can be found in
+// the LICENSE file.
+
+package main
+
+import (
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/tiagomelo/go-makefile-gen/mfile"
+)
+
+// MergeCommand is used to combine the targets of several Makefiles
+type MergeCommand struct {
+	OutputFile string `short:"o" long:"output-file" description:"Write the merged Makefile to this file instead of stdout"`
+	Args       struct {
+		Paths []string `positional-arg-name:"makefile" description:"Paths to the Makefiles to merge, in order"`
+	} `positional-args:"yes" required:"yes"`
+}
+
+// Execute is the method invoked for the merge command
+func (m *MergeCommand) Execute(args []string) error {
+	content, conflicts, err := mfile.Merge(m.Args.Paths...)
+	if err != nil {
+		return err
+	}
+	if len(conflicts) > 0 {
+		r := mergeResult{Conflicts: make([]mergeConflict, len(conflicts))}
+		for i, c := range conflicts {
+			r.Conflicts[i] = mergeConflict{Target: c.Target, Paths: c.Paths}
+		}
+		if err := show(r); err != nil {
+			return err
+		}
+		return fmt.Errorf("%d conflicting target(s) found", len(conflicts))
+	}
+	if m.OutputFile == "" {
+		if opts.Output == outputJSON {
+			return report(mergeResult{Content: string(content), Conflicts: []mergeConflict{}})
+		}
+		fmt.Print(string(content))
+		return nil
+	}
+	if err := os.WriteFile(m.OutputFile, content, 0644); err != nil {
+		return err
+	}
+	absPath, err := absPath(m.OutputFile)
+	if err != nil {
+		return err
+	}
+	return report(mergeResult{Path: absPath, Conflicts: []mergeConflict{}})
+}
+
+// mergeConflict is a target declared with different recipes.
+type mergeConflict struct {
+	Target string   `json:"target"`
+	Paths  []string `json:"paths"`
+}
+
+// mergeResult is the outcome of the merge command.
+type mergeResult struct {
+	Path      string          `json:"path,omitempty"`
+	Content   string          `json:"content,omitempty"`
+	Conflicts []mergeConflict `json:"conflicts"`
+}
+
+func (r mergeResult) text() string {
+	if len(r.Conflicts) == 0 {
+		return fmt.Sprintf("Makefiles were merged successfully into %s", r.Path)
+	}
+	lines := make([]string, len(r.Conflicts))
+	for i, c := range r.Conflicts {
+		lines[i] = fmt.Sprintf("target %s is declared with different recipes in %s", c.Target, strings.Join(c.Paths, ", "))
+	}
+	return strings.Join(lines, "\n")
+}
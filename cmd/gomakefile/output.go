@@ -0,0 +1,55 @@
+// Copyright (c) 2023 Tiago Melo. All rights reserved.
+// Use of this source code is governed by the MIT License that can be found in
+// the LICENSE file.
+
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+)
+
+// Supported output formats.
+const (
+	outputText = "text"
+	outputJSON = "json"
+)
+
+// result is implemented by the outcome of every command, so that it can
+// be printed either as human readable text or as JSON.
+type result interface {
+	text() string
+}
+
+// errorResult is the JSON representation of a failed command.
+type errorResult struct {
+	Error string `json:"error"`
+}
+
+func (e errorResult) text() string {
+	return e.Error
+}
+
+// report prints the given result in the selected output format:
+// JSON on stdout, or a text message logged at info level.
+func report(r result) error {
+	if opts.Output == outputJSON {
+		enc := json.NewEncoder(os.Stdout)
+		enc.SetIndent("", "  ")
+		return enc.Encode(r)
+	}
+	logger.Info(r.text())
+	return nil
+}
+
+// show prints the given result on stdout in the selected output format.
+// It is meant for commands whose result is data rather than a message,
+// so that it can be piped to other tools.
+func show(r result) error {
+	if opts.Output == outputJSON {
+		return report(r)
+	}
+	fmt.Println(r.text())
+	return nil
+}
@@ -0,0 +1,56 @@
+// Copyright (c) 2023 Tiago Melo. All rights reserved.
+// Use of this source code is governed by the MIT License that can be found in
+// the LICENSE file.
+
+package main
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/tiagomelo/go-makefile-gen/mfile"
+)
+
+// PresetsCommand is used to list the available presets
+type PresetsCommand struct{}
+
+// Execute is the method invoked for the presets command
+func (p *PresetsCommand) Execute(args []string) error {
+	var r presetsResult
+	for _, preset := range mfile.Presets() {
+		var targets []string
+		for _, t := range preset.Targets {
+			targets = append(targets, t.Name)
+		}
+		r.Presets = append(r.Presets, presetResult{
+			Name:        preset.Name,
+			Description: preset.Description,
+			Include:     preset.Include,
+			Targets:     targets,
+			Parameters:  preset.Parameters,
+		})
+	}
+	return show(r)
+}
+
+// presetResult describes a preset.
+type presetResult struct {
+	Name        string            `json:"name"`
+	Description string            `json:"description"`
+	Include     []string          `json:"include,omitempty"`
+	Targets     []string          `json:"targets"`
+	Parameters  map[string]string `json:"parameters,omitempty"`
+}
+
+// presetsResult is the outcome of the presets command.
+type presetsResult struct {
+	Presets []presetResult `json:"presets"`
+}
+
+func (r presetsResult) text() string {
+	var sb strings.Builder
+	for _, p := range r.Presets {
+		fmt.Fprintf(&sb, "%-12s %s\n", p.Name, p.Description)
+	}
+	return strings.TrimSuffix(sb.String(), "\n")
+}
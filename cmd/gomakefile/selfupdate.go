@@ -0,0 +1,314 @@
+// Copyright (c) 2023 Tiago Melo. All rights reserved.
+// Use of this source code is governed by the MIT License that can be found in
+// the LICENSE file.
+
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"runtime"
+	"runtime/debug"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// version is the version of gomakefile, set when building a release with
+// -ldflags "-X main.version=v1.2.3".
+var version string
+
+// devVersion is the version of the binaries built from source.
+const devVersion = "dev"
+
+// releasesURL is the GitHub API endpoint of the releases of gomakefile.
+const releasesURL = "https://api.github.com/repos/tiagomelo/go-makefile-gen/releases"
+
+// checksumsAsset is the release asset listing the SHA-256 checksums of the
+// other ones, in the format of sha256sum. It is not signed: it only
+// guards against corrupted downloads, not against a compromised release.
+const checksumsAsset = "checksums.txt"
+
+// updateClient is the HTTP client used by self-update.
+var updateClient = &http.Client{Timeout: 5 * time.Minute}
+
+// SelfUpdateCommand is used to replace the gomakefile binary with a release
+type SelfUpdateCommand struct {
+	Check   bool   `long:"check" description:"Only report whether a newer release is available"`
+	Version string `long:"version" description:"Release to install, like v1.2.3, instead of the latest one, even if it is older"`
+	Force   bool   `long:"force" description:"Install the release even if it is the current version, or if gomakefile was built from source"`
+}
+
+// Execute is the method invoked for the self-update command
+func (s *SelfUpdateCommand) Execute(args []string) error {
+	current := currentVersion()
+	rel, err := fetchRelease(s.Version)
+	if err != nil {
+		return err
+	}
+	r := selfUpdateResult{Current: current, Release: rel.TagName}
+	if s.Check || (sameVersion(current, rel.TagName) && !s.Force) {
+		return report(r)
+	}
+	if s.Version == "" && current != devVersion && compareVersions(current, rel.TagName) > 0 {
+		// The latest release is older than the running gomakefile, like
+		// a pre-release.
+		return report(r)
+	}
+	if current == devVersion && !s.Force {
+		return fmt.Errorf("gomakefile was built from source, update it with go install github.com/tiagomelo/go-makefile-gen/cmd/gomakefile@latest, or use --force")
+	}
+	binary, err := releaseBinary(rel)
+	if err != nil {
+		return err
+	}
+	if r.Path, err = replaceExecutable(binary); err != nil {
+		return err
+	}
+	r.Updated = true
+	return report(r)
+}
+
+// currentVersion returns the version of the running gomakefile: the one
+// set when building the release, the one of the module when installed
+// with go install, or devVersion.
+func currentVersion() string {
+	if version != "" {
+		return version
+	}
+	if info, ok := debug.ReadBuildInfo(); ok && info.Main.Version != "" && info.Main.Version != "(devel)" {
+		return info.Main.Version
+	}
+	return devVersion
+}
+
+// sameVersion reports whether the given versions are the same, with or
+// without the v prefix.
+func sameVersion(a, b string) bool {
+	return strings.TrimPrefix(a, "v") == strings.TrimPrefix(b, "v")
+}
+
+// compareVersions compares the given semantic versions, like v1.2.3 and
+// v1.10.0-rc.1, returning -1, 0 or +1 whether a is older than, the same
+// as or newer than b. A pre-release is older than its release.
+func compareVersions(a, b string) int {
+	a, _, _ = strings.Cut(strings.TrimPrefix(a, "v"), "+")
+	b, _, _ = strings.Cut(strings.TrimPrefix(b, "v"), "+")
+	a, aPre, _ := strings.Cut(a, "-")
+	b, bPre, _ := strings.Cut(b, "-")
+	as, bs := strings.Split(a, "."), strings.Split(b, ".")
+	for i := 0; i < max(len(as), len(bs)); i++ {
+		var x, y int
+		if i < len(as) {
+			x, _ = strconv.Atoi(as[i])
+		}
+		if i < len(bs) {
+			y, _ = strconv.Atoi(bs[i])
+		}
+		switch {
+		case x < y:
+			return -1
+		case x > y:
+			return 1
+		}
+	}
+	switch {
+	case aPre == bPre:
+		return 0
+	case aPre == "":
+		return 1
+	case bPre == "":
+		return -1
+	}
+	return strings.Compare(aPre, bPre)
+}
+
+// assetName returns the name of the release asset holding the binary for
+// the given platform.
+func assetName(goos, goarch string) string {
+	name := "gomakefile_" + goos + "_" + goarch
+	if goos == "windows" {
+		name += ".exe"
+	}
+	return name
+}
+
+// release is a GitHub release.
+type release struct {
+	TagName string `json:"tag_name"`
+	Assets  []struct {
+		Name string `json:"name"`
+		URL  string `json:"browser_download_url"`
+	} `json:"assets"`
+}
+
+// assetURL returns the download URL of the asset with the given name, or
+// an empty string if the release has none.
+func (r release) assetURL(name string) string {
+	for _, a := range r.Assets {
+		if a.Name == name {
+			return a.URL
+		}
+	}
+	return ""
+}
+
+// fetchRelease fetches the release with the given tag, or the latest one
+// if tag is empty.
+func fetchRelease(tag string) (release, error) {
+	u := releasesURL + "/latest"
+	if tag != "" {
+		u = releasesURL + "/tags/" + tag
+	}
+	var rel release
+	body, err := download(u)
+	if err != nil {
+		return rel, err
+	}
+	if err := json.Unmarshal(body, &rel); err != nil {
+		return rel, fmt.Errorf("parsing release: %w", err)
+	}
+	return rel, nil
+}
+
+// releaseBinary downloads the binary of the given release for the
+// running platform, verified against the checksums of the release. This
+// only checks the integrity of the download: the checksums come from the
+// same release and are not signed, so they can't tell whether the release
+// itself is authentic.
+func releaseBinary(rel release) ([]byte, error) {
+	name := assetName(runtime.GOOS, runtime.GOARCH)
+	binaryURL, checksumsURL := rel.assetURL(name), rel.assetURL(checksumsAsset)
+	if binaryURL == "" {
+		return nil, fmt.Errorf("release %s has no binary for %s/%s", rel.TagName, runtime.GOOS, runtime.GOARCH)
+	}
+	if checksumsURL == "" {
+		return nil, fmt.Errorf("release %s has no %s to verify the binary with", rel.TagName, checksumsAsset)
+	}
+	checksums, err := download(checksumsURL)
+	if err != nil {
+		return nil, err
+	}
+	binary, err := download(binaryURL)
+	if err != nil {
+		return nil, err
+	}
+	if err := verifyChecksum(checksums, name, binary); err != nil {
+		return nil, err
+	}
+	return binary, nil
+}
+
+// download returns the content at the given URL.
+func download(u string) ([]byte, error) {
+	logger.Debug("downloading", "url", u)
+	resp, err := updateClient.Get(u)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("downloading %s: %s", u, resp.Status)
+	}
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, fmt.Errorf("downloading %s: %w", u, err)
+	}
+	return body, nil
+}
+
+// verifyChecksum checks the given binary against its SHA-256 checksum in
+// the given checksums file, in the format of sha256sum.
+func verifyChecksum(checksums []byte, name string, binary []byte) error {
+	sum := sha256.Sum256(binary)
+	scanner := bufio.NewScanner(bytes.NewReader(checksums))
+	for scanner.Scan() {
+		fields := strings.Fields(scanner.Text())
+		if len(fields) != 2 || strings.TrimPrefix(fields[1], "*") != name {
+			continue
+		}
+		if !strings.EqualFold(fields[0], hex.EncodeToString(sum[:])) {
+			return fmt.Errorf("checksum of %s does not match, not installing it", name)
+		}
+		return nil
+	}
+	return fmt.Errorf("no checksum of %s found in %s", name, checksumsAsset)
+}
+
+// replaceExecutable replaces the running executable with the given
+// binary, returning its path. The binary is written next to it, then
+// renamed over it, so that the executable is never left half written.
+func replaceExecutable(binary []byte) (string, error) {
+	exe, err := os.Executable()
+	if err != nil {
+		return "", err
+	}
+	if exe, err = filepath.EvalSymlinks(exe); err != nil {
+		return "", err
+	}
+	tmp, err := os.CreateTemp(filepath.Dir(exe), ".gomakefile-update-*")
+	if err != nil {
+		return "", fmt.Errorf("replacing %s: %w", exe, err)
+	}
+	defer os.Remove(tmp.Name())
+	if _, err := tmp.Write(binary); err != nil {
+		tmp.Close()
+		return "", fmt.Errorf("replacing %s: %w", exe, err)
+	}
+	if err := tmp.Close(); err != nil {
+		return "", fmt.Errorf("replacing %s: %w", exe, err)
+	}
+	if err := os.Chmod(tmp.Name(), 0755); err != nil {
+		return "", fmt.Errorf("replacing %s: %w", exe, err)
+	}
+	var old string
+	if runtime.GOOS == "windows" {
+		// A running executable can't be overwritten on Windows, but
+		// it can be renamed.
+		old = exe + ".old"
+		os.Remove(old)
+		if err := os.Rename(exe, old); err != nil {
+			return "", fmt.Errorf("replacing %s: %w", exe, err)
+		}
+	}
+	if err := os.Rename(tmp.Name(), exe); err != nil {
+		if old != "" {
+			// Put the executable back.
+			if rerr := os.Rename(old, exe); rerr != nil {
+				return "", fmt.Errorf("replacing %s: %w, and restoring it from %s: %v", exe, err, old, rerr)
+			}
+		}
+		return "", fmt.Errorf("replacing %s: %w", exe, err)
+	}
+	logger.Debug("replaced executable", "path", exe, "bytes", len(binary))
+	return exe, nil
+}
+
+// selfUpdateResult is the outcome of the self-update command.
+type selfUpdateResult struct {
+	Current string `json:"current"`
+	Release string `json:"release"`
+	Updated bool   `json:"updated"`
+	Path    string `json:"path,omitempty"`
+}
+
+func (r selfUpdateResult) text() string {
+	switch {
+	case r.Updated:
+		return fmt.Sprintf("gomakefile was updated from %s to %s at %s", r.Current, r.Release, r.Path)
+	case sameVersion(r.Current, r.Release):
+		return fmt.Sprintf("gomakefile %s is up to date", r.Current)
+	case r.Current != devVersion && compareVersions(r.Current, r.Release) > 0:
+		return fmt.Sprintf("gomakefile %s is newer than release %s; use --version to install it", r.Current, r.Release)
+	default:
+		return fmt.Sprintf("gomakefile %s is available, current version is %s; run gomakefile self-update to install it", r.Release, r.Current)
+	}
+}
@@ -0,0 +1,306 @@
+// Copyright (c) 2023 Tiago Melo. All rights reserved.
+// Use of this source code is governed by the MIT License that can be found in
+// the LICENSE file.
+
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"os"
+	"os/signal"
+	"path/filepath"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/tiagomelo/go-makefile-gen/mfile"
+)
+
+// maxRequestSize is the size limit of the requests to the API.
+const maxRequestSize = 1 << 20
+
+// ServeCommand is used to expose the generator as an HTTP API
+type ServeCommand struct {
+	Addr string `long:"addr" description:"Address the API listens on" default:"localhost:8080"`
+}
+
+// Execute is the method invoked for the serve command
+func (s *ServeCommand) Execute(args []string) error {
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+	defer stop()
+	ln, err := net.Listen("tcp", s.Addr)
+	if err != nil {
+		return err
+	}
+	srv := &http.Server{Handler: newAPI(), ReadHeaderTimeout: 10 * time.Second}
+	logger.Info(fmt.Sprintf("Serving the API at http://%s, press Ctrl+C to stop", ln.Addr()))
+	served := make(chan error, 1)
+	go func() {
+		served <- srv.Serve(ln)
+	}()
+	select {
+	case err := <-served:
+		return err
+	case <-ctx.Done():
+		shutdownCtx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+		return srv.Shutdown(shutdownCtx)
+	}
+}
+
+// newAPI returns the handler of the API, whose endpoints take and return
+// JSON, working on the Makefile held by the request rather than on files
+// of the server:
+//   - POST /generate generates a Makefile from a spec;
+//   - POST /addtarget adds a target to a Makefile;
+//   - POST /lint checks a Makefile for common mistakes;
+//   - POST /export converts a Makefile into the file of another tool.
+func newAPI() http.Handler {
+	mux := http.NewServeMux()
+	mux.Handle("/generate", apiHandler(serveGenerate))
+	mux.Handle("/addtarget", apiHandler(serveAddTarget))
+	mux.Handle("/lint", apiHandler(serveLint))
+	mux.Handle("/export", apiHandler(serveExport))
+	return mux
+}
+
+// apiMu serializes the requests to the API, as mfile is configured with
+// package-level state.
+var apiMu sync.Mutex
+
+// apiHandler returns the handler of an endpoint of the API, decoding the
+// JSON request and calling serve with it and a temporary directory to
+// write the Makefile to. The result of serve is encoded as JSON, and its
+// error as an errorResult, with the status code of its cause.
+func apiHandler[Request any](serve func(dir string, req *Request) (any, error)) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost {
+			w.Header().Set("Allow", http.MethodPost)
+			writeJSON(w, http.StatusMethodNotAllowed, errorResult{Error: "method not allowed, use POST"})
+			return
+		}
+		req := new(Request)
+		dec := json.NewDecoder(http.MaxBytesReader(w, r.Body, maxRequestSize))
+		dec.DisallowUnknownFields()
+		if err := dec.Decode(req); err != nil {
+			writeJSON(w, http.StatusBadRequest, errorResult{Error: "decoding request: " + err.Error()})
+			return
+		}
+		dir, err := os.MkdirTemp("", "gomakefile-serve-")
+		if err != nil {
+			writeJSON(w, http.StatusInternalServerError, errorResult{Error: err.Error()})
+			return
+		}
+		defer os.RemoveAll(dir)
+		apiMu.Lock()
+		res, err := serve(dir, req)
+		apiMu.Unlock()
+		if err != nil {
+			logger.Debug("request failed", "path", r.URL.Path, "error", err)
+			// The temporary directory means nothing to the client.
+			msg := strings.ReplaceAll(err.Error(), filepath.Join(dir, makefileName), makefileName)
+			writeJSON(w, statusCode(err), errorResult{Error: msg})
+			return
+		}
+		writeJSON(w, http.StatusOK, res)
+	})
+}
+
+// statusCodes maps the mfile sentinel errors to HTTP status codes.
+var statusCodes = []struct {
+	err  error
+	code int
+}{
+	{mfile.ErrTargetExists, http.StatusConflict},
+	{mfile.ErrTargetNotFound, http.StatusNotFound},
+}
+
+// statusCode returns the HTTP status code for the given error, the
+// failures not listed in statusCodes being caused by the request.
+func statusCode(err error) int {
+	for _, sc := range statusCodes {
+		if errors.Is(err, sc.err) {
+			return sc.code
+		}
+	}
+	return http.StatusUnprocessableEntity
+}
+
+// writeJSON writes the given value as the JSON response, with the given
+// status code.
+func writeJSON(w http.ResponseWriter, code int, v any) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(code)
+	if err := json.NewEncoder(w).Encode(v); err != nil {
+		logger.Debug("writing response failed", "error", err)
+	}
+}
+
+// writeRequestMakefile writes the given Makefile content of a request to
+// the given directory, returning its path.
+func writeRequestMakefile(dir, content string) (string, error) {
+	path := filepath.Join(dir, makefileName)
+	return path, os.WriteFile(path, []byte(content), 0644)
+}
+
+// readResponseMakefile reads the Makefile at the given path into a
+// makefileResponse.
+func readResponseMakefile(path string) (any, error) {
+	content, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	return makefileResponse{Makefile: string(content)}, nil
+}
+
+// makefileResponse is the response of the endpoints changing a Makefile.
+type makefileResponse struct {
+	Makefile string `json:"makefile"`
+}
+
+// generateRequest is the request of POST /generate.
+type generateRequest struct {
+	// Spec declares how the Makefile is generated, with the keys of the
+	// spec files, like {"flavor": "full", "presets": ["docker"]}.
+	Spec json.RawMessage `json:"spec"`
+}
+
+// serveGenerate generates a Makefile from the spec of the given request.
+func serveGenerate(dir string, req *generateRequest) (any, error) {
+	var spec mfile.Spec
+	if len(req.Spec) > 0 {
+		s, err := mfile.ParseSpec(req.Spec)
+		if err != nil {
+			return nil, err
+		}
+		spec = *s
+	}
+	if err := checkAPISpec(&spec); err != nil {
+		return nil, err
+	}
+	if err := mfile.Generate(dir, spec.Options()...); err != nil {
+		return nil, err
+	}
+	return readResponseMakefile(filepath.Join(dir, makefileName))
+}
+
+// checkAPISpec checks that the given spec of a request does not make the
+// server read, fetch or write anything but the Makefile of the request:
+// its template must be a registered one, and its fragments directory and
+// environment file local paths, which are relative to the directory of
+// the Makefile.
+func checkAPISpec(spec *mfile.Spec) error {
+	if spec.Template != "" {
+		templates, err := mfile.Templates()
+		if err != nil {
+			return err
+		}
+		registered := slices.ContainsFunc(templates, func(t mfile.RegisteredTemplate) bool {
+			return t.Name == spec.Template
+		})
+		if !registered {
+			return fmt.Errorf("invalid spec: template %q is not registered, the API only generates from registered templates", spec.Template)
+		}
+	}
+	for _, p := range []struct{ key, path string }{
+		{"fragments", spec.Fragments},
+		{"env-file", spec.EnvFile},
+	} {
+		if p.path != "" && !filepath.IsLocal(p.path) {
+			return fmt.Errorf("invalid spec: %s %q must be a relative path within the project", p.key, p.path)
+		}
+	}
+	return nil
+}
+
+// addTargetRequest is the request of POST /addtarget.
+type addTargetRequest struct {
+	Makefile     string   `json:"makefile"`
+	Target       string   `json:"target"`
+	Content      string   `json:"content"`
+	Dependencies []string `json:"dependencies"`
+	Aliases      []string `json:"aliases"`
+	Namespace    string   `json:"namespace"`
+	Replace      bool     `json:"replace"`
+}
+
+// serveAddTarget adds the target of the given request to its Makefile.
+func serveAddTarget(dir string, req *addTargetRequest) (any, error) {
+	path, err := writeRequestMakefile(dir, req.Makefile)
+	if err != nil {
+		return nil, err
+	}
+	policy := mfile.ErrorIfExists
+	if req.Replace {
+		policy = mfile.ReplaceIfExists
+	}
+	err = mfile.AddTarget(path, req.Target,
+		mfile.WithContent(req.Content),
+		mfile.WithDependencies(req.Dependencies...),
+		mfile.WithAliases(req.Aliases...),
+		mfile.WithNamespace(req.Namespace),
+		mfile.WithExistsPolicy(policy),
+	)
+	if err != nil {
+		return nil, err
+	}
+	return readResponseMakefile(path)
+}
+
+// lintRequest is the request of POST /lint.
+type lintRequest struct {
+	Makefile string `json:"makefile"`
+	Dialect  string `json:"dialect"` // Defaults to gnu.
+}
+
+// serveLint checks the Makefile of the given request for common
+// mistakes. Unlike the lint command, it does not check it against the
+// installed make.
+func serveLint(dir string, req *lintRequest) (any, error) {
+	path, err := writeRequestMakefile(dir, req.Makefile)
+	if err != nil {
+		return nil, err
+	}
+	if req.Dialect == "" {
+		req.Dialect = mfile.DialectGNU
+	}
+	issues, err := mfile.LintMakefile(path, req.Dialect)
+	if err != nil {
+		return nil, err
+	}
+	r := lintResult{Issues: append([]string{}, issues...)}
+	if req.Dialect == mfile.DialectGNU {
+		requirements, err := mfile.MakeRequirements(path)
+		if err != nil {
+			return nil, err
+		}
+		r.RequiredMake = mfile.RequiredMakeVersion(requirements)
+	}
+	return r, nil
+}
+
+// exportRequest is the request of POST /export.
+type exportRequest struct {
+	Makefile string `json:"makefile"`
+	Format   string `json:"format"`
+}
+
+// serveExport converts the Makefile of the given request into the
+// format it selects.
+func serveExport(dir string, req *exportRequest) (any, error) {
+	path, err := writeRequestMakefile(dir, req.Makefile)
+	if err != nil {
+		return nil, err
+	}
+	content, err := mfile.Export(path, req.Format)
+	if err != nil {
+		return nil, err
+	}
+	return exportResult{Format: req.Format, Content: string(content)}, nil
+}
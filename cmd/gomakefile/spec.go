@@ -0,0 +1,107 @@
+// Copyright (c) 2023 Tiago Melo. All rights reserved.
+// Use of this source code is governed by the MIT License that can be found in
+// the LICENSE file.
+
+package main
+
+import (
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/tiagomelo/go-makefile-gen/mfile"
+)
+
+// SpecCommand is used to work with the spec files declaring how Makefiles are generated
+type SpecCommand struct {
+	Schema   SpecSchemaCommand   `command:"schema" description:"Print the JSON Schema of the spec files, for editors to complete and check them"`
+	Validate SpecValidateCommand `command:"validate" description:"Check a spec file against the JSON Schema of the spec files"`
+}
+
+// SpecSchemaCommand is used to print the JSON Schema of the spec files
+type SpecSchemaCommand struct {
+	OutputFile string `short:"f" long:"file" description:"Write the schema to this file instead of stdout"`
+}
+
+// Execute is the method invoked for the spec schema command
+func (s *SpecSchemaCommand) Execute(args []string) error {
+	schema, err := mfile.SpecSchema()
+	if err != nil {
+		return err
+	}
+	if s.OutputFile == "" {
+		fmt.Print(string(schema))
+		return nil
+	}
+	if err := os.WriteFile(s.OutputFile, schema, 0644); err != nil {
+		return err
+	}
+	absPath, err := absPath(s.OutputFile)
+	if err != nil {
+		return err
+	}
+	return report(specSchemaResult{Path: absPath})
+}
+
+// SpecValidateCommand is used to check a spec file against the JSON Schema of the spec files
+type SpecValidateCommand struct {
+	Spec string `long:"spec" description:"Spec file declaring how the Makefile is generated" default:"makefile.yaml"`
+}
+
+// Execute is the method invoked for the spec validate command
+func (s *SpecValidateCommand) Execute(args []string) error {
+	content, err := os.ReadFile(s.Spec)
+	if err != nil {
+		return err
+	}
+	violations, err := mfile.ValidateSpec(content)
+	if err != nil {
+		return fmt.Errorf("parsing spec at %s: %w", s.Spec, err)
+	}
+	r := specValidateResult{Spec: s.Spec, Violations: []specViolation{}}
+	for _, v := range violations {
+		r.Violations = append(r.Violations, specViolation(*v))
+	}
+	if err := show(r); err != nil {
+		return err
+	}
+	if len(violations) > 0 {
+		return fmt.Errorf("%d violation(s) found", len(violations))
+	}
+	return nil
+}
+
+// specSchemaResult is the outcome of the spec schema command.
+type specSchemaResult struct {
+	Path string `json:"path"`
+}
+
+func (r specSchemaResult) text() string {
+	return fmt.Sprintf("Spec schema was generated successfully at %s", r.Path)
+}
+
+// specViolation is a violation of the schema of the spec files.
+type specViolation struct {
+	Line   int    `json:"line"`
+	Column int    `json:"column"`
+	Field  string `json:"field,omitempty"`
+	Reason string `json:"reason"`
+}
+
+// specValidateResult is the outcome of the spec validate command.
+type specValidateResult struct {
+	Spec       string          `json:"spec"`
+	Violations []specViolation `json:"violations"`
+}
+
+func (r specValidateResult) text() string {
+	if len(r.Violations) == 0 {
+		return fmt.Sprintf("%s is a valid spec", r.Spec)
+	}
+	var sb strings.Builder
+	for _, v := range r.Violations {
+		e := mfile.SpecError(v)
+		fmt.Fprintf(&sb, "%s: %s\n", r.Spec, e.Error())
+	}
+	return strings.TrimSuffix(sb.String(), "\n")
+}
@@ -0,0 +1,72 @@
+// Copyright (c) 2023 Tiago Melo. All rights reserved.
+// Use of this source code is governed by the MIT License that can be found in
+// the LICENSE file.
+
+package main
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/tiagomelo/go-makefile-gen/mfile"
+)
+
+// StatsCommand is used to report the size and complexity of a Makefile
+type StatsCommand struct {
+	MakefilePath string `short:"p" long:"path" description:"Path to the Makefile" default:"."`
+}
+
+// Execute is the method invoked for the stats command
+func (s *StatsCommand) Execute(args []string) error {
+	stats, err := mfile.Stats(s.MakefilePath)
+	if err != nil {
+		return err
+	}
+	r := statsResult{
+		Targets:      stats.Targets,
+		Variables:    stats.Variables,
+		DeepestChain: append([]string{}, stats.DeepestChain...),
+		Undocumented: append([]string{}, stats.Undocumented...),
+		Tools:        append([]string{}, stats.Tools...),
+	}
+	if stats.LongestRecipe != "" {
+		r.LongestRecipe = &longestRecipe{Target: stats.LongestRecipe, Lines: stats.LongestRecipeLength}
+	}
+	return show(r)
+}
+
+// longestRecipe is the target with the most recipe lines.
+type longestRecipe struct {
+	Target string `json:"target"`
+	Lines  int    `json:"lines"`
+}
+
+// statsResult is the outcome of the stats command.
+type statsResult struct {
+	Targets       int            `json:"targets"`
+	Variables     int            `json:"variables"`
+	LongestRecipe *longestRecipe `json:"longestRecipe,omitempty"`
+	DeepestChain  []string       `json:"deepestChain"`
+	Undocumented  []string       `json:"undocumented"`
+	Tools         []string       `json:"tools"`
+}
+
+func (r statsResult) text() string {
+	lines := []string{
+		fmt.Sprintf("targets: %d", r.Targets),
+		fmt.Sprintf("variables: %d", r.Variables),
+	}
+	if r.LongestRecipe != nil {
+		lines = append(lines, fmt.Sprintf("longest recipe: %s (%d lines)", r.LongestRecipe.Target, r.LongestRecipe.Lines))
+	}
+	if len(r.DeepestChain) > 1 {
+		lines = append(lines, fmt.Sprintf("deepest dependency chain: %s (%d targets)", strings.Join(r.DeepestChain, " -> "), len(r.DeepestChain)))
+	}
+	if len(r.Undocumented) > 0 {
+		lines = append(lines, fmt.Sprintf("undocumented targets: %s", strings.Join(r.Undocumented, ", ")))
+	}
+	if len(r.Tools) > 0 {
+		lines = append(lines, fmt.Sprintf("external tools: %s", strings.Join(r.Tools, ", ")))
+	}
+	return strings.Join(lines, "\n")
+}
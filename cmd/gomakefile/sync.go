@@ -0,0 +1,64 @@
+// Copyright (c) 2023 Tiago Melo. All rights reserved.
+// Use of this source code is governed by the MIT License that can be found in
+// the LICENSE file.
+
+package main
+
+import (
+	"strings"
+
+	"github.com/tiagomelo/go-makefile-gen/mfile"
+)
+
+// SyncCommand is used to reconcile a Makefile with the project it sits in
+type SyncCommand struct {
+	MakefilePath string `short:"p" long:"path" description:"Path to the Makefile" default:"."`
+	DryRun       bool   `short:"n" long:"dry-run" description:"Only print the changes, without writing the Makefile"`
+}
+
+// Execute is the method invoked for the sync command
+func (s *SyncCommand) Execute(args []string) error {
+	result, err := mfile.Sync(s.MakefilePath, s.DryRun)
+	if err != nil {
+		return err
+	}
+	return show(syncResult{
+		Added:    result.Added,
+		Removed:  result.Removed,
+		Updated:  result.Updated,
+		Modified: result.Modified,
+		DryRun:   s.DryRun,
+	})
+}
+
+// syncResult is the outcome of the sync command.
+type syncResult struct {
+	Added    []string `json:"added"`
+	Removed  []string `json:"removed"`
+	Updated  []string `json:"updated"`
+	Modified []string `json:"modified"`
+	DryRun   bool     `json:"dryRun"`
+}
+
+func (r syncResult) text() string {
+	var lines []string
+	for _, t := range r.Added {
+		lines = append(lines, "+ "+t)
+	}
+	for _, t := range r.Removed {
+		lines = append(lines, "- "+t)
+	}
+	for _, t := range r.Updated {
+		lines = append(lines, "~ "+t+": dependencies updated")
+	}
+	for _, t := range r.Modified {
+		lines = append(lines, "! "+t+": edited by hand, left as it is")
+	}
+	if len(lines) == len(r.Modified) {
+		lines = append([]string{"Makefile is in sync with the project"}, lines...)
+	}
+	if r.DryRun && len(lines) > len(r.Modified) {
+		lines = append(lines, "dry run, the Makefile was not written")
+	}
+	return strings.Join(lines, "\n")
+}
@@ -0,0 +1,150 @@
+// Copyright (c) 2023 Tiago Melo. All rights reserved.
+// Use of this source code is governed by the MIT License that can be found in
+// the LICENSE file.
+
+package main
+
+import (
+	"fmt"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/tiagomelo/go-makefile-gen/mfile"
+)
+
+// TemplateCommand is used to manage the local cache of named templates
+type TemplateCommand struct {
+	List     TemplateListCommand     `command:"list" description:"List the registered templates"`
+	Add      TemplateAddCommand      `command:"add" description:"Fetch a template and register it with a name"`
+	Update   TemplateUpdateCommand   `command:"update" description:"Fetch the registered templates again"`
+	Validate TemplateValidateCommand `command:"validate" description:"Check that a template renders a sound Makefile"`
+	Diff     TemplateDiffCommand     `command:"diff" description:"Show how the built-in flavors, presets and templates changed between releases"`
+}
+
+// TemplateListCommand is used to list the registered templates
+type TemplateListCommand struct{}
+
+// Execute is the method invoked for the template list command
+func (t *TemplateListCommand) Execute(args []string) error {
+	templates, err := mfile.Templates()
+	if err != nil {
+		return err
+	}
+	r := templatesResult{Templates: []templateResult{}}
+	for _, tmpl := range templates {
+		r.Templates = append(r.Templates, templateResult(tmpl))
+	}
+	return show(r)
+}
+
+// TemplateAddCommand is used to register a template
+type TemplateAddCommand struct {
+	Args struct {
+		Name   string `positional-arg-name:"name" description:"Name of the template"`
+		Source string `positional-arg-name:"source" description:"Source of the template, like github.com/org/repo//dir?ref=v1.0.0"`
+	} `positional-args:"yes" required:"yes"`
+}
+
+// Execute is the method invoked for the template add command
+func (t *TemplateAddCommand) Execute(args []string) error {
+	if err := mfile.AddTemplate(t.Args.Name, t.Args.Source); err != nil {
+		return err
+	}
+	return report(templateActionResult{Action: "registered", Templates: []string{t.Args.Name}})
+}
+
+// TemplateUpdateCommand is used to fetch the registered templates again
+type TemplateUpdateCommand struct {
+	Args struct {
+		Names []string `positional-arg-name:"name" description:"Names of the templates to update; all of them if none is given"`
+	} `positional-args:"yes"`
+}
+
+// Execute is the method invoked for the template update command
+func (t *TemplateUpdateCommand) Execute(args []string) error {
+	if err := mfile.UpdateTemplates(t.Args.Names...); err != nil {
+		return err
+	}
+	return report(templateActionResult{Action: "updated", Templates: t.Args.Names})
+}
+
+// TemplateValidateCommand is used to validate a custom template
+type TemplateValidateCommand struct {
+	Target bool   `short:"t" long:"target" description:"Validate a target block template, as used by addtarget, instead of a Makefile template"`
+	Values string `long:"values" description:"YAML file holding values the Makefile template can use as {{ .Values.<key> }}"`
+	Args   struct {
+		File string `positional-arg-name:"file" description:"File holding the template"`
+	} `positional-args:"yes" required:"yes"`
+}
+
+// Execute is the method invoked for the template validate command
+func (t *TemplateValidateCommand) Execute(args []string) error {
+	text, err := os.ReadFile(t.Args.File)
+	if err != nil {
+		return err
+	}
+	kind := "Makefile"
+	if t.Target {
+		kind = "target"
+		err = mfile.ValidateTargetTemplate(string(text))
+	} else {
+		var values map[string]any
+		if t.Values != "" {
+			if values, err = mfile.ReadValues(t.Values); err != nil {
+				return err
+			}
+		}
+		err = mfile.ValidateMakefileTemplate(string(text), values)
+	}
+	if err != nil {
+		return err
+	}
+	return report(templateValidateResult{File: t.Args.File, Kind: kind})
+}
+
+// templateResult describes a registered template.
+type templateResult struct {
+	Name      string    `json:"name"`
+	Source    string    `json:"source"`
+	FetchedAt time.Time `json:"fetchedAt"`
+}
+
+// templatesResult is the outcome of the template list command.
+type templatesResult struct {
+	Templates []templateResult `json:"templates"`
+}
+
+func (r templatesResult) text() string {
+	if len(r.Templates) == 0 {
+		return "no template registered"
+	}
+	var sb strings.Builder
+	for _, t := range r.Templates {
+		fmt.Fprintf(&sb, "%-16s %s (fetched %s)\n", t.Name, t.Source, t.FetchedAt.Format(time.RFC3339))
+	}
+	return strings.TrimSuffix(sb.String(), "\n")
+}
+
+// templateActionResult is the outcome of the template add and update commands.
+type templateActionResult struct {
+	Action    string   `json:"action"`
+	Templates []string `json:"templates,omitempty"`
+}
+
+// templateValidateResult is the outcome of the template validate command.
+type templateValidateResult struct {
+	File string `json:"file"`
+	Kind string `json:"kind"`
+}
+
+func (r templateValidateResult) text() string {
+	return fmt.Sprintf("%s is a valid %s template", r.File, r.Kind)
+}
+
+func (r templateActionResult) text() string {
+	if len(r.Templates) == 0 {
+		return fmt.Sprintf("Templates were %s successfully", r.Action)
+	}
+	return fmt.Sprintf("Template %s was %s successfully", strings.Join(r.Templates, ", "), r.Action)
+}
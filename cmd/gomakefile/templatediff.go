@@ -0,0 +1,119 @@
+// Copyright (c) 2023 Tiago Melo. All rights reserved.
+// Use of this source code is governed by the MIT License that can be found in
+// the LICENSE file.
+
+package main
+
+import (
+	"archive/tar"
+	"bytes"
+	"compress/gzip"
+	"errors"
+	"fmt"
+	"io"
+	"path"
+	"strings"
+
+	"github.com/tiagomelo/go-makefile-gen/mfile"
+)
+
+// sourceArchiveURL is the URL of the source archive of the release with
+// the given tag.
+const sourceArchiveURL = "https://github.com/tiagomelo/go-makefile-gen/archive/refs/tags/%s.tar.gz"
+
+// builtinSources are the patterns of the files of the repository the
+// built-in content is declared in: the target templates, and the Go files
+// declaring the flavors and presets, which lived in preset.go before
+// builtin.go.
+var builtinSources = []string{"mfile/templates/*.tmpl", "mfile/flavor.go", "mfile/builtin.go", "mfile/preset.go"}
+
+// TemplateDiffCommand is used to compare the built-in content of two
+// releases
+type TemplateDiffCommand struct {
+	From string `long:"from" description:"Release to compare from, like v0.3.0" required:"yes"`
+	To   string `long:"to" description:"Release to compare to, like v0.5.0; defaults to the release of the running gomakefile"`
+}
+
+// Execute is the method invoked for the template diff command
+func (t *TemplateDiffCommand) Execute(args []string) error {
+	to := t.To
+	if to == "" {
+		if to = currentVersion(); to == devVersion {
+			return errors.New("gomakefile was built from source, give the release to compare to with --to")
+		}
+	}
+	from, err := releaseSources(t.From)
+	if err != nil {
+		return err
+	}
+	current, err := releaseSources(to)
+	if err != nil {
+		return err
+	}
+	return show(templateDiffResult{From: t.From, To: to, Diff: mfile.DiffContent(t.From, to, from, current)})
+}
+
+// releaseSources returns the content of the files declaring the built-in
+// content of the release with the given tag, see builtinSources, by path,
+// read from its source archive. Nothing of the release is run.
+func releaseSources(tag string) (map[string]string, error) {
+	archive, err := download(fmt.Sprintf(sourceArchiveURL, tag))
+	if err != nil {
+		return nil, err
+	}
+	gz, err := gzip.NewReader(bytes.NewReader(archive))
+	if err != nil {
+		return nil, fmt.Errorf("reading the source archive of release %s: %w", tag, err)
+	}
+	sources := make(map[string]string)
+	tr := tar.NewReader(gz)
+	for {
+		hdr, err := tr.Next()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, fmt.Errorf("reading the source archive of release %s: %w", tag, err)
+		}
+		// The files are in a directory named after the repository and
+		// the tag.
+		_, name, ok := strings.Cut(hdr.Name, "/")
+		if !ok || hdr.Typeflag != tar.TypeReg || !isBuiltinSource(name) {
+			continue
+		}
+		content, err := io.ReadAll(tr)
+		if err != nil {
+			return nil, fmt.Errorf("reading the source archive of release %s: %w", tag, err)
+		}
+		sources[name] = string(content)
+	}
+	if len(sources) == 0 {
+		return nil, fmt.Errorf("release %s has no built-in content to compare", tag)
+	}
+	return sources, nil
+}
+
+// isBuiltinSource reports whether the file at the given path of the
+// repository declares built-in content, see builtinSources.
+func isBuiltinSource(name string) bool {
+	for _, pattern := range builtinSources {
+		if ok, _ := path.Match(pattern, name); ok {
+			return true
+		}
+	}
+	return false
+}
+
+// templateDiffResult is the outcome of the template diff command.
+type templateDiffResult struct {
+	From string `json:"from"`
+	To   string `json:"to"`
+	Diff string `json:"diff"`
+}
+
+func (r templateDiffResult) text() string {
+	if r.Diff == "" {
+		return fmt.Sprintf("the built-in content of %s and %s is the same", r.From, r.To)
+	}
+	return strings.TrimSuffix(r.Diff, "\n")
+}
@@ -0,0 +1,100 @@
+// Copyright (c) 2023 Tiago Melo. All rights reserved.
+// Use of this source code is governed by the MIT License that can be found in
+// the LICENSE file.
+
+package main
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/tiagomelo/go-makefile-gen/mfile"
+)
+
+// UndoCommand is used to revert the last operations recorded in the audit
+// log of a Makefile
+type UndoCommand struct {
+	MakefilePath string `short:"p" long:"path" description:"Path to the Makefile" default:"."`
+	Steps        int    `short:"n" long:"steps" description:"Number of operations to revert" default:"1"`
+	List         bool   `long:"list" description:"List the operations that can be reverted instead, newest first"`
+	Force        bool   `long:"force" description:"Revert even if the Makefile changed since its last recorded operation"`
+}
+
+// Execute is the method invoked for the undo command
+func (u *UndoCommand) Execute(args []string) error {
+	makefile, err := makefileFile(u.MakefilePath)
+	if err != nil {
+		return err
+	}
+	if !mfile.HistoryEnabled(makefile) {
+		return fmt.Errorf("no operation recorded for %s, run the commands modifying it with --audit to undo them", makefile)
+	}
+	if u.List {
+		points, err := mfile.RestorePoints(makefile)
+		if err != nil {
+			return err
+		}
+		return show(restorePointsResult{Makefile: makefile, RestorePoints: operations(points)})
+	}
+	reverted, err := mfile.Undo(makefile, u.Steps, u.Force, mfile.HistoryEntry{Command: commandLine(), User: userName()})
+	if err != nil {
+		return err
+	}
+	return report(undoResult{Makefile: makefile, Reverted: operations(reverted)})
+}
+
+// operation is a recorded operation of the audit log.
+type operation struct {
+	Time    time.Time `json:"time"`
+	Command string    `json:"command"`
+	User    string    `json:"user,omitempty"`
+}
+
+// operations returns the operations of the given history entries.
+func operations(entries []mfile.HistoryEntry) []operation {
+	ops := make([]operation, len(entries))
+	for i, e := range entries {
+		ops[i] = operation{Time: e.Time, Command: e.Command, User: e.User}
+	}
+	return ops
+}
+
+func (o operation) text() string {
+	s := o.Time.Local().Format("2006-01-02 15:04:05") + "  " + o.Command
+	if o.User != "" {
+		s += " (" + o.User + ")"
+	}
+	return s
+}
+
+// restorePointsResult is the outcome of the undo command with --list.
+type restorePointsResult struct {
+	Makefile      string      `json:"makefile"`
+	RestorePoints []operation `json:"restorePoints"`
+}
+
+func (r restorePointsResult) text() string {
+	if len(r.RestorePoints) == 0 {
+		return "no operation to undo"
+	}
+	lines := make([]string, len(r.RestorePoints))
+	for i, o := range r.RestorePoints {
+		lines[i] = fmt.Sprintf("%3d  %s", i+1, o.text())
+	}
+	return strings.Join(lines, "\n")
+}
+
+// undoResult is the outcome of the undo command.
+type undoResult struct {
+	Makefile string      `json:"makefile"`
+	Reverted []operation `json:"reverted"`
+}
+
+func (r undoResult) text() string {
+	lines := []string{fmt.Sprintf("Reverted %d operation(s) on %s:", len(r.Reverted), r.Makefile)}
+	for _, o := range r.Reverted {
+		lines = append(lines, "  "+o.text())
+	}
+	return strings.Join(lines, "\n")
+}
@@ -0,0 +1,61 @@
+// Copyright (c) 2023 Tiago Melo. All rights reserved.
+// Use of this source code is governed by the MIT License that can be found in
+// the LICENSE file.
+
+package main
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/tiagomelo/go-makefile-gen/mfile"
+)
+
+// UpgradeCommand is used to regenerate the managed block of a Makefile
+// with the templates of this release
+type UpgradeCommand struct {
+	MakefilePath string `short:"p" long:"path" description:"Path to the Makefile" default:"."`
+	DryRun       bool   `short:"n" long:"dry-run" description:"Only print the changes, without writing the Makefile"`
+	Force        bool   `long:"force" description:"Upgrade even if the managed block was edited by hand, losing the edits"`
+}
+
+// Execute is the method invoked for the upgrade command
+func (u *UpgradeCommand) Execute(args []string) error {
+	makefile, err := makefileFile(u.MakefilePath)
+	if err != nil {
+		return err
+	}
+	result, err := mfile.Upgrade(makefile, u.Force, u.DryRun)
+	if err != nil {
+		return err
+	}
+	return show(upgradeResult{
+		Makefile:    makefile,
+		FromVersion: result.FromVersion,
+		ToVersion:   result.ToVersion,
+		Diff:        result.Diff,
+		DryRun:      u.DryRun,
+	})
+}
+
+// upgradeResult is the outcome of the upgrade command.
+type upgradeResult struct {
+	Makefile    string `json:"makefile"`
+	FromVersion int    `json:"fromVersion"`
+	ToVersion   int    `json:"toVersion"`
+	Diff        string `json:"diff,omitempty"`
+	DryRun      bool   `json:"dryRun"`
+}
+
+func (r upgradeResult) text() string {
+	if r.Diff == "" {
+		return fmt.Sprintf("%s is up to date with template version %d", r.Makefile, r.ToVersion)
+	}
+	lines := []string{strings.TrimSuffix(r.Diff, "\n")}
+	if r.DryRun {
+		lines = append(lines, fmt.Sprintf("dry run, %s would be upgraded from template version %d to %d", r.Makefile, r.FromVersion, r.ToVersion))
+	} else {
+		lines = append(lines, fmt.Sprintf("upgraded %s from template version %d to %d", r.Makefile, r.FromVersion, r.ToVersion))
+	}
+	return strings.Join(lines, "\n")
+}
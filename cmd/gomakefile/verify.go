@@ -0,0 +1,67 @@
+// Copyright (c) 2023 Tiago Melo. All rights reserved.
+// Use of this source code is governed by the MIT License that can be found in
+// the LICENSE file.
+
+package main
+
+import (
+	"fmt"
+
+	"github.com/tiagomelo/go-makefile-gen/mfile"
+)
+
+// VerifyCommand is used to check that the Makefile is the one its spec generates
+type VerifyCommand struct {
+	MakefilePath string `short:"p" long:"path" description:"Path to the Makefile" default:"."`
+	Spec         string `long:"spec" description:"Spec file declaring how the Makefile is generated" default:"makefile.yaml"`
+	Fix          bool   `long:"fix" description:"Regenerate the Makefile from the spec when it differs"`
+}
+
+// Execute is the method invoked for the verify command
+func (v *VerifyCommand) Execute(args []string) error {
+	diff, err := mfile.Verify(v.MakefilePath, v.Spec)
+	if err != nil {
+		return err
+	}
+	makefile, err := makefileFile(v.MakefilePath)
+	if err != nil {
+		return err
+	}
+	r := verifyResult{Path: makefile, Spec: v.Spec, Diff: diff}
+	if diff == "" {
+		return report(r)
+	}
+	if v.Fix {
+		spec, err := mfile.ReadSpec(v.Spec)
+		if err != nil {
+			return err
+		}
+		if err := mfile.Generate(v.MakefilePath, append(spec.Options(), mfile.WithOverwrite(true))...); err != nil {
+			return err
+		}
+		r.Fixed = true
+		return report(r)
+	}
+	if err := show(r); err != nil {
+		return err
+	}
+	return fmt.Errorf("the Makefile differs from what %s generates, run gomakefile verify --fix to regenerate it", v.Spec)
+}
+
+// verifyResult is the outcome of the verify command.
+type verifyResult struct {
+	Path  string `json:"path"`
+	Spec  string `json:"spec"`
+	Diff  string `json:"diff,omitempty"`
+	Fixed bool   `json:"fixed,omitempty"`
+}
+
+func (r verifyResult) text() string {
+	switch {
+	case r.Diff == "":
+		return fmt.Sprintf("%s is up to date with %s", r.Path, r.Spec)
+	case r.Fixed:
+		return fmt.Sprintf("%s was regenerated from %s", r.Path, r.Spec)
+	}
+	return r.Diff
+}
@@ -0,0 +1,60 @@
+// Copyright (c) 2023 Tiago Melo. All rights reserved.
+// Use of this source code is governed by the MIT License that can be found in
+// the LICENSE file.
+
+package main
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"os/signal"
+	"time"
+
+	"github.com/tiagomelo/go-makefile-gen/mfile"
+)
+
+// WatchCommand is used to regenerate the Makefile from a spec on change
+type WatchCommand struct {
+	MakefilePath string        `short:"p" long:"path" description:"Path to the Makefile" default:"."`
+	Spec         string        `long:"spec" description:"Spec file declaring how the Makefile is generated" default:"makefile.yaml"`
+	Interval     time.Duration `long:"interval" description:"Interval at which changes are polled" default:"1s"`
+}
+
+// Execute is the method invoked for the watch command
+func (w *WatchCommand) Execute(args []string) error {
+	if w.Interval <= 0 {
+		return fmt.Errorf("invalid --interval %s, want a positive duration", w.Interval)
+	}
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+	defer stop()
+	makefile, err := makefileFile(w.MakefilePath)
+	if err != nil {
+		return err
+	}
+	logger.Info(fmt.Sprintf("Watching %s, press Ctrl+C to stop", w.Spec))
+	var reportErr error
+	err = mfile.Watch(ctx, w.MakefilePath, w.Spec, w.Interval, func(diff string) {
+		if opts.Output == outputJSON {
+			if err := report(watchResult{Path: makefile, Diff: diff}); err != nil {
+				reportErr = err
+			}
+			return
+		}
+		fmt.Print(diff)
+	})
+	if err != nil {
+		return err
+	}
+	return reportErr
+}
+
+// watchResult is a regeneration of the watch command.
+type watchResult struct {
+	Path string `json:"path"`
+	Diff string `json:"diff"`
+}
+
+func (r watchResult) text() string {
+	return r.Diff
+}
@@ -0,0 +1,17 @@
+package main
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/tiagomelo/go-makefile-gen/mfile"
+)
+
+func main() {
+	const makeFilePath = "."
+	lines := []string{"Owned by the platform team.", "Ask in #platform before changing the release targets."}
+	if err := mfile.AddComment(makeFilePath, lines, mfile.Top); err != nil {
+		fmt.Println(err)
+		os.Exit(1)
+	}
+}
@@ -0,0 +1,18 @@
+package main
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/tiagomelo/go-makefile-gen/mfile"
+)
+
+func main() {
+	const makeFilePath = "."
+	image, err := mfile.Expand(makeFilePath, "$(IMAGE_NAME):$(IMAGE_TAG)")
+	if err != nil {
+		fmt.Println(err)
+		os.Exit(1)
+	}
+	fmt.Println(image)
+}
@@ -0,0 +1,27 @@
+// Copyright (c) 2023 Tiago Melo. All rights reserved.
+// Use of this source code is governed by the MIT License that can be found in
+// the LICENSE file.
+
+package main
+
+import (
+	"context"
+	"fmt"
+	"os"
+
+	"github.com/tiagomelo/go-makefile-gen/mfile/run"
+)
+
+func main() {
+	const makeFilePath = "."
+	result, err := run.Target(context.Background(), makeFilePath, "test",
+		run.WithEnv("CGO_ENABLED=0"),
+		run.WithStdout(os.Stdout),
+		run.WithStderr(os.Stderr),
+	)
+	if err != nil {
+		fmt.Println(err)
+		os.Exit(1)
+	}
+	os.Exit(result.ExitCode)
+}
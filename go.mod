@@ -4,13 +4,12 @@ go 1.21.3
 
 require (
 	github.com/jessevdk/go-flags v1.5.0
-	github.com/pkg/errors v0.9.1
 	github.com/stretchr/testify v1.8.4
+	gopkg.in/yaml.v3 v3.0.1
 )
 
 require (
 	github.com/davecgh/go-spew v1.1.1 // indirect
 	github.com/pmezard/go-difflib v1.0.0 // indirect
 	golang.org/x/sys v0.0.0-20210320140829-1e4c9ba3b0c4 // indirect
-	gopkg.in/yaml.v3 v3.0.1 // indirect
 )
@@ -0,0 +1,157 @@
+// Copyright (c) 2023 Tiago Melo. All rights reserved.
+// Use of this source code is governed by the MIT License that can be found in
+// the LICENSE file.
+
+package mfile
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// Op is an operation on a Makefile applied by Batch, like adding a target
+// with AddTargetOp or removing one with RemoveTargetOp.
+type Op struct {
+	name  string
+	apply func(m *Makefile, makeFilePath string) error
+}
+
+// AddTargetOp adds a target like AddTarget does, configured by the given
+// options.
+func AddTargetOp(targetName string, opts ...TargetOption) Op {
+	return Op{
+		name: "adding target " + targetName,
+		apply: func(m *Makefile, makeFilePath string) error {
+			return addTarget(fsProvider, m, makeFilePath, targetName, opts)
+		},
+	}
+}
+
+// addTarget adds a target to the given Makefile, at the given path of the
+// given file system, like AddTarget does, configured by the given options.
+func addTarget(fsys fileSystem, m *Makefile, makeFilePath, targetName string, opts []TargetOption) error {
+	name, data, o, err := targetTemplate(targetName, opts)
+	if err != nil {
+		return err
+	}
+	kept, block, rest, _, err := addTargetContent(fsys, makeFilePath, m.String(), name, data, o)
+	if e := (*Error)(nil); errors.As(err, &e) && e.Path == makeFilePath {
+		// Batch reports the operation and the path.
+		return e.Err
+	}
+	if err != nil {
+		return err
+	}
+	*m = *ParseString(kept + block + rest)
+	return nil
+}
+
+// RemoveTargetOp removes the given target, with the comments before its
+// rules, its recipes, its help comments and its .PHONY declaration. It
+// fails with ErrTargetNotFound if the target is not declared. Unless
+// forced, see WithForce, the only option it takes, targets managed by the
+// generator or special to make are refused with ErrReservedTarget.
+func RemoveTargetOp(target string, opts ...TargetOption) Op {
+	var o addTargetOptions
+	for _, opt := range opts {
+		opt(&o)
+	}
+	return Op{
+		name: "removing target " + target,
+		apply: func(m *Makefile, _ string) error {
+			if !o.force {
+				if err := checkReserved(target); err != nil {
+					return err
+				}
+			}
+			if _, _, _, _, ok := ruleBlock(m.lines, target); !ok {
+				return fmt.Errorf("looking up target %s: %w", target, ErrTargetNotFound)
+			}
+			m.removeTarget(target)
+			return nil
+		},
+	}
+}
+
+// AddDependencyOp adds a dependency to a target, see
+// Makefile.AddDependency.
+func AddDependencyOp(target, dependency string) Op {
+	return editOp("adding dependency "+dependency+" to "+target, func(m *Makefile) error {
+		return m.AddDependency(target, dependency)
+	})
+}
+
+// RemoveDependencyOp removes a dependency from a target, see
+// Makefile.RemoveDependency.
+func RemoveDependencyOp(target, dependency string) Op {
+	return editOp("removing dependency "+dependency+" from "+target, func(m *Makefile) error {
+		return m.RemoveDependency(target, dependency)
+	})
+}
+
+// SetTargetDescriptionOp sets the description of a target, see
+// Makefile.SetTargetDescription.
+func SetTargetDescriptionOp(target, description string) Op {
+	return editOp("setting description of "+target, func(m *Makefile) error {
+		return m.SetTargetDescription(target, description)
+	})
+}
+
+// AppendRecipeLineOp adds a command at the end of the recipe of a target,
+// see Makefile.AppendRecipeLine.
+func AppendRecipeLineOp(target, line string) Op {
+	return editOp("appending recipe line to "+target, func(m *Makefile) error {
+		return m.AppendRecipeLine(target, line)
+	})
+}
+
+// PrependRecipeLineOp adds a command at the start of the recipe of a
+// target, see Makefile.PrependRecipeLine.
+func PrependRecipeLineOp(target, line string) Op {
+	return editOp("prepending recipe line to "+target, func(m *Makefile) error {
+		return m.PrependRecipeLine(target, line)
+	})
+}
+
+// SetVariableOp sets the value of a variable, see Makefile.SetVariable.
+func SetVariableOp(name, value string) Op {
+	return editOp("setting variable "+name, func(m *Makefile) error {
+		return m.SetVariable(name, value)
+	})
+}
+
+// editOp returns the operation with the given name editing the model.
+func editOp(name string, edit func(m *Makefile) error) Op {
+	return Op{
+		name: name,
+		apply: func(m *Makefile, _ string) error {
+			return edit(m)
+		},
+	}
+}
+
+// Batch applies the given operations, in order, to the Makefile at the
+// given path, and writes it once, atomically, when they all succeed.
+// Each operation sees the changes made by the previous ones, like a
+// dependency added to a target added earlier in the batch. If one fails,
+// the Makefile is left as it is, and the error, marked like the one of
+// the operation, tells which one it was.
+func Batch(path string, ops ...Op) error {
+	m, err := Parse(path)
+	if err != nil {
+		return err
+	}
+	makeFilePath := mkFilePath(path)
+	for i, op := range ops {
+		if err := op.apply(m, makeFilePath); err != nil {
+			return &Error{Op: fmt.Sprintf("operation %d, %s", i+1, op.name), Path: makeFilePath, Err: err}
+		}
+	}
+	content := m.String()
+	if err := fsProvider.WriteFileFrom(makeFilePath, strings.NewReader(content), 0644); err != nil {
+		return &Error{Op: "writing Makefile", Path: makeFilePath, Err: err}
+	}
+	logger.Debug("applied batch", "path", makeFilePath, "operations", len(ops), "bytes", len(content))
+	return nil
+}
@@ -0,0 +1,145 @@
+// Copyright (c) 2023 Tiago Melo. All rights reserved.
+// Use of this source code is governed by the MIT License that can be found in
+// the LICENSE file.
+
+package mfile
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestBatch(t *testing.T) {
+	testCases := []struct {
+		name            string
+		content         string
+		ops             []Op
+		mockClosure     func(m *mockFileSystem)
+		expectedContent string
+		expectedError   error
+	}{
+		{
+			name:    "happy path",
+			content: ".PHONY: build\n## build: build the binary\nbuild:\n\tgo build ./...\n",
+			ops: []Op{
+				AddTargetOp("vet", WithContent("go vet ./...")),
+				AddDependencyOp("build", "vet"),
+				AppendRecipeLineOp("build", "@ echo done"),
+				SetTargetDescriptionOp("build", "build the binary after vetting it"),
+			},
+			expectedContent: ".PHONY: build\n## build: build the binary after vetting it\nbuild: vet\n\tgo build ./...\n\t@ echo done\n" +
+				"\n.PHONY: vet\n## vet: explain what vet does\nvet:\n\tgo vet ./...\n",
+		},
+		{
+			name:    "happy path, removing and replacing targets",
+			content: "build: vet\n\tgo build ./...\n\nvet:\n\tgo vet ./...\n\nlint:\n\tgolangci-lint run\n",
+			ops: []Op{
+				RemoveTargetOp("lint"),
+				RemoveDependencyOp("build", "vet"),
+				AddTargetOp("vet", WithContent("go vet -all ./..."), WithExistsPolicy(ReplaceIfExists)),
+				PrependRecipeLineOp("build", "@ echo building"),
+			},
+			expectedContent: "build:\n\t@ echo building\n\tgo build ./...\n" +
+				"\n.PHONY: vet\n## vet: explain what vet does\nvet:\n\tgo vet -all ./...\n",
+		},
+		{
+			name:    "happy path, skipping existing target",
+			content: "build:\n",
+			ops: []Op{
+				AddTargetOp("build", WithContent("go build ./..."), WithExistsPolicy(SkipIfExists)),
+				AddDependencyOp("build", "vet"),
+			},
+			expectedContent: "build: vet\n",
+		},
+		{
+			name:    "operation on target added in the batch",
+			content: "build:\n",
+			ops: []Op{
+				AddTargetOp("docker-build"),
+				AppendRecipeLineOp("docker-build", "docker build ."),
+			},
+			expectedContent: "build:\n\n.PHONY: docker-build\n## docker-build: explain what docker-build does\ndocker-build:\n\tdocker build .\n",
+		},
+		{
+			name:          "target already exists",
+			content:       "build:\n",
+			ops:           []Op{AddDependencyOp("build", "vet"), AddTargetOp("build")},
+			expectedError: errors.New("operation 2, adding target build at Makefile: target already exists"),
+		},
+		{
+			name:          "removing target not declared",
+			content:       "build:\n",
+			ops:           []Op{RemoveTargetOp("lint")},
+			expectedError: errors.New("operation 1, removing target lint at Makefile: looking up target lint: target not found"),
+		},
+		{
+			name:          "removing reserved target",
+			content:       "help:\n\nbuild:\n",
+			ops:           []Op{RemoveTargetOp("help")},
+			expectedError: errors.New("operation 1, removing target help at Makefile: target help is managed by the generator"),
+		},
+		{
+			name:          "removing special target",
+			content:       ".PHONY: build\nbuild:\n",
+			ops:           []Op{RemoveTargetOp(".PHONY")},
+			expectedError: errors.New("operation 1, removing target .PHONY at Makefile: .PHONY is a special target of make"),
+		},
+		{
+			name:            "happy path, removing reserved target with force",
+			content:         "coverage:\n\tgo test -cover ./...\n\nbuild:\n",
+			ops:             []Op{RemoveTargetOp("coverage", WithForce())},
+			expectedContent: "build:\n",
+		},
+		{
+			name:          "invalid target name",
+			content:       "build:\n",
+			ops:           []Op{AddTargetOp("go vet")},
+			expectedError: errors.New("operation 1, adding target go vet at Makefile: target name cannot contain space"),
+		},
+		{
+			name:    "Makefile does not exist",
+			content: "build:\n",
+			mockClosure: func(m *mockFileSystem) {
+				m.files = map[string][]byte{}
+			},
+			ops:           []Op{AddTargetOp("vet")},
+			expectedError: errors.New("reading Makefile at Makefile: file does not exist"),
+		},
+		{
+			name:    "error when writing file",
+			content: "build:\n",
+			mockClosure: func(m *mockFileSystem) {
+				m.writeFileErr = errors.New("write error")
+			},
+			ops:           []Op{AddDependencyOp("build", "vet")},
+			expectedError: errors.New("writing Makefile at Makefile: write error"),
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			m := &mockFileSystem{
+				isNotExistOutput: true,
+				files:            map[string][]byte{"Makefile": []byte(tc.content)},
+			}
+			if tc.mockClosure != nil {
+				tc.mockClosure(m)
+			}
+			fsProvider = m
+			templateProcessorProvider = htmlTemplateProcessor{}
+			userHomeDir = func() (string, error) { return "/home/gopher", nil }
+			err := Batch("Makefile", tc.ops...)
+			if tc.expectedError != nil {
+				require.EqualError(t, err, tc.expectedError.Error())
+				if m.writeFileErr == nil {
+					// The Makefile is left as it is.
+					require.Nil(t, m.writtenData)
+				}
+				return
+			}
+			require.NoError(t, err)
+			require.Equal(t, tc.expectedContent, string(m.written["Makefile"]))
+		})
+	}
+}
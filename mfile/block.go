@@ -0,0 +1,91 @@
+// Copyright (c) 2023 Tiago Melo. All rights reserved.
+// Use of this source code is governed by the MIT License that can be found in
+// the LICENSE file.
+
+package mfile
+
+import (
+	"slices"
+	"strings"
+)
+
+// ruleBlock locates the first rule of the given target among the given
+// Makefile lines. The block of the rule starts with the comments before
+// it, possibly interleaved with its .PHONY declaration, and ends with its
+// recipe. It returns the indexes of the first line of the block, of the
+// rule line, of the first line of its recipe, which is past the last line
+// of the block if the recipe is empty, of the last line of the block and
+// whether the rule was found. The rule line is followed by its
+// continuation lines, if any, up to the recipe.
+func ruleBlock(lines []string, target string) (first, rule, recipe, last int, ok bool) {
+	var (
+		prefix   = "\t"
+		inDefine bool
+	)
+	for i := 0; i < len(lines); i++ {
+		start := i
+		line := strings.TrimRight(lines[i], "\r")
+		if strings.HasPrefix(line, prefix) {
+			continue
+		}
+		for strings.HasSuffix(line, "\\") && i+1 < len(lines) {
+			i++
+			line = strings.TrimSuffix(line, "\\") + " " + strings.TrimSpace(lines[i])
+		}
+		directive := strings.Fields(line)
+		if inDefine {
+			inDefine = len(directive) == 0 || directive[0] != "endef"
+			continue
+		}
+		if len(directive) > 0 && directive[0] == "define" {
+			inDefine = true
+			continue
+		}
+		if p, ok := parseRecipePrefix(line); ok {
+			prefix = p
+			continue
+		}
+		names, _, ok := parseRule(line)
+		if !ok || !slices.Contains(names, target) {
+			continue
+		}
+		first = start
+		for j := start - 1; j >= 0; j-- {
+			previous := strings.TrimRight(lines[j], "\r")
+			if strings.HasPrefix(previous, "#") && !strings.HasPrefix(previous, sectionPrefix) || strings.HasPrefix(previous, ".PHONY:") {
+				first = j
+				continue
+			}
+			break
+		}
+		last = i
+		for last+1 < len(lines) && strings.HasPrefix(lines[last+1], prefix) {
+			last++
+		}
+		return first, start, i + 1, last, true
+	}
+	return 0, 0, 0, 0, false
+}
+
+// dropLines returns the given lines but the removed ones. Removing a block
+// between blank lines leaves a single one, and removing the first block
+// drops the blank line after it.
+func dropLines(lines []string, removed []bool) []string {
+	var kept []string
+	for i, line := range lines {
+		if !removed[i] {
+			if len(kept) == 0 && i > 0 && strings.TrimSpace(line) == "" {
+				continue
+			}
+			kept = append(kept, line)
+			continue
+		}
+		if i+1 < len(lines) && removed[i+1] {
+			continue
+		}
+		if n := len(kept); n > 0 && strings.TrimSpace(kept[n-1]) == "" && (i+1 == len(lines) || strings.TrimSpace(lines[i+1]) == "") {
+			kept = kept[:n-1]
+		}
+	}
+	return kept
+}
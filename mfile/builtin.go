@@ -0,0 +1,1013 @@
+// Copyright (c) 2023 Tiago Melo. All rights reserved.
+// Use of this source code is governed by the MIT License that can be found in
+// the LICENSE file.
+
+package mfile
+
+import (
+	"fmt"
+	"path/filepath"
+	"slices"
+	"strconv"
+	"strings"
+)
+
+// Names of the built-in presets.
+const (
+	PresetMinimal     = "minimal"
+	PresetHelp        = "help"
+	PresetGo          = "go"
+	PresetGoLibrary   = "go-library"
+	PresetGoCLI       = "go-cli"
+	PresetGoService   = "go-service"
+	PresetLint        = "lint"
+	PresetSecurity    = "security"
+	PresetCross       = "cross"
+	PresetDocker      = "docker"
+	PresetIntegration = "integration"
+	PresetMocks       = "mocks"
+	PresetBench       = "bench"
+	PresetTools       = "tools"
+	PresetClean       = "clean"
+	PresetInstall     = "install"
+	PresetDist        = "dist"
+	PresetChangelog   = "changelog"
+	PresetHooks       = "hooks"
+	PresetCI          = "ci"
+	PresetNode        = "node"
+	PresetRust        = "rust"
+	PresetProto       = "proto"
+	PresetMigrations  = "migrations"
+	PresetTerraform   = "terraform"
+	PresetGuard       = "guard"
+	PresetDebug       = "debug"
+	PresetCompletions = "completions"
+)
+
+// Targets shared by the built-in presets.
+var (
+	guardTarget = Target{
+		Name:   "guard-%",
+		Recipe: []string{`@ test -n "$($*)" || (echo "$* must be set" && exit 1)`},
+	}
+	helpTarget = Target{
+		Name:        "help",
+		Description: "shows this help message",
+		Recipe:      helpRecipes[HelpStyleAWK],
+		Phony:       true,
+	}
+	buildTarget = Target{
+		Name:        "build",
+		Description: "build the binary into the bin directory",
+		Recipe:      []string{"@ go build -o bin/$(BINARY_NAME) $(MAIN_PACKAGE)"},
+		Phony:       true,
+	}
+	runTarget = Target{
+		Name:         "run",
+		Description:  "build and run the binary",
+		Dependencies: []string{"build"},
+		Recipe:       []string{"@ ./bin/$(BINARY_NAME)"},
+		Phony:        true,
+	}
+	vetTarget = Target{
+		Name:        "vet",
+		Description: "run go vet",
+		Recipe:      []string{"@ go vet ./..."},
+		Phony:       true,
+	}
+	fmtTarget = Target{
+		Name:        "fmt",
+		Description: "format the source code",
+		Recipe:      []string{"@ go fmt ./..."},
+		Phony:       true,
+	}
+	completionsTarget = Target{
+		Name:        "completions",
+		Description: "regenerate the bash and zsh completion scripts of the make targets",
+		Recipe: []string{
+			"@ mkdir -p $(COMPLETIONS_DIR)",
+			"@ $(GOMAKEFILE) completion -s bash -f $(COMPLETIONS_DIR)/make.bash",
+			"@ $(GOMAKEFILE) completion -s zsh -f $(COMPLETIONS_DIR)/make.zsh",
+		},
+		Phony: true,
+	}
+	tidyTarget = Target{
+		Name:        "tidy",
+		Description: "add missing and remove unused modules",
+		Recipe:      []string{"@ go mod tidy"},
+		Phony:       true,
+	}
+)
+
+// installTarget returns an install-<tool> target that installs the given
+// Go package at the version held by the given variable.
+func installTarget(tool, pkg, versionVariable string) Target {
+	return Target{
+		Name:        "install-" + tool,
+		Description: "install " + tool,
+		Recipe:      []string{"@ go install " + pkg + "@$(" + versionVariable + ")"},
+		Phony:       true,
+	}
+}
+
+// toolRecipe returns a recipe that runs the tool whose path is held by
+// the given variable with the given arguments, installing it first
+// with its install-<tool> target if needed.
+func toolRecipe(tool, pathVariable, args string) []string {
+	return []string{
+		"@ test -x $(" + pathVariable + ") || $(MAKE) install-" + tool,
+		strings.TrimSpace("@ $(" + pathVariable + ") " + args),
+	}
+}
+
+// platform is a GOOS/GOARCH pair.
+type platform struct {
+	goos, goarch string
+}
+
+// String returns the platform as "GOOS/GOARCH".
+func (p platform) String() string {
+	return p.goos + "/" + p.goarch
+}
+
+// suffix returns the platform as "GOOS-GOARCH", for target and file names.
+func (p platform) suffix() string {
+	return p.goos + "-" + p.goarch
+}
+
+// binary returns the name of the binary built for the platform.
+func (p platform) binary() string {
+	if p.goos == "windows" {
+		return "$(BINARY_NAME)-" + p.suffix() + ".exe"
+	}
+	return "$(BINARY_NAME)-" + p.suffix()
+}
+
+// parsePlatforms parses a space-separated list of GOOS/GOARCH pairs.
+func parsePlatforms(list string) ([]platform, error) {
+	var platforms []platform
+	for _, p := range strings.Fields(list) {
+		goos, goarch, ok := strings.Cut(p, "/")
+		if !ok || goos == "" || goarch == "" || strings.Contains(goarch, "/") {
+			return nil, fmt.Errorf(`invalid platform %q, expected "GOOS/GOARCH"`, p)
+		}
+		platforms = append(platforms, platform{goos: goos, goarch: goarch})
+	}
+	return platforms, nil
+}
+
+// crossCompileTargets returns one build-<os>-<arch> target per platform
+// listed in the "platforms" parameter, plus a build-all target building
+// all of them into the dist directory.
+func crossCompileTargets(ctx PresetContext) ([]Variable, []Target, error) {
+	platforms, err := parsePlatforms(ctx.Params["platforms"])
+	if err != nil {
+		return nil, nil, err
+	}
+	var (
+		targets []Target
+		names   []string
+	)
+	for _, p := range platforms {
+		names = append(names, "build-"+p.suffix())
+		targets = append(targets, Target{
+			Name:        "build-" + p.suffix(),
+			Description: "build the binary for " + p.String(),
+			Recipe:      []string{"@ GOOS=" + p.goos + " GOARCH=" + p.goarch + " go build -o dist/" + p.binary() + " $(MAIN_PACKAGE)"},
+			Phony:       true,
+		})
+	}
+	targets = append(targets, Target{
+		Name:         "build-all",
+		Description:  "build the binary for all platforms",
+		Dependencies: names,
+		Phony:        true,
+	})
+	return nil, targets, nil
+}
+
+// distTargets returns one dist-<os>-<arch> target per platform listed
+// in the "platforms" parameter, packaging its binary as a tar.gz file,
+// or a zip file for Windows, plus a dist target packaging all of them
+// and writing their checksums.
+func distTargets(ctx PresetContext) ([]Variable, []Target, error) {
+	platforms, err := parsePlatforms(ctx.Params["platforms"])
+	if err != nil {
+		return nil, nil, err
+	}
+	var (
+		targets []Target
+		names   []string
+	)
+	for _, p := range platforms {
+		archive := "$(BINARY_NAME)-$(VERSION)-" + p.suffix()
+		recipe := "@ tar -czf dist/" + archive + ".tar.gz -C dist " + p.binary()
+		if p.goos == "windows" {
+			recipe = "@ cd dist && zip -q " + archive + ".zip " + p.binary()
+		}
+		names = append(names, "dist-"+p.suffix())
+		targets = append(targets, Target{
+			Name:         "dist-" + p.suffix(),
+			Description:  "package the binary for " + p.String(),
+			Dependencies: []string{"build-" + p.suffix()},
+			Recipe:       []string{recipe},
+			Phony:        true,
+		})
+	}
+	targets = append(targets, Target{
+		Name:         "dist",
+		Description:  "package the binaries for all platforms into dist, with their checksums",
+		Dependencies: names,
+		Recipe:       []string{"@ cd dist && $(SHA256SUM) $(notdir $(wildcard dist/*.tar.gz dist/*.zip)) > checksums.txt"},
+		Phony:        true,
+	})
+	return nil, targets, nil
+}
+
+// goContent returns the variables of the go preset, naming the binary
+// after the module declared by go.mod. When the project has several
+// binaries, in cmd/<name>/main.go, it also returns one build-<name>
+// target per binary and a build target building all of them.
+func goContent(ctx PresetContext) ([]Variable, []Target, error) {
+	var variables []Variable
+	if ctx.Module != "" {
+		variables = append(variables, Variable{Name: "MODULE", Value: ctx.Module})
+	}
+	variables = append(variables, Variable{Name: "APP_NAME", Value: ctx.AppName})
+	binaries, err := cmdBinaries(ctx.files(), ctx.Dir)
+	if err != nil {
+		return nil, nil, err
+	}
+	if len(binaries) == 0 {
+		variables = append(variables,
+			Variable{Name: "BINARY_NAME", Value: "$(APP_NAME)"},
+			Variable{Name: "MAIN_PACKAGE", Value: "."},
+		)
+		return variables, nil, nil
+	}
+	binary := "$(APP_NAME)"
+	if !slices.Contains(binaries, ctx.AppName) {
+		binary = binaries[0]
+	}
+	variables = append(variables,
+		Variable{Name: "BINARY_NAME", Value: binary},
+		Variable{Name: "MAIN_PACKAGE", Value: "./cmd/$(BINARY_NAME)"},
+	)
+	var (
+		targets []Target
+		names   []string
+	)
+	for _, b := range binaries {
+		names = append(names, "build-"+b)
+		targets = append(targets, binaryTarget(b))
+	}
+	targets = append(targets, Target{
+		Name:         "build",
+		Description:  "build all the binaries into the bin directory",
+		Dependencies: names,
+		Phony:        true,
+	})
+	return variables, targets, nil
+}
+
+// binaryTarget returns the build-<binary> target building the given
+// binary, whose main package is in the cmd directory.
+func binaryTarget(binary string) Target {
+	return Target{
+		Name:        "build-" + binary,
+		Description: "build the " + binary + " binary into the bin directory",
+		Recipe:      []string{"@ go build -o bin/" + binary + " ./cmd/" + binary},
+		Phony:       true,
+	}
+}
+
+// dockerVariables returns the variables of the docker preset, naming the
+// image after the module declared by go.mod.
+func dockerVariables(ctx PresetContext) ([]Variable, []Target, error) {
+	variables := []Variable{
+		{Name: "IMAGE_NAME", Value: ctx.AppName},
+		{Name: "IMAGE_TAG", Value: "$(shell git describe --tags --always --dirty 2> /dev/null || echo latest)"},
+		{Name: "DOCKERFILE", Value: "Dockerfile"},
+		{Name: "DOCKER_RUN_ARGS", Value: ""},
+	}
+	return variables, nil, nil
+}
+
+// integrationContent returns the test-int target, running integration
+// tests against the services of the docker compose file given by the
+// "compose-file" parameter.
+func integrationContent(ctx PresetContext) ([]Variable, []Target, error) {
+	variables := []Variable{
+		{Name: "COMPOSE_FILE", Value: ctx.Params["compose-file"]},
+		{Name: "INTEGRATION_PACKAGES", Value: "./..."},
+	}
+	targets := []Target{
+		{
+			Name:        "test-int",
+			Description: "start the docker compose services, wait for them to be healthy and run integration tests",
+			Recipe: []string{
+				"@ docker compose -f $(COMPOSE_FILE) up -d --wait",
+				"@ go test -v -tags=integration $(INTEGRATION_PACKAGES) -count=1; status=$$?; docker compose -f $(COMPOSE_FILE) down; exit $$status",
+			},
+			Phony: true,
+		},
+	}
+	return variables, targets, nil
+}
+
+// mocksContent returns the mocks and check-mocks targets for the mock
+// generator selected by the "mock-tool" parameter: mockery, configured
+// by its .mockery.yaml file, or mockgen, run through go:generate directives.
+func mocksContent(ctx PresetContext) ([]Variable, []Target, error) {
+	var (
+		variables []Variable
+		targets   []Target
+		recipe    []string
+	)
+	switch tool := ctx.Params["mock-tool"]; tool {
+	case "mockery":
+		variables = []Variable{
+			{Name: "MOCKERY_VERSION", Value: "v2.43.2"},
+			{Name: "MOCKERY", Value: "$(shell go env GOPATH)/bin/mockery"},
+		}
+		targets = append(targets, installTarget("mockery", "github.com/vektra/mockery/v2", "MOCKERY_VERSION"))
+		recipe = toolRecipe("mockery", "MOCKERY", "")
+	case "mockgen":
+		variables = []Variable{
+			{Name: "MOCKGEN_VERSION", Value: "v0.4.0"},
+			{Name: "MOCKGEN", Value: "$(shell go env GOPATH)/bin/mockgen"},
+		}
+		targets = append(targets, installTarget("mockgen", "go.uber.org/mock/mockgen", "MOCKGEN_VERSION"))
+		recipe = []string{
+			"@ test -x $(MOCKGEN) || $(MAKE) install-mockgen",
+			"@ PATH=$(dir $(MOCKGEN)):$$PATH go generate -run mockgen ./...",
+		}
+	default:
+		return nil, nil, fmt.Errorf(`invalid mock tool %q, expected "mockery" or "mockgen"`, tool)
+	}
+	variables = append(variables, Variable{Name: "MOCKS_DIR", Value: "mocks"})
+	targets = append(targets,
+		Target{
+			Name:        "mocks",
+			Description: "regenerate the mocks with " + ctx.Params["mock-tool"],
+			Recipe:      recipe,
+			Phony:       true,
+		},
+		Target{
+			Name:         "check-mocks",
+			Description:  "fail if the generated mocks are stale",
+			Dependencies: []string{"mocks"},
+			Recipe: []string{
+				`@ test -z "$$(git status --porcelain -- $(MOCKS_DIR))" || (echo "mocks are stale, run make mocks" && git status --porcelain -- $(MOCKS_DIR) && exit 1)`,
+			},
+			Phony: true,
+		},
+	)
+	return variables, targets, nil
+}
+
+// benchContent returns the bench target and, if the "bench-compare"
+// parameter is true, the bench-baseline and bench-compare targets
+// comparing the results against a saved baseline with benchstat.
+func benchContent(ctx PresetContext) ([]Variable, []Target, error) {
+	compare, err := strconv.ParseBool(ctx.Params["bench-compare"])
+	if err != nil {
+		return nil, nil, fmt.Errorf("invalid bench-compare value %q, expected a boolean", ctx.Params["bench-compare"])
+	}
+	const benchCommand = "go test -run='^$$' -bench='$(BENCH)' -benchmem"
+	variables := []Variable{
+		{Name: "BENCH", Value: "."},
+		{Name: "BENCH_PACKAGES", Value: "./..."},
+	}
+	targets := []Target{
+		{
+			Name:        "bench",
+			Description: "run the benchmarks matching BENCH in BENCH_PACKAGES",
+			Recipe:      []string{"@ " + benchCommand + " $(BENCH_PACKAGES)"},
+			Phony:       true,
+		},
+	}
+	if !compare {
+		return variables, targets, nil
+	}
+	variables = append(variables,
+		Variable{Name: "BENCH_COUNT", Value: "6"},
+		Variable{Name: "BENCH_BASELINE", Value: "bench.baseline.txt"},
+		Variable{Name: "BENCHSTAT_VERSION", Value: "latest"},
+		Variable{Name: "BENCHSTAT", Value: "$(shell go env GOPATH)/bin/benchstat"},
+	)
+	targets = append(targets,
+		installTarget("benchstat", "golang.org/x/perf/cmd/benchstat", "BENCHSTAT_VERSION"),
+		Target{
+			Name:        "bench-baseline",
+			Description: "save the benchmark results as the baseline for bench-compare",
+			Recipe:      []string{"@ " + benchCommand + " -count=$(BENCH_COUNT) $(BENCH_PACKAGES) > $(BENCH_BASELINE)"},
+			Phony:       true,
+		},
+		Target{
+			Name:        "bench-compare",
+			Description: "compare the benchmark results against the baseline with benchstat",
+			Recipe: append([]string{
+				`@ test -f $(BENCH_BASELINE) || (echo "no baseline at $(BENCH_BASELINE), run make bench-baseline" && exit 1)`,
+				"@ " + benchCommand + " -count=$(BENCH_COUNT) $(BENCH_PACKAGES) > bench.new.txt",
+			}, toolRecipe("benchstat", "BENCHSTAT", "$(BENCH_BASELINE) bench.new.txt")...),
+			Phony: true,
+		},
+	)
+	return variables, targets, nil
+}
+
+// toolsContent returns an install-tools target installing the tools
+// the module depends on at the versions pinned in go.mod: its tool
+// directives (Go 1.24+) or, if there are none, the imports of the
+// file given by the "tools-file" parameter.
+func toolsContent(ctx PresetContext) ([]Variable, []Target, error) {
+	target := Target{
+		Name:        "install-tools",
+		Description: "install the tools the module depends on, at the versions pinned in go.mod",
+		Phony:       true,
+	}
+	hasToolDirectives, err := goModHasToolDirectives(ctx.files(), ctx.Dir)
+	if err != nil {
+		return nil, nil, err
+	}
+	if hasToolDirectives {
+		target.Recipe = []string{"@ go install tool"}
+		return nil, []Target{target}, nil
+	}
+	packages, err := toolsFilePackages(ctx.files(), filepath.Join(ctx.Dir, ctx.Params["tools-file"]))
+	if err != nil {
+		return nil, nil, err
+	}
+	if len(packages) == 0 {
+		return nil, nil, fmt.Errorf("no tools found in %s", ctx.Params["tools-file"])
+	}
+	for _, pkg := range packages {
+		target.Recipe = append(target.Recipe, "@ go install "+pkg)
+	}
+	return nil, []Target{target}, nil
+}
+
+// cleanContent returns the clean target, removing the artifacts listed
+// in the "clean-artifacts" parameter, and the distclean target, which
+// also removes those listed in the "distclean-artifacts" parameter and
+// cleans the test cache.
+func cleanContent(ctx PresetContext) ([]Variable, []Target, error) {
+	variables := []Variable{
+		{Name: "CLEAN_ARTIFACTS", Value: strings.Join(strings.Fields(ctx.Params["clean-artifacts"]), " ")},
+		{Name: "DISTCLEAN_ARTIFACTS", Value: strings.Join(strings.Fields(ctx.Params["distclean-artifacts"]), " ")},
+	}
+	targets := []Target{
+		{
+			Name:        "clean",
+			Description: "remove the build artifacts",
+			Recipe:      []string{"@ rm -rf $(CLEAN_ARTIFACTS)"},
+			Phony:       true,
+		},
+		{
+			Name:         "distclean",
+			Description:  "remove the build artifacts, everything else generated and the test cache",
+			Dependencies: []string{"clean"},
+			Recipe: []string{
+				"@ rm -rf $(DISTCLEAN_ARTIFACTS)",
+				"@ go clean -testcache",
+			},
+			Phony: true,
+		},
+	}
+	return variables, targets, nil
+}
+
+// changelogContent returns a changelog target writing the changes in
+// CHANGELOG_RANGE to CHANGELOG_FILE, using the tool selected by the
+// "changelog-tool" parameter: git-cliff, or plain git log.
+func changelogContent(ctx PresetContext) ([]Variable, []Target, error) {
+	var recipe string
+	switch tool := ctx.Params["changelog-tool"]; tool {
+	case "git":
+		recipe = `@ (echo "# Changelog"; echo; git log --no-merges --pretty=format:'- %s (%h)' $(CHANGELOG_RANGE)) > $(CHANGELOG_FILE)`
+	case "git-cliff":
+		recipe = "@ git-cliff $(CHANGELOG_RANGE) --output $(CHANGELOG_FILE)"
+	default:
+		return nil, nil, fmt.Errorf(`invalid changelog tool %q, expected "git" or "git-cliff"`, tool)
+	}
+	variables := []Variable{
+		{Name: "CHANGELOG_FILE", Value: ctx.Params["changelog-file"]},
+		{Name: "CHANGELOG_RANGE", Value: ctx.Params["changelog-range"]},
+	}
+	targets := []Target{
+		{
+			Name:        "changelog",
+			Description: "write the changes in CHANGELOG_RANGE to CHANGELOG_FILE",
+			Recipe:      []string{recipe},
+			Phony:       true,
+		},
+	}
+	return variables, targets, nil
+}
+
+// nodeContent returns install, build, test and lint targets wrapping the
+// scripts of a Node.js project, run with the package manager given by the
+// "package-manager" parameter or, if it is "auto", detected from the
+// project lockfile.
+func nodeContent(ctx PresetContext) ([]Variable, []Target, error) {
+	packageManager := ctx.Params["package-manager"]
+	switch packageManager {
+	case "auto":
+		packageManager = detectPackageManager(ctx.files(), ctx.Dir)
+	case "npm", "pnpm", "yarn":
+	default:
+		return nil, nil, fmt.Errorf(`invalid package manager %q, expected "auto", "npm", "pnpm" or "yarn"`, packageManager)
+	}
+	variables := []Variable{
+		{Name: "PACKAGE_MANAGER", Value: packageManager},
+	}
+	targets := []Target{
+		{
+			Name:        "install",
+			Description: "install the dependencies",
+			Recipe:      []string{"@ $(PACKAGE_MANAGER) install"},
+			Phony:       true,
+		},
+	}
+	for _, script := range []string{"build", "test", "lint"} {
+		targets = append(targets, Target{
+			Name:        script,
+			Description: "run the " + script + " script",
+			Recipe:      []string{"@ $(PACKAGE_MANAGER) run " + script},
+			Phony:       true,
+		})
+	}
+	return variables, targets, nil
+}
+
+// migrationsContent returns targets applying, reverting and creating
+// database migrations with golang-migrate, kept in the directory given
+// by the "migrations-dir" parameter.
+func migrationsContent(ctx PresetContext) ([]Variable, []Target, error) {
+	variables := []Variable{
+		{Name: "MIGRATIONS_DIR", Value: ctx.Params["migrations-dir"]},
+		{Name: "DATABASE_URL", Value: ""},
+		{Name: "MIGRATE_VERSION", Value: "v4.17.1"},
+		{Name: "MIGRATE_TAGS", Value: "postgres mysql sqlite3"},
+		{Name: "MIGRATE", Value: "$(shell go env GOPATH)/bin/migrate"},
+	}
+	const migrate = `-path $(MIGRATIONS_DIR) -database "$(DATABASE_URL)"`
+	targets := []Target{
+		{
+			Name:        "install-migrate",
+			Description: "install migrate",
+			Recipe:      []string{"@ go install -tags '$(MIGRATE_TAGS)' github.com/golang-migrate/migrate/v4/cmd/migrate@$(MIGRATE_VERSION)"},
+			Phony:       true,
+		},
+		{
+			Name:         "migrate-up",
+			Description:  "apply all pending migrations to DATABASE_URL",
+			Dependencies: Guards("DATABASE_URL"),
+			Recipe:       toolRecipe("migrate", "MIGRATE", migrate+" up"),
+			Phony:        true,
+		},
+		{
+			Name:         "migrate-down",
+			Description:  "revert the last migration applied to DATABASE_URL",
+			Dependencies: Guards("DATABASE_URL"),
+			Recipe:       toolRecipe("migrate", "MIGRATE", migrate+" down 1"),
+			Phony:        true,
+		},
+		{
+			Name:        "migrate-create",
+			Description: "create a new migration named after NAME",
+			Recipe: []string{
+				`@ test -n "$(NAME)" || (echo "usage: make migrate-create NAME=<name>" && exit 1)`,
+				"@ test -x $(MIGRATE) || $(MAKE) install-migrate",
+				"@ $(MIGRATE) create -ext sql -dir $(MIGRATIONS_DIR) -seq $(NAME)",
+			},
+			Phony: true,
+		},
+	}
+	return variables, targets, nil
+}
+
+// terraformContent returns targets initializing, planning and applying
+// the terraform configuration in the directory given by the
+// "terraform-dir" parameter.
+func terraformContent(ctx PresetContext) ([]Variable, []Target, error) {
+	variables := []Variable{
+		{Name: "TF_DIR", Value: ctx.Params["terraform-dir"]},
+	}
+	var targets []Target
+	for _, command := range []struct{ name, description string }{
+		{"init", "initialize the terraform working directory"},
+		{"plan", "show the changes terraform would apply"},
+		{"apply", "apply the terraform changes"},
+	} {
+		targets = append(targets, Target{
+			Name:        "tf-" + command.name,
+			Description: command.description,
+			Recipe:      []string{"@ terraform -chdir=$(TF_DIR) " + command.name},
+			Phony:       true,
+		})
+	}
+	return variables, targets, nil
+}
+
+// builtinPresets are the presets shipped with the package.
+var builtinPresets = []Preset{
+	{
+		Name:        PresetMinimal,
+		Description: "help, test and coverage targets",
+		Targets: []Target{
+			helpTarget,
+			{
+				Name:        "test",
+				Description: "run unit tests",
+				Recipe:      []string{"@ go test -v ./... -count=1"},
+				Phony:       true,
+			},
+			{
+				Name:        "coverage",
+				Description: "run unit tests and generate coverage report in html format",
+				Recipe:      []string{"@ go test -coverprofile=coverage.out ./...  && go tool cover -html=coverage.out"},
+				Phony:       true,
+			},
+		},
+	},
+	{
+		Name:        PresetHelp,
+		Description: "help target only",
+		Targets:     []Target{helpTarget},
+	},
+	{
+		Name:        PresetGo,
+		Description: "build, run, vet, fmt and tidy targets, with a BINARY_NAME variable",
+		Targets:     []Target{buildTarget, runTarget, vetTarget, fmtTarget, tidyTarget},
+		Func:        goContent,
+	},
+	{
+		Name:        PresetGoLibrary,
+		Description: "minimal, plus vet, fmt and tidy targets for Go libraries",
+		Include:     []string{PresetMinimal},
+		Targets:     []Target{vetTarget, fmtTarget, tidyTarget},
+	},
+	{
+		Name:        PresetGoCLI,
+		Description: "minimal and go, for command-line tools",
+		Include:     []string{PresetMinimal, PresetGo},
+	},
+	{
+		Name:        PresetGoService,
+		Description: "go-cli, plus race detection for long-running services",
+		Include:     []string{PresetGoCLI},
+		Variables: []Variable{
+			{Name: "PORT", Value: "8080"},
+		},
+		Targets: []Target{
+			{
+				Name:         "run",
+				Description:  "build and run the service",
+				Dependencies: []string{"build"},
+				Recipe:       []string{"@ PORT=$(PORT) ./bin/$(BINARY_NAME)"},
+				Phony:        true,
+			},
+			{
+				Name:        "test-race",
+				Description: "run unit tests with the race detector",
+				Recipe:      []string{"@ go test -race ./... -count=1"},
+				Phony:       true,
+			},
+		},
+	},
+	{
+		Name:        PresetLint,
+		Description: "lint target running golangci-lint, installed at a pinned version",
+		Variables: []Variable{
+			{Name: "GOLANGCI_LINT_VERSION", Value: "v1.59.1"},
+			{Name: "GOLANGCI_LINT", Value: "$(shell go env GOPATH)/bin/golangci-lint"},
+		},
+		Targets: []Target{
+			installTarget("golangci-lint", "github.com/golangci/golangci-lint/cmd/golangci-lint", "GOLANGCI_LINT_VERSION"),
+			{
+				Name:        "lint",
+				Description: "run golangci-lint, installing it if needed",
+				Recipe:      toolRecipe("golangci-lint", "GOLANGCI_LINT", "run ./..."),
+				Phony:       true,
+			},
+		},
+	},
+	{
+		Name:        PresetSecurity,
+		Description: "vuln and sec targets running govulncheck and gosec, installed at pinned versions",
+		Variables: []Variable{
+			{Name: "GOVULNCHECK_VERSION", Value: "v1.1.3"},
+			{Name: "GOVULNCHECK", Value: "$(shell go env GOPATH)/bin/govulncheck"},
+			{Name: "GOSEC_VERSION", Value: "v2.20.0"},
+			{Name: "GOSEC", Value: "$(shell go env GOPATH)/bin/gosec"},
+		},
+		Targets: []Target{
+			installTarget("govulncheck", "golang.org/x/vuln/cmd/govulncheck", "GOVULNCHECK_VERSION"),
+			{
+				Name:        "vuln",
+				Description: "check dependencies for known vulnerabilities with govulncheck",
+				Recipe:      toolRecipe("govulncheck", "GOVULNCHECK", "./..."),
+				Phony:       true,
+			},
+			installTarget("gosec", "github.com/securego/gosec/v2/cmd/gosec", "GOSEC_VERSION"),
+			{
+				Name:        "sec",
+				Description: "inspect the source code for security problems with gosec",
+				Recipe:      toolRecipe("gosec", "GOSEC", "./..."),
+				Phony:       true,
+			},
+		},
+	},
+	{
+		Name:        PresetCross,
+		Description: "go, plus per-platform build targets into dist and a build-all target",
+		Include:     []string{PresetGo},
+		Parameters: map[string]string{
+			"platforms": "linux/amd64 linux/arm64 darwin/amd64 darwin/arm64 windows/amd64",
+		},
+		Func: crossCompileTargets,
+	},
+	{
+		Name:        PresetDocker,
+		Description: "docker-build, docker-push and docker-run targets, tagging images with git describe",
+		Func:        dockerVariables,
+		Targets: []Target{
+			{
+				Name:        "docker-build",
+				Description: "build the docker image",
+				Recipe:      []string{"@ docker build -f $(DOCKERFILE) -t $(IMAGE_NAME):$(IMAGE_TAG) ."},
+				Phony:       true,
+			},
+			{
+				Name:         "docker-push",
+				Description:  "push the docker image to its registry",
+				Dependencies: []string{"docker-build"},
+				Recipe:       []string{"@ docker push $(IMAGE_NAME):$(IMAGE_TAG)"},
+				Phony:        true,
+			},
+			{
+				Name:         "docker-run",
+				Description:  "run the docker image",
+				Dependencies: []string{"docker-build"},
+				Recipe:       []string{"@ docker run --rm $(DOCKER_RUN_ARGS) $(IMAGE_NAME):$(IMAGE_TAG)"},
+				Phony:        true,
+			},
+		},
+	},
+	{
+		Name:        PresetIntegration,
+		Description: "test-int target running integration tests against docker compose services",
+		Parameters: map[string]string{
+			"compose-file": "docker-compose.yml",
+		},
+		Func: integrationContent,
+	},
+	{
+		Name:        PresetMocks,
+		Description: "mocks and check-mocks targets, using mockery or mockgen",
+		Parameters: map[string]string{
+			"mock-tool": "mockery",
+		},
+		Func: mocksContent,
+	},
+	{
+		Name:        PresetBench,
+		Description: "bench target and, optionally, bench-compare using benchstat against a baseline",
+		Parameters: map[string]string{
+			"bench-compare": "false",
+		},
+		Func: benchContent,
+	},
+	{
+		Name:        PresetTools,
+		Description: "install-tools target installing the tools pinned by tools.go or go.mod tool directives",
+		Parameters: map[string]string{
+			"tools-file": "tools.go",
+		},
+		Func: toolsContent,
+	},
+	{
+		Name:        PresetClean,
+		Description: "clean and distclean targets removing configurable lists of artifacts",
+		Parameters: map[string]string{
+			"clean-artifacts":     "bin dist coverage.out",
+			"distclean-artifacts": "",
+		},
+		Func: cleanContent,
+	},
+	{
+		Name:        PresetInstall,
+		Description: "go, plus install and uninstall targets honoring PREFIX, DESTDIR and GOBIN",
+		Include:     []string{PresetGo},
+		Variables: []Variable{
+			{Name: "PREFIX", Value: "/usr/local"},
+			// GOBIN is used unless PREFIX is given on the command line,
+			// like by packagers.
+			{Name: "BINDIR", Value: "$(if $(filter command line,$(origin PREFIX)),$(PREFIX)/bin,$(or $(GOBIN),$(PREFIX)/bin))"},
+		},
+		Targets: []Target{
+			{
+				Name:         "install",
+				Description:  "install the binary into $(DESTDIR)$(BINDIR)",
+				Dependencies: []string{"build"},
+				Recipe: []string{
+					"@ install -d $(DESTDIR)$(BINDIR)",
+					"@ install -m 0755 bin/$(BINARY_NAME) $(DESTDIR)$(BINDIR)/$(BINARY_NAME)",
+				},
+				Phony: true,
+			},
+			{
+				Name:        "uninstall",
+				Description: "remove the binary from $(DESTDIR)$(BINDIR)",
+				Recipe:      []string{"@ rm -f $(DESTDIR)$(BINDIR)/$(BINARY_NAME)"},
+				Phony:       true,
+			},
+		},
+	},
+	{
+		Name:        PresetDist,
+		Description: "cross, plus dist targets packaging the binaries with their checksums",
+		Include:     []string{PresetCross},
+		Variables: []Variable{
+			{Name: "VERSION", Value: "$(shell git describe --tags --always --dirty 2> /dev/null || echo dev)"},
+			{Name: "SHA256SUM", Value: "sha256sum"},
+		},
+		Func: distTargets,
+	},
+	{
+		Name:        PresetChangelog,
+		Description: "changelog target using git log or git-cliff",
+		Parameters: map[string]string{
+			"changelog-tool":  "git",
+			"changelog-file":  "CHANGELOG.md",
+			"changelog-range": "",
+		},
+		Func: changelogContent,
+	},
+	{
+		Name:        PresetHooks,
+		Description: "install-hooks target installing a git pre-commit hook that runs make lint test",
+		Variables: []Variable{
+			{Name: "HOOK_TARGETS", Value: "lint test"},
+			{Name: "HOOKS_DIR", Value: "$(shell git rev-parse --git-path hooks)"},
+		},
+		Targets: []Target{
+			{
+				Name:        "install-hooks",
+				Description: "install a git pre-commit hook running make $(HOOK_TARGETS)",
+				Recipe: []string{
+					"@ mkdir -p $(HOOKS_DIR)",
+					`@ printf '#!/bin/sh\n# Installed by make install-hooks.\nexec make $(HOOK_TARGETS)\n' > $(HOOKS_DIR)/pre-commit`,
+					"@ chmod +x $(HOOKS_DIR)/pre-commit",
+				},
+				Phony: true,
+			},
+		},
+	},
+	{
+		Name:        PresetCI,
+		Description: "minimal, go and lint, plus fmt-check and a ci target running the checks pipelines call",
+		Include:     []string{PresetMinimal, PresetGo, PresetLint},
+		Targets: []Target{
+			{
+				Name:        "fmt-check",
+				Description: "fail if any file is not formatted with gofmt",
+				Recipe:      []string{`@ test -z "$$(gofmt -l .)" || (echo "files not formatted with gofmt:"; gofmt -l .; exit 1)`},
+				Phony:       true,
+			},
+			{
+				Name:         "ci",
+				Description:  "run the checks of the continuous integration pipeline",
+				Dependencies: []string{"fmt-check", "lint", "vet", "test", "build"},
+				Phony:        true,
+			},
+		},
+	},
+	{
+		Name:        PresetNode,
+		Description: "install, build, test and lint targets wrapping the scripts of a Node.js project",
+		Parameters: map[string]string{
+			"package-manager": "auto",
+		},
+		Func: nodeContent,
+	},
+	{
+		Name:        PresetRust,
+		Description: "build, test, clippy, fmt-check and release targets wrapping cargo",
+		Variables: []Variable{
+			{Name: "CARGO", Value: "cargo"},
+			{Name: "CARGO_FLAGS", Value: ""},
+		},
+		Targets: []Target{
+			{
+				Name:        "build",
+				Description: "build the crate",
+				Recipe:      []string{"@ $(CARGO) build $(CARGO_FLAGS)"},
+				Phony:       true,
+			},
+			{
+				Name:        "test",
+				Description: "run the tests",
+				Recipe:      []string{"@ $(CARGO) test $(CARGO_FLAGS)"},
+				Phony:       true,
+			},
+			{
+				Name:        "clippy",
+				Description: "run clippy, failing on warnings",
+				Recipe:      []string{"@ $(CARGO) clippy $(CARGO_FLAGS) --all-targets -- -D warnings"},
+				Phony:       true,
+			},
+			{
+				Name:        "fmt-check",
+				Description: "fail if any file is not formatted with rustfmt",
+				Recipe:      []string{"@ $(CARGO) fmt --all --check"},
+				Phony:       true,
+			},
+			{
+				Name:        "release",
+				Description: "build the crate with optimizations",
+				Recipe:      []string{"@ $(CARGO) build --release $(CARGO_FLAGS)"},
+				Phony:       true,
+			},
+		},
+	},
+	{
+		Name:        PresetProto,
+		Description: "proto and proto-lint targets generating code from and linting protobuf files with buf",
+		Variables: []Variable{
+			{Name: "BUF_VERSION", Value: "v1.34.0"},
+			{Name: "BUF", Value: "$(shell go env GOPATH)/bin/buf"},
+		},
+		Targets: []Target{
+			installTarget("buf", "github.com/bufbuild/buf/cmd/buf", "BUF_VERSION"),
+			{
+				Name:        "proto",
+				Description: "generate code from the protobuf files",
+				Recipe:      toolRecipe("buf", "BUF", "generate"),
+				Phony:       true,
+			},
+			{
+				Name:        "proto-lint",
+				Description: "lint the protobuf files",
+				Recipe:      toolRecipe("buf", "BUF", "lint"),
+				Phony:       true,
+			},
+		},
+	},
+	{
+		Name:        PresetMigrations,
+		Description: "migrate-up, migrate-down and migrate-create targets using golang-migrate",
+		Include:     []string{PresetGuard},
+		Parameters: map[string]string{
+			"migrations-dir": "migrations",
+		},
+		Func: migrationsContent,
+	},
+	{
+		Name:        PresetTerraform,
+		Description: "tf-init, tf-plan and tf-apply targets",
+		Parameters: map[string]string{
+			"terraform-dir": "terraform",
+		},
+		Func: terraformContent,
+	},
+	{
+		Name:        PresetDebug,
+		Description: "print-% rule printing the value of the variable named after the %, like GOFLAGS for print-GOFLAGS",
+		Targets: []Target{
+			{
+				Name:        "print-%",
+				Description: "print the value of a variable, like make print-GOFLAGS",
+				Recipe:      []string{`@ echo '$*=$($*)'`},
+			},
+		},
+	},
+	{
+		Name:        PresetGuard,
+		Description: "guard-% rule failing when the variable named after the %, like DATABASE_URL for guard-DATABASE_URL, is empty",
+		Targets:     []Target{guardTarget},
+	},
+	{
+		Name:        PresetCompletions,
+		Description: "completions target regenerating the bash and zsh completion scripts of the make targets with gomakefile",
+		Variables: []Variable{
+			{Name: "GOMAKEFILE", Value: "gomakefile"},
+			{Name: "COMPLETIONS_DIR", Value: ".completions"},
+		},
+		Targets: []Target{completionsTarget},
+	},
+}
+
+// Guards returns the prerequisites making make fail, before running the
+// recipe of the target depending on them, when one of the given variables
+// is empty, like guard-DATABASE_URL. They need the guard-% rule of
+// PresetGuard, which the presets using them should include.
+func Guards(variables ...string) []string {
+	guards := make([]string, len(variables))
+	for i, v := range variables {
+		guards[i] = "guard-" + v
+	}
+	return guards
+}
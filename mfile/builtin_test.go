@@ -0,0 +1,518 @@
+// Copyright (c) 2023 Tiago Melo. All rights reserved.
+// Use of this source code is governed by the MIT License that can be found in
+// the LICENSE file.
+
+package mfile
+
+import (
+	"errors"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+	"testing/fstest"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestBuiltinPresets(t *testing.T) {
+	fsProvider = &mockFileSystem{
+		file: []byte("package tools\n\nimport _ \"golang.org/x/tools/cmd/stringer\"\n"),
+	}
+	for _, p := range builtinPresets {
+		t.Run(p.Name, func(t *testing.T) {
+			variables, targets, err := resolvePresets(".", []string{p.Name}, nil)
+			require.NoError(t, err)
+			parsed := parseTargets(render(variables, targets))
+			require.Len(t, parsed, len(targets))
+			for i, target := range targets {
+				require.Equal(t, target.Name, parsed[i].Name)
+				require.Equal(t, target.Description, parsed[i].Description)
+				require.Equal(t, target.Recipe, parsed[i].Recipe)
+			}
+		})
+	}
+}
+
+func TestInstallBindir(t *testing.T) {
+	if _, err := exec.LookPath("make"); err != nil {
+		t.Skip("make is not installed")
+	}
+	variables, _, err := resolvePresets(".", []string{PresetInstall}, nil)
+	require.NoError(t, err)
+	dir := t.TempDir()
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "Makefile"), []byte(render(variables, nil)), 0644))
+	testCases := []struct {
+		name           string
+		gobin          string
+		args           []string
+		expectedBindir string
+	}{
+		{
+			name:           "default",
+			expectedBindir: "/usr/local/bin",
+		},
+		{
+			name:           "GOBIN set",
+			gobin:          "/home/gopher/go/bin",
+			expectedBindir: "/home/gopher/go/bin",
+		},
+		{
+			name:           "GOBIN set, PREFIX given on the command line",
+			gobin:          "/home/gopher/go/bin",
+			args:           []string{"PREFIX=/usr"},
+			expectedBindir: "/usr/bin",
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			args := append([]string{"-s", "--eval", "print-bindir: ; @echo $(BINDIR)", "print-bindir"}, tc.args...)
+			cmd := exec.Command("make", args...)
+			cmd.Dir = dir
+			cmd.Env = append(os.Environ(), "GOBIN="+tc.gobin)
+			out, err := cmd.CombinedOutput()
+			require.NoError(t, err, string(out))
+			require.Equal(t, tc.expectedBindir, strings.TrimSpace(string(out)))
+		})
+	}
+}
+
+func TestCrossCompileTargets(t *testing.T) {
+	testCases := []struct {
+		name            string
+		platforms       string
+		expectedTargets []Target
+		expectedError   error
+	}{
+		{
+			name:      "happy path",
+			platforms: "linux/amd64 windows/arm64",
+			expectedTargets: []Target{
+				{
+					Name:        "build-linux-amd64",
+					Description: "build the binary for linux/amd64",
+					Recipe:      []string{"@ GOOS=linux GOARCH=amd64 go build -o dist/$(BINARY_NAME)-linux-amd64 $(MAIN_PACKAGE)"},
+					Phony:       true,
+				},
+				{
+					Name:        "build-windows-arm64",
+					Description: "build the binary for windows/arm64",
+					Recipe:      []string{"@ GOOS=windows GOARCH=arm64 go build -o dist/$(BINARY_NAME)-windows-arm64.exe $(MAIN_PACKAGE)"},
+					Phony:       true,
+				},
+				{
+					Name:         "build-all",
+					Description:  "build the binary for all platforms",
+					Dependencies: []string{"build-linux-amd64", "build-windows-arm64"},
+					Phony:        true,
+				},
+			},
+		},
+		{
+			name:          "invalid platform",
+			platforms:     "linux",
+			expectedError: errors.New(`invalid platform "linux", expected "GOOS/GOARCH"`),
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			_, targets, err := crossCompileTargets(PresetContext{Params: map[string]string{"platforms": tc.platforms}})
+			if err != nil {
+				if tc.expectedError == nil {
+					t.Fatalf("expected no error, got %v", err)
+				}
+				require.Equal(t, tc.expectedError.Error(), err.Error())
+			} else {
+				if tc.expectedError != nil {
+					t.Fatalf("expected error to be %v, got nil", tc.expectedError)
+				}
+				require.Equal(t, tc.expectedTargets, targets)
+			}
+		})
+	}
+}
+
+func TestMocksContent(t *testing.T) {
+	testCases := []struct {
+		name            string
+		tool            string
+		expectedTargets []string
+		expectedError   error
+	}{
+		{
+			name:            "happy path, mockery",
+			tool:            "mockery",
+			expectedTargets: []string{"install-mockery", "mocks", "check-mocks"},
+		},
+		{
+			name:            "happy path, mockgen",
+			tool:            "mockgen",
+			expectedTargets: []string{"install-mockgen", "mocks", "check-mocks"},
+		},
+		{
+			name:          "invalid tool",
+			tool:          "gomock",
+			expectedError: errors.New(`invalid mock tool "gomock", expected "mockery" or "mockgen"`),
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			_, targets, err := mocksContent(PresetContext{Params: map[string]string{"mock-tool": tc.tool}})
+			if err != nil {
+				if tc.expectedError == nil {
+					t.Fatalf("expected no error, got %v", err)
+				}
+				require.Equal(t, tc.expectedError.Error(), err.Error())
+			} else {
+				if tc.expectedError != nil {
+					t.Fatalf("expected error to be %v, got nil", tc.expectedError)
+				}
+				var names []string
+				for _, target := range targets {
+					names = append(names, target.Name)
+				}
+				require.Equal(t, tc.expectedTargets, names)
+			}
+		})
+	}
+}
+
+func TestBenchContent(t *testing.T) {
+	testCases := []struct {
+		name            string
+		compare         string
+		expectedTargets []string
+		expectedError   error
+	}{
+		{
+			name:            "happy path",
+			compare:         "false",
+			expectedTargets: []string{"bench"},
+		},
+		{
+			name:            "happy path, with bench-compare",
+			compare:         "true",
+			expectedTargets: []string{"bench", "install-benchstat", "bench-baseline", "bench-compare"},
+		},
+		{
+			name:          "invalid bench-compare value",
+			compare:       "maybe",
+			expectedError: errors.New(`invalid bench-compare value "maybe", expected a boolean`),
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			_, targets, err := benchContent(PresetContext{Params: map[string]string{"bench-compare": tc.compare}})
+			if err != nil {
+				if tc.expectedError == nil {
+					t.Fatalf("expected no error, got %v", err)
+				}
+				require.Equal(t, tc.expectedError.Error(), err.Error())
+			} else {
+				if tc.expectedError != nil {
+					t.Fatalf("expected error to be %v, got nil", tc.expectedError)
+				}
+				var names []string
+				for _, target := range targets {
+					names = append(names, target.Name)
+				}
+				require.Equal(t, tc.expectedTargets, names)
+			}
+		})
+	}
+}
+
+func TestToolsContent(t *testing.T) {
+	testCases := []struct {
+		name           string
+		files          map[string][]byte
+		expectedRecipe []string
+		expectedError  error
+	}{
+		{
+			name: "happy path, tools.go",
+			files: map[string][]byte{
+				"go.mod":   []byte("module example.com/app\n\ngo 1.21\n"),
+				"tools.go": []byte("//go:build tools\n\npackage tools\n\nimport (\n\t_ \"golang.org/x/tools/cmd/stringer\"\n\t_ \"github.com/golangci/golangci-lint/cmd/golangci-lint\"\n)\n"),
+			},
+			expectedRecipe: []string{
+				"@ go install golang.org/x/tools/cmd/stringer",
+				"@ go install github.com/golangci/golangci-lint/cmd/golangci-lint",
+			},
+		},
+		{
+			name: "happy path, go.mod tool directives",
+			files: map[string][]byte{
+				"go.mod": []byte("module example.com/app\n\ngo 1.24\n\ntool golang.org/x/tools/cmd/stringer\n"),
+			},
+			expectedRecipe: []string{"@ go install tool"},
+		},
+		{
+			name: "no tools",
+			files: map[string][]byte{
+				"tools.go": []byte("package tools\n"),
+			},
+			expectedError: errors.New("no tools found in tools.go"),
+		},
+		{
+			name:          "no tools.go",
+			files:         map[string][]byte{},
+			expectedError: errors.New("reading tools.go: file does not exist"),
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			fsProvider = &mockFileSystem{files: tc.files, isNotExistOutput: true}
+			_, targets, err := toolsContent(PresetContext{Dir: ".", Params: map[string]string{"tools-file": "tools.go"}})
+			if err != nil {
+				if tc.expectedError == nil {
+					t.Fatalf("expected no error, got %v", err)
+				}
+				require.Equal(t, tc.expectedError.Error(), err.Error())
+			} else {
+				if tc.expectedError != nil {
+					t.Fatalf("expected error to be %v, got nil", tc.expectedError)
+				}
+				require.Equal(t, tc.expectedRecipe, targets[0].Recipe)
+			}
+		})
+	}
+}
+
+func TestCleanContent(t *testing.T) {
+	variables, targets, err := cleanContent(PresetContext{Params: map[string]string{
+		"clean-artifacts":     "bin  coverage.out",
+		"distclean-artifacts": "vendor",
+	}})
+	require.NoError(t, err)
+	require.Equal(t, []Variable{
+		{Name: "CLEAN_ARTIFACTS", Value: "bin coverage.out"},
+		{Name: "DISTCLEAN_ARTIFACTS", Value: "vendor"},
+	}, variables)
+	require.Equal(t, "clean", targets[0].Name)
+	require.Equal(t, "distclean", targets[1].Name)
+	require.Equal(t, []string{"clean"}, targets[1].Dependencies)
+}
+
+func TestDistTargets(t *testing.T) {
+	_, targets, err := distTargets(PresetContext{Params: map[string]string{"platforms": "linux/amd64 windows/amd64"}})
+	require.NoError(t, err)
+	require.Equal(t, []Target{
+		{
+			Name:         "dist-linux-amd64",
+			Description:  "package the binary for linux/amd64",
+			Dependencies: []string{"build-linux-amd64"},
+			Recipe:       []string{"@ tar -czf dist/$(BINARY_NAME)-$(VERSION)-linux-amd64.tar.gz -C dist $(BINARY_NAME)-linux-amd64"},
+			Phony:        true,
+		},
+		{
+			Name:         "dist-windows-amd64",
+			Description:  "package the binary for windows/amd64",
+			Dependencies: []string{"build-windows-amd64"},
+			Recipe:       []string{"@ cd dist && zip -q $(BINARY_NAME)-$(VERSION)-windows-amd64.zip $(BINARY_NAME)-windows-amd64.exe"},
+			Phony:        true,
+		},
+		{
+			Name:         "dist",
+			Description:  "package the binaries for all platforms into dist, with their checksums",
+			Dependencies: []string{"dist-linux-amd64", "dist-windows-amd64"},
+			Recipe:       []string{"@ cd dist && $(SHA256SUM) $(notdir $(wildcard dist/*.tar.gz dist/*.zip)) > checksums.txt"},
+			Phony:        true,
+		},
+	}, targets)
+
+	_, _, err = distTargets(PresetContext{Params: map[string]string{"platforms": "linux"}})
+	require.EqualError(t, err, `invalid platform "linux", expected "GOOS/GOARCH"`)
+}
+
+func TestChangelogContent(t *testing.T) {
+	testCases := []struct {
+		name           string
+		tool           string
+		expectedRecipe []string
+		expectedError  error
+	}{
+		{
+			name:           "happy path, git",
+			tool:           "git",
+			expectedRecipe: []string{`@ (echo "# Changelog"; echo; git log --no-merges --pretty=format:'- %s (%h)' $(CHANGELOG_RANGE)) > $(CHANGELOG_FILE)`},
+		},
+		{
+			name:           "happy path, git-cliff",
+			tool:           "git-cliff",
+			expectedRecipe: []string{"@ git-cliff $(CHANGELOG_RANGE) --output $(CHANGELOG_FILE)"},
+		},
+		{
+			name:          "invalid tool",
+			tool:          "svn",
+			expectedError: errors.New(`invalid changelog tool "svn", expected "git" or "git-cliff"`),
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			variables, targets, err := changelogContent(PresetContext{Params: map[string]string{
+				"changelog-tool":  tc.tool,
+				"changelog-file":  "CHANGES.md",
+				"changelog-range": "v1.0.0..HEAD",
+			}})
+			if err != nil {
+				if tc.expectedError == nil {
+					t.Fatalf("expected no error, got %v", err)
+				}
+				require.Equal(t, tc.expectedError.Error(), err.Error())
+			} else {
+				if tc.expectedError != nil {
+					t.Fatalf("expected error to be %v, got nil", tc.expectedError)
+				}
+				require.Equal(t, []Variable{
+					{Name: "CHANGELOG_FILE", Value: "CHANGES.md"},
+					{Name: "CHANGELOG_RANGE", Value: "v1.0.0..HEAD"},
+				}, variables)
+				require.Equal(t, tc.expectedRecipe, targets[0].Recipe)
+			}
+		})
+	}
+}
+
+func TestNodeContent(t *testing.T) {
+	testCases := []struct {
+		name                   string
+		packageManager         string
+		files                  map[string][]byte
+		expectedPackageManager string
+		expectedError          error
+	}{
+		{
+			name:                   "happy path, detected from lockfile",
+			packageManager:         "auto",
+			files:                  map[string][]byte{"web/pnpm-lock.yaml": nil},
+			expectedPackageManager: "pnpm",
+		},
+		{
+			name:                   "happy path, no lockfile",
+			packageManager:         "auto",
+			files:                  map[string][]byte{},
+			expectedPackageManager: "npm",
+		},
+		{
+			name:                   "happy path, explicit",
+			packageManager:         "yarn",
+			files:                  map[string][]byte{"web/pnpm-lock.yaml": nil},
+			expectedPackageManager: "yarn",
+		},
+		{
+			name:           "invalid package manager",
+			packageManager: "bun",
+			files:          map[string][]byte{},
+			expectedError:  errors.New(`invalid package manager "bun", expected "auto", "npm", "pnpm" or "yarn"`),
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			fsProvider = &mockFileSystem{files: tc.files}
+			variables, targets, err := nodeContent(PresetContext{Dir: "web", Params: map[string]string{"package-manager": tc.packageManager}})
+			if err != nil {
+				if tc.expectedError == nil {
+					t.Fatalf("expected no error, got %v", err)
+				}
+				require.Equal(t, tc.expectedError.Error(), err.Error())
+			} else {
+				if tc.expectedError != nil {
+					t.Fatalf("expected error to be %v, got nil", tc.expectedError)
+				}
+				require.Equal(t, []Variable{{Name: "PACKAGE_MANAGER", Value: tc.expectedPackageManager}}, variables)
+				require.Len(t, targets, 4)
+			}
+		})
+	}
+}
+
+func TestGoContent(t *testing.T) {
+	testCases := []struct {
+		name              string
+		tree              fstest.MapFS
+		appName           string
+		expectedVariables []Variable
+		expectedTargets   []Target
+	}{
+		{
+			name:    "single binary",
+			tree:    fstest.MapFS{"main.go": {}},
+			appName: "widget",
+			expectedVariables: []Variable{
+				{Name: "APP_NAME", Value: "widget"},
+				{Name: "BINARY_NAME", Value: "$(APP_NAME)"},
+				{Name: "MAIN_PACKAGE", Value: "."},
+			},
+		},
+		{
+			name: "binaries in cmd",
+			tree: fstest.MapFS{
+				"cmd/widget/main.go":   {},
+				"cmd/migrate/main.go":  {},
+				"cmd/internal/util.go": {},
+				"cmd/README.md":        {},
+			},
+			appName: "widget",
+			expectedVariables: []Variable{
+				{Name: "APP_NAME", Value: "widget"},
+				{Name: "BINARY_NAME", Value: "$(APP_NAME)"},
+				{Name: "MAIN_PACKAGE", Value: "./cmd/$(BINARY_NAME)"},
+			},
+			expectedTargets: []Target{
+				{
+					Name:        "build-migrate",
+					Description: "build the migrate binary into the bin directory",
+					Recipe:      []string{"@ go build -o bin/migrate ./cmd/migrate"},
+					Phony:       true,
+				},
+				{
+					Name:        "build-widget",
+					Description: "build the widget binary into the bin directory",
+					Recipe:      []string{"@ go build -o bin/widget ./cmd/widget"},
+					Phony:       true,
+				},
+				{
+					Name:         "build",
+					Description:  "build all the binaries into the bin directory",
+					Dependencies: []string{"build-migrate", "build-widget"},
+					Phony:        true,
+				},
+			},
+		},
+		{
+			name:    "no binary named after the module",
+			tree:    fstest.MapFS{"cmd/server/main.go": {}},
+			appName: "widget",
+			expectedVariables: []Variable{
+				{Name: "APP_NAME", Value: "widget"},
+				{Name: "BINARY_NAME", Value: "server"},
+				{Name: "MAIN_PACKAGE", Value: "./cmd/$(BINARY_NAME)"},
+			},
+			expectedTargets: []Target{
+				{
+					Name:        "build-server",
+					Description: "build the server binary into the bin directory",
+					Recipe:      []string{"@ go build -o bin/server ./cmd/server"},
+					Phony:       true,
+				},
+				{
+					Name:         "build",
+					Description:  "build all the binaries into the bin directory",
+					Dependencies: []string{"build-server"},
+					Phony:        true,
+				},
+			},
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			fsProvider = &mockFileSystem{tree: tc.tree}
+			variables, targets, err := goContent(PresetContext{Dir: ".", AppName: tc.appName})
+			require.NoError(t, err)
+			require.Equal(t, tc.expectedVariables, variables)
+			require.Equal(t, tc.expectedTargets, targets)
+		})
+	}
+}
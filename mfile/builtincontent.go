@@ -0,0 +1,95 @@
+// Copyright (c) 2023 Tiago Melo. All rights reserved.
+// Use of this source code is governed by the MIT License that can be found in
+// the LICENSE file.
+
+package mfile
+
+import (
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// BuiltinContent returns the content generated by the built-in flavors
+// and presets, and the built-in target templates, by name, like
+// "flavor/full", "preset/docker" and "template/target". The flavors and
+// presets are generated as Generate does for a project without go.mod, so
+// that the content of two releases can be compared, see DiffContent. A
+// preset that can't be generated outside of a project holds the error as
+// a comment.
+func BuiltinContent() (map[string]string, error) {
+	content := make(map[string]string)
+	// A directory that does not exist stands for an empty project.
+	makeFilePath := filepath.Join(os.TempDir(), "gomakefile-builtin-content", "app", makefileName)
+	for _, f := range flavors {
+		c, err := generatedContent(makeFilePath, WithFlavor(f.Name))
+		if err != nil {
+			return nil, err
+		}
+		content["flavor/"+f.Name] = c
+	}
+	for _, p := range builtinPresets {
+		c, err := generatedContent(makeFilePath, WithPresets(p.Name))
+		if err != nil {
+			c = "# " + err.Error() + "\n"
+		}
+		content["preset/"+p.Name] = c
+	}
+	err := fs.WalkDir(BuiltinTemplates(), ".", func(path string, d fs.DirEntry, err error) error {
+		if err != nil || d.IsDir() {
+			return err
+		}
+		c, err := fs.ReadFile(BuiltinTemplates(), path)
+		if err != nil {
+			return err
+		}
+		content["template/"+strings.TrimSuffix(path, templateExt)] = string(c)
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return content, nil
+}
+
+// generatedContent returns the content Generate generates for the
+// Makefile at the given path with the given options, without writing it.
+func generatedContent(makeFilePath string, opts ...GenerateOption) (string, error) {
+	capture := &capturingFileSystem{fileSystem: fsProvider, written: make(map[string][]byte)}
+	if err := generate(capture, makeFilePath, append(opts, WithOverwrite(true))...); err != nil {
+		return "", err
+	}
+	return string(capture.written[makeFilePath]), nil
+}
+
+// DiffContent returns the unified diff turning the given old built-in
+// content into the new one, as returned by BuiltinContent, labeled with
+// the given names, like the releases they come from, or an empty string
+// if they are the same. The content is compared by name, in order, the
+// names only in one of them being added or removed.
+func DiffContent(oldName, newName string, old, new map[string]string) string {
+	names := make([]string, 0, len(old)+len(new))
+	for name := range old {
+		names = append(names, name)
+	}
+	for name := range new {
+		if _, ok := old[name]; !ok {
+			names = append(names, name)
+		}
+	}
+	sort.Strings(names)
+	var sb strings.Builder
+	for _, name := range names {
+		oldLabel, newLabel := oldName+"/"+name, newName+"/"+name
+		if _, ok := old[name]; !ok {
+			oldLabel = "/dev/null"
+		}
+		if _, ok := new[name]; !ok {
+			newLabel = "/dev/null"
+		}
+		sb.WriteString(lineDiff(oldLabel, newLabel, old[name], new[name]))
+	}
+	return sb.String()
+}
@@ -0,0 +1,58 @@
+// Copyright (c) 2023 Tiago Melo. All rights reserved.
+// Use of this source code is governed by the MIT License that can be found in
+// the LICENSE file.
+
+package mfile
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestBuiltinContent(t *testing.T) {
+	fsProvider = osFileSystem{}
+	content, err := BuiltinContent()
+	require.NoError(t, err)
+	for _, f := range Flavors() {
+		require.Contains(t, content, "flavor/"+f.Name)
+	}
+	for _, p := range builtinPresets {
+		require.Contains(t, content, "preset/"+p.Name)
+	}
+	for _, name := range TemplateNames() {
+		require.Contains(t, content, "template/"+name)
+	}
+	require.Contains(t, content["preset/docker"], "docker-build:")
+	again, err := BuiltinContent()
+	require.NoError(t, err)
+	require.Equal(t, content, again)
+}
+
+func TestDiffContent(t *testing.T) {
+	testCases := []struct {
+		name         string
+		old          map[string]string
+		new          map[string]string
+		expectedDiff string
+	}{
+		{
+			name: "happy path",
+			old:  map[string]string{"preset/docker": "build:\n", "preset/lint": "lint:\n", "preset/old": "old:\n"},
+			new:  map[string]string{"preset/docker": "build:\npush:\n", "preset/lint": "lint:\n", "preset/new": "new:\n"},
+			expectedDiff: "--- v1/preset/docker\n+++ v2/preset/docker\n@@ -1 +1,2 @@\n build:\n+push:\n" +
+				"--- /dev/null\n+++ v2/preset/new\n@@ -0,0 +1 @@\n+new:\n" +
+				"--- v1/preset/old\n+++ /dev/null\n@@ -1 +0,0 @@\n-old:\n",
+		},
+		{
+			name: "same content",
+			old:  map[string]string{"preset/lint": "lint:\n"},
+			new:  map[string]string{"preset/lint": "lint:\n"},
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			require.Equal(t, tc.expectedDiff, DiffContent("v1", "v2", tc.old, tc.new))
+		})
+	}
+}
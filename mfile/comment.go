@@ -0,0 +1,94 @@
+// Copyright (c) 2023 Tiago Melo. All rights reserved.
+// Use of this source code is governed by the MIT License that can be found in
+// the LICENSE file.
+
+package mfile
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+)
+
+// Position is a point of a Makefile where content is inserted, see
+// AddComment.
+type Position struct {
+	target string // Target the content is inserted next to, if any.
+	after  bool   // Whether the content is inserted after the target, or at the end of the Makefile.
+}
+
+var (
+	// Top is the start of the Makefile.
+	Top = Position{}
+
+	// Bottom is the end of the Makefile.
+	Bottom = Position{after: true}
+)
+
+// BeforeTarget is the point right before the rule of the given target,
+// including the comments and .PHONY declaration before it.
+func BeforeTarget(target string) Position {
+	return Position{target: target}
+}
+
+// AfterTarget is the point right after the recipe of the given target.
+func AfterTarget(target string) Position {
+	return Position{target: target, after: true}
+}
+
+// AddComment inserts a block of comments, like usage notes or ownership
+// tags, at the given position of the Makefile at the given path. Each
+// line is turned into a comment with "# ", and the block is separated by
+// blank lines from the content around it, so that it is not mistaken
+// for the help comment of a target. It returns ErrTargetNotFound if the
+// position is next to a target that is not declared in the Makefile.
+func AddComment(path string, lines []string, position Position) error {
+	makeFilePath := mkFilePath(path)
+	content, err := readMakefile(makeFilePath)
+	if err != nil {
+		return err
+	}
+	updated, err := insertComment(content, lines, position)
+	if err != nil {
+		return fmt.Errorf("adding comment to %s: %w", makeFilePath, err)
+	}
+	if err := fsProvider.WriteFile(makeFilePath, []byte(updated), 0644); err != nil {
+		return &Error{Op: "writing Makefile", Path: makeFilePath, Err: err}
+	}
+	logger.Debug("added comment", "path", makeFilePath, "lines", len(lines))
+	return nil
+}
+
+// insertComment returns the given Makefile content with the given lines
+// inserted as comments at the given position.
+func insertComment(content string, lines []string, position Position) (string, error) {
+	comment := make([]string, len(lines))
+	for i, line := range lines {
+		comment[i] = strings.TrimRight("# "+line, " ")
+	}
+	existing := strings.Split(strings.TrimSuffix(content, "\n"), "\n")
+	if content == "" {
+		existing = nil
+	}
+	at := 0
+	switch {
+	case position.target != "":
+		first, _, _, last, ok := ruleBlock(existing, position.target)
+		if !ok {
+			return "", fmt.Errorf("looking up target %s: %w", position.target, ErrTargetNotFound)
+		}
+		at = first
+		if position.after {
+			at = last + 1
+		}
+	case position.after:
+		at = len(existing)
+	}
+	if at > 0 && strings.TrimSpace(existing[at-1]) != "" {
+		comment = append([]string{""}, comment...)
+	}
+	if at < len(existing) && strings.TrimSpace(existing[at]) != "" {
+		comment = append(comment, "")
+	}
+	return strings.Join(slices.Insert(existing, at, comment...), "\n") + "\n", nil
+}
@@ -0,0 +1,127 @@
+// Copyright (c) 2023 Tiago Melo. All rights reserved.
+// Use of this source code is governed by the MIT License that can be found in
+// the LICENSE file.
+
+package mfile
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestAddComment(t *testing.T) {
+	const content = `GO ?= go
+
+.PHONY: build
+## build: build the binary
+build:
+	$(GO) build ./...
+.PHONY: test
+test: build
+	$(GO) test ./...
+`
+	testCases := []struct {
+		name            string
+		lines           []string
+		position        Position
+		readFileErr     error
+		expectedContent string
+		expectedError   error
+	}{
+		{
+			name:     "happy path, top",
+			lines:    []string{"Owned by the platform team.", "", "Run make help for usage."},
+			position: Top,
+			expectedContent: `# Owned by the platform team.
+#
+# Run make help for usage.
+
+GO ?= go
+
+.PHONY: build
+## build: build the binary
+build:
+	$(GO) build ./...
+.PHONY: test
+test: build
+	$(GO) test ./...
+`,
+		},
+		{
+			name:     "happy path, bottom",
+			lines:    []string{"end of generated targets"},
+			position: Bottom,
+			expectedContent: content + `
+# end of generated targets
+`,
+		},
+		{
+			name:     "happy path, before target",
+			lines:    []string{"needs Go 1.21"},
+			position: BeforeTarget("build"),
+			expectedContent: `GO ?= go
+
+# needs Go 1.21
+
+.PHONY: build
+## build: build the binary
+build:
+	$(GO) build ./...
+.PHONY: test
+test: build
+	$(GO) test ./...
+`,
+		},
+		{
+			name:     "happy path, after target",
+			lines:    []string{"tests run after the build"},
+			position: AfterTarget("build"),
+			expectedContent: `GO ?= go
+
+.PHONY: build
+## build: build the binary
+build:
+	$(GO) build ./...
+
+# tests run after the build
+
+.PHONY: test
+test: build
+	$(GO) test ./...
+`,
+		},
+		{
+			name:          "target not found",
+			lines:         []string{"note"},
+			position:      BeforeTarget("lint"),
+			expectedError: errors.New("adding comment to Makefile: looking up target lint: target not found"),
+		},
+		{
+			name:          "error when reading Makefile",
+			lines:         []string{"note"},
+			position:      Top,
+			readFileErr:   errors.New("read error"),
+			expectedError: errors.New("reading Makefile at Makefile: read error"),
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			fs := &mockFileSystem{file: []byte(content), readFileErr: tc.readFileErr}
+			fsProvider = fs
+			err := AddComment("Makefile", tc.lines, tc.position)
+			if err != nil {
+				if tc.expectedError == nil {
+					t.Fatalf("expected no error, got %v", err)
+				}
+				require.Equal(t, tc.expectedError.Error(), err.Error())
+			} else {
+				if tc.expectedError != nil {
+					t.Fatalf("expected error to be %v, got nil", tc.expectedError)
+				}
+				require.Equal(t, tc.expectedContent, string(fs.writtenData))
+			}
+		})
+	}
+}
@@ -0,0 +1,117 @@
+// Copyright (c) 2023 Tiago Melo. All rights reserved.
+// Use of this source code is governed by the MIT License that can be found in
+// the LICENSE file.
+
+package mfile
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Supported shells for completion scripts.
+const (
+	ShellBash = "bash"
+	ShellZsh  = "zsh"
+)
+
+// Templates for the completion scripts.
+const (
+	bashCompletionTemplate = `# bash completion for make targets, generated by gomakefile.
+# Regenerate it whenever targets change. Options, their arguments, and
+# directories without a Makefile are left to the make completion
+# registered before, if any, or to file completion.
+_gomakefile_make_fallback=$(complete -p make 2>/dev/null | sed -n 's/.* -F \([^ ]*\) .*/\1/p')
+[[ $_gomakefile_make_fallback == _gomakefile_make_targets ]] && _gomakefile_make_fallback=
+_gomakefile_make_targets() {
+	local cur="${COMP_WORDS[COMP_CWORD]}" prev="${COMP_WORDS[COMP_CWORD-1]}"
+	COMPREPLY=()
+	if [[ $cur != -* ]] && [[ -f Makefile || -f makefile || -f GNUmakefile ]]; then
+		case "$prev" in
+		-f | --file | --makefile | -C | --directory | -I | --include-dir | -o | --old-file | -W | --what-if) ;;
+		*) COMPREPLY=($(compgen -W "%s" -- "$cur")) ;;
+		esac
+	fi
+	((${#COMPREPLY[@]})) && return
+	local fallback="${_gomakefile_make_fallback:-_make}"
+	if declare -F "$fallback" >/dev/null; then
+		"$fallback" "$@"
+	fi
+}
+complete -o bashdefault -o default -F _gomakefile_make_targets make
+`
+	zshCompletionTemplate = `# zsh completion for make targets, generated by gomakefile.
+# Regenerate it whenever targets change.
+_gomakefile_make_targets() {
+	local -a targets
+	targets=(
+%s	)
+	_describe -t targets 'make target' targets
+}
+compdef _gomakefile_make_targets make
+`
+)
+
+// Completion parses the Makefile at the given path and returns a completion
+// script for the given shell, so that `make <TAB>` completes its targets.
+// The zsh script lists the description of each target, from its
+// "## name: description" comment, next to its name.
+func Completion(path, shell string) (string, error) {
+	all, err := ListTargets(path)
+	if err != nil {
+		return "", err
+	}
+	var targets []Target
+	for _, t := range all {
+		// Pattern rules can't be invoked by name.
+		if !strings.Contains(t.Name, "%") {
+			targets = append(targets, t)
+		}
+	}
+	switch shell {
+	case ShellBash:
+		names := make([]string, len(targets))
+		for i, t := range targets {
+			names[i] = t.Name
+		}
+		return fmt.Sprintf(bashCompletionTemplate, strings.Join(names, " ")), nil
+	case ShellZsh:
+		var sb strings.Builder
+		for _, t := range targets {
+			sb.WriteString("\t\t" + zshDescription(t) + "\n")
+		}
+		return fmt.Sprintf(zshCompletionTemplate, sb.String()), nil
+	}
+	return "", fmt.Errorf("unsupported shell %q", shell)
+}
+
+// zshDescription returns the name:description entry of the given target
+// listed by _describe, single-quoted.
+func zshDescription(t Target) string {
+	entry := strings.ReplaceAll(t.Name, ":", `\:`)
+	if t.Description != "" {
+		entry += ":" + t.Description
+	}
+	return "'" + strings.ReplaceAll(entry, "'", `'\''`) + "'"
+}
+
+// AddCompletionsTarget adds the completions target of PresetCompletions
+// to the Makefile at the given path, along with the variables it uses
+// the Makefile doesn't assign, so that make completions regenerates its
+// completion scripts whenever its targets change. It returns
+// ErrTargetExists if the Makefile already declares it.
+func AddCompletionsTarget(path string) error {
+	makeFilePath := mkFilePath(path)
+	m, err := Parse(makeFilePath)
+	if err != nil {
+		return err
+	}
+	for _, t := range m.Targets() {
+		if t.Name == completionsTarget.Name {
+			return &Error{Op: "adding target " + t.Name, Path: makeFilePath, Err: ErrTargetExists}
+		}
+	}
+	p := presets[PresetCompletions]
+	m = appendTargets(m, missingVariables(m, p.Variables), p.Targets)
+	return m.Write(makeFilePath)
+}
@@ -0,0 +1,199 @@
+// Copyright (c) 2023 Tiago Melo. All rights reserved.
+// Use of this source code is governed by the MIT License that can be found in
+// the LICENSE file.
+
+package mfile
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestCompletion(t *testing.T) {
+	testCases := []struct {
+		name           string
+		shell          string
+		mockClosure    func(m *mockFileSystem)
+		expectedOutput string
+		expectedError  error
+	}{
+		{
+			name:  "happy path, bash",
+			shell: ShellBash,
+			mockClosure: func(m *mockFileSystem) {
+				m.file = []byte("build:\n%.o: %.c\ntest: build\n")
+			},
+			expectedOutput: `# bash completion for make targets, generated by gomakefile.
+# Regenerate it whenever targets change. Options, their arguments, and
+# directories without a Makefile are left to the make completion
+# registered before, if any, or to file completion.
+_gomakefile_make_fallback=$(complete -p make 2>/dev/null | sed -n 's/.* -F \([^ ]*\) .*/\1/p')
+[[ $_gomakefile_make_fallback == _gomakefile_make_targets ]] && _gomakefile_make_fallback=
+_gomakefile_make_targets() {
+	local cur="${COMP_WORDS[COMP_CWORD]}" prev="${COMP_WORDS[COMP_CWORD-1]}"
+	COMPREPLY=()
+	if [[ $cur != -* ]] && [[ -f Makefile || -f makefile || -f GNUmakefile ]]; then
+		case "$prev" in
+		-f | --file | --makefile | -C | --directory | -I | --include-dir | -o | --old-file | -W | --what-if) ;;
+		*) COMPREPLY=($(compgen -W "build test" -- "$cur")) ;;
+		esac
+	fi
+	((${#COMPREPLY[@]})) && return
+	local fallback="${_gomakefile_make_fallback:-_make}"
+	if declare -F "$fallback" >/dev/null; then
+		"$fallback" "$@"
+	fi
+}
+complete -o bashdefault -o default -F _gomakefile_make_targets make
+`,
+		},
+		{
+			name:  "happy path, zsh",
+			shell: ShellZsh,
+			mockClosure: func(m *mockFileSystem) {
+				m.file = []byte("## build: build the app's binary\nbuild:\ntest: build\n")
+			},
+			expectedOutput: `# zsh completion for make targets, generated by gomakefile.
+# Regenerate it whenever targets change.
+_gomakefile_make_targets() {
+	local -a targets
+	targets=(
+		'build:build the app'\''s binary'
+		'test'
+	)
+	_describe -t targets 'make target' targets
+}
+compdef _gomakefile_make_targets make
+`,
+		},
+		{
+			name:          "unsupported shell",
+			shell:         "fish",
+			mockClosure:   func(m *mockFileSystem) {},
+			expectedError: errors.New(`unsupported shell "fish"`),
+		},
+		{
+			name:  "error when reading file",
+			shell: ShellBash,
+			mockClosure: func(m *mockFileSystem) {
+				m.readFileErr = errors.New("read error")
+			},
+			expectedError: errors.New("reading Makefile at some/path: read error"),
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			m := new(mockFileSystem)
+			tc.mockClosure(m)
+			fsProvider = m
+			output, err := Completion("some/path", tc.shell)
+			if err != nil {
+				if tc.expectedError == nil {
+					t.Fatalf("expected no error, got %v", err)
+				}
+				require.Equal(t, tc.expectedError.Error(), err.Error())
+			} else {
+				if tc.expectedError != nil {
+					t.Fatalf("expected error to be %v, got nil", tc.expectedError)
+				}
+				require.Equal(t, tc.expectedOutput, output)
+			}
+		})
+	}
+}
+
+func TestBashCompletionFallback(t *testing.T) {
+	if _, err := exec.LookPath("bash"); err != nil {
+		t.Skip("bash is not installed")
+	}
+	script := filepath.Join(t.TempDir(), "make.bash")
+	require.NoError(t, os.WriteFile(script, []byte(fmt.Sprintf(bashCompletionTemplate, "build test")), 0644))
+	project, empty := t.TempDir(), t.TempDir()
+	require.NoError(t, os.WriteFile(filepath.Join(project, "Makefile"), []byte("build:\n"), 0644))
+	testCases := []struct {
+		name          string
+		dir           string
+		words         string
+		expectedReply string
+	}{
+		{name: "target", dir: project, words: "make b", expectedReply: "build"},
+		{name: "option", dir: project, words: "make -", expectedReply: "previous"},
+		{name: "option argument", dir: project, words: "make -f b", expectedReply: "previous"},
+		{name: "not a target", dir: project, words: "make x", expectedReply: "previous"},
+		{name: "directory without a Makefile", dir: empty, words: "make b", expectedReply: "previous"},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			cmd := exec.Command("bash", "-c", `_make() { COMPREPLY=(previous); }
+complete -F _make make
+source "$1"
+COMP_WORDS=($2)
+COMP_CWORD=$((${#COMP_WORDS[@]} - 1))
+_gomakefile_make_targets make "${COMP_WORDS[COMP_CWORD]}" "${COMP_WORDS[COMP_CWORD-1]}"
+echo "${COMPREPLY[*]}"`, "bash", script, tc.words)
+			cmd.Dir = tc.dir
+			out, err := cmd.CombinedOutput()
+			require.NoError(t, err, string(out))
+			require.Equal(t, tc.expectedReply, strings.TrimSpace(string(out)))
+		})
+	}
+}
+
+func TestAddCompletionsTarget(t *testing.T) {
+	testCases := []struct {
+		name                   string
+		content                string
+		expectedWrittenContent string
+		expectedError          error
+	}{
+		{
+			name:    "happy path",
+			content: "GOMAKEFILE ?= ./bin/gomakefile\n\nbuild:\n\tgo build\n",
+			expectedWrittenContent: `GOMAKEFILE ?= ./bin/gomakefile
+
+build:
+	go build
+
+COMPLETIONS_DIR ?= .completions
+
+.PHONY: completions
+## completions: regenerate the bash and zsh completion scripts of the make targets
+completions:
+	@ mkdir -p $(COMPLETIONS_DIR)
+	@ $(GOMAKEFILE) completion -s bash -f $(COMPLETIONS_DIR)/make.bash
+	@ $(GOMAKEFILE) completion -s zsh -f $(COMPLETIONS_DIR)/make.zsh
+`,
+		},
+		{
+			name:          "target exists",
+			content:       "completions:\n\t@ echo done\n",
+			expectedError: errors.New("adding target completions at some/path: target already exists"),
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			m := &mockFileSystem{file: []byte(tc.content)}
+			fsProvider = m
+			err := AddCompletionsTarget("some/path")
+			if err != nil {
+				if tc.expectedError == nil {
+					t.Fatalf("expected no error, got %v", err)
+				}
+				require.Equal(t, tc.expectedError.Error(), err.Error())
+				require.True(t, errors.Is(err, ErrTargetExists))
+			} else {
+				if tc.expectedError != nil {
+					t.Fatalf("expected error to be %v, got nil", tc.expectedError)
+				}
+				require.Equal(t, tc.expectedWrittenContent, string(m.writtenData))
+			}
+		})
+	}
+}
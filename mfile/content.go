@@ -0,0 +1,63 @@
+// Copyright (c) 2023 Tiago Melo. All rights reserved.
+// Use of this source code is governed by the MIT License that can be found in
+// the LICENSE file.
+
+package mfile
+
+// The functions of this file work on Makefile content rather than on
+// files, in memory, without touching the disk, so that they can be used
+// where there is none, like in a browser when built to WebAssembly, or by
+// services generating Makefiles for their clients. They don't change the
+// file system the package works on, so they can run concurrently with
+// each other and with the functions working on files. Their errors refer
+// to the Makefile as "Makefile".
+
+// GenerateContent returns the content Generate generates with the given
+// options for an empty project, without go.mod. Fragments, see
+// WithFragments, are not returned.
+func GenerateContent(opts ...GenerateOption) (string, error) {
+	mem := newMemFileSystem(nil)
+	if err := generate(mem, makefileName, opts...); err != nil {
+		return "", err
+	}
+	return string(mem.files[makefileName]), nil
+}
+
+// AddTargetContent returns the given Makefile content with the given
+// target added, like AddTarget adds it.
+func AddTargetContent(content, targetName string, opts ...TargetOption) (string, error) {
+	m := ParseString(content)
+	if err := addTarget(newMemFileSystem(nil), m, makefileName, targetName, opts); err != nil {
+		return "", &Error{Op: "adding target " + targetName, Path: makefileName, Err: err}
+	}
+	return m.String(), nil
+}
+
+// LintContent returns the problems found in the given Makefile content,
+// see LintMakefile.
+func LintContent(content, dialect string) ([]string, error) {
+	return onContent(content, func(fsys fileSystem, path string) ([]string, error) {
+		return lintFile(fsys, path, dialect)
+	})
+}
+
+// MakeRequirementsContent returns the constructs of the given Makefile
+// content that older versions of GNU make don't support, see
+// MakeRequirements.
+func MakeRequirementsContent(content string) ([]MakeRequirement, error) {
+	return onContent(content, requirementsFile)
+}
+
+// ExportContent converts the given Makefile content into the given
+// format, see Export.
+func ExportContent(content, format string) ([]byte, error) {
+	return onContent(content, func(fsys fileSystem, path string) ([]byte, error) {
+		return exportFile(fsys, path, format)
+	})
+}
+
+// onContent calls the given function with an in-memory file system and
+// the path of a Makefile it holds with the given content.
+func onContent[T any](content string, f func(fsys fileSystem, path string) (T, error)) (T, error) {
+	return f(newMemFileSystem(map[string][]byte{makefileName: []byte(content)}), makefileName)
+}
@@ -0,0 +1,193 @@
+// Copyright (c) 2023 Tiago Melo. All rights reserved.
+// Use of this source code is governed by the MIT License that can be found in
+// the LICENSE file.
+
+package mfile
+
+import (
+	"errors"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestGenerateContent(t *testing.T) {
+	testCases := []struct {
+		name          string
+		opts          []GenerateOption
+		expectedError error
+	}{
+		{
+			name: "happy path",
+		},
+		{
+			name: "happy path, flavor and presets",
+			opts: []GenerateOption{WithFlavor("full"), WithPresets("docker")},
+		},
+		{
+			name:          "unknown flavor",
+			opts:          []GenerateOption{WithFlavor("tiny")},
+			expectedError: errors.New(`unknown flavor "tiny"`),
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			disk := &mockFileSystem{readFileErr: errors.New("disk used")}
+			fsProvider = disk
+			templateProcessorProvider = htmlTemplateProcessor{}
+			content, err := GenerateContent(tc.opts...)
+			require.Same(t, disk, fsProvider)
+			if tc.expectedError != nil {
+				require.ErrorContains(t, err, tc.expectedError.Error())
+				return
+			}
+			require.NoError(t, err)
+			fsProvider = &osFileSystem{}
+			dir := t.TempDir()
+			require.NoError(t, Generate(dir, tc.opts...))
+			expected, err := readMakefile(mkFilePath(dir))
+			require.NoError(t, err)
+			require.Equal(t, expected, content)
+		})
+	}
+}
+
+func TestAddTargetContent(t *testing.T) {
+	testCases := []struct {
+		name            string
+		content         string
+		targetName      string
+		opts            []TargetOption
+		expectedContent string
+		expectedError   error
+	}{
+		{
+			name:            "happy path",
+			content:         "build:\n",
+			targetName:      "lint",
+			opts:            []TargetOption{WithContent("golangci-lint run")},
+			expectedContent: "build:\n\n.PHONY: lint\n## lint: explain what lint does\nlint:\n\tgolangci-lint run\n",
+		},
+		{
+			name:            "happy path, replacing target",
+			content:         "build:\n\tgo build\n",
+			targetName:      "build",
+			opts:            []TargetOption{WithExistsPolicy(ReplaceIfExists)},
+			expectedContent: ".PHONY: build\n## build: explain what build does\nbuild:\n",
+		},
+		{
+			name:            "happy path, replacing target keeps its place and comments",
+			content:         "vet:\n\tgo vet\n\n# builds it\n## build: old\nbuild:\n\tgo build\n\ntest:\n\tgo test\n",
+			targetName:      "build",
+			opts:            []TargetOption{WithContent("go build ./..."), WithExistsPolicy(ReplaceIfExists)},
+			expectedContent: "vet:\n\tgo vet\n\n# builds it\n.PHONY: build\n## build: explain what build does\nbuild:\n\tgo build ./...\n\ntest:\n\tgo test\n",
+		},
+		{
+			name:          "target already exists",
+			content:       "build:\n",
+			targetName:    "build",
+			expectedError: errors.New("adding target build at Makefile: target already exists"),
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			fsProvider = &mockFileSystem{readFileErr: errors.New("disk used")}
+			templateProcessorProvider = htmlTemplateProcessor{}
+			userHomeDir = func() (string, error) { return "/home/gopher", nil }
+			content, err := AddTargetContent(tc.content, tc.targetName, tc.opts...)
+			if tc.expectedError != nil {
+				require.EqualError(t, err, tc.expectedError.Error())
+				require.ErrorIs(t, err, ErrTargetExists)
+				return
+			}
+			require.NoError(t, err)
+			require.Equal(t, tc.expectedContent, content)
+		})
+	}
+}
+
+func TestLintContent(t *testing.T) {
+	fsProvider = &mockFileSystem{readFileErr: errors.New("disk used")}
+	issues, err := LintContent("build:\n    go build\n", DialectGNU)
+	require.NoError(t, err)
+	require.Equal(t, []string{"line 2: recipe line indented with spaces instead of a tab"}, issues)
+	_, err = LintContent("build:\n", "nmake")
+	require.EqualError(t, err, `unknown dialect "nmake"`)
+	_, err = LintContent("\x7fELF\x00", DialectGNU)
+	require.EqualError(t, err, "reading Makefile at Makefile: not a Makefile")
+}
+
+func TestMakeRequirementsContent(t *testing.T) {
+	fsProvider = &mockFileSystem{readFileErr: errors.New("disk used")}
+	requirements, err := MakeRequirementsContent(".ONESHELL:\nbuild:\n")
+	require.NoError(t, err)
+	require.Equal(t, "3.82", RequiredMakeVersion(requirements))
+}
+
+func TestExportContent(t *testing.T) {
+	fsProvider = &mockFileSystem{readFileErr: errors.New("disk used")}
+	out, err := ExportContent("## build: build it\nbuild:\n\tgo build\n", "vscode")
+	require.NoError(t, err)
+	require.Contains(t, string(out), `"detail": "make build"`)
+	_, err = ExportContent("build:\n", "ant")
+	require.EqualError(t, err, `unknown export format "ant"`)
+}
+
+func TestContentConcurrency(t *testing.T) {
+	fsProvider = &osFileSystem{}
+	templateProcessorProvider = htmlTemplateProcessor{}
+	dirs := make([]string, 20)
+	for i := range dirs {
+		dirs[i] = t.TempDir()
+		require.NoError(t, Generate(dirs[i]))
+	}
+	var wg sync.WaitGroup
+	errs := make(chan error, 2*len(dirs))
+	for _, dir := range dirs {
+		wg.Add(2)
+		go func() {
+			defer wg.Done()
+			_, err := GenerateContent(WithFlavor("full"))
+			errs <- err
+		}()
+		go func(dir string) {
+			defer wg.Done()
+			errs <- AddTarget(dir, "lint")
+		}(dir)
+	}
+	wg.Wait()
+	close(errs)
+	for err := range errs {
+		require.NoError(t, err)
+	}
+	for _, dir := range dirs {
+		ok, err := HasTarget(dir, "lint")
+		require.NoError(t, err)
+		require.True(t, ok)
+	}
+}
+
+func TestMemFileSystem(t *testing.T) {
+	m := newMemFileSystem(map[string][]byte{"Makefile": []byte("build:\n"), "api/Makefile": nil, "api/cmd/main.go": nil})
+	fi, err := m.Stat("api")
+	require.NoError(t, err)
+	require.True(t, m.IsDir(fi))
+	_, err = m.Stat("web")
+	require.True(t, m.IsNotExist(err))
+	entries, err := m.ReadDir(".")
+	require.NoError(t, err)
+	require.Len(t, entries, 2)
+	require.Equal(t, "Makefile", entries[0].Name())
+	require.Equal(t, "api", entries[1].Name())
+	require.True(t, entries[1].IsDir())
+	require.NoError(t, m.WriteFile("./web/Makefile", []byte("test:\n"), 0644))
+	content, err := m.ReadFile("web/Makefile")
+	require.NoError(t, err)
+	require.Equal(t, "test:\n", string(content))
+	require.NoError(t, m.Remove("web/Makefile"))
+	_, err = m.ReadFile("web/Makefile")
+	require.True(t, m.IsNotExist(err))
+	_, err = m.OpenFile("Makefile", 0, 0644)
+	require.ErrorIs(t, err, errors.ErrUnsupported)
+}
@@ -0,0 +1,159 @@
+// Copyright (c) 2023 Tiago Melo. All rights reserved.
+// Use of this source code is governed by the MIT License that can be found in
+// the LICENSE file.
+
+package mfile
+
+import (
+	"strings"
+)
+
+// Duplicate is a repeated declaration removed from a Makefile.
+type Duplicate struct {
+	Target string // Name of the target declared again, or names for rules declaring several.
+	Phony  bool   // Whether the .PHONY declaration of the target is repeated, rather than its rule.
+	Line   int    // 1-based line number of the removed declaration.
+}
+
+// Dedupe removes the repeated declarations of the Makefile at the given
+// path, like the ones left by adding the same target twice: the rules
+// repeating, with their help comments and recipes, a previous rule
+// exactly, and the targets already declared as .PHONY from the .PHONY
+// declarations, removed when none is left. The first occurrences are
+// kept, as are the declarations within conditionals, which may be
+// alternatives. It returns the removed declarations, in order, and only
+// writes the Makefile if there are some.
+func Dedupe(path string) ([]Duplicate, error) {
+	makeFilePath := mkFilePath(path)
+	content, err := readMakefile(makeFilePath)
+	if err != nil {
+		return nil, err
+	}
+	deduped, duplicates := dedupe(content)
+	if len(duplicates) == 0 {
+		logger.Debug("no duplicate found", "path", makeFilePath)
+		return nil, nil
+	}
+	if err := fsProvider.WriteFile(makeFilePath, []byte(deduped), 0644); err != nil {
+		return nil, &Error{Op: "writing Makefile", Path: makeFilePath, Err: err}
+	}
+	logger.Debug("removed duplicates", "path", makeFilePath, "duplicates", len(duplicates))
+	return duplicates, nil
+}
+
+// dedupe returns the given Makefile content without its repeated
+// declarations, see Dedupe, and the removed declarations.
+func dedupe(content string) (string, []Duplicate) {
+	var (
+		duplicates   []Duplicate
+		lines        = strings.Split(content, "\n")
+		removed      = make([]bool, len(lines))
+		phony        = make(map[string]bool)
+		rules        = make(map[string]bool)
+		conditionals int
+		inDefine     bool
+		prefix       = "\t"
+	)
+	for i := 0; i < len(lines); i++ {
+		start := i
+		line := strings.TrimRight(lines[i], "\r")
+		for strings.HasSuffix(line, "\\") && i+1 < len(lines) {
+			i++
+			line = strings.TrimSuffix(line, "\\") + " " + strings.TrimSpace(lines[i])
+		}
+		directive := strings.Fields(line)
+		if inDefine {
+			if len(directive) > 0 && directive[0] == "endef" {
+				inDefine = false
+			}
+			continue
+		}
+		if len(directive) > 0 && directive[0] == "define" {
+			inDefine = true
+			continue
+		}
+		if isConditional(line) {
+			switch directive[0] {
+			case "endif", ".endif", ".endfor":
+				conditionals--
+			case "else", ".else", ".elif", ".elifdef", ".elifndef":
+			default:
+				conditionals++
+			}
+			continue
+		}
+		if p, ok := parseRecipePrefix(line); ok {
+			prefix = p
+			continue
+		}
+		if conditionals > 0 || strings.HasPrefix(line, prefix) {
+			continue
+		}
+		names, deps, ok := parseRule(line)
+		if !ok {
+			continue
+		}
+		if names[0] == ".PHONY" {
+			var kept []string
+			for _, d := range deps {
+				if phony[d] {
+					duplicates = append(duplicates, Duplicate{Target: d, Phony: true, Line: start + 1})
+					continue
+				}
+				phony[d] = true
+				kept = append(kept, d)
+			}
+			if len(kept) == len(deps) {
+				continue
+			}
+			for j := start; j <= i; j++ {
+				removed[j] = true
+			}
+			if len(kept) > 0 {
+				lines[start], removed[start] = ".PHONY: "+strings.Join(kept, " "), false
+			}
+			continue
+		}
+		if strings.HasPrefix(names[0], ".") {
+			continue
+		}
+		// The block of the rule starts with the comments before it,
+		// possibly interleaved with its .PHONY declaration, and ends
+		// with its recipe.
+		first := start
+		for j := start - 1; j >= 0; j-- {
+			previous := strings.TrimRight(lines[j], "\r")
+			if strings.HasPrefix(previous, "#") && !strings.HasPrefix(previous, sectionPrefix) {
+				first = j
+				continue
+			}
+			if strings.HasPrefix(previous, ".PHONY:") {
+				continue
+			}
+			break
+		}
+		last := i
+		for last+1 < len(lines) && strings.HasPrefix(lines[last+1], prefix) {
+			last++
+		}
+		var block []string
+		for j := first; j <= last; j++ {
+			if j >= start || strings.HasPrefix(lines[j], "#") {
+				block = append(block, strings.TrimRight(lines[j], " \t\r"))
+			}
+		}
+		key := strings.Join(block, "\n")
+		i = last
+		if !rules[key] {
+			rules[key] = true
+			continue
+		}
+		duplicates = append(duplicates, Duplicate{Target: strings.Join(names, " "), Line: start + 1})
+		for j := first; j <= last; j++ {
+			if j >= start || strings.HasPrefix(lines[j], "#") {
+				removed[j] = true
+			}
+		}
+	}
+	return strings.Join(dropLines(lines, removed), "\n"), duplicates
+}
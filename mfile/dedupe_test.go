@@ -0,0 +1,112 @@
+// Copyright (c) 2023 Tiago Melo. All rights reserved.
+// Use of this source code is governed by the MIT License that can be found in
+// the LICENSE file.
+
+package mfile
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestDedupe(t *testing.T) {
+	testCases := []struct {
+		name               string
+		content            string
+		expectedContent    string
+		expectedDuplicates []Duplicate
+		expectedError      error
+	}{
+		{
+			name: "happy path, target added twice",
+			content: `## help: show help
+help:
+	@ echo help
+
+.PHONY: x
+## x: explain what x does
+x:
+	echo x
+
+.PHONY: y
+y: x
+
+.PHONY: x
+## x: explain what x does
+x:
+	echo x
+
+.PHONY: x y z
+ifdef WINDOWS
+z:
+	echo windows
+else
+z:
+	echo windows
+endif
+`,
+			expectedContent: `## help: show help
+help:
+	@ echo help
+
+.PHONY: x
+## x: explain what x does
+x:
+	echo x
+
+.PHONY: y
+y: x
+
+.PHONY: z
+ifdef WINDOWS
+z:
+	echo windows
+else
+z:
+	echo windows
+endif
+`,
+			expectedDuplicates: []Duplicate{
+				{Target: "x", Phony: true, Line: 13},
+				{Target: "x", Line: 15},
+				{Target: "x", Phony: true, Line: 18},
+				{Target: "y", Phony: true, Line: 18},
+			},
+		},
+		{
+			name: "happy path, different recipes are kept",
+			content: `x:
+	echo x
+
+x:
+	echo y
+`,
+		},
+		{
+			name:          "invalid Makefile",
+			content:       "\x00",
+			expectedError: errors.New("reading Makefile at Makefile: not a Makefile"),
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			fs := &mockFileSystem{file: []byte(tc.content)}
+			fsProvider = fs
+			duplicates, err := Dedupe("Makefile")
+			if err != nil {
+				if tc.expectedError == nil {
+					t.Fatalf("expected no error, got %v", err)
+				}
+				require.Equal(t, tc.expectedError.Error(), err.Error())
+			} else {
+				if tc.expectedError != nil {
+					t.Fatalf("expected error to be %v, got nil", tc.expectedError)
+				}
+				require.Equal(t, tc.expectedDuplicates, duplicates)
+				require.Equal(t, tc.expectedContent, string(fs.writtenData))
+			}
+		})
+	}
+}
@@ -0,0 +1,135 @@
+// Copyright (c) 2023 Tiago Melo. All rights reserved.
+// Use of this source code is governed by the MIT License that can be found in
+// the LICENSE file.
+
+package mfile
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+	"unicode"
+)
+
+// AddDependency adds the given dependency to the given target of the
+// Makefile at the given path, see Makefile.AddDependency.
+func AddDependency(path, target, dependency string) error {
+	return editMakefile(path, func(m *Makefile) error {
+		return m.AddDependency(target, dependency)
+	})
+}
+
+// RemoveDependency removes the given dependency from the given target of
+// the Makefile at the given path, see Makefile.RemoveDependency.
+func RemoveDependency(path, target, dependency string) error {
+	return editMakefile(path, func(m *Makefile) error {
+		return m.RemoveDependency(target, dependency)
+	})
+}
+
+// AddDependency adds the given dependency after the other ones of the
+// first rule of the given target, before its order-only dependencies, if
+// any. The rest of the rule is left as it is, and nothing is done if the
+// target already depends on it. It returns ErrTargetNotFound if the
+// target is not declared.
+func (m *Makefile) AddDependency(target, dependency string) error {
+	if dependency == "" || containsSpace(dependency) {
+		return mark(ErrInvalidTargetName, fmt.Errorf("invalid dependency name %q", dependency))
+	}
+	_, rule, recipe, _, ok := ruleBlock(m.lines, target)
+	if !ok {
+		return fmt.Errorf("looking up target %s: %w", target, ErrTargetNotFound)
+	}
+	lines := m.lines[rule:recipe]
+	spans, k := prerequisiteSpans(lines, "|;#")
+	for i, span := range spans {
+		if slices.Contains(strings.Fields(lines[i][span[0]:span[1]]), dependency) {
+			return nil
+		}
+	}
+	// The dependency goes at the end of the line the dependencies end
+	// on: the one with the order-only dependencies, inline recipe or
+	// comment, if any, or the last one.
+	if k < 0 {
+		k = len(lines) - 1
+	}
+	line, end := lines[k], spans[k][1]
+	before := strings.TrimRight(line[:end], " \t")
+	after := line[end:]
+	if rest := strings.TrimLeft(after, " \t"); rest != "" && rest != "\r" {
+		after = " " + rest
+	}
+	lines[k] = before + " " + dependency + after
+	return nil
+}
+
+// RemoveDependency removes the given dependency, order-only or not, from
+// the first rule of the given target. The rest of the rule is left as it
+// is, and nothing is done if the target doesn't depend on it. It returns
+// ErrTargetNotFound if the target is not declared.
+func (m *Makefile) RemoveDependency(target, dependency string) error {
+	if dependency == "" || containsSpace(dependency) {
+		return mark(ErrInvalidTargetName, fmt.Errorf("invalid dependency name %q", dependency))
+	}
+	_, rule, recipe, _, ok := ruleBlock(m.lines, target)
+	if !ok {
+		return fmt.Errorf("looking up target %s: %w", target, ErrTargetNotFound)
+	}
+	lines := m.lines[rule:recipe]
+	spans, _ := prerequisiteSpans(lines, ";#")
+	for k, span := range spans {
+		line := lines[k]
+		lines[k] = line[:span[0]] + removeWord(line[span[0]:span[1]], dependency) + line[span[1]:]
+	}
+	return nil
+}
+
+// prerequisiteSpans returns the start and end offsets of the part of each
+// of the given rule lines listing dependencies: after the colon on the
+// first line, and before the continuation backslash, if any. The list
+// ends at the first of the given characters found, on the returned line,
+// or -1 if none is found.
+func prerequisiteSpans(lines []string, stops string) (spans [][2]int, endLine int) {
+	spans, endLine = make([][2]int, len(lines)), -1
+	for k, line := range lines {
+		end := len(strings.TrimRight(line, "\r"))
+		if endLine >= 0 {
+			spans[k] = [2]int{end, end}
+			continue
+		}
+		start := 0
+		if k == 0 {
+			start = strings.Index(line, ":") + 1
+			for start < end && line[start] == ':' {
+				start++
+			}
+		}
+		if k < len(lines)-1 {
+			end = strings.LastIndex(line[:end], "\\")
+		}
+		if i := strings.IndexAny(line[start:end], stops); i >= 0 {
+			end, endLine = start+i, k
+		}
+		spans[k] = [2]int{start, end}
+	}
+	return spans, endLine
+}
+
+// removeWord removes the given whitespace-separated word from s, along
+// with the whitespace before it, keeping the rest of s as it is.
+func removeWord(s, word string) string {
+	var sb strings.Builder
+	for len(s) > 0 {
+		space := len(s) - len(strings.TrimLeftFunc(s, unicode.IsSpace))
+		rest := s[space:]
+		n := strings.IndexFunc(rest, unicode.IsSpace)
+		if n < 0 {
+			n = len(rest)
+		}
+		if rest[:n] != word {
+			sb.WriteString(s[:space+n])
+		}
+		s = rest[n:]
+	}
+	return sb.String()
+}
@@ -0,0 +1,163 @@
+// Copyright (c) 2023 Tiago Melo. All rights reserved.
+// Use of this source code is governed by the MIT License that can be found in
+// the LICENSE file.
+
+package mfile
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestAddDependency(t *testing.T) {
+	testCases := []struct {
+		name            string
+		content         string
+		target          string
+		dependency      string
+		expectedContent string
+		expectedError   error
+	}{
+		{
+			name:            "happy path",
+			content:         "## build: build the binary\nbuild: vet\n\tgo build ./...\n",
+			target:          "build",
+			dependency:      "lint",
+			expectedContent: "## build: build the binary\nbuild: vet lint\n\tgo build ./...\n",
+		},
+		{
+			name:            "happy path, no dependencies",
+			content:         "build:\n\tgo build ./...\n",
+			target:          "build",
+			dependency:      "vet",
+			expectedContent: "build: vet\n\tgo build ./...\n",
+		},
+		{
+			name:            "happy path, order-only dependencies and comment",
+			content:         "bin/app: main.go | bin # the binary\n\tgo build -o $@\n",
+			target:          "bin/app",
+			dependency:      "go.mod",
+			expectedContent: "bin/app: main.go go.mod | bin # the binary\n\tgo build -o $@\n",
+		},
+		{
+			name:            "happy path, rule spanning several lines",
+			content:         "build: vet \\\r\n       lint\r\n\tgo build ./...\r\n",
+			target:          "build",
+			dependency:      "generate",
+			expectedContent: "build: vet \\\r\n       lint generate\r\n\tgo build ./...\r\n",
+		},
+		{
+			name:            "happy path, already a dependency",
+			content:         "build: vet \\\n\tlint\n",
+			target:          "build",
+			dependency:      "vet",
+			expectedContent: "build: vet \\\n\tlint\n",
+		},
+		{
+			name:          "invalid dependency name",
+			content:       "build:\n",
+			target:        "build",
+			dependency:    "go vet",
+			expectedError: errors.New(`editing Makefile: invalid dependency name "go vet"`),
+		},
+		{
+			name:          "target not found",
+			content:       "build:\n",
+			target:        "test",
+			dependency:    "build",
+			expectedError: errors.New("editing Makefile: looking up target test: target not found"),
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			fs := &mockFileSystem{file: []byte(tc.content)}
+			fsProvider = fs
+			err := AddDependency("Makefile", tc.target, tc.dependency)
+			if err != nil {
+				if tc.expectedError == nil {
+					t.Fatalf("expected no error, got %v", err)
+				}
+				require.Equal(t, tc.expectedError.Error(), err.Error())
+			} else {
+				if tc.expectedError != nil {
+					t.Fatalf("expected error to be %v, got nil", tc.expectedError)
+				}
+				require.Equal(t, tc.expectedContent, string(fs.writtenData))
+			}
+		})
+	}
+}
+
+func TestRemoveDependency(t *testing.T) {
+	testCases := []struct {
+		name            string
+		content         string
+		target          string
+		dependency      string
+		expectedContent string
+		expectedError   error
+	}{
+		{
+			name:            "happy path",
+			content:         "build: vet lint test\n\tgo build ./...\n",
+			target:          "build",
+			dependency:      "lint",
+			expectedContent: "build: vet test\n\tgo build ./...\n",
+		},
+		{
+			name:            "happy path, only dependency",
+			content:         "build: vet # checks first\n\tgo build ./...\n",
+			target:          "build",
+			dependency:      "vet",
+			expectedContent: "build: # checks first\n\tgo build ./...\n",
+		},
+		{
+			name:            "happy path, order-only dependency",
+			content:         "bin/app: main.go | bin\n\tgo build -o $@\n",
+			target:          "bin/app",
+			dependency:      "bin",
+			expectedContent: "bin/app: main.go |\n\tgo build -o $@\n",
+		},
+		{
+			name:            "happy path, rule spanning several lines",
+			content:         "build: vet \\\n       lint \\\n       test\n",
+			target:          "build",
+			dependency:      "lint",
+			expectedContent: "build: vet \\\n \\\n       test\n",
+		},
+		{
+			name:            "happy path, not a dependency",
+			content:         "build: vet\n\tgo vet ./...\n",
+			target:          "build",
+			dependency:      "go",
+			expectedContent: "build: vet\n\tgo vet ./...\n",
+		},
+		{
+			name:          "target not found",
+			content:       "build:\n",
+			target:        "test",
+			dependency:    "build",
+			expectedError: errors.New("editing Makefile: looking up target test: target not found"),
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			fs := &mockFileSystem{file: []byte(tc.content)}
+			fsProvider = fs
+			err := RemoveDependency("Makefile", tc.target, tc.dependency)
+			if err != nil {
+				if tc.expectedError == nil {
+					t.Fatalf("expected no error, got %v", err)
+				}
+				require.Equal(t, tc.expectedError.Error(), err.Error())
+			} else {
+				if tc.expectedError != nil {
+					t.Fatalf("expected error to be %v, got nil", tc.expectedError)
+				}
+				require.Equal(t, tc.expectedContent, string(fs.writtenData))
+			}
+		})
+	}
+}
@@ -0,0 +1,49 @@
+// Copyright (c) 2023 Tiago Melo. All rights reserved.
+// Use of this source code is governed by the MIT License that can be found in
+// the LICENSE file.
+
+package mfile
+
+import (
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+)
+
+// SetTargetDescription sets the description of the given target of the
+// Makefile at the given path, see Makefile.SetTargetDescription.
+func SetTargetDescription(path, target, description string) error {
+	return editMakefile(path, func(m *Makefile) error {
+		return m.SetTargetDescription(target, description)
+	})
+}
+
+// SetTargetDescription sets the description of the given target, listed
+// by help, by rewriting its "## target: description" comments or, if it
+// has none, by adding one right before its first rule. It returns
+// ErrTargetNotFound if the target is not declared.
+func (m *Makefile) SetTargetDescription(target, description string) error {
+	if strings.ContainsAny(description, "\r\n") {
+		return errors.New("description cannot span several lines")
+	}
+	_, rule, _, _, ok := ruleBlock(m.lines, target)
+	if !ok {
+		return fmt.Errorf("looking up target %s: %w", target, ErrTargetNotFound)
+	}
+	comment := strings.TrimSpace("## " + target + ": " + description)
+	found := false
+	for i, line := range m.lines {
+		if name, _, ok := parseDescription(strings.TrimRight(line, "\r")); ok && name == target {
+			m.lines[i] = comment + line[len(strings.TrimRight(line, "\r")):]
+			found = true
+		}
+	}
+	if !found {
+		if strings.HasSuffix(m.lines[rule], "\r") {
+			comment += "\r"
+		}
+		m.lines = slices.Insert(m.lines, rule, comment)
+	}
+	return nil
+}
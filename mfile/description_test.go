@@ -0,0 +1,84 @@
+// Copyright (c) 2023 Tiago Melo. All rights reserved.
+// Use of this source code is governed by the MIT License that can be found in
+// the LICENSE file.
+
+package mfile
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestSetTargetDescription(t *testing.T) {
+	testCases := []struct {
+		name            string
+		content         string
+		target          string
+		description     string
+		expectedContent string
+		expectedError   error
+	}{
+		{
+			name:            "happy path",
+			content:         ".PHONY: build\n## build: explain what build does\nbuild:\n\tgo build ./...\n",
+			target:          "build",
+			description:     "build the binary",
+			expectedContent: ".PHONY: build\n## build: build the binary\nbuild:\n\tgo build ./...\n",
+		},
+		{
+			name:            "happy path, comment away from the rule",
+			content:         "## build: build it\r\n## test: run tests\r\n\r\nbuild:\r\n\tgo build ./...\r\ntest:\r\n\tgo test ./...\r\n",
+			target:          "test",
+			description:     "run the unit tests",
+			expectedContent: "## build: build it\r\n## test: run the unit tests\r\n\r\nbuild:\r\n\tgo build ./...\r\ntest:\r\n\tgo test ./...\r\n",
+		},
+		{
+			name:            "happy path, no comment",
+			content:         ".PHONY: build\nbuild: vet\n\tgo build ./...\n",
+			target:          "build",
+			description:     "build the binary",
+			expectedContent: ".PHONY: build\n## build: build the binary\nbuild: vet\n\tgo build ./...\n",
+		},
+		{
+			name:            "happy path, target among others",
+			content:         "# builds and tests\nbuild test:\n\tgo $@ ./...\n",
+			target:          "test",
+			description:     "run tests",
+			expectedContent: "# builds and tests\n## test: run tests\nbuild test:\n\tgo $@ ./...\n",
+		},
+		{
+			name:          "description spanning several lines",
+			content:       "build:\n",
+			target:        "build",
+			description:   "build\nthe binary",
+			expectedError: errors.New("editing Makefile: description cannot span several lines"),
+		},
+		{
+			name:          "target not found",
+			content:       "## test: run tests\nbuild:\n",
+			target:        "test",
+			description:   "run the tests",
+			expectedError: errors.New("editing Makefile: looking up target test: target not found"),
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			fs := &mockFileSystem{file: []byte(tc.content)}
+			fsProvider = fs
+			err := SetTargetDescription("Makefile", tc.target, tc.description)
+			if err != nil {
+				if tc.expectedError == nil {
+					t.Fatalf("expected no error, got %v", err)
+				}
+				require.Equal(t, tc.expectedError.Error(), err.Error())
+			} else {
+				if tc.expectedError != nil {
+					t.Fatalf("expected error to be %v, got nil", tc.expectedError)
+				}
+				require.Equal(t, tc.expectedContent, string(fs.writtenData))
+			}
+		})
+	}
+}
@@ -0,0 +1,198 @@
+// Copyright (c) 2023 Tiago Melo. All rights reserved.
+// Use of this source code is governed by the MIT License that can be found in
+// the LICENSE file.
+
+package mfile
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+)
+
+// Detection is a preset proposed for a project by Detect.
+type Detection struct {
+	Preset     string            // Name of the proposed preset.
+	Reason     string            // Why the preset was proposed.
+	Parameters map[string]string // Parameters to generate the preset with, if any.
+}
+
+// composeFiles are the file names docker compose looks for, in order.
+var composeFiles = []string{"compose.yaml", "compose.yml", "docker-compose.yaml", "docker-compose.yml"}
+
+// golangciFiles are the configuration files of golangci-lint.
+var golangciFiles = []string{".golangci.yml", ".golangci.yaml", ".golangci.toml", ".golangci.json"}
+
+// skippedDirs are the dependency and fixture directories not scanned
+// for project files.
+var skippedDirs = map[string]bool{"vendor": true, "node_modules": true, "testdata": true}
+
+// moduleFiles are the files marking the root of a module.
+var moduleFiles = []string{"go.mod", "package.json", "Cargo.toml"}
+
+// Detect scans the project at the given directory and proposes the
+// presets matching what it finds: go.mod, Dockerfile, docker compose
+// files, protobuf files, migrations, golangci-lint configuration,
+// terraform configuration, package.json and Cargo.toml.
+func Detect(dir string) ([]Detection, error) {
+	return detectPresets(fsProvider, dir)
+}
+
+// detectPresets is like Detect, scanning the given file system.
+func detectPresets(fsys fileSystem, dir string) ([]Detection, error) {
+	entries, err := fsys.ReadDir(dir)
+	if err != nil {
+		return nil, fmt.Errorf("reading directory %s: %w", dir, err)
+	}
+	files := make(map[string]bool)
+	dirs := make(map[string]bool)
+	var tfFound bool
+	for _, e := range entries {
+		if e.IsDir() {
+			dirs[e.Name()] = true
+			continue
+		}
+		files[e.Name()] = true
+		if filepath.Ext(e.Name()) == ".tf" {
+			tfFound = true
+		}
+	}
+	var detections []Detection
+	detect := func(preset, reason string, params map[string]string) {
+		detections = append(detections, Detection{Preset: preset, Reason: reason, Parameters: params})
+	}
+	if files["go.mod"] {
+		switch {
+		case files["main.go"]:
+			detect(PresetGoCLI, "go.mod and main.go found", nil)
+		case dirs["cmd"]:
+			detect(PresetGoCLI, "go.mod and cmd/ found", nil)
+		default:
+			detect(PresetGoLibrary, "go.mod found", nil)
+		}
+		if files["tools.go"] {
+			detect(PresetTools, "tools.go found", nil)
+		}
+	}
+	if files["Dockerfile"] {
+		detect(PresetDocker, "Dockerfile found", nil)
+	}
+	for _, f := range composeFiles {
+		if files[f] {
+			detect(PresetIntegration, f+" found", map[string]string{"compose-file": f})
+			break
+		}
+	}
+	proto, err := findFile(fsys, dir, ".proto")
+	if err != nil {
+		return nil, err
+	}
+	if proto != "" {
+		detect(PresetProto, proto+" found", nil)
+	}
+	if dirs["migrations"] {
+		detect(PresetMigrations, "migrations/ found", nil)
+	}
+	for _, f := range golangciFiles {
+		if files[f] {
+			detect(PresetLint, f+" found", nil)
+			break
+		}
+	}
+	switch {
+	case dirs["terraform"]:
+		detect(PresetTerraform, "terraform/ found", nil)
+	case tfFound:
+		detect(PresetTerraform, "terraform files found", map[string]string{"terraform-dir": "."})
+	}
+	if files["package.json"] {
+		detect(PresetNode, "package.json found", nil)
+	}
+	if files["Cargo.toml"] {
+		detect(PresetRust, "Cargo.toml found", nil)
+	}
+	return detections, nil
+}
+
+// findFile returns the path, relative to dir, of the first file with the
+// given extension found under dir in the given file system, or an empty
+// string if there is none. Hidden directories and dependency directories
+// are skipped.
+func findFile(fsys fileSystem, dir, ext string) (string, error) {
+	var walk func(rel string) (string, error)
+	walk = func(rel string) (string, error) {
+		entries, err := fsys.ReadDir(filepath.Join(dir, rel))
+		if err != nil {
+			return "", fmt.Errorf("reading directory %s: %w", filepath.Join(dir, rel), err)
+		}
+		var subdirs []os.DirEntry
+		for _, e := range entries {
+			if e.IsDir() {
+				subdirs = append(subdirs, e)
+				continue
+			}
+			if filepath.Ext(e.Name()) == ext {
+				return filepath.ToSlash(filepath.Join(rel, e.Name())), nil
+			}
+		}
+		for _, e := range subdirs {
+			if skipDir(e.Name()) {
+				continue
+			}
+			found, err := walk(filepath.Join(rel, e.Name()))
+			if err != nil || found != "" {
+				return found, err
+			}
+		}
+		return "", nil
+	}
+	return walk("")
+}
+
+// Modules returns the directories, relative to dir, of the modules found
+// under it: the directories with a go.mod, package.json or Cargo.toml
+// file. Modules nested in other modules are not returned.
+func Modules(dir string) ([]string, error) {
+	return findModules(fsProvider, dir)
+}
+
+// findModules is like Modules, scanning the given file system.
+func findModules(fsys fileSystem, dir string) ([]string, error) {
+	var modules []string
+	var walk func(rel string) error
+	walk = func(rel string) error {
+		entries, err := fsys.ReadDir(filepath.Join(dir, rel))
+		if err != nil {
+			return fmt.Errorf("reading directory %s: %w", filepath.Join(dir, rel), err)
+		}
+		if rel != "" {
+			for _, e := range entries {
+				if !e.IsDir() && slices.Contains(moduleFiles, e.Name()) {
+					modules = append(modules, filepath.ToSlash(rel))
+					return nil
+				}
+			}
+		}
+		for _, e := range entries {
+			if !e.IsDir() || skipDir(e.Name()) {
+				continue
+			}
+			if err := walk(filepath.Join(rel, e.Name())); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+	if err := walk(""); err != nil {
+		return nil, err
+	}
+	return modules, nil
+}
+
+// skipDir reports whether the directory with the given name is skipped
+// when scanning a project: hidden directories and dependency directories.
+func skipDir(name string) bool {
+	return strings.HasPrefix(name, ".") || skippedDirs[name]
+}
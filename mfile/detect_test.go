@@ -0,0 +1,178 @@
+// Copyright (c) 2023 Tiago Melo. All rights reserved.
+// Use of this source code is governed by the MIT License that can be found in
+// the LICENSE file.
+
+package mfile
+
+import (
+	"errors"
+	"testing"
+	"testing/fstest"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestDetect(t *testing.T) {
+	testCases := []struct {
+		name               string
+		mockClosure        func(m *mockFileSystem)
+		expectedDetections []Detection
+		expectedError      error
+	}{
+		{
+			name: "go library",
+			mockClosure: func(m *mockFileSystem) {
+				m.tree = fstest.MapFS{
+					"go.mod":  {},
+					"lib.go":  {},
+					"docs/x":  {},
+					"LICENSE": {},
+				}
+			},
+			expectedDetections: []Detection{
+				{Preset: PresetGoLibrary, Reason: "go.mod found"},
+			},
+		},
+		{
+			name: "go service with everything",
+			mockClosure: func(m *mockFileSystem) {
+				m.tree = fstest.MapFS{
+					"go.mod":                      {},
+					"tools.go":                    {},
+					"cmd/api/main.go":             {},
+					"Dockerfile":                  {},
+					"compose.yaml":                {},
+					"api/v1/service.proto":        {},
+					"migrations/0001_init.up.sql": {},
+					".golangci.yml":               {},
+					"terraform/main.tf":           {},
+				}
+			},
+			expectedDetections: []Detection{
+				{Preset: PresetGoCLI, Reason: "go.mod and cmd/ found"},
+				{Preset: PresetTools, Reason: "tools.go found"},
+				{Preset: PresetDocker, Reason: "Dockerfile found"},
+				{Preset: PresetIntegration, Reason: "compose.yaml found", Parameters: map[string]string{"compose-file": "compose.yaml"}},
+				{Preset: PresetProto, Reason: "api/v1/service.proto found"},
+				{Preset: PresetMigrations, Reason: "migrations/ found"},
+				{Preset: PresetLint, Reason: ".golangci.yml found"},
+				{Preset: PresetTerraform, Reason: "terraform/ found"},
+			},
+		},
+		{
+			name: "protobuf files in skipped directories are ignored",
+			mockClosure: func(m *mockFileSystem) {
+				m.tree = fstest.MapFS{
+					"go.mod":                  {},
+					"main.go":                 {},
+					"vendor/x/y.proto":        {},
+					".git/z.proto":            {},
+					"node_modules/p/q.proto":  {},
+					"docker-compose.yml":      {},
+					"infra.tf":                {},
+					"package.json":            {},
+					"crates/Cargo.toml":       {},
+					"testdata/fixture.proto":  {},
+					"internal/handler/doc.go": {},
+				}
+			},
+			expectedDetections: []Detection{
+				{Preset: PresetGoCLI, Reason: "go.mod and main.go found"},
+				{Preset: PresetIntegration, Reason: "docker-compose.yml found", Parameters: map[string]string{"compose-file": "docker-compose.yml"}},
+				{Preset: PresetTerraform, Reason: "terraform files found", Parameters: map[string]string{"terraform-dir": "."}},
+				{Preset: PresetNode, Reason: "package.json found"},
+			},
+		},
+		{
+			name: "nothing detected",
+			mockClosure: func(m *mockFileSystem) {
+				m.tree = fstest.MapFS{"README.md": {}}
+			},
+		},
+		{
+			name: "error when reading directory",
+			mockClosure: func(m *mockFileSystem) {
+				m.readDirErr = errors.New("read dir error")
+			},
+			expectedError: errors.New("reading directory .: read dir error"),
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			m := new(mockFileSystem)
+			tc.mockClosure(m)
+			fsProvider = m
+			detections, err := Detect(".")
+			if err != nil {
+				if tc.expectedError == nil {
+					t.Fatalf("expected no error, got %v", err)
+				}
+				require.Equal(t, tc.expectedError.Error(), err.Error())
+			} else {
+				if tc.expectedError != nil {
+					t.Fatalf("expected error to be %v, got nil", tc.expectedError)
+				}
+				require.Equal(t, tc.expectedDetections, detections)
+			}
+		})
+	}
+}
+
+func TestModules(t *testing.T) {
+	testCases := []struct {
+		name            string
+		mockClosure     func(m *mockFileSystem)
+		expectedModules []string
+		expectedError   error
+	}{
+		{
+			name: "happy path",
+			mockClosure: func(m *mockFileSystem) {
+				m.tree = fstest.MapFS{
+					"go.mod":                        {},
+					"services/api/go.mod":           {},
+					"services/api/tools/go.mod":     {},
+					"services/web/package.json":     {},
+					"crates/parser/Cargo.toml":      {},
+					"node_modules/left-pad/go.mod":  {},
+					".github/workflows/ci.yml":      {},
+					"services/README.md":            {},
+					"services/web/node_modules/x/y": {},
+				}
+			},
+			expectedModules: []string{"crates/parser", "services/api", "services/web"},
+		},
+		{
+			name: "no module",
+			mockClosure: func(m *mockFileSystem) {
+				m.tree = fstest.MapFS{"go.mod": {}, "main.go": {}}
+			},
+		},
+		{
+			name: "error when reading directory",
+			mockClosure: func(m *mockFileSystem) {
+				m.readDirErr = errors.New("read dir error")
+			},
+			expectedError: errors.New("reading directory .: read dir error"),
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			m := new(mockFileSystem)
+			tc.mockClosure(m)
+			fsProvider = m
+			modules, err := Modules(".")
+			if err != nil {
+				if tc.expectedError == nil {
+					t.Fatalf("expected no error, got %v", err)
+				}
+				require.Equal(t, tc.expectedError.Error(), err.Error())
+			} else {
+				if tc.expectedError != nil {
+					t.Fatalf("expected error to be %v, got nil", tc.expectedError)
+				}
+				require.Equal(t, tc.expectedModules, modules)
+			}
+		})
+	}
+}
@@ -0,0 +1,197 @@
+// Copyright (c) 2023 Tiago Melo. All rights reserved.
+// Use of this source code is governed by the MIT License that can be found in
+// the LICENSE file.
+
+package mfile
+
+import (
+	"fmt"
+	"regexp"
+	"slices"
+	"strings"
+	"unicode"
+)
+
+// Dialects of make the generated Makefile targets.
+const (
+	// DialectGNU targets GNU make. The default.
+	DialectGNU = "gnu"
+
+	// DialectPOSIX targets any make implementing POSIX.1-2024, like the
+	// ones of BSD and Solaris build hosts.
+	DialectPOSIX = "posix"
+
+	// DialectBMake targets BSD make (bmake), the make of FreeBSD and
+	// NetBSD, with its .if/.endif conditionals and .include directive.
+	DialectBMake = "bmake"
+)
+
+// Dialects returns the dialects of make the generated Makefile can target.
+func Dialects() []string {
+	return []string{DialectGNU, DialectPOSIX, DialectBMake}
+}
+
+// bmakeSupported are the constructs reported by gnuOnly that bmake
+// understands too.
+var bmakeSupported = []string{":=", "-include", "sinclude", "export", "unexport", "automatic variable $^"}
+
+// gnuDirectives are the directives only GNU make understands.
+var gnuDirectives = []string{
+	"ifeq", "ifneq", "ifdef", "ifndef", "else", "endif", "define", "endef",
+	"export", "unexport", "override", "vpath", "-include", "sinclude", "load",
+}
+
+// gnuSpecialTargets are the special targets only GNU make understands.
+var gnuSpecialTargets = []string{".ONESHELL", ".RECIPEPREFIX", ".SECONDEXPANSION", ".DELETE_ON_ERROR", ".EXPORT_ALL_VARIABLES"}
+
+// gnuFunction matches the calls to GNU make functions and the uses of the
+// variables only GNU make sets.
+var gnuFunction = regexp.MustCompile(`\$[({](shell|wildcard|if|or|and|foreach|call|eval|subst|patsubst|strip|findstring|filter|filter-out|sort|word|words|wordlist|firstword|lastword|dir|notdir|suffix|basename|addsuffix|addprefix|join|realpath|abspath|info|warning|error|value|origin|flavor|file)[ ,]|\$[({](MAKEFILE_LIST|CURDIR|MAKECMDGOALS)[)}]`)
+
+// gnuAutomaticVariable matches the automatic variables only GNU make sets.
+var gnuAutomaticVariable = regexp.MustCompile(`(?:^|[^$])(\$[\^+|])`)
+
+// shellCall matches a $(shell ...) call without nested parentheses.
+var shellCall = regexp.MustCompile(`\$\(shell ([^()]*)\)`)
+
+// validateDialect checks that the given dialect is known and fits the
+// other options.
+func validateDialect(o *generateOptions) error {
+	if o.recipePrefix != "" {
+		if r := []rune(o.recipePrefix); len(r) != 1 || unicode.IsSpace(r[0]) || r[0] == '#' || r[0] == '$' {
+			return fmt.Errorf("invalid recipe prefix %q, want a single printable character", o.recipePrefix)
+		}
+	}
+	if o.dialect == DialectGNU {
+		return nil
+	}
+	if !slices.Contains(Dialects(), o.dialect) {
+		return fmt.Errorf("unknown dialect %q", o.dialect)
+	}
+	if o.windows {
+		return fmt.Errorf("the %s dialect does not support Windows recipe variants", o.dialect)
+	}
+	if o.recipePrefix != "" {
+		return fmt.Errorf("the %s dialect does not support a custom recipe prefix", o.dialect)
+	}
+	if o.helpStyle == HelpStyleInfo {
+		return fmt.Errorf("the %s dialect does not support the %s help style", o.dialect, HelpStyleInfo)
+	}
+	if o.envFile != "" {
+		return fmt.Errorf("the %s dialect does not support loading an environment file", o.dialect)
+	}
+	return nil
+}
+
+// toDialect rewrites the given variables and targets, generated for GNU
+// make, with constructs of the given dialect where possible: $(shell ...)
+// calls become != assignments and help reads the Makefile itself instead
+// of $(MAKEFILE_LIST). For POSIX make, := assignments also become =,
+// $(MAKE) -C becomes cd and, as it has no conditionals, variants differing
+// per operating system are dropped.
+func toDialect(dialect string, variables []Variable, targets []Target) ([]Variable, []Target) {
+	if dialect == "" || dialect == DialectGNU {
+		return variables, targets
+	}
+	posix := dialect == DialectPOSIX
+	dialectVariables := make([]Variable, len(variables))
+	for i, v := range variables {
+		if posix {
+			v.OSValues = nil
+			if v.Operator == ":=" {
+				v.Operator = "="
+			}
+		}
+		if strings.Contains(v.Value, "$(shell ") {
+			v.Operator = "!="
+			if m := shellCall.FindStringSubmatch(v.Value); m != nil && m[0] == v.Value {
+				v.Value = m[1]
+			} else {
+				v.Value = `echo "` + shellCall.ReplaceAllString(v.Value, "$$$$($1)") + `"`
+			}
+		}
+		dialectVariables[i] = v
+	}
+	dialectTargets := make([]Target, len(targets))
+	for i, t := range targets {
+		if posix {
+			t.OSRecipes = nil
+		}
+		recipe := make([]string, len(t.Recipe))
+		for j, line := range t.Recipe {
+			line = strings.NewReplacer("$(MAKEFILE_LIST)", makefileName, "${MAKEFILE_LIST}", makefileName).Replace(line)
+			if rest, ok := strings.CutPrefix(line, "@ $(MAKE) -C "); ok && posix {
+				if dir, goal, ok := strings.Cut(rest, " "); ok {
+					line = "@ cd " + dir + " && $(MAKE) " + goal
+				}
+			}
+			recipe[j] = line
+		}
+		t.Recipe = recipe
+		dialectTargets[i] = t
+	}
+	return dialectVariables, dialectTargets
+}
+
+// unsupported returns the constructs of the given Makefile content that
+// the given dialect does not support.
+func unsupported(dialect, content string) []string {
+	switch dialect {
+	case DialectPOSIX:
+		return gnuOnly(content)
+	case DialectBMake:
+		return gnuOnly(content, bmakeSupported...)
+	}
+	return nil
+}
+
+// includeDirective returns the directive including the given file, in the
+// syntax of the given dialect.
+func includeDirective(dialect, file string) string {
+	if dialect == DialectBMake {
+		return `.include "` + file + `"`
+	}
+	return "include " + file
+}
+
+// gnuOnly returns the constructs of the given Makefile content that only
+// GNU make understands, but the given supported ones.
+func gnuOnly(content string, supported ...string) []string {
+	var issues []string
+	report := func(lineNumber int, construct string) {
+		if slices.Contains(supported, construct) {
+			return
+		}
+		issues = append(issues, fmt.Sprintf("line %d: %s is GNU make only", lineNumber, construct))
+	}
+	lines := strings.Split(content, "\n")
+	for i, line := range lines {
+		line = strings.TrimRight(line, "\r")
+		lineNumber := i + 1
+		if strings.HasPrefix(strings.TrimSpace(line), "#") {
+			continue
+		}
+		if fields := strings.Fields(line); len(fields) > 0 && !strings.HasPrefix(line, "\t") {
+			if slices.Contains(gnuDirectives, fields[0]) {
+				report(lineNumber, fields[0])
+			}
+			if names, _, ok := parseRule(line); ok {
+				if slices.Contains(gnuSpecialTargets, names[0]) {
+					report(lineNumber, names[0])
+				}
+				if strings.Contains(line[:strings.Index(line, ":")], "%") {
+					report(lineNumber, "pattern rule")
+				}
+			} else if strings.Contains(line, ":=") && !strings.Contains(line, "::=") {
+				report(lineNumber, ":=")
+			}
+		}
+		for _, m := range gnuFunction.FindAllStringSubmatch(line, -1) {
+			report(lineNumber, "$("+m[1]+m[2]+")")
+		}
+		for _, m := range gnuAutomaticVariable.FindAllStringSubmatch(line, -1) {
+			report(lineNumber, "automatic variable "+m[1])
+		}
+	}
+	return issues
+}
@@ -0,0 +1,129 @@
+// Copyright (c) 2023 Tiago Melo. All rights reserved.
+// Use of this source code is governed by the MIT License that can be found in
+// the LICENSE file.
+
+package mfile
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestToPOSIX(t *testing.T) {
+	variables, targets := toDialect(
+		DialectPOSIX,
+		[]Variable{
+			{Name: "NOW", Operator: ":=", Value: "1"},
+			{Name: "TAG", Value: "$(shell git describe --tags)"},
+			{Name: "LINT", Value: "$(shell go env GOPATH)/bin/golangci-lint"},
+			{Name: "EXE", OSValues: map[string]string{OSWindows: ".exe"}},
+		},
+		[]Target{
+			helpTarget,
+			{Name: "vet", Recipe: []string{"@ $(MAKE) -C api vet"}, OSRecipes: map[string][]string{OSWindows: {"@ vet.cmd"}}},
+		},
+	)
+	require.Equal(t, []Variable{
+		{Name: "NOW", Operator: "=", Value: "1"},
+		{Name: "TAG", Operator: "!=", Value: "git describe --tags"},
+		{Name: "LINT", Operator: "!=", Value: `echo "$$(go env GOPATH)/bin/golangci-lint"`},
+		{Name: "EXE"},
+	}, variables)
+	require.Len(t, targets, 2)
+	require.NotContains(t, targets[0].Recipe[0], "MAKEFILE_LIST")
+	require.Contains(t, targets[0].Recipe[0], "' Makefile")
+	require.Equal(t, []string{"@ cd api && $(MAKE) vet"}, targets[1].Recipe)
+	require.Nil(t, targets[1].OSRecipes)
+	require.Empty(t, gnuOnly(render(variables, targets)))
+}
+
+func TestGNUOnly(t *testing.T) {
+	testCases := []struct {
+		name           string
+		content        string
+		expectedIssues []string
+	}{
+		{
+			name:    "portable Makefile",
+			content: "# $(shell) in a comment\nGO ?= go\nTAG != git describe\nNOW ::= now\n.PHONY: build\nbuild: main.go\n\t$(GO) build -o $@ $<\n",
+		},
+		{
+			name:    "GNU-only constructs",
+			content: "ifeq ($(OS),Windows_NT)\nEXE := .exe\nendif\nSRC = $(wildcard *.go)\n%.o: %.c\n\tcc -o $@ $^\n.ONESHELL:\n-include local.mk\nall:\n\t@ echo $(CURDIR)\n",
+			expectedIssues: []string{
+				"line 1: ifeq is GNU make only",
+				"line 2: := is GNU make only",
+				"line 3: endif is GNU make only",
+				"line 4: $(wildcard) is GNU make only",
+				"line 5: pattern rule is GNU make only",
+				"line 6: automatic variable $^ is GNU make only",
+				"line 7: .ONESHELL is GNU make only",
+				"line 8: -include is GNU make only",
+				"line 10: $(CURDIR) is GNU make only",
+			},
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			require.Equal(t, tc.expectedIssues, gnuOnly(tc.content))
+		})
+	}
+}
+
+func TestRenderBMake(t *testing.T) {
+	variables, targets := toDialect(
+		DialectBMake,
+		[]Variable{
+			{Name: "TAG", Value: "$(shell git describe --tags)"},
+			{Name: "EXE", OSValues: map[string]string{OSWindows: ".exe"}},
+		},
+		[]Target{
+			{
+				Name:      "clean",
+				Recipe:    []string{"rm -rf bin"},
+				OSRecipes: map[string][]string{OSDarwin: {"rm -rf bin .DS_Store"}, OSWindows: {"rmdir /S /Q bin"}},
+			},
+		},
+	)
+	content := renderSyntax(syntax{dialect: DialectBMake}, variables, targets)
+	require.Equal(t, `DETECTED_OS != uname -s
+
+TAG != git describe --tags
+.if ${DETECTED_OS} == "Windows"
+EXE ?= .exe
+.else
+EXE ?=
+.endif
+
+clean:
+.if ${DETECTED_OS} == "Darwin"
+	rm -rf bin .DS_Store
+.elif ${DETECTED_OS} == "Windows"
+	rmdir /S /Q bin
+.else
+	rm -rf bin
+.endif
+`, content)
+	require.Empty(t, lintMakefile(content))
+	require.Empty(t, unsupported(DialectBMake, content))
+	require.Equal(t, []Target{
+		{Name: "clean", Recipe: []string{"rm -rf bin .DS_Store", "rmdir /S /Q bin", "rm -rf bin"}, Line: 10},
+	}, parseTargets(content))
+}
+
+func TestUnsupported(t *testing.T) {
+	content := "SRC := $(wildcard *.go)\nifdef CI\nendif\n"
+	require.Nil(t, unsupported(DialectGNU, content))
+	require.Equal(t, []string{
+		"line 1: := is GNU make only",
+		"line 1: $(wildcard) is GNU make only",
+		"line 2: ifdef is GNU make only",
+		"line 3: endif is GNU make only",
+	}, unsupported(DialectPOSIX, content))
+	require.Equal(t, []string{
+		"line 1: $(wildcard) is GNU make only",
+		"line 2: ifdef is GNU make only",
+		"line 3: endif is GNU make only",
+	}, unsupported(DialectBMake, content))
+}
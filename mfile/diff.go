@@ -0,0 +1,215 @@
+// Copyright (c) 2023 Tiago Melo. All rights reserved.
+// Use of this source code is governed by the MIT License that can be found in
+// the LICENSE file.
+
+package mfile
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+)
+
+// MakefileDiff is the difference between two Makefiles, at the level of
+// their targets and variables.
+type MakefileDiff struct {
+	AddedTargets     []Target         // Targets declared only in the new Makefile.
+	RemovedTargets   []Target         // Targets declared only in the old Makefile.
+	ChangedTargets   []TargetChange   // Targets declared in both, differently.
+	AddedVariables   []Variable       // Variables assigned only in the new Makefile.
+	RemovedVariables []Variable       // Variables assigned only in the old Makefile.
+	ChangedVariables []VariableChange // Variables assigned in both, differently.
+}
+
+// Empty reports whether the Makefiles declare the same targets and
+// variables.
+func (d *MakefileDiff) Empty() bool {
+	return len(d.AddedTargets)+len(d.RemovedTargets)+len(d.ChangedTargets)+
+		len(d.AddedVariables)+len(d.RemovedVariables)+len(d.ChangedVariables) == 0
+}
+
+// TargetChange is a target declared differently in two Makefiles.
+type TargetChange struct {
+	Old, New            Target
+	AddedDependencies   []string // Prerequisites only the new target has.
+	RemovedDependencies []string // Prerequisites only the old target has.
+	DescriptionChanged  bool
+	RecipeChanged       bool
+	PhonyChanged        bool
+}
+
+// VariableChange is a variable assigned differently in two Makefiles.
+type VariableChange struct {
+	Old, New Variable
+}
+
+// Diff parses the Makefiles at the given paths and returns what changes
+// from the old one to the new one: the targets added, removed or whose
+// description, prerequisites, recipe or phony status changed, and the
+// variables added, removed or whose value, operator or export changed.
+// The order of declaration is not compared.
+func Diff(oldPath, newPath string) (*MakefileDiff, error) {
+	oldContent, err := readMakefile(mkFilePath(oldPath))
+	if err != nil {
+		return nil, err
+	}
+	newContent, err := readMakefile(mkFilePath(newPath))
+	if err != nil {
+		return nil, err
+	}
+	d := new(MakefileDiff)
+	oldTargets, newTargets := parseTargets(oldContent), parseTargets(newContent)
+	for _, o := range oldTargets {
+		i := slices.IndexFunc(newTargets, func(t Target) bool { return t.Name == o.Name })
+		if i < 0 {
+			d.RemovedTargets = append(d.RemovedTargets, o)
+			continue
+		}
+		n := newTargets[i]
+		c := TargetChange{
+			Old:                 o,
+			New:                 n,
+			AddedDependencies:   missing(n.Dependencies, o.Dependencies),
+			RemovedDependencies: missing(o.Dependencies, n.Dependencies),
+			DescriptionChanged:  o.Description != n.Description,
+			RecipeChanged:       !slices.Equal(o.Recipe, n.Recipe),
+			PhonyChanged:        o.Phony != n.Phony,
+		}
+		if len(c.AddedDependencies) > 0 || len(c.RemovedDependencies) > 0 || c.DescriptionChanged || c.RecipeChanged || c.PhonyChanged {
+			d.ChangedTargets = append(d.ChangedTargets, c)
+		}
+	}
+	for _, n := range newTargets {
+		if !slices.ContainsFunc(oldTargets, func(t Target) bool { return t.Name == n.Name }) {
+			d.AddedTargets = append(d.AddedTargets, n)
+		}
+	}
+	oldVariables, newVariables := parseVariables(oldContent), parseVariables(newContent)
+	for _, o := range oldVariables {
+		i := slices.IndexFunc(newVariables, func(v Variable) bool { return v.Name == o.Name })
+		if i < 0 {
+			d.RemovedVariables = append(d.RemovedVariables, o)
+			continue
+		}
+		if n := newVariables[i]; n.Operator != o.Operator || n.Value != o.Value || n.Export != o.Export {
+			d.ChangedVariables = append(d.ChangedVariables, VariableChange{Old: o, New: n})
+		}
+	}
+	for _, n := range newVariables {
+		if !slices.ContainsFunc(oldVariables, func(v Variable) bool { return v.Name == n.Name }) {
+			d.AddedVariables = append(d.AddedVariables, n)
+		}
+	}
+	return d, nil
+}
+
+// missing returns the given names not in others.
+func missing(names, others []string) []string {
+	var m []string
+	for _, n := range names {
+		if !slices.Contains(others, n) {
+			m = append(m, n)
+		}
+	}
+	return m
+}
+
+// diffContext is the number of unchanged lines shown around changes.
+const diffContext = 3
+
+// lineDiff returns the unified diff turning the old content into the new
+// one, labeled with the given names, or an empty string if they are
+// equal.
+func lineDiff(oldName, newName, oldContent, newContent string) string {
+	if oldContent == newContent {
+		return ""
+	}
+	a, b := diffLines(oldContent), diffLines(newContent)
+	// lcs[i][j] is the length of the longest common subsequence of a[i:]
+	// and b[j:].
+	lcs := make([][]int, len(a)+1)
+	for i := range lcs {
+		lcs[i] = make([]int, len(b)+1)
+	}
+	for i := len(a) - 1; i >= 0; i-- {
+		for j := len(b) - 1; j >= 0; j-- {
+			if a[i] == b[j] {
+				lcs[i][j] = lcs[i+1][j+1] + 1
+			} else {
+				lcs[i][j] = max(lcs[i+1][j], lcs[i][j+1])
+			}
+		}
+	}
+	type edit struct {
+		op   byte
+		line string
+		i, j int // Lines of a and b the edit is at.
+	}
+	var edits []edit
+	i, j := 0, 0
+	for i < len(a) || j < len(b) {
+		switch {
+		case i < len(a) && j < len(b) && a[i] == b[j]:
+			edits = append(edits, edit{' ', a[i], i, j})
+			i, j = i+1, j+1
+		case i < len(a) && (j == len(b) || lcs[i+1][j] >= lcs[i][j+1]):
+			edits = append(edits, edit{'-', a[i], i, j})
+			i++
+		default:
+			edits = append(edits, edit{'+', b[j], i, j})
+			j++
+		}
+	}
+	var sb strings.Builder
+	sb.WriteString("--- " + oldName + "\n+++ " + newName + "\n")
+	for start := 0; start < len(edits); {
+		if edits[start].op == ' ' {
+			start++
+			continue
+		}
+		// A hunk spans the changes closer than twice the context, and
+		// the context around them.
+		end := start
+		for k := start; k < len(edits) && k-end <= 2*diffContext; k++ {
+			if edits[k].op != ' ' {
+				end = k
+			}
+		}
+		first, last := max(start-diffContext, 0), min(end+diffContext+1, len(edits))
+		var oldCount, newCount int
+		for _, e := range edits[first:last] {
+			if e.op != '+' {
+				oldCount++
+			}
+			if e.op != '-' {
+				newCount++
+			}
+		}
+		fmt.Fprintf(&sb, "@@ -%s +%s @@\n", hunkRange(edits[first].i, oldCount), hunkRange(edits[first].j, newCount))
+		for _, e := range edits[first:last] {
+			sb.WriteString(string(e.op) + e.line + "\n")
+		}
+		start = last
+	}
+	return sb.String()
+}
+
+// hunkRange returns the range of a hunk starting at the given 0-based line
+// and spanning the given number of lines, in the unified diff format.
+func hunkRange(line, count int) string {
+	if count == 0 {
+		return fmt.Sprintf("%d,0", line)
+	}
+	if count == 1 {
+		return fmt.Sprintf("%d", line+1)
+	}
+	return fmt.Sprintf("%d,%d", line+1, count)
+}
+
+// diffLines returns the lines of the given content.
+func diffLines(content string) []string {
+	if content == "" {
+		return nil
+	}
+	return strings.Split(strings.TrimSuffix(content, "\n"), "\n")
+}
@@ -0,0 +1,136 @@
+// Copyright (c) 2023 Tiago Melo. All rights reserved.
+// Use of this source code is governed by the MIT License that can be found in
+// the LICENSE file.
+
+package mfile
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestLineDiff(t *testing.T) {
+	testCases := []struct {
+		name         string
+		oldContent   string
+		newContent   string
+		expectedDiff string
+	}{
+		{
+			name:         "equal",
+			oldContent:   "a\nb\n",
+			newContent:   "a\nb\n",
+			expectedDiff: "",
+		},
+		{
+			name:         "created",
+			oldContent:   "",
+			newContent:   "a\nb\n",
+			expectedDiff: "--- old\n+++ new\n@@ -0,0 +1,2 @@\n+a\n+b\n",
+		},
+		{
+			name:         "changed line",
+			oldContent:   "1\n2\n3\n4\n5\n6\n7\n8\n",
+			newContent:   "1\n2\n3\n4\nfive\n6\n7\n8\n",
+			expectedDiff: "--- old\n+++ new\n@@ -2,7 +2,7 @@\n 2\n 3\n 4\n-5\n+five\n 6\n 7\n 8\n",
+		},
+		{
+			name:         "distant changes",
+			oldContent:   "1\n2\n3\n4\n5\n6\n7\n8\n9\n10\n",
+			newContent:   "0\n1\n2\n3\n4\n5\n6\n7\n8\n9\n",
+			expectedDiff: "--- old\n+++ new\n@@ -1,3 +1,4 @@\n+0\n 1\n 2\n 3\n@@ -7,4 +8,3 @@\n 7\n 8\n 9\n-10\n",
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			require.Equal(t, tc.expectedDiff, lineDiff("old", "new", tc.oldContent, tc.newContent))
+		})
+	}
+}
+
+func TestDiff(t *testing.T) {
+	const oldMakefile = `GO ?= go
+OLD = 1
+.PHONY: build
+## build: build it
+build: gen
+	go build
+## test: run unit tests
+test:
+	go test ./...
+old:
+`
+	testCases := []struct {
+		name          string
+		newMakefile   string
+		expectedDiff  *MakefileDiff
+		expectedError error
+	}{
+		{
+			name:         "happy path, equal",
+			newMakefile:  oldMakefile,
+			expectedDiff: &MakefileDiff{},
+		},
+		{
+			name: "happy path, changed",
+			newMakefile: `GO := go
+NEW = 1
+## build: build the binary
+build: tools
+	go build -o bin/app
+## test: run unit tests
+test:
+	go test ./...
+new:
+`,
+			expectedDiff: &MakefileDiff{
+				AddedTargets:   []Target{{Name: "new", Line: 9}},
+				RemovedTargets: []Target{{Name: "old", Line: 10}},
+				ChangedTargets: []TargetChange{
+					{
+						Old:                 Target{Name: "build", Description: "build it", Dependencies: []string{"gen"}, Recipe: []string{"go build"}, Phony: true, Line: 5},
+						New:                 Target{Name: "build", Description: "build the binary", Dependencies: []string{"tools"}, Recipe: []string{"go build -o bin/app"}, Line: 4},
+						AddedDependencies:   []string{"tools"},
+						RemovedDependencies: []string{"gen"},
+						DescriptionChanged:  true,
+						RecipeChanged:       true,
+						PhonyChanged:        true,
+					},
+				},
+				AddedVariables:   []Variable{{Name: "NEW", Operator: "=", Value: "1"}},
+				RemovedVariables: []Variable{{Name: "OLD", Operator: "=", Value: "1"}},
+				ChangedVariables: []VariableChange{
+					{Old: Variable{Name: "GO", Operator: "?=", Value: "go"}, New: Variable{Name: "GO", Operator: ":=", Value: "go"}},
+				},
+			},
+		},
+		{
+			name:          "new Makefile not found",
+			expectedError: errors.New("reading Makefile at b.mk: file does not exist"),
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			files := map[string][]byte{"a.mk": []byte(oldMakefile)}
+			if tc.newMakefile != "" {
+				files["b.mk"] = []byte(tc.newMakefile)
+			}
+			fsProvider = &mockFileSystem{files: files, isNotExistOutput: true}
+			diff, err := Diff("a.mk", "b.mk")
+			if err != nil {
+				if tc.expectedError == nil {
+					t.Fatalf("expected no error, got %v", err)
+				}
+				require.Equal(t, tc.expectedError.Error(), err.Error())
+			} else {
+				if tc.expectedError != nil {
+					t.Fatalf("expected error to be %v, got nil", tc.expectedError)
+				}
+				require.Equal(t, tc.expectedDiff, diff)
+				require.Equal(t, tc.expectedDiff.Empty(), diff.Empty())
+			}
+		})
+	}
+}
@@ -0,0 +1,184 @@
+// Copyright (c) 2023 Tiago Melo. All rights reserved.
+// Use of this source code is governed by the MIT License that can be found in
+// the LICENSE file.
+
+package mfile
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// errEditorClosed is returned when using an Editor after its Commit or
+// Rollback.
+var errEditorClosed = errors.New("edit session already committed or rolled back")
+
+// Editor is an edit session of a Makefile, started with Edit. Its edits
+// are applied to a copy of the Makefile in memory, each one seeing the
+// previous ones, and reach the disk all at once with Commit, or not at
+// all with Rollback. An edit that fails leaves the session as it was, so
+// that the other edits can still be committed.
+type Editor struct {
+	makeFilePath string
+	original     string    // Content of the Makefile when the session started.
+	m            *Makefile // Edited copy of the Makefile.
+	closed       bool
+}
+
+// Edit starts an edit session of the Makefile at the given path:
+//
+//	e, err := mfile.Edit(".")
+//	if err != nil {
+//		return err
+//	}
+//	defer e.Rollback()
+//	if err := e.AddTarget("vet", mfile.WithContent("go vet ./...")); err != nil {
+//		return err
+//	}
+//	if err := e.SetVariable("GOFLAGS", "-mod=readonly"); err != nil {
+//		return err
+//	}
+//	return e.Commit()
+func Edit(path string) (*Editor, error) {
+	makeFilePath := mkFilePath(path)
+	content, err := readMakefile(makeFilePath)
+	if err != nil {
+		return nil, err
+	}
+	return &Editor{makeFilePath: makeFilePath, original: content, m: ParseString(content)}, nil
+}
+
+// AddTarget adds a target like AddTarget does, configured by the given
+// options.
+func (e *Editor) AddTarget(targetName string, opts ...TargetOption) error {
+	return e.apply(AddTargetOp(targetName, opts...))
+}
+
+// RemoveTarget removes a target, see RemoveTargetOp.
+func (e *Editor) RemoveTarget(target string, opts ...TargetOption) error {
+	return e.apply(RemoveTargetOp(target, opts...))
+}
+
+// AddDependency adds a dependency to a target, see
+// Makefile.AddDependency.
+func (e *Editor) AddDependency(target, dependency string) error {
+	return e.apply(AddDependencyOp(target, dependency))
+}
+
+// RemoveDependency removes a dependency from a target, see
+// Makefile.RemoveDependency.
+func (e *Editor) RemoveDependency(target, dependency string) error {
+	return e.apply(RemoveDependencyOp(target, dependency))
+}
+
+// SetTargetDescription sets the description of a target, see
+// Makefile.SetTargetDescription.
+func (e *Editor) SetTargetDescription(target, description string) error {
+	return e.apply(SetTargetDescriptionOp(target, description))
+}
+
+// AppendRecipeLine adds a command at the end of the recipe of a target,
+// see Makefile.AppendRecipeLine.
+func (e *Editor) AppendRecipeLine(target, line string) error {
+	return e.apply(AppendRecipeLineOp(target, line))
+}
+
+// PrependRecipeLine adds a command at the start of the recipe of a
+// target, see Makefile.PrependRecipeLine.
+func (e *Editor) PrependRecipeLine(target, line string) error {
+	return e.apply(PrependRecipeLineOp(target, line))
+}
+
+// SetVariable sets the value of a variable, see Makefile.SetVariable.
+func (e *Editor) SetVariable(name, value string) error {
+	return e.apply(SetVariableOp(name, value))
+}
+
+// String returns the edited content of the Makefile, as Commit would
+// write it.
+func (e *Editor) String() string {
+	return e.m.String()
+}
+
+// apply applies the given operation to the edited copy of the Makefile,
+// which is left as it was if it fails.
+func (e *Editor) apply(op Op) error {
+	if e.closed {
+		return &Error{Op: op.name, Path: e.makeFilePath, Err: errEditorClosed}
+	}
+	edited := ParseString(e.m.String())
+	if err := op.apply(edited, e.makeFilePath); err != nil {
+		return &Error{Op: op.name, Path: e.makeFilePath, Err: err}
+	}
+	e.m = edited
+	return nil
+}
+
+// Commit validates the edited Makefile and writes it, atomically, ending
+// the session. It fails, leaving the Makefile as it is and the session
+// open, if the Makefile changed on disk since the session started, or if
+// the edits introduced problems make would reject or silently misread,
+// see LintMakefile, the ones the Makefile already had being tolerated.
+func (e *Editor) Commit() error {
+	if e.closed {
+		return &Error{Op: "committing edits", Path: e.makeFilePath, Err: errEditorClosed}
+	}
+	current, err := readMakefile(e.makeFilePath)
+	if err != nil {
+		return err
+	}
+	if current != e.original {
+		return &Error{Op: "committing edits", Path: e.makeFilePath, Err: errors.New("the Makefile changed since the edit session started")}
+	}
+	content := e.m.String()
+	if issues := newIssues(lintMakefile(e.original), lintMakefile(content)); len(issues) > 0 {
+		return &Error{Op: "committing edits", Path: e.makeFilePath, Err: fmt.Errorf("invalid Makefile: %s", strings.Join(issues, "; "))}
+	}
+	if content != e.original {
+		if err := fsProvider.WriteFileFrom(e.makeFilePath, strings.NewReader(content), 0644); err != nil {
+			return &Error{Op: "writing Makefile", Path: e.makeFilePath, Err: err}
+		}
+		logger.Debug("committed edits", "path", e.makeFilePath, "bytes", len(content))
+	}
+	e.closed = true
+	return nil
+}
+
+// Rollback discards the edits, ending the session. It does nothing once
+// the session is committed, so that it can be deferred.
+func (e *Editor) Rollback() {
+	if !e.closed {
+		logger.Debug("rolled back edits", "path", e.makeFilePath)
+	}
+	e.closed = true
+}
+
+// newIssues returns the given lint issues of an edited Makefile that the
+// given issues of the original one don't account for, regardless of the
+// lines they are on, which the edits may have moved.
+func newIssues(original, edited []string) []string {
+	known := make(map[string]int)
+	for _, issue := range original {
+		known[issueText(issue)]++
+	}
+	var issues []string
+	for _, issue := range edited {
+		if text := issueText(issue); known[text] > 0 {
+			known[text]--
+			continue
+		}
+		issues = append(issues, issue)
+	}
+	return issues
+}
+
+// issueText returns the given lint issue without its line number.
+func issueText(issue string) string {
+	if rest, ok := strings.CutPrefix(issue, "line "); ok {
+		if _, text, ok := strings.Cut(rest, ": "); ok {
+			return text
+		}
+	}
+	return issue
+}
@@ -0,0 +1,135 @@
+// Copyright (c) 2023 Tiago Melo. All rights reserved.
+// Use of this source code is governed by the MIT License that can be found in
+// the LICENSE file.
+
+package mfile
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestEditor(t *testing.T) {
+	testCases := []struct {
+		name            string
+		content         string
+		edit            func(e *Editor) error
+		mockClosure     func(m *mockFileSystem)
+		expectedContent string
+		expectedError   error
+	}{
+		{
+			name:    "happy path",
+			content: "GO ?= go\n\nbuild:\n\t$(GO) build ./...\n",
+			edit: func(e *Editor) error {
+				if err := e.AddTarget("vet", WithContent("$(GO) vet ./...")); err != nil {
+					return err
+				}
+				if err := e.AddDependency("build", "vet"); err != nil {
+					return err
+				}
+				return e.SetVariable("GOFLAGS", "-mod=readonly")
+			},
+			expectedContent: "GO ?= go\nGOFLAGS ?= -mod=readonly\n\nbuild: vet\n\t$(GO) build ./...\n" +
+				"\n.PHONY: vet\n## vet: explain what vet does\nvet:\n\t$(GO) vet ./...\n",
+		},
+		{
+			name:    "happy path, failed edit left out",
+			content: "build:\n",
+			edit: func(e *Editor) error {
+				require.EqualError(t, e.AddDependency("test", "build"),
+					"adding dependency build to test at Makefile: looking up target test: target not found")
+				return e.AppendRecipeLine("build", "go build ./...")
+			},
+			expectedContent: "build:\n\tgo build ./...\n",
+		},
+		{
+			name:    "happy path, reserved target removed only when forced",
+			content: "help:\n\nbuild:\n",
+			edit: func(e *Editor) error {
+				require.ErrorIs(t, e.RemoveTarget("help"), ErrReservedTarget)
+				return e.RemoveTarget("help", WithForce())
+			},
+			expectedContent: "build:\n",
+		},
+		{
+			name:    "happy path, problems already in the Makefile",
+			content: "## lint: run the linter\nbuild:\n    go build ./...\n",
+			edit: func(e *Editor) error {
+				return e.SetTargetDescription("build", "build the binary")
+			},
+			expectedContent: "## lint: run the linter\n## build: build the binary\nbuild:\n    go build ./...\n",
+		},
+		{
+			name:    "edits introducing problems",
+			content: "build:\n",
+			edit: func(e *Editor) error {
+				return e.AddTarget("vet", WithContent("go vet ./...\nendif"))
+			},
+			expectedError: errors.New("committing edits at Makefile: invalid Makefile: line 7: endif without conditional"),
+		},
+		{
+			name:    "Makefile changed since the session started",
+			content: "build:\n",
+			edit: func(e *Editor) error {
+				return e.AddDependency("build", "vet")
+			},
+			mockClosure: func(m *mockFileSystem) {
+				m.files["Makefile"] = []byte("build: lint\n")
+			},
+			expectedError: errors.New("committing edits at Makefile: the Makefile changed since the edit session started"),
+		},
+		{
+			name:    "error when writing file",
+			content: "build:\n",
+			edit: func(e *Editor) error {
+				return e.AddDependency("build", "vet")
+			},
+			mockClosure: func(m *mockFileSystem) {
+				m.writeFileErr = errors.New("write error")
+			},
+			expectedError: errors.New("writing Makefile at Makefile: write error"),
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			m := &mockFileSystem{
+				isNotExistOutput: true,
+				files:            map[string][]byte{"Makefile": []byte(tc.content)},
+			}
+			fsProvider = m
+			templateProcessorProvider = htmlTemplateProcessor{}
+			userHomeDir = func() (string, error) { return "/home/gopher", nil }
+			e, err := Edit("Makefile")
+			require.NoError(t, err)
+			defer e.Rollback()
+			require.NoError(t, tc.edit(e))
+			require.Nil(t, m.writtenData)
+			if tc.mockClosure != nil {
+				tc.mockClosure(m)
+			}
+			err = e.Commit()
+			if tc.expectedError != nil {
+				require.EqualError(t, err, tc.expectedError.Error())
+				return
+			}
+			require.NoError(t, err)
+			require.Equal(t, tc.expectedContent, string(m.written["Makefile"]))
+		})
+	}
+}
+
+func TestEditorRollback(t *testing.T) {
+	m := &mockFileSystem{files: map[string][]byte{"Makefile": []byte("build:\n")}}
+	fsProvider = m
+	e, err := Edit("Makefile")
+	require.NoError(t, err)
+	require.NoError(t, e.SetVariable("GO", "go"))
+	require.Equal(t, "GO ?= go\n\nbuild:\n", e.String())
+	e.Rollback()
+	require.EqualError(t, e.Commit(), "committing edits at Makefile: edit session already committed or rolled back")
+	require.EqualError(t, e.SetVariable("GO", "go1.22"), "setting variable GO at Makefile: edit session already committed or rolled back")
+	require.Nil(t, m.writtenData)
+}
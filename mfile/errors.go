@@ -0,0 +1,131 @@
+// Copyright (c) 2023 Tiago Melo. All rights reserved.
+// Use of this source code is governed by the MIT License that can be found in
+// the LICENSE file.
+
+package mfile
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"unicode/utf8"
+)
+
+// Sentinel errors returned by the package. Use errors.Is to check for them.
+var (
+	// ErrMakefileNotFound is returned when the Makefile does not exist.
+	ErrMakefileNotFound = errors.New("Makefile not found")
+
+	// ErrTargetExists is returned when adding a target that is already
+	// declared in the Makefile.
+	ErrTargetExists = errors.New("target already exists")
+
+	// ErrInvalidTargetName is returned when a target or dependency
+	// name is not valid.
+	ErrInvalidTargetName = errors.New("invalid target name")
+
+	// ErrNotAMakefile is returned when the file at the given path
+	// is not a text file, and therefore can't be a Makefile.
+	ErrNotAMakefile = errors.New("not a Makefile")
+
+	// ErrSnippetNotFound is returned when a snippet is not found in
+	// any of the snippet directories.
+	ErrSnippetNotFound = errors.New("snippet not found")
+
+	// ErrTargetNotFound is returned when a target is not declared in the
+	// Makefile.
+	ErrTargetNotFound = errors.New("target not found")
+
+	// ErrReservedTarget is returned when adding or replacing a target
+	// managed by the generator, like help, or a special target of make,
+	// like .PHONY, without forcing it.
+	ErrReservedTarget = errors.New("reserved target")
+)
+
+// Error records an operation on a file that failed, like reading a
+// Makefile, with the path of the file and the cause of the failure. Use
+// errors.As to get it, and errors.Is to check its cause, like
+// ErrTargetExists or fs.ErrNotExist:
+//
+//	var e *mfile.Error
+//	if errors.As(err, &e) && errors.Is(err, mfile.ErrTargetExists) {
+//		fmt.Println(e.Path, "already declares the target")
+//	}
+type Error struct {
+	Op   string // Operation that failed, like "reading Makefile" or "adding target build".
+	Path string // Path of the file the operation was on.
+	Err  error  // Cause of the failure.
+}
+
+func (e *Error) Error() string {
+	return e.Op + " at " + e.Path + ": " + e.Err.Error()
+}
+
+func (e *Error) Unwrap() error {
+	return e.Err
+}
+
+// markedError is an error that keeps the message of the wrapped error
+// while matching the given sentinel error with errors.Is.
+type markedError struct {
+	sentinel error
+	err      error
+}
+
+func (e *markedError) Error() string {
+	return e.err.Error()
+}
+
+func (e *markedError) Is(target error) bool {
+	return target == e.sentinel
+}
+
+func (e *markedError) Unwrap() error {
+	return e.err
+}
+
+// mark makes err match the given sentinel error.
+func mark(sentinel, err error) error {
+	return &markedError{sentinel: sentinel, err: err}
+}
+
+// isText reports whether the given content looks like a text file.
+func isText(content []byte) bool {
+	return utf8.Valid(content) && !bytes.Contains(content, []byte{0})
+}
+
+// textReader reads from r, failing with ErrNotAMakefile as soon as the
+// content read is not text, see isText, so that it can be checked while
+// streamed.
+type textReader struct {
+	r       io.Reader
+	err     error  // Error of the reads, io.EOF aside.
+	buf     []byte // Bytes checked by the last read.
+	partial []byte // Bytes of a rune cut by the end of the last read.
+}
+
+func (t *textReader) Read(p []byte) (int, error) {
+	n, err := t.r.Read(p)
+	if err != nil && err != io.EOF {
+		t.err = err
+		return n, err
+	}
+	t.buf = append(append(t.buf[:0], t.partial...), p[:n]...)
+	// The bytes of a rune cut by the end of the read are checked along
+	// with the next read.
+	cut := len(t.buf)
+	for i := len(t.buf) - 1; i >= 0 && i >= len(t.buf)-utf8.UTFMax; i-- {
+		if utf8.RuneStart(t.buf[i]) {
+			if !utf8.FullRune(t.buf[i:]) {
+				cut = i
+			}
+			break
+		}
+	}
+	if !isText(t.buf[:cut]) || err == io.EOF && cut < len(t.buf) {
+		t.err = ErrNotAMakefile
+		return 0, t.err
+	}
+	t.partial = append(t.partial[:0], t.buf[cut:]...)
+	return n, err
+}
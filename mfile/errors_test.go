@@ -0,0 +1,127 @@
+// Copyright (c) 2023 Tiago Melo. All rights reserved.
+// Use of this source code is governed by the MIT License that can be found in
+// the LICENSE file.
+
+package mfile
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"os"
+	"testing"
+	"testing/iotest"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestSentinelErrors(t *testing.T) {
+	testCases := []struct {
+		name          string
+		mockClosure   func(m *mockFileSystem)
+		targetName    string
+		expectedError error
+		// expectedOp is the operation of the Error, if any.
+		expectedOp string
+	}{
+		{
+			name: "Makefile not found",
+			mockClosure: func(m *mockFileSystem) {
+				m.readFileErr = os.ErrNotExist
+				m.isNotExistOutput = true
+			},
+			targetName:    "test-target",
+			expectedError: ErrMakefileNotFound,
+			expectedOp:    "reading Makefile",
+		},
+		{
+			name: "target exists",
+			mockClosure: func(m *mockFileSystem) {
+				m.file = []byte("test-target:\n")
+			},
+			targetName:    "test-target",
+			expectedError: ErrTargetExists,
+			expectedOp:    "adding target test-target",
+		},
+		{
+			name:          "invalid target name",
+			mockClosure:   func(m *mockFileSystem) {},
+			targetName:    "test target",
+			expectedError: ErrInvalidTargetName,
+		},
+		{
+			name: "not a Makefile",
+			mockClosure: func(m *mockFileSystem) {
+				m.file = []byte{0xff, 0xfe}
+			},
+			targetName:    "test-target",
+			expectedError: ErrNotAMakefile,
+			expectedOp:    "reading Makefile",
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			m := new(mockFileSystem)
+			tc.mockClosure(m)
+			fsProvider = m
+			err := AddTargetToMakefile("path/to/Makefile", tc.targetName)
+			require.True(t, errors.Is(err, tc.expectedError), "expected %v to be %v", err, tc.expectedError)
+			var e *Error
+			if tc.expectedOp == "" {
+				require.False(t, errors.As(err, &e), "expected %v not to be an Error", err)
+				return
+			}
+			require.True(t, errors.As(err, &e), "expected %v to be an Error", err)
+			require.Equal(t, tc.expectedOp, e.Op)
+			require.Equal(t, "path/to/Makefile", e.Path)
+		})
+	}
+}
+
+func TestTextReader(t *testing.T) {
+	testCases := []struct {
+		name          string
+		content       []byte
+		expectedError error
+	}{
+		{
+			name:    "happy path",
+			content: []byte("build:\n\tgo build ./...\n"),
+		},
+		{
+			name:    "happy path, multi-byte runes",
+			content: []byte("## build: compila o binário ✓\n"),
+		},
+		{
+			name:          "NUL byte",
+			content:       []byte("build:\n\x00"),
+			expectedError: ErrNotAMakefile,
+		},
+		{
+			name:          "invalid UTF-8",
+			content:       []byte("build:\xff\n"),
+			expectedError: ErrNotAMakefile,
+		},
+		{
+			name:          "rune cut by the end of the content",
+			content:       []byte("build: \xc3"),
+			expectedError: ErrNotAMakefile,
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			// Reading one byte at a time cuts every multi-byte rune.
+			for _, src := range []io.Reader{bytes.NewReader(tc.content), iotest.OneByteReader(bytes.NewReader(tc.content))} {
+				r := &textReader{r: src}
+				content, err := io.ReadAll(r)
+				if tc.expectedError != nil {
+					require.ErrorIs(t, err, tc.expectedError)
+					require.ErrorIs(t, r.err, tc.expectedError)
+					continue
+				}
+				require.NoError(t, err)
+				require.Equal(t, tc.content, content)
+			}
+		})
+	}
+}
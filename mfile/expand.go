@@ -0,0 +1,191 @@
+// Copyright (c) 2023 Tiago Melo. All rights reserved.
+// Use of this source code is governed by the MIT License that can be found in
+// the LICENSE file.
+
+package mfile
+
+import (
+	"fmt"
+	"os"
+	"strings"
+)
+
+// Expand parses the Makefile at the given path and returns the given text
+// with its variable references, like $(IMAGE_NAME) or ${VERSION}, resolved
+// as make would: the assignments are applied in order, the variables
+// assigned with = are expanded when referenced and the ones assigned with
+// := when assigned, ?= only assigns variables not set in the Makefile nor
+// in the environment, and the variables the Makefile does not assign are
+// read from the environment, or are empty. Conditionals are not
+// evaluated, so the assignments of all their branches apply, and calls to
+// functions, like $(shell ...), are left as they are.
+func Expand(path, text string) (string, error) {
+	content, err := readMakefile(mkFilePath(path))
+	if err != nil {
+		return "", err
+	}
+	e := newExpander(content)
+	expanded, err := e.expand(text)
+	if err != nil {
+		return "", fmt.Errorf("expanding %s: %w", text, err)
+	}
+	return expanded, nil
+}
+
+// expandedVariable is a variable known to an expander.
+type expandedVariable struct {
+	value     string
+	recursive bool // Whether the value is expanded when referenced, as with =, rather than when assigned.
+}
+
+// expander resolves the variable references of a Makefile.
+type expander struct {
+	variables map[string]*expandedVariable
+	expanding map[string]bool
+}
+
+// newExpander returns an expander of the variables assigned by the given
+// Makefile content.
+func newExpander(content string) *expander {
+	e := &expander{variables: make(map[string]*expandedVariable), expanding: make(map[string]bool)}
+	prefix := "\t"
+	lines := strings.Split(content, "\n")
+	for i := 0; i < len(lines); i++ {
+		line := strings.TrimRight(lines[i], "\r")
+		if strings.HasPrefix(line, prefix) {
+			continue
+		}
+		for strings.HasSuffix(line, "\\") && i+1 < len(lines) {
+			i++
+			line = strings.TrimRight(strings.TrimSuffix(line, "\\"), " \t") + " " + strings.TrimSpace(lines[i])
+		}
+		if p, ok := parseRecipePrefix(line); ok {
+			prefix = p
+		}
+		if v, ok := parseAssignment(line); ok {
+			e.assign(v)
+		}
+	}
+	return e
+}
+
+// assign applies the given assignment. Values that cannot be expanded,
+// like the ones of self-referencing simple assignments, are kept as they
+// are.
+func (e *expander) assign(v Variable) {
+	immediate := func(value string) string {
+		if expanded, err := e.expand(value); err == nil {
+			return expanded
+		}
+		return value
+	}
+	current, defined := e.variables[v.Name]
+	switch v.Operator {
+	case "?=":
+		if _, inEnv := os.LookupEnv(v.Name); defined || inEnv {
+			return
+		}
+		e.variables[v.Name] = &expandedVariable{value: v.Value, recursive: true}
+	case ":=", "::=", ":::=":
+		e.variables[v.Name] = &expandedVariable{value: immediate(v.Value)}
+	case "!=":
+		e.variables[v.Name] = &expandedVariable{value: "$(shell " + v.Value + ")"}
+	case "+=":
+		if !defined {
+			value, _ := os.LookupEnv(v.Name)
+			current = &expandedVariable{value: value, recursive: true}
+			e.variables[v.Name] = current
+		}
+		value := v.Value
+		if !current.recursive {
+			value = immediate(value)
+		}
+		current.value = strings.TrimSpace(current.value + " " + value)
+	default:
+		e.variables[v.Name] = &expandedVariable{value: v.Value, recursive: true}
+	}
+}
+
+// expand returns the given text with its variable references resolved.
+func (e *expander) expand(text string) (string, error) {
+	var sb strings.Builder
+	for i := 0; i < len(text); i++ {
+		if text[i] != '$' || i+1 == len(text) {
+			sb.WriteByte(text[i])
+			continue
+		}
+		i++
+		var reference, name string
+		switch c := text[i]; c {
+		case '$':
+			sb.WriteByte('$')
+			continue
+		case '(', '{':
+			end := closingDelimiter(text, i)
+			if end < 0 {
+				sb.WriteString(text[i-1:])
+				return sb.String(), nil
+			}
+			reference, name = text[i-1:end+1], text[i+1:end]
+			i = end
+		default:
+			reference, name = text[i-1:i+1], string(c)
+		}
+		if strings.ContainsAny(name, " \t,") {
+			// A function call, like $(shell ...) or $(subst a,b,$(X)).
+			sb.WriteString(reference)
+			continue
+		}
+		name, err := e.expand(name)
+		if err != nil {
+			return "", err
+		}
+		value, err := e.value(name)
+		if err != nil {
+			return "", err
+		}
+		sb.WriteString(value)
+	}
+	return sb.String(), nil
+}
+
+// value returns the value of the named variable, expanded.
+func (e *expander) value(name string) (string, error) {
+	v, ok := e.variables[name]
+	if !ok {
+		value, _ := os.LookupEnv(name)
+		return value, nil
+	}
+	if !v.recursive {
+		return v.value, nil
+	}
+	if e.expanding[name] {
+		return "", fmt.Errorf("recursive variable %s references itself", name)
+	}
+	e.expanding[name] = true
+	defer delete(e.expanding, name)
+	return e.expand(v.value)
+}
+
+// closingDelimiter returns the index of the parenthesis or brace closing
+// the one at the given index of the given text, or -1.
+func closingDelimiter(text string, open int) int {
+	opening := text[open]
+	closing := byte(')')
+	if opening == '{' {
+		closing = '}'
+	}
+	depth := 0
+	for i := open; i < len(text); i++ {
+		switch text[i] {
+		case opening:
+			depth++
+		case closing:
+			depth--
+			if depth == 0 {
+				return i
+			}
+		}
+	}
+	return -1
+}
@@ -0,0 +1,91 @@
+// Copyright (c) 2023 Tiago Melo. All rights reserved.
+// Use of this source code is governed by the MIT License that can be found in
+// the LICENSE file.
+
+package mfile
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestExpand(t *testing.T) {
+	const content = `REGISTRY ?= ghcr.io
+IMAGE_NAME = $(REGISTRY)/$(APP_NAME)
+APP_NAME ?= app
+VERSION := $(TAG)
+TAG = v1
+TAG := $(TAG)-rc
+export FLAGS = -v
+FLAGS += -race
+SIMPLE := a
+SIMPLE += $(TAG)
+GO_app = go-app
+COMMIT != git rev-parse HEAD
+LOOP = $(LOOP) x
+.RECIPEPREFIX = >
+build:
+> IMAGE_NAME = ignored
+`
+	testCases := []struct {
+		name          string
+		text          string
+		env           map[string]string
+		expected      string
+		expectedError error
+	}{
+		{
+			name:     "happy path, recursive variable",
+			text:     "$(IMAGE_NAME):$(VERSION)",
+			expected: "ghcr.io/app:",
+		},
+		{
+			name:     "happy path, environment",
+			text:     "${IMAGE_NAME}:$(TAG) $(HOME) $$HOME",
+			env:      map[string]string{"REGISTRY": "docker.io", "APP_NAME": "svc", "HOME": "/home/me", "TAG": "v9"},
+			expected: "docker.io/svc:v1-rc /home/me $HOME",
+		},
+		{
+			name:     "happy path, appended values",
+			text:     "$(FLAGS) $(SIMPLE)",
+			expected: "-v -race a v1-rc",
+		},
+		{
+			name:     "happy path, computed name",
+			text:     "$(GO_$(APP_NAME))",
+			expected: "go-app",
+		},
+		{
+			name:     "happy path, functions are kept",
+			text:     "$(COMMIT) $(notdir $(IMAGE_NAME)) $(UNDEFINED)",
+			expected: "$(shell git rev-parse HEAD) $(notdir $(IMAGE_NAME)) ",
+		},
+		{
+			name:          "recursive variable referencing itself",
+			text:          "$(LOOP)",
+			expectedError: errors.New("expanding $(LOOP): recursive variable LOOP references itself"),
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			for k, v := range tc.env {
+				t.Setenv(k, v)
+			}
+			fsProvider = &mockFileSystem{file: []byte(content)}
+			expanded, err := Expand("Makefile", tc.text)
+			if err != nil {
+				if tc.expectedError == nil {
+					t.Fatalf("expected no error, got %v", err)
+				}
+				require.Equal(t, tc.expectedError.Error(), err.Error())
+			} else {
+				if tc.expectedError != nil {
+					t.Fatalf("expected error to be %v, got nil", tc.expectedError)
+				}
+				require.Equal(t, tc.expected, expanded)
+			}
+		})
+	}
+}
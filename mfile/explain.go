@@ -0,0 +1,94 @@
+// Copyright (c) 2023 Tiago Melo. All rights reserved.
+// Use of this source code is governed by the MIT License that can be found in
+// the LICENSE file.
+
+package mfile
+
+import (
+	"strings"
+)
+
+// Explanation describes what running a target of a Makefile does.
+type Explanation struct {
+	Name        string // Name of the target, or of the file prerequisite.
+	Description string // Description taken from the "## name: description" comment.
+	Declared    bool   // Whether it is declared as a target, rather than being a file prerequisite.
+	Recipe      []string
+
+	// Dependencies holds the explanations of the prerequisites of the
+	// target, recursively. Circular dependencies are dropped, as make
+	// does.
+	Dependencies []*Explanation
+}
+
+// Explain parses the Makefile at the given path and returns the
+// explanation of the given target: its description, its dependency chain
+// and its recipe. It returns ErrTargetNotFound if the target is not
+// declared.
+func Explain(path, target string) (*Explanation, error) {
+	makeFilePath := mkFilePath(path)
+	content, err := readMakefile(makeFilePath)
+	if err != nil {
+		return nil, err
+	}
+	targets := make(map[string]Target)
+	for _, t := range parseTargets(content) {
+		targets[t.Name] = t
+	}
+	if _, ok := targets[target]; !ok {
+		return nil, &Error{Op: "explaining target " + target, Path: makeFilePath, Err: ErrTargetNotFound}
+	}
+	return explain(targets, target, make(map[string]bool)), nil
+}
+
+// explain returns the explanation of the given target, following the
+// given chain of targets it is a dependency of.
+func explain(targets map[string]Target, name string, chain map[string]bool) *Explanation {
+	t, ok := targets[name]
+	if !ok {
+		return &Explanation{Name: name}
+	}
+	e := &Explanation{Name: name, Description: t.Description, Declared: true, Recipe: t.Recipe}
+	chain[name] = true
+	defer delete(chain, name)
+	for _, d := range t.Dependencies {
+		if chain[d] {
+			logger.Warn("circular dependency dropped", "target", name, "dependency", d)
+			continue
+		}
+		e.Dependencies = append(e.Dependencies, explain(targets, d, chain))
+	}
+	return e
+}
+
+// Commands returns the commands run to build the target, in order: the
+// ones of its dependencies first, each target once, with their @, - and
+// + prefixes removed, $@ replaced by the name of their target and $$ by
+// $. Other variables are not expanded.
+func (e *Explanation) Commands() []string {
+	var commands []string
+	e.commands(make(map[string]bool), &commands)
+	return commands
+}
+
+func (e *Explanation) commands(done map[string]bool, commands *[]string) {
+	if done[e.Name] {
+		return
+	}
+	done[e.Name] = true
+	for _, d := range e.Dependencies {
+		d.commands(done, commands)
+	}
+	for _, line := range e.Recipe {
+		line = strings.TrimLeft(line, " \t")
+		for line != "" && strings.ContainsRune("@-+", rune(line[0])) {
+			line = strings.TrimLeft(line[1:], " \t")
+		}
+		if line == "" {
+			continue
+		}
+		line = strings.ReplaceAll(line, "$$", "\x00")
+		line = strings.NewReplacer("$@", e.Name, "$(@)", e.Name).Replace(line)
+		*commands = append(*commands, strings.ReplaceAll(line, "\x00", "$"))
+	}
+}
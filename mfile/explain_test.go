@@ -0,0 +1,113 @@
+// Copyright (c) 2023 Tiago Melo. All rights reserved.
+// Use of this source code is governed by the MIT License that can be found in
+// the LICENSE file.
+
+package mfile
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestExplain(t *testing.T) {
+	const makefile = `.PHONY: lint
+## lint: run linters
+lint: tools.go
+	@ $(GO) vet ./...
+
+.PHONY: test
+## test: run unit tests
+test: lint build
+	-@ go test ./... && echo $$HOME $@
+
+build: lint
+	go build
+`
+	lint := &Explanation{
+		Name:         "lint",
+		Description:  "run linters",
+		Declared:     true,
+		Recipe:       []string{"@ $(GO) vet ./..."},
+		Dependencies: []*Explanation{{Name: "tools.go"}},
+	}
+	testCases := []struct {
+		name                string
+		target              string
+		mockClosure         func(m *mockFileSystem)
+		expectedExplanation *Explanation
+		expectedCommands    []string
+		expectedError       error
+	}{
+		{
+			name:   "happy path",
+			target: "test",
+			mockClosure: func(m *mockFileSystem) {
+				m.file = []byte(makefile)
+			},
+			expectedExplanation: &Explanation{
+				Name:        "test",
+				Description: "run unit tests",
+				Declared:    true,
+				Recipe:      []string{"-@ go test ./... && echo $$HOME $@"},
+				Dependencies: []*Explanation{
+					lint,
+					{Name: "build", Declared: true, Recipe: []string{"go build"}, Dependencies: []*Explanation{lint}},
+				},
+			},
+			expectedCommands: []string{"$(GO) vet ./...", "go build", "go test ./... && echo $HOME test"},
+		},
+		{
+			name:   "circular dependency",
+			target: "a",
+			mockClosure: func(m *mockFileSystem) {
+				m.file = []byte("a: b\n\techo a\nb: a\n\techo b\n")
+			},
+			expectedExplanation: &Explanation{
+				Name:         "a",
+				Declared:     true,
+				Recipe:       []string{"echo a"},
+				Dependencies: []*Explanation{{Name: "b", Declared: true, Recipe: []string{"echo b"}}},
+			},
+			expectedCommands: []string{"echo b", "echo a"},
+		},
+		{
+			name:   "target not found",
+			target: "deploy",
+			mockClosure: func(m *mockFileSystem) {
+				m.file = []byte(makefile)
+			},
+			expectedError: errors.New("explaining target deploy at some/path: target not found"),
+		},
+		{
+			name:   "Makefile not found",
+			target: "test",
+			mockClosure: func(m *mockFileSystem) {
+				m.readFileErr = errors.New("file does not exist")
+				m.isNotExistOutput = true
+			},
+			expectedError: errors.New("reading Makefile at some/path: file does not exist"),
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			m := new(mockFileSystem)
+			tc.mockClosure(m)
+			fsProvider = m
+			explanation, err := Explain("some/path", tc.target)
+			if err != nil {
+				if tc.expectedError == nil {
+					t.Fatalf("expected no error, got %v", err)
+				}
+				require.Equal(t, tc.expectedError.Error(), err.Error())
+			} else {
+				if tc.expectedError != nil {
+					t.Fatalf("expected error to be %v, got nil", tc.expectedError)
+				}
+				require.Equal(t, tc.expectedExplanation, explanation)
+				require.Equal(t, tc.expectedCommands, explanation.Commands())
+			}
+		})
+	}
+}
@@ -0,0 +1,156 @@
+// Copyright (c) 2023 Tiago Melo. All rights reserved.
+// Use of this source code is governed by the MIT License that can be found in
+// the LICENSE file.
+
+package mfile
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"regexp"
+	"sort"
+
+	"gopkg.in/yaml.v3"
+)
+
+// exporter converts the variables and targets of a Makefile into the
+// content of a file of some format.
+type exporter func(variables []Variable, targets []Target) ([]byte, error)
+
+// exporters holds the exporter of each format.
+var exporters = map[string]exporter{
+	FormatTaskfile: exportTaskfile,
+	FormatGHA:      exportWorkflow,
+	FormatGitLabCI: exportGitLabCI,
+	FormatVSCode:   exportVSCode,
+}
+
+// ExportFormats returns the formats a Makefile can be exported to.
+func ExportFormats() []string {
+	formats := make([]string, 0, len(exporters))
+	for name := range exporters {
+		formats = append(formats, name)
+	}
+	sort.Strings(formats)
+	return formats
+}
+
+// Export converts the variables and targets of the Makefile at the
+// specified path into a file of the given format, see ExportFormats,
+// and returns its content.
+func Export(path, format string) ([]byte, error) {
+	return exportFile(fsProvider, mkFilePath(path), format)
+}
+
+// exportFile is like Export, for the Makefile at the given path of the
+// given file system.
+func exportFile(fsys fileSystem, makeFilePath, format string) ([]byte, error) {
+	exp, ok := exporters[format]
+	if !ok {
+		return nil, fmt.Errorf("unknown export format %q", format)
+	}
+	content, err := readMakefileFrom(fsys, makeFilePath)
+	if err != nil {
+		return nil, err
+	}
+	out, err := exp(parseVariables(content), parseTargets(content))
+	if err != nil {
+		return nil, fmt.Errorf("exporting to %s: %w", format, err)
+	}
+	return out, nil
+}
+
+// ciTargetNames are the targets run by CI when the Makefile declares no
+// ci target, in their usual order.
+var ciTargetNames = []string{"lint", "vet", "test", "build"}
+
+// goCommand matches the recipe lines running the go command, which need
+// Go to be set up.
+var goCommand = regexp.MustCompile(`(?:^|[\s;&|(@])(?:go|\$\(GO\)|\$\{GO\})\s`)
+
+// ciTargets returns the names of the targets of the given ones run by CI,
+// and the targets by name: the ci target if declared, otherwise the lint,
+// vet, test and build ones.
+func ciTargets(targets []Target) ([]string, map[string]Target, error) {
+	byName := make(map[string]Target)
+	for _, t := range targets {
+		byName[t.Name] = t
+	}
+	if _, ok := byName["ci"]; ok {
+		return []string{"ci"}, byName, nil
+	}
+	var names []string
+	for _, name := range ciTargetNames {
+		if _, ok := byName[name]; ok {
+			names = append(names, name)
+		}
+	}
+	if len(names) == 0 {
+		return nil, nil, errors.New("no ci, lint, vet, test or build target found")
+	}
+	return names, byName, nil
+}
+
+// usesGo reports whether the recipe of the given target, or of one of
+// its dependencies, runs the go command.
+func usesGo(targets map[string]Target, name string, seen map[string]bool) bool {
+	if seen[name] {
+		return false
+	}
+	seen[name] = true
+	t, ok := targets[name]
+	if !ok {
+		return false
+	}
+	for _, line := range t.Recipe {
+		if goCommand.MatchString(" " + line + " ") {
+			return true
+		}
+	}
+	for _, d := range t.Dependencies {
+		if usesGo(targets, d, seen) {
+			return true
+		}
+	}
+	return false
+}
+
+// yamlString returns the YAML node of the given string.
+func yamlString(value string) *yaml.Node {
+	return &yaml.Node{Kind: yaml.ScalarNode, Tag: "!!str", Value: value}
+}
+
+// yamlBool returns the YAML node of the given boolean.
+func yamlBool(value bool) *yaml.Node {
+	v := "false"
+	if value {
+		v = "true"
+	}
+	return &yaml.Node{Kind: yaml.ScalarNode, Tag: "!!bool", Value: v}
+}
+
+// yamlMapping returns the YAML mapping node of the given keys and values,
+// keeping their order.
+func yamlMapping(keysAndValues ...*yaml.Node) *yaml.Node {
+	return &yaml.Node{Kind: yaml.MappingNode, Content: keysAndValues}
+}
+
+// yamlSequence returns the YAML sequence node of the given items.
+func yamlSequence(items ...*yaml.Node) *yaml.Node {
+	return &yaml.Node{Kind: yaml.SequenceNode, Content: items}
+}
+
+// encodeYAML returns the given YAML node, encoded with a 2-space indent.
+func encodeYAML(node *yaml.Node) ([]byte, error) {
+	var buf bytes.Buffer
+	enc := yaml.NewEncoder(&buf)
+	enc.SetIndent(2)
+	if err := enc.Encode(node); err != nil {
+		return nil, fmt.Errorf("encoding YAML: %w", err)
+	}
+	if err := enc.Close(); err != nil {
+		return nil, fmt.Errorf("encoding YAML: %w", err)
+	}
+	return buf.Bytes(), nil
+}
@@ -0,0 +1,77 @@
+// Copyright (c) 2023 Tiago Melo. All rights reserved.
+// Use of this source code is governed by the MIT License that can be found in
+// the LICENSE file.
+
+package mfile
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestExport(t *testing.T) {
+	testCases := []struct {
+		name            string
+		format          string
+		mockClosure     func(m *mockFileSystem)
+		expectedContent string
+		expectedError   error
+	}{
+		{
+			name:   "happy path",
+			format: FormatTaskfile,
+			mockClosure: func(m *mockFileSystem) {
+				m.file = []byte(minimalMakefile)
+			},
+			expectedContent: `version: "3"
+tasks:
+  test:
+    desc: run unit tests
+    silent: true
+    cmds:
+      - go test -v ./... -count=1
+  coverage:
+    desc: run unit tests and generate coverage report in html format
+    silent: true
+    cmds:
+      - go test -coverprofile=coverage.out ./...  && go tool cover -html=coverage.out
+`,
+		},
+		{
+			name:          "unknown format",
+			format:        "unknown",
+			mockClosure:   func(m *mockFileSystem) {},
+			expectedError: errors.New(`unknown export format "unknown"`),
+		},
+		{
+			name:   "Makefile not found",
+			format: FormatTaskfile,
+			mockClosure: func(m *mockFileSystem) {
+				m.readFileErr = errors.New("file does not exist")
+				m.isNotExistOutput = true
+			},
+			expectedError: errors.New("reading Makefile at some/path: file does not exist"),
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			m := new(mockFileSystem)
+			tc.mockClosure(m)
+			fsProvider = m
+			content, err := Export("some/path", tc.format)
+			if err != nil {
+				if tc.expectedError == nil {
+					t.Fatalf("expected no error, got %v", err)
+				}
+				require.Equal(t, tc.expectedError.Error(), err.Error())
+			} else {
+				if tc.expectedError != nil {
+					t.Fatalf("expected error to be %v, got nil", tc.expectedError)
+				}
+				require.Equal(t, tc.expectedContent, string(content))
+			}
+		})
+	}
+}
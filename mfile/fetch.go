@@ -0,0 +1,226 @@
+// Copyright (c) 2023 Tiago Melo. All rights reserved.
+// Use of this source code is governed by the MIT License that can be found in
+// the LICENSE file.
+
+package mfile
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// templateFileName is the name of the template file looked up when a
+// template source is a directory.
+const templateFileName = "Makefile.tmpl"
+
+// maxTemplateSize is the size, in bytes, of the largest template fetched
+// over HTTP.
+const maxTemplateSize = 1 << 20
+
+// Kinds of template sources.
+const (
+	sourceLocal = "local"
+	sourceHTTP  = "http"
+	sourceGit   = "git"
+)
+
+// gitHosts are the hosts whose sources are fetched with git even without
+// a scheme, like github.com/org/repo.
+var gitHosts = []string{"github.com/", "gitlab.com/", "bitbucket.org/"}
+
+// For ease of unit testing.
+var (
+	// httpClient is the client used to fetch templates over HTTP.
+	httpClient = &http.Client{Timeout: 30 * time.Second}
+
+	// runGit runs git with the given arguments in the given directory.
+	runGit = func(dir string, args ...string) error {
+		cmd := exec.Command("git", args...)
+		cmd.Dir = dir
+		if out, err := cmd.CombinedOutput(); err != nil {
+			return fmt.Errorf("git %s: %s", args[0], strings.TrimSpace(string(out)))
+		}
+		return nil
+	}
+)
+
+// templateSource is the location of a Makefile template.
+type templateSource struct {
+	kind   string // One of sourceLocal, sourceHTTP or sourceGit.
+	url    string // Path, URL or git repository.
+	subdir string // Path of the template in the git repository.
+	ref    string // Git branch, tag or commit to fetch.
+}
+
+// parseTemplateSource parses a template source, go-getter style:
+//
+//	./templates/Makefile.tmpl                       local file or directory
+//	https://example.com/Makefile.tmpl               file fetched over HTTP
+//	github.com/org/templates//go-service?ref=v1.2.0 directory in a git repository
+//	git::https://example.com/templates.git//go      same, with an explicit git scheme
+func parseTemplateSource(src string) (templateSource, error) {
+	s := src
+	forceGit := strings.HasPrefix(s, "git::")
+	s = strings.TrimPrefix(s, "git::")
+	var ts templateSource
+	if i := strings.Index(s, "?"); i >= 0 {
+		query, err := url.ParseQuery(s[i+1:])
+		if err != nil {
+			return ts, fmt.Errorf("parsing template source %s: %w", src, err)
+		}
+		ts.ref = query.Get("ref")
+		s = s[:i]
+	}
+	start := 0
+	if i := strings.Index(s, "://"); i >= 0 {
+		start = i + len("://")
+	}
+	if i := strings.Index(s[start:], "//"); i >= 0 {
+		ts.subdir = s[start+i+len("//"):]
+		s = s[:start+i]
+	}
+	ts.url = s
+	switch {
+	case forceGit, strings.HasPrefix(s, "git@"), strings.HasPrefix(s, "ssh://"):
+		ts.kind = sourceGit
+	case strings.HasPrefix(s, "http://"), strings.HasPrefix(s, "https://"):
+		ts.kind = sourceHTTP
+		if strings.HasSuffix(s, ".git") {
+			ts.kind = sourceGit
+		}
+	default:
+		ts.kind = sourceLocal
+		for _, host := range gitHosts {
+			if strings.HasPrefix(s, host) {
+				ts.kind, ts.url = sourceGit, "https://"+s
+			}
+		}
+	}
+	if ts.kind != sourceGit && (ts.ref != "" || ts.subdir != "") {
+		return ts, fmt.Errorf("invalid template source %s: ref and subdirectory are only supported for git sources", src)
+	}
+	if ts.subdir != "" && !filepath.IsLocal(filepath.FromSlash(ts.subdir)) {
+		return ts, fmt.Errorf("invalid template source %s: subdirectory must be a relative path within the repository", src)
+	}
+	return ts, nil
+}
+
+// loadTemplate returns the content of the template with the given name,
+// from the local cache, or fetches it from the given source if it is not
+// the name of a registered template, reading local files from the given
+// file system.
+func loadTemplate(fsys fileSystem, src string) (string, error) {
+	content, ok, err := cachedTemplate(fsys, src)
+	if err != nil || ok {
+		return content, err
+	}
+	return fetchTemplate(fsys, src)
+}
+
+// fetchTemplate returns the content of the template at the given source,
+// reading local files from the given file system. See
+// parseTemplateSource for the supported sources.
+func fetchTemplate(fsys fileSystem, src string) (string, error) {
+	ts, err := parseTemplateSource(src)
+	if err != nil {
+		return "", err
+	}
+	logger.Debug("fetching template", "source", src, "kind", ts.kind)
+	switch ts.kind {
+	case sourceHTTP:
+		return fetchHTTPTemplate(ts.url)
+	case sourceGit:
+		return fetchGitTemplate(ts)
+	default:
+		return readTemplate(fsys, ts.url)
+	}
+}
+
+// fetchHTTPTemplate returns the content of the template at the given URL.
+// It fails if the template is larger than maxTemplateSize.
+func fetchHTTPTemplate(u string) (string, error) {
+	resp, err := httpClient.Get(u)
+	if err != nil {
+		return "", fmt.Errorf("fetching template %s: %w", u, err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return "", fmt.Errorf("fetching template %s: %s", u, resp.Status)
+	}
+	var buf bytes.Buffer
+	if _, err := io.Copy(&buf, io.LimitReader(resp.Body, maxTemplateSize+1)); err != nil {
+		return "", fmt.Errorf("fetching template %s: %w", u, err)
+	}
+	if buf.Len() > maxTemplateSize {
+		return "", fmt.Errorf("fetching template %s: larger than %d bytes", u, maxTemplateSize)
+	}
+	return buf.String(), nil
+}
+
+// fetchGitTemplate fetches the given ref, or the default branch, of the
+// git repository of the given source in a temporary directory, and
+// returns the content of the template in its subdirectory.
+func fetchGitTemplate(ts templateSource) (string, error) {
+	dir, err := os.MkdirTemp("", "gomakefile-")
+	if err != nil {
+		return "", fmt.Errorf("creating temporary directory: %w", err)
+	}
+	defer os.RemoveAll(dir)
+	ref := ts.ref
+	if ref == "" {
+		ref = "HEAD"
+	}
+	for _, args := range [][]string{
+		{"init", "-q"},
+		{"fetch", "-q", "--depth", "1", ts.url, ref},
+		{"checkout", "-q", "FETCH_HEAD"},
+	} {
+		if err := runGit(dir, args...); err != nil {
+			return "", fmt.Errorf("fetching template from %s at %s: %w", ts.url, ref, err)
+		}
+	}
+	// The repository is fetched on disk.
+	return readTemplate(fsProvider, filepath.Join(dir, filepath.FromSlash(ts.subdir)))
+}
+
+// readTemplate returns the content of the template at the given path in
+// the given file system. If path is a directory, the template is its
+// Makefile.tmpl file.
+func readTemplate(fsys fileSystem, path string) (string, error) {
+	if fi, err := fsys.Stat(path); err == nil && fsys.IsDir(fi) {
+		path = filepath.Join(path, templateFileName)
+	}
+	content, err := fsys.ReadFile(path)
+	if err != nil {
+		return "", &Error{Op: "reading template", Path: path, Err: err}
+	}
+	return string(content), nil
+}
+
+// executeTemplate executes the given Makefile template with the Module
+// and AppName values derived from the go.mod file in the given directory
+// of the given file system, and the given values as Values.
+func executeTemplate(fsys fileSystem, text, dir string, values map[string]any) (string, error) {
+	tmplExecutor, err := templateProcessorProvider.Parse("Makefile", text)
+	if err != nil {
+		return "", fmt.Errorf("parsing template: %w", err)
+	}
+	module, err := modulePath(fsys, dir)
+	if err != nil {
+		return "", err
+	}
+	var buf bytes.Buffer
+	data := map[string]any{"Module": module, "AppName": appName(module), "Values": values}
+	if err := tmplExecutor.Execute(&buf, data); err != nil {
+		return "", fmt.Errorf("executing template: %w", err)
+	}
+	return buf.String(), nil
+}
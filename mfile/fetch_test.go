@@ -0,0 +1,183 @@
+// Copyright (c) 2023 Tiago Melo. All rights reserved.
+// Use of this source code is governed by the MIT License that can be found in
+// the LICENSE file.
+
+package mfile
+
+import (
+	"bytes"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseTemplateSource(t *testing.T) {
+	testCases := []struct {
+		name           string
+		source         string
+		expectedSource templateSource
+		expectedError  error
+	}{
+		{
+			name:           "local file",
+			source:         "templates/Makefile.tmpl",
+			expectedSource: templateSource{kind: sourceLocal, url: "templates/Makefile.tmpl"},
+		},
+		{
+			name:           "URL",
+			source:         "https://example.com/templates/Makefile.tmpl",
+			expectedSource: templateSource{kind: sourceHTTP, url: "https://example.com/templates/Makefile.tmpl"},
+		},
+		{
+			name:           "git host with subdirectory and ref",
+			source:         "github.com/org/makefile-templates//go-service?ref=v1.2.0",
+			expectedSource: templateSource{kind: sourceGit, url: "https://github.com/org/makefile-templates", subdir: "go-service", ref: "v1.2.0"},
+		},
+		{
+			name:           "git URL",
+			source:         "https://example.com/org/templates.git//go",
+			expectedSource: templateSource{kind: sourceGit, url: "https://example.com/org/templates.git", subdir: "go"},
+		},
+		{
+			name:           "forced git",
+			source:         "git::file:///srv/templates//go?ref=main",
+			expectedSource: templateSource{kind: sourceGit, url: "file:///srv/templates", subdir: "go", ref: "main"},
+		},
+		{
+			name:           "ssh",
+			source:         "git@github.com:org/templates.git",
+			expectedSource: templateSource{kind: sourceGit, url: "git@github.com:org/templates.git"},
+		},
+		{
+			name:          "subdirectory escaping the repository",
+			source:        "github.com/org/templates//../../etc",
+			expectedError: errors.New("invalid template source github.com/org/templates//../../etc: subdirectory must be a relative path within the repository"),
+		},
+		{
+			name:          "absolute subdirectory",
+			source:        "git::file:///srv/templates///etc",
+			expectedError: errors.New("invalid template source git::file:///srv/templates///etc: subdirectory must be a relative path within the repository"),
+		},
+		{
+			name:          "ref on a local source",
+			source:        "templates?ref=v1",
+			expectedError: errors.New("invalid template source templates?ref=v1: ref and subdirectory are only supported for git sources"),
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			source, err := parseTemplateSource(tc.source)
+			if err != nil {
+				if tc.expectedError == nil {
+					t.Fatalf("expected no error, got %v", err)
+				}
+				require.Equal(t, tc.expectedError.Error(), err.Error())
+			} else {
+				if tc.expectedError != nil {
+					t.Fatalf("expected error to be %v, got nil", tc.expectedError)
+				}
+				require.Equal(t, tc.expectedSource, source)
+			}
+		})
+	}
+}
+
+func TestFetchTemplate(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/Makefile.tmpl":
+			w.Write([]byte("build:\n"))
+		case "/large.tmpl":
+			w.Write(bytes.Repeat([]byte("#\n"), maxTemplateSize/2+1))
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer server.Close()
+	repo := newTemplateRepo(t)
+	testCases := []struct {
+		name             string
+		source           string
+		expectedTemplate string
+		expectedError    error
+	}{
+		{
+			name:             "local directory",
+			source:           filepath.Join(repo, "go"),
+			expectedTemplate: "deploy:\n",
+		},
+		{
+			name:             "URL",
+			source:           server.URL + "/Makefile.tmpl",
+			expectedTemplate: "build:\n",
+		},
+		{
+			name:          "URL not found",
+			source:        server.URL + "/missing.tmpl",
+			expectedError: errors.New("fetching template " + server.URL + "/missing.tmpl: 404 Not Found"),
+		},
+		{
+			name:          "URL of a template too large",
+			source:        server.URL + "/large.tmpl",
+			expectedError: errors.New("fetching template " + server.URL + "/large.tmpl: larger than 1048576 bytes"),
+		},
+		{
+			name:             "git repository at a tag",
+			source:           "git::file://" + repo + "//go?ref=v1.0.0",
+			expectedTemplate: "release:\n",
+		},
+		{
+			name:             "git repository at its default branch",
+			source:           "git::file://" + repo + "//go",
+			expectedTemplate: "deploy:\n",
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			fsProvider = osFileSystem{}
+			template, err := fetchTemplate(fsProvider, tc.source)
+			if err != nil {
+				if tc.expectedError == nil {
+					t.Fatalf("expected no error, got %v", err)
+				}
+				require.Equal(t, tc.expectedError.Error(), err.Error())
+			} else {
+				if tc.expectedError != nil {
+					t.Fatalf("expected error to be %v, got nil", tc.expectedError)
+				}
+				require.Equal(t, tc.expectedTemplate, template)
+			}
+		})
+	}
+}
+
+// newTemplateRepo creates a git repository with a go/Makefile.tmpl
+// template, tagged v1.0.0 at its first version.
+func newTemplateRepo(t *testing.T) string {
+	t.Helper()
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skip("git is not installed")
+	}
+	dir := t.TempDir()
+	require.NoError(t, os.Mkdir(filepath.Join(dir, "go"), 0755))
+	git := func(args ...string) {
+		args = append([]string{"-c", "user.name=gopher", "-c", "user.email=gopher@example.com"}, args...)
+		require.NoError(t, runGit(dir, args...))
+	}
+	commit := func(content string) {
+		require.NoError(t, os.WriteFile(filepath.Join(dir, "go", templateFileName), []byte(content), 0644))
+		git("add", ".")
+		git("commit", "-q", "-m", "update template")
+	}
+	git("init", "-q")
+	commit("release:\n")
+	git("tag", "v1.0.0")
+	commit("deploy:\n")
+	return dir
+}
@@ -0,0 +1,63 @@
+// Copyright (c) 2023 Tiago Melo. All rights reserved.
+// Use of this source code is governed by the MIT License that can be found in
+// the LICENSE file.
+
+package mfile
+
+import "fmt"
+
+// Names of the built-in flavors.
+const (
+	FlavorMinimal  = "minimal"
+	FlavorStandard = "standard"
+	FlavorLibrary  = "library"
+	FlavorFull     = "full"
+)
+
+// Flavor is a base skeleton of the generated Makefile, more or less
+// opinionated, which the selected presets are added to.
+type Flavor struct {
+	Name        string   // Name used to select the flavor.
+	Description string   // Short description of the flavor.
+	Presets     []string // Presets the skeleton is made of.
+}
+
+// flavors are the built-in flavors, from the least to the most opinionated.
+var flavors = []Flavor{
+	{
+		Name:        FlavorMinimal,
+		Description: "help target only",
+		Presets:     []string{PresetHelp},
+	},
+	{
+		Name:        FlavorStandard,
+		Description: "help, test and coverage targets; the default",
+		Presets:     []string{PresetMinimal},
+	},
+	{
+		Name:        FlavorLibrary,
+		Description: "standard, plus vet, fmt and tidy targets for Go libraries",
+		Presets:     []string{PresetGoLibrary},
+	},
+	{
+		Name:        FlavorFull,
+		Description: "standard, plus build, lint and clean targets, along with the rest of the go preset",
+		Presets:     []string{PresetMinimal, PresetGo, PresetLint, PresetClean},
+	},
+}
+
+// Flavors returns the built-in flavors, from the least to the most
+// opinionated.
+func Flavors() []Flavor {
+	return append([]Flavor(nil), flavors...)
+}
+
+// flavor returns the built-in flavor with the given name.
+func flavor(name string) (Flavor, error) {
+	for _, f := range flavors {
+		if f.Name == name {
+			return f, nil
+		}
+	}
+	return Flavor{}, fmt.Errorf("unknown flavor %q", name)
+}
@@ -0,0 +1,51 @@
+// Copyright (c) 2023 Tiago Melo. All rights reserved.
+// Use of this source code is governed by the MIT License that can be found in
+// the LICENSE file.
+
+package mfile
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestFlavors(t *testing.T) {
+	testCases := []struct {
+		flavor          string
+		expectedTargets []string
+	}{
+		{
+			flavor:          FlavorMinimal,
+			expectedTargets: []string{"help"},
+		},
+		{
+			flavor:          FlavorStandard,
+			expectedTargets: []string{"help", "test", "coverage"},
+		},
+		{
+			flavor:          FlavorLibrary,
+			expectedTargets: []string{"help", "test", "coverage", "vet", "fmt", "tidy"},
+		},
+		{
+			flavor:          FlavorFull,
+			expectedTargets: []string{"help", "test", "coverage", "build", "run", "vet", "fmt", "tidy", "install-golangci-lint", "lint", "clean", "distclean"},
+		},
+	}
+	require.Len(t, Flavors(), len(testCases))
+	for i, tc := range testCases {
+		t.Run(tc.flavor, func(t *testing.T) {
+			require.Equal(t, tc.flavor, Flavors()[i].Name)
+			fsProvider = &mockFileSystem{isNotExistOutput: true, files: map[string][]byte{}}
+			f, err := flavor(tc.flavor)
+			require.NoError(t, err)
+			_, targets, err := resolvePresets("some", f.Presets, nil)
+			require.NoError(t, err)
+			var names []string
+			for _, target := range targets {
+				names = append(names, target.Name)
+			}
+			require.Equal(t, tc.expectedTargets, names)
+		})
+	}
+}
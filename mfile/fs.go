@@ -5,8 +5,10 @@
 package mfile
 
 import (
+	"io"
 	"io/fs"
 	"os"
+	"path/filepath"
 )
 
 // fileSystem interface abstracts the file system operations. This allows
@@ -19,6 +21,18 @@ type fileSystem interface {
 	WriteFile(name string, data []byte, perm fs.FileMode) error
 	IsNotExist(err error) bool
 	IsDir(fi fs.FileInfo) bool
+	ReadDir(name string) ([]os.DirEntry, error)
+	MkdirAll(path string, perm os.FileMode) error
+	Remove(name string) error
+
+	// Open opens the named file for reading, so that it can be streamed
+	// rather than read at once.
+	Open(name string) (io.ReadCloser, error)
+
+	// WriteFileFrom writes the content read from r to the named file,
+	// replacing it at once once all of it is written, so that the file is
+	// never left half written and r may read from it.
+	WriteFileFrom(name string, r io.Reader, perm fs.FileMode) error
 }
 
 // osFileSystem struct implements the fileSystem interface using
@@ -49,3 +63,45 @@ func (osFileSystem) IsNotExist(err error) bool {
 func (osFileSystem) IsDir(fi fs.FileInfo) bool {
 	return fi.IsDir()
 }
+
+func (osFileSystem) ReadDir(name string) ([]os.DirEntry, error) {
+	return os.ReadDir(name)
+}
+
+func (osFileSystem) MkdirAll(path string, perm os.FileMode) error {
+	return os.MkdirAll(path, perm)
+}
+
+func (osFileSystem) Remove(name string) error {
+	return os.Remove(name)
+}
+
+func (osFileSystem) Open(name string) (io.ReadCloser, error) {
+	return os.Open(name)
+}
+
+func (osFileSystem) WriteFileFrom(name string, r io.Reader, perm fs.FileMode) error {
+	// A symbolic link is kept, the file it points to being replaced.
+	if target, err := filepath.EvalSymlinks(name); err == nil {
+		name = target
+	}
+	if fi, err := os.Stat(name); err == nil {
+		perm = fi.Mode().Perm()
+	}
+	tmp, err := os.CreateTemp(filepath.Dir(name), "."+filepath.Base(name)+".tmp-*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+	if _, err := io.Copy(tmp, r); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	if err := os.Chmod(tmp.Name(), perm); err != nil {
+		return err
+	}
+	return os.Rename(tmp.Name(), name)
+}
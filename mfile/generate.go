@@ -0,0 +1,698 @@
+// Copyright (c) 2023 Tiago Melo. All rights reserved.
+// Use of this source code is governed by the MIT License that can be found in
+// the LICENSE file.
+
+package mfile
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"path/filepath"
+	"strings"
+)
+
+// generateOptions holds the options used by Generate.
+type generateOptions struct {
+	overwrite    bool
+	flavor       string
+	helpStyle    string
+	windows      bool
+	dialect      string
+	recipePrefix string
+	presets      []string
+	parameters   map[string]string
+	autoDetect   bool
+	recursive    bool
+	fragmentsDir string
+	envFile      string
+	template     string
+	templateText string
+	values       map[string]any
+	parallel     string
+	notParallel  bool
+	strict       bool
+	shell        string
+	shellFlags   string
+	makeFlags    []string
+	managed      bool
+}
+
+// GenerateOption configures how a Makefile is generated.
+type GenerateOption func(*generateOptions)
+
+// WithOverwrite makes Generate overwrite the existing Makefile,
+// instead of prepending the generated content to it.
+func WithOverwrite(overwrite bool) GenerateOption {
+	return func(o *generateOptions) {
+		o.overwrite = overwrite
+	}
+}
+
+// WithFlavor selects the flavor, see Flavors, the Makefile is based on.
+// The content of the presets selected with WithPresets, if any, follows
+// the content of the flavor. Without a flavor, the standard one is used
+// when no preset is selected.
+func WithFlavor(name string) GenerateOption {
+	return func(o *generateOptions) {
+		o.flavor = name
+	}
+}
+
+// WithHelpStyle selects the implementation of the help target, see
+// HelpStyles. Defaults to HelpStyleAWK.
+func WithHelpStyle(style string) GenerateOption {
+	return func(o *generateOptions) {
+		o.helpStyle = style
+	}
+}
+
+// WithWindows makes the generated Makefile work under Windows too: the
+// recipes of the built-in presets relying on a POSIX shell, or building
+// binaries without the .exe suffix, get a Windows variant, and the help
+// target defaults to HelpStyleInfo, which does not rely on sed nor awk.
+func WithWindows(windows bool) GenerateOption {
+	return func(o *generateOptions) {
+		o.windows = windows
+	}
+}
+
+// WithDialect selects the dialect of make, see Dialects, the Makefile
+// targets. Defaults to DialectGNU. With DialectPOSIX, the content of the
+// presets is rewritten with POSIX constructs where possible, variants
+// differing per operating system are dropped, and the GNU-only constructs
+// left are logged as warnings.
+func WithDialect(dialect string) GenerateOption {
+	return func(o *generateOptions) {
+		o.dialect = dialect
+	}
+}
+
+// WithRecipePrefix makes the generated Makefile start its recipe lines
+// with the given character, like ">", instead of a tab, declaring it with
+// .RECIPEPREFIX. Only GNU make supports it.
+func WithRecipePrefix(prefix string) GenerateOption {
+	return func(o *generateOptions) {
+		o.recipePrefix = prefix
+	}
+}
+
+// WithPresets selects the presets used to generate the Makefile.
+// Each name may also be a comma-separated list of presets, which are
+// composed in order. Defaults to the minimal preset.
+func WithPresets(names ...string) GenerateOption {
+	return func(o *generateOptions) {
+		for _, n := range names {
+			o.presets = append(o.presets, strings.Split(n, ",")...)
+		}
+	}
+}
+
+// WithParameter sets a generation-time parameter of the selected presets,
+// overriding its default value.
+func WithParameter(name, value string) GenerateOption {
+	return func(o *generateOptions) {
+		if o.parameters == nil {
+			o.parameters = make(map[string]string)
+		}
+		o.parameters[name] = value
+	}
+}
+
+// WithAutoDetect adds the presets detected from the project, as returned
+// by Detect, to the selected ones. Each detection is logged at info level.
+func WithAutoDetect(autoDetect bool) GenerateOption {
+	return func(o *generateOptions) {
+		o.autoDetect = autoDetect
+	}
+}
+
+// WithRecursive makes Generate create a Makefile in each module found
+// under the given path, as returned by Modules, and a root Makefile whose
+// targets run the targets of the same name in every module declaring it.
+func WithRecursive(recursive bool) GenerateOption {
+	return func(o *generateOptions) {
+		o.recursive = recursive
+	}
+}
+
+// WithFragments makes Generate write the content of each preset to its
+// own fragment file, <dir>/<preset>.mk, relative to the Makefile, and the
+// Makefile include them. Unless WithOverwrite is set, existing fragment
+// files are kept as they are.
+func WithFragments(dir string) GenerateOption {
+	return func(o *generateOptions) {
+		o.fragmentsDir = dir
+	}
+}
+
+// WithEnvFile makes the generated Makefile load the environment file at
+// the given path, like .env, relative to the directory make runs in, if
+// it exists, and export all the variables to the recipes. The path is the
+// default value of an ENV_FILE variable, so that another file can be
+// loaded with make ENV_FILE=.env.test. As it is loaded first, the file
+// can override the default values of the variables of the presets.
+// Only DialectGNU supports it.
+func WithEnvFile(path string) GenerateOption {
+	return func(o *generateOptions) {
+		o.envFile = path
+	}
+}
+
+// WithTemplate generates the Makefile from the template at the given
+// source: a local file or directory, a URL, or a directory in a git
+// repository, like github.com/org/templates//go-service?ref=v1.2.0, or
+// the name of a template registered with AddTemplate, read from the cache.
+// When the source is a directory, the template is its Makefile.tmpl file.
+// The template can use the Module and AppName values derived from go.mod.
+// The content of the presets selected with WithPresets or WithAutoDetect,
+// if any, follows the content of the template.
+func WithTemplate(source string) GenerateOption {
+	return func(o *generateOptions) {
+		o.template = source
+	}
+}
+
+// WithValues sets arbitrary values, like a registry URL or a team name,
+// that the template set with WithTemplate can use as {{ .Values.<key> }}.
+// See ReadValues to read them from a file.
+func WithValues(values map[string]any) GenerateOption {
+	return func(o *generateOptions) {
+		o.values = values
+	}
+}
+
+// defaultHelpStyle returns the selected help style or, if none is,
+// the one working on the selected platforms.
+func (o *generateOptions) defaultHelpStyle() string {
+	if o.helpStyle == "" && o.windows {
+		return HelpStyleInfo
+	}
+	return o.helpStyle
+}
+
+// syntax returns the syntax the Makefile is rendered with.
+func (o *generateOptions) syntax() syntax {
+	return syntax{dialect: o.dialect, recipePrefix: o.recipePrefix}
+}
+
+// Generate creates or updates a Makefile at the specified path,
+// according to the given options.
+func Generate(path string, opts ...GenerateOption) error {
+	return generate(fsProvider, mkFilePath(path), opts...)
+}
+
+// generate is like Generate, for the Makefile at the given path of the
+// given file system, which the project is read from and the Makefile
+// written to.
+func generate(fsys fileSystem, makeFilePath string, opts ...GenerateOption) error {
+	o := new(generateOptions)
+	for _, opt := range opts {
+		opt(o)
+	}
+	if o.dialect == "" {
+		o.dialect = DialectGNU
+	}
+	if err := validateDialect(o); err != nil {
+		return err
+	}
+	if err := validateParallel(o); err != nil {
+		return err
+	}
+	if err := validateShell(o); err != nil {
+		return err
+	}
+	if err := validateManaged(o); err != nil {
+		return err
+	}
+	if o.template != "" {
+		text, err := loadTemplate(fsys, o.template)
+		if err != nil {
+			return err
+		}
+		o.templateText = text
+	}
+	if o.recursive {
+		return generateRecursive(fsys, makeFilePath, o)
+	}
+	_, err := generateMakefile(fsys, makeFilePath, o)
+	return err
+}
+
+// generateMakefile creates or updates the Makefile at the given path of
+// the given file system from the selected presets, and returns the
+// targets it declares.
+func generateMakefile(fsys fileSystem, makeFilePath string, o *generateOptions) ([]Target, error) {
+	dir := filepath.Dir(makeFilePath)
+	presets, params := o.presets, o.parameters
+	if o.autoDetect {
+		detections, err := detectPresets(fsys, dir)
+		if err != nil {
+			return nil, err
+		}
+		presets = append([]string{}, presets...)
+		params = make(map[string]string)
+		for _, d := range detections {
+			logger.Info("detected preset", "path", dir, "preset", d.Preset, "reason", d.Reason)
+			presets = append(presets, d.Preset)
+			for k, v := range d.Parameters {
+				params[k] = v
+			}
+		}
+		for k, v := range o.parameters {
+			params[k] = v
+		}
+	}
+	spec := o.spec(presets, params)
+	write := func(content string) error {
+		if o.managed {
+			var err error
+			if content, err = managedBlock(spec, content); err != nil {
+				return err
+			}
+		}
+		return writeMakefile(fsys, makeFilePath, content, o.overwrite)
+	}
+	var base []string
+	if o.flavor != "" {
+		f, err := flavor(o.flavor)
+		if err != nil {
+			return nil, err
+		}
+		base = f.Presets
+	}
+	var templateContent string
+	if o.template != "" {
+		var err error
+		if templateContent, err = executeTemplate(fsys, o.templateText, dir, o.values); err != nil {
+			return nil, err
+		}
+		if len(base)+len(presets) == 0 {
+			if block := parallelBlock(o); block != "" {
+				templateContent = block + "\n" + templateContent
+			}
+			if o.envFile != "" {
+				templateContent = envFileBlock(o.envFile) + "\n" + templateContent
+			}
+			if block := shellBlock(o); block != "" {
+				templateContent = block + "\n" + templateContent
+			}
+			if err := write(templateContent); err != nil {
+				return nil, err
+			}
+			return parseTargets(templateContent), nil
+		}
+	}
+	if len(base)+len(presets) == 0 {
+		base = []string{PresetMinimal}
+	}
+	r, err := resolve(fsys, dir, base, presets, params)
+	if err != nil {
+		return nil, err
+	}
+	logger.Debug("resolved presets", "presets", strings.Join(r.presets, ","), "targets", len(r.targets))
+	if err := applyHelpStyle(o.defaultHelpStyle(), r.targets); err != nil {
+		return nil, err
+	}
+	if o.windows {
+		applyWindows(r.targets)
+	}
+	r.variables, r.targets = toDialect(o.dialect, r.variables, r.targets)
+	content := renderSyntax(o.syntax(), r.variables, r.targets)
+	warnUnsupported(makeFilePath, o, content)
+	if o.fragmentsDir != "" {
+		if content, err = writeFragments(fsys, dir, r, o); err != nil {
+			return nil, err
+		}
+	}
+	if templateContent != "" {
+		content = templateContent + "\n" + content
+	}
+	if block := parallelBlock(o); block != "" {
+		content = block + "\n" + content
+	}
+	if o.envFile != "" {
+		content = envFileBlock(o.envFile) + "\n" + content
+	}
+	if block := shellBlock(o); block != "" {
+		content = block + "\n" + content
+	}
+	if err := write(content); err != nil {
+		return nil, err
+	}
+	return append(parseTargets(templateContent), r.targets...), nil
+}
+
+// envFileBlock returns the lines loading the environment file at the
+// given path, if it exists, and exporting all the variables.
+func envFileBlock(path string) string {
+	return "ENV_FILE ?= " + path + "\n-include $(ENV_FILE)\nexport\n"
+}
+
+// warnUnsupported logs the constructs of the given content generated for
+// the Makefile at the given path that the selected dialect does not support.
+func warnUnsupported(makeFilePath string, o *generateOptions, content string) {
+	for _, issue := range unsupported(o.dialect, content) {
+		logger.Warn("construct not supported by the dialect", "path", makeFilePath, "dialect", o.dialect, "issue", issue)
+	}
+}
+
+// writeFragments writes the variables and targets declared by each of the
+// resolved presets to its own fragment file, in the fragments directory
+// of the given Makefile directory of the given file system, and returns
+// the Makefile content including them.
+func writeFragments(fsys fileSystem, dir string, r *resolution, o *generateOptions) (string, error) {
+	fragmentsDir := filepath.Join(dir, o.fragmentsDir)
+	if err := fsys.MkdirAll(fragmentsDir, 0755); err != nil {
+		return "", fmt.Errorf("creating fragments directory %s: %w", fragmentsDir, err)
+	}
+	var sb strings.Builder
+	for _, preset := range r.presets {
+		var (
+			variables []Variable
+			targets   []Target
+		)
+		for i, v := range r.variables {
+			if r.variableOwners[i] == preset {
+				variables = append(variables, v)
+			}
+		}
+		for i, t := range r.targets {
+			if r.targetOwners[i] == preset {
+				targets = append(targets, t)
+			}
+		}
+		if len(variables) == 0 && len(targets) == 0 {
+			continue
+		}
+		fragment := filepath.Join(o.fragmentsDir, preset+".mk")
+		sb.WriteString(includeDirective(o.dialect, filepath.ToSlash(fragment)) + "\n")
+		fragmentPath := filepath.Join(dir, fragment)
+		if !o.overwrite && fileExists(fsys, fragmentPath) {
+			logger.Debug("keeping existing fragment", "path", fragmentPath)
+			continue
+		}
+		content := renderSyntax(o.syntax(), variables, targets)
+		if err := fsys.WriteFile(fragmentPath, []byte(content), 0644); err != nil {
+			return "", &Error{Op: "writing fragment", Path: fragmentPath, Err: err}
+		}
+		logger.Debug("wrote fragment", "path", fragmentPath, "bytes", len(content))
+	}
+	if o.recipePrefix != "" {
+		// Keeps the recipe prefix of the included fragments in effect for
+		// the targets later added to the Makefile itself.
+		sb.WriteString(recipePrefixDeclaration(o.recipePrefix))
+	}
+	return sb.String(), nil
+}
+
+// writeMakefile writes the given content to the Makefile at the given
+// path of the given file system. Unless overwrite, the content is
+// prepended to the existing one, restoring the tab recipe prefix before
+// it if the content changes it.
+func writeMakefile(fsys fileSystem, makeFilePath, content string, overwrite bool) error {
+	if !overwrite {
+		existing, err := fsys.Open(makeFilePath)
+		if err != nil && !fsys.IsNotExist(err) {
+			return &Error{Op: "reading Makefile", Path: makeFilePath, Err: err}
+		}
+		if err == nil {
+			defer existing.Close()
+			return prependMakefile(fsys, makeFilePath, content, existing)
+		}
+	}
+	if err := fsys.WriteFile(makeFilePath, []byte(content), 0644); err != nil {
+		return &Error{Op: "writing Makefile", Path: makeFilePath, Err: err}
+	}
+	logger.Debug("wrote Makefile", "path", makeFilePath, "bytes", len(content))
+	return nil
+}
+
+// prependMakefile writes the given content to the Makefile at the given
+// path of the given file system, followed by its existing content, read
+// from the given reader. The existing content is streamed rather than
+// read at once, so that the memory used doesn't grow with the size of the
+// Makefile.
+func prependMakefile(fsys fileSystem, makeFilePath, content string, existing io.Reader) error {
+	logger.Debug("prepending to Makefile", "path", makeFilePath)
+	r := bufio.NewReader(existing)
+	if _, err := r.Peek(1); err == nil && recipePrefixAt(content) != "\t" {
+		content += recipePrefixDirective + " =\n"
+	}
+	text := &textReader{r: r}
+	err := fsys.WriteFileFrom(makeFilePath, io.MultiReader(strings.NewReader(content), text), 0644)
+	switch {
+	case text.err != nil:
+		return &Error{Op: "reading Makefile", Path: makeFilePath, Err: text.err}
+	case err != nil:
+		return &Error{Op: "writing Makefile", Path: makeFilePath, Err: err}
+	}
+	logger.Debug("wrote Makefile", "path", makeFilePath, "prepended", len(content))
+	return nil
+}
+
+// generateRecursive creates a Makefile in each module found in the
+// directory of the given root Makefile of the given file system, then the
+// root Makefile itself, delegating its targets to the modules with
+// $(MAKE) -C.
+func generateRecursive(fsys fileSystem, rootMakefile string, o *generateOptions) error {
+	root := filepath.Dir(rootMakefile)
+	modules, err := findModules(fsys, root)
+	if err != nil {
+		return err
+	}
+	if len(modules) == 0 {
+		return fmt.Errorf("no module found under %s", root)
+	}
+	var (
+		names []string
+		dirs  = make(map[string][]string)
+	)
+	for _, m := range modules {
+		targets, err := generateMakefile(fsys, filepath.Join(root, m, makefileName), o)
+		if err != nil {
+			return fmt.Errorf("generating Makefile for %s: %w", m, err)
+		}
+		for _, t := range targets {
+			if t.Name == helpTarget.Name {
+				continue
+			}
+			if _, ok := dirs[t.Name]; !ok {
+				names = append(names, t.Name)
+			}
+			dirs[t.Name] = append(dirs[t.Name], m)
+		}
+	}
+	targets := []Target{helpTarget}
+	for _, name := range names {
+		var recipe []string
+		for _, dir := range dirs[name] {
+			recipe = append(recipe, "@ $(MAKE) -C "+dir+" "+name)
+		}
+		targets = append(targets, Target{
+			Name:        name,
+			Description: "run " + name + " in " + strings.Join(dirs[name], ", "),
+			Recipe:      recipe,
+			Phony:       true,
+		})
+	}
+	if err := applyHelpStyle(o.defaultHelpStyle(), targets); err != nil {
+		return err
+	}
+	_, targets = toDialect(o.dialect, nil, targets)
+	return writeMakefile(fsys, rootMakefile, renderSyntax(o.syntax(), nil, targets), o.overwrite)
+}
+
+// syntax is the syntax a Makefile is rendered with.
+type syntax struct {
+	dialect      string // Dialect of make, see Dialects.
+	recipePrefix string // Character starting the recipe lines, instead of a tab.
+}
+
+// render returns the Makefile content declaring the given variables
+// followed by the given targets, for GNU make. See renderSyntax.
+func render(variables []Variable, targets []Target) string {
+	return renderSyntax(syntax{dialect: DialectGNU}, variables, targets)
+}
+
+// renderSyntax returns the Makefile content declaring the given variables
+// followed by the given targets, in the given syntax, with a
+// "##@ Section" comment before each target starting a new section, see
+// targetSection. The content starts with the .RECIPEPREFIX declaration if
+// the syntax has a recipe prefix then, if some of them differ per
+// operating system, with the block detecting it.
+// The content is deterministic: the variables and targets are normalized
+// first, see normalizeVariable and normalizeTarget, and the variants per
+// operating system are sorted, so that models differing only in spacing
+// or map ordering render the same bytes, and regenerating from a spec can
+// be checked with a checksum.
+func renderSyntax(s syntax, variables []Variable, targets []Target) string {
+	variables, targets = normalize(variables, targets)
+	var sb strings.Builder
+	if s.recipePrefix != "" {
+		sb.WriteString(recipePrefixDeclaration(s.recipePrefix) + "\n")
+	}
+	if needsOSDetection(variables, targets) {
+		sb.WriteString(osDetections[s.dialect] + "\n")
+	}
+	for _, v := range variables {
+		op := v.Operator
+		if op == "" {
+			op = "?="
+		}
+		name := v.Name
+		if v.Export {
+			name = "export " + name
+		}
+		assignment := func(value string) []string {
+			return []string{strings.TrimSpace(name + " " + op + " " + value)}
+		}
+		if len(v.OSValues) > 0 {
+			sb.WriteString(osBlock(s.dialect, v.OSValues, v.Value, assignment))
+			continue
+		}
+		sb.WriteString(assignment(v.Value)[0] + "\n")
+	}
+	section := ""
+	for i, t := range targets {
+		if i > 0 || len(variables) > 0 {
+			sb.WriteString("\n")
+		}
+		if ts := targetSection(t); ts != "" && ts != section {
+			sb.WriteString(sectionPrefix + " " + ts + "\n\n")
+		}
+		section = targetSection(t)
+		sb.WriteString(s.renderTarget(t))
+	}
+	return sb.String()
+}
+
+// normalize returns copies of the given variables and targets, normalized
+// for rendering.
+func normalize(variables []Variable, targets []Target) ([]Variable, []Target) {
+	normalizedVariables := make([]Variable, len(variables))
+	for i, v := range variables {
+		normalizedVariables[i] = normalizeVariable(v)
+	}
+	normalizedTargets := make([]Target, len(targets))
+	for i, t := range targets {
+		normalizedTargets[i] = normalizeTarget(t)
+	}
+	return normalizedVariables, normalizedTargets
+}
+
+// normalizeVariable returns the given variable without the whitespace
+// around its value, and around its values per operating system.
+func normalizeVariable(v Variable) Variable {
+	v.Value = strings.TrimSpace(v.Value)
+	if len(v.OSValues) > 0 {
+		osValues := make(map[string]string, len(v.OSValues))
+		for os, value := range v.OSValues {
+			osValues[os] = strings.TrimSpace(value)
+		}
+		v.OSValues = osValues
+	}
+	return v
+}
+
+// normalizeTarget returns the given target with a single space between
+// its dependencies, without the whitespace around its description and
+// its section, and without the trailing whitespace of its recipe lines,
+// including the ones of its recipes per operating system.
+func normalizeTarget(t Target) Target {
+	t.Description = strings.TrimSpace(t.Description)
+	t.Section = strings.TrimSpace(t.Section)
+	t.Dependencies = strings.Fields(strings.Join(t.Dependencies, " "))
+	t.Recipe = trimRecipe(t.Recipe)
+	if len(t.OSRecipes) > 0 {
+		osRecipes := make(map[string][]string, len(t.OSRecipes))
+		for os, recipe := range t.OSRecipes {
+			osRecipes[os] = trimRecipe(recipe)
+		}
+		t.OSRecipes = osRecipes
+	}
+	if len(t.Variables) > 0 {
+		variables := make([]Variable, len(t.Variables))
+		for i, v := range t.Variables {
+			variables[i] = normalizeVariable(v)
+		}
+		t.Variables = variables
+	}
+	return t
+}
+
+// trimRecipe returns the given recipe lines without their trailing
+// whitespace, unless it is escaped, which would turn the line into a
+// continued one.
+func trimRecipe(recipe []string) []string {
+	if recipe == nil {
+		return nil
+	}
+	trimmed := make([]string, len(recipe))
+	for i, line := range recipe {
+		trimmed[i] = strings.TrimRight(line, " \t\r")
+		if strings.HasSuffix(trimmed[i], "\\") && trimmed[i] != line {
+			trimmed[i] = line
+		}
+	}
+	return trimmed
+}
+
+// recipePrefixDeclaration returns the line declaring the given recipe prefix.
+func recipePrefixDeclaration(prefix string) string {
+	return recipePrefixDirective + " = " + prefix + "\n"
+}
+
+// recipeLines returns the given recipe lines, prefixed with the recipe
+// prefix of the syntax.
+func (s syntax) recipeLines(recipe []string) []string {
+	prefix := s.recipePrefix
+	if prefix == "" {
+		prefix = "\t"
+	}
+	lines := make([]string, len(recipe))
+	for i, line := range recipe {
+		lines[i] = prefix + line
+	}
+	return lines
+}
+
+// renderTarget returns the block declaring the given target, in the
+// syntax. Recipes differing per operating system are wrapped in
+// conditional blocks.
+func (s syntax) renderTarget(t Target) string {
+	var sb strings.Builder
+	if t.Phony {
+		sb.WriteString(".PHONY: " + t.Name + "\n")
+	}
+	for _, v := range t.Variables {
+		op := v.Operator
+		if op == "" {
+			op = "?="
+		}
+		name := v.Name
+		if v.Export {
+			name = "export " + name
+		}
+		sb.WriteString(strings.TrimSpace(t.Name+": "+name+" "+op+" "+v.Value) + "\n")
+	}
+	if t.Description != "" {
+		sb.WriteString("## " + t.Name + ": " + t.Description + "\n")
+	}
+	if t.CIStage != "" {
+		sb.WriteString("# ci-stage: " + t.CIStage + "\n")
+	}
+	sb.WriteString(t.Name + ":")
+	if len(t.Dependencies) > 0 {
+		sb.WriteString(" " + strings.Join(t.Dependencies, " "))
+	}
+	sb.WriteString("\n")
+	if len(t.OSRecipes) > 0 {
+		sb.WriteString(osBlock(s.dialect, t.OSRecipes, t.Recipe, s.recipeLines))
+		return sb.String()
+	}
+	for _, line := range s.recipeLines(t.Recipe) {
+		sb.WriteString(line + "\n")
+	}
+	return sb.String()
+}
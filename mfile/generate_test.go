@@ -0,0 +1,808 @@
+// Copyright (c) 2023 Tiago Melo. All rights reserved.
+// Use of this source code is governed by the MIT License that can be found in
+// the LICENSE file.
+
+package mfile
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"testing/fstest"
+
+	"github.com/stretchr/testify/require"
+)
+
+// minimalMakefile is the content generated by the minimal preset.
+const minimalMakefile = `.PHONY: help
+## help: shows this help message
+help:
+	@ awk 'BEGIN { print "Usage: make [target]\n" } /^##@/ { printf "\n%s\n", substr($$0, 5); next } /^##/ { line = substr($$0, 3); sub(/^ +/, "", line); i = index(line, ":"); if (i > 1) { desc = substr(line, i + 1); sub(/^ +/, "", desc); printf "  %-24s %s\n", substr(line, 1, i - 1), desc } }' $(MAKEFILE_LIST)
+
+.PHONY: test
+## test: run unit tests
+test:
+	@ go test -v ./... -count=1
+
+.PHONY: coverage
+## coverage: run unit tests and generate coverage report in html format
+coverage:
+	@ go test -coverprofile=coverage.out ./...  && go tool cover -html=coverage.out
+`
+
+func TestGenerate(t *testing.T) {
+	testCases := []struct {
+		name            string
+		options         []GenerateOption
+		mockClosure     func(m *mockFileSystem)
+		expectedContent string
+		expectedError   error
+	}{
+		{
+			name:            "happy path, default preset",
+			mockClosure:     func(m *mockFileSystem) {},
+			expectedContent: minimalMakefile,
+		},
+		{
+			name:    "happy path, existing content is kept",
+			options: []GenerateOption{WithPresets(PresetMinimal)},
+			mockClosure: func(m *mockFileSystem) {
+				m.file = []byte("\nbuild:\n")
+			},
+			expectedContent: minimalMakefile + "\nbuild:\n",
+		},
+		{
+			name:    "happy path, recipe prefix, existing content is kept",
+			options: []GenerateOption{WithPresets(PresetMinimal), WithRecipePrefix(">")},
+			mockClosure: func(m *mockFileSystem) {
+				m.file = []byte("\nbuild:\n\tgo build\n")
+			},
+			expectedContent: ".RECIPEPREFIX = >\n\n" + strings.ReplaceAll(minimalMakefile, "\n\t", "\n>") + ".RECIPEPREFIX =\n\nbuild:\n\tgo build\n",
+		},
+		{
+			name:    "happy path, overwrite",
+			options: []GenerateOption{WithOverwrite(true)},
+			mockClosure: func(m *mockFileSystem) {
+				m.file = []byte("\nbuild:\n")
+			},
+			expectedContent: minimalMakefile,
+		},
+		{
+			name:            "happy path, env file",
+			options:         []GenerateOption{WithEnvFile(".env")},
+			mockClosure:     func(m *mockFileSystem) {},
+			expectedContent: "ENV_FILE ?= .env\n-include $(ENV_FILE)\nexport\n\n" + minimalMakefile,
+		},
+		{
+			name:            "happy path, parallel",
+			options:         []GenerateOption{WithParallel(ParallelAuto)},
+			mockClosure:     func(m *mockFileSystem) {},
+			expectedContent: "# Targets run in parallel, one job per CPU; run make JOBS=1 to run them\n# one at a time.\nJOBS ?= $(shell nproc 2> /dev/null || sysctl -n hw.ncpu 2> /dev/null || echo 1)\nMAKEFLAGS += -j$(JOBS)\n\n" + minimalMakefile,
+		},
+		{
+			name:            "happy path, parallel with env file",
+			options:         []GenerateOption{WithParallel("4"), WithEnvFile(".env")},
+			mockClosure:     func(m *mockFileSystem) {},
+			expectedContent: "ENV_FILE ?= .env\n-include $(ENV_FILE)\nexport\n\n# Targets run in parallel, 4 jobs at a time; run make JOBS=1 to run\n# them one at a time.\nJOBS ?= 4\nMAKEFLAGS += -j$(JOBS)\n\n" + minimalMakefile,
+		},
+		{
+			name:            "happy path, not parallel",
+			options:         []GenerateOption{WithNotParallel(true)},
+			mockClosure:     func(m *mockFileSystem) {},
+			expectedContent: "# Targets run one at a time, even with make -j.\n.NOTPARALLEL:\n\n" + minimalMakefile,
+		},
+		{
+			name:            "happy path, strict",
+			options:         []GenerateOption{WithStrict(true), WithEnvFile(".env")},
+			mockClosure:     func(m *mockFileSystem) {},
+			expectedContent: "SHELL := /bin/bash\n.SHELLFLAGS := -eu -o pipefail -c\nMAKEFLAGS += --warn-undefined-variables --no-builtin-rules\n\nENV_FILE ?= .env\n-include $(ENV_FILE)\nexport\n\n" + minimalMakefile,
+		},
+		{
+			name:            "happy path, strict with custom shell",
+			options:         []GenerateOption{WithStrict(true), WithShell("/bin/zsh"), WithMakeFlags("--no-builtin-rules")},
+			mockClosure:     func(m *mockFileSystem) {},
+			expectedContent: "SHELL := /bin/zsh\n.SHELLFLAGS := -eu -o pipefail -c\nMAKEFLAGS += --no-builtin-rules\n\n" + minimalMakefile,
+		},
+		{
+			name:            "happy path, shell flags only",
+			options:         []GenerateOption{WithShellFlags("-ec")},
+			mockClosure:     func(m *mockFileSystem) {},
+			expectedContent: ".SHELLFLAGS := -ec\n\n" + minimalMakefile,
+		},
+		{
+			name:        "happy path, composed presets",
+			options:     []GenerateOption{WithPresets("go-service,go-library")},
+			mockClosure: func(m *mockFileSystem) {},
+			expectedContent: `APP_NAME ?= app
+BINARY_NAME ?= $(APP_NAME)
+MAIN_PACKAGE ?= .
+PORT ?= 8080
+
+` + minimalMakefile + `
+.PHONY: build
+## build: build the binary into the bin directory
+build:
+	@ go build -o bin/$(BINARY_NAME) $(MAIN_PACKAGE)
+
+.PHONY: run
+## run: build and run the service
+run: build
+	@ PORT=$(PORT) ./bin/$(BINARY_NAME)
+
+.PHONY: vet
+## vet: run go vet
+vet:
+	@ go vet ./...
+
+.PHONY: fmt
+## fmt: format the source code
+fmt:
+	@ go fmt ./...
+
+.PHONY: tidy
+## tidy: add missing and remove unused modules
+tidy:
+	@ go mod tidy
+
+.PHONY: test-race
+## test-race: run unit tests with the race detector
+test-race:
+	@ go test -race ./... -count=1
+`,
+		},
+		{
+			name:    "happy path, module metadata from go.mod",
+			options: []GenerateOption{WithPresets(PresetDocker)},
+			mockClosure: func(m *mockFileSystem) {
+				m.files = map[string][]byte{"some/go.mod": []byte("module github.com/acme/widget\n")}
+				m.isNotExistOutput = true
+			},
+			expectedContent: `IMAGE_NAME ?= widget
+IMAGE_TAG ?= $(shell git describe --tags --always --dirty 2> /dev/null || echo latest)
+DOCKERFILE ?= Dockerfile
+DOCKER_RUN_ARGS ?=
+
+.PHONY: docker-build
+## docker-build: build the docker image
+docker-build:
+	@ docker build -f $(DOCKERFILE) -t $(IMAGE_NAME):$(IMAGE_TAG) .
+
+.PHONY: docker-push
+## docker-push: push the docker image to its registry
+docker-push: docker-build
+	@ docker push $(IMAGE_NAME):$(IMAGE_TAG)
+
+.PHONY: docker-run
+## docker-run: run the docker image
+docker-run: docker-build
+	@ docker run --rm $(DOCKER_RUN_ARGS) $(IMAGE_NAME):$(IMAGE_TAG)
+`,
+		},
+		{
+			name:    "happy path, auto-detected presets",
+			options: []GenerateOption{WithAutoDetect(true), WithOverwrite(true)},
+			mockClosure: func(m *mockFileSystem) {
+				m.tree = fstest.MapFS{"some/go.mod": {}, "some/lib.go": {}}
+			},
+			expectedContent: minimalMakefile + `
+.PHONY: vet
+## vet: run go vet
+vet:
+	@ go vet ./...
+
+.PHONY: fmt
+## fmt: format the source code
+fmt:
+	@ go fmt ./...
+
+.PHONY: tidy
+## tidy: add missing and remove unused modules
+tidy:
+	@ go mod tidy
+`,
+		},
+		{
+			name:    "happy path, auto-detected presets declaring the same targets",
+			options: []GenerateOption{WithAutoDetect(true), WithOverwrite(true)},
+			mockClosure: func(m *mockFileSystem) {
+				m.tree = fstest.MapFS{"some/go.mod": {}, "some/main.go": {}, "some/package.json": {}, "some/pnpm-lock.yaml": {}}
+			},
+			expectedContent: `APP_NAME ?= app
+BINARY_NAME ?= $(APP_NAME)
+MAIN_PACKAGE ?= .
+PACKAGE_MANAGER ?= pnpm
+
+` + minimalMakefile + `
+.PHONY: build
+## build: build the binary into the bin directory
+build:
+	@ go build -o bin/$(BINARY_NAME) $(MAIN_PACKAGE)
+
+.PHONY: run
+## run: build and run the binary
+run: build
+	@ ./bin/$(BINARY_NAME)
+
+.PHONY: vet
+## vet: run go vet
+vet:
+	@ go vet ./...
+
+.PHONY: fmt
+## fmt: format the source code
+fmt:
+	@ go fmt ./...
+
+.PHONY: tidy
+## tidy: add missing and remove unused modules
+tidy:
+	@ go mod tidy
+
+##@ node
+
+.PHONY: node/install
+## node/install: install the dependencies
+node/install:
+	@ $(PACKAGE_MANAGER) install
+
+.PHONY: node/build
+## node/build: run the build script
+node/build:
+	@ $(PACKAGE_MANAGER) run build
+
+.PHONY: node/test
+## node/test: run the test script
+node/test:
+	@ $(PACKAGE_MANAGER) run test
+
+.PHONY: node/lint
+## node/lint: run the lint script
+node/lint:
+	@ $(PACKAGE_MANAGER) run lint
+`,
+		},
+		{
+			name:    "happy path, recursive",
+			options: []GenerateOption{WithRecursive(true), WithAutoDetect(true)},
+			mockClosure: func(m *mockFileSystem) {
+				m.isDirOutput = true
+				m.tree = fstest.MapFS{
+					"some/path/api/go.mod":        {},
+					"some/path/worker/go.mod":     {},
+					"some/path/worker/main.go":    {},
+					"some/path/docs/index.md":     {},
+					"some/path/vendor/x/go.mod":   {},
+					"some/path/worker/sub/go.mod": {},
+					"some/path/.cache/go/go.mod":  {},
+					"some/path/api/internal/a.go": {},
+					"some/path/worker/cmd/README": {},
+				}
+			},
+			expectedContent: `.PHONY: help
+## help: shows this help message
+help:
+	@ awk 'BEGIN { print "Usage: make [target]\n" } /^##@/ { printf "\n%s\n", substr($$0, 5); next } /^##/ { line = substr($$0, 3); sub(/^ +/, "", line); i = index(line, ":"); if (i > 1) { desc = substr(line, i + 1); sub(/^ +/, "", desc); printf "  %-24s %s\n", substr(line, 1, i - 1), desc } }' $(MAKEFILE_LIST)
+
+.PHONY: test
+## test: run test in api, worker
+test:
+	@ $(MAKE) -C api test
+	@ $(MAKE) -C worker test
+
+.PHONY: coverage
+## coverage: run coverage in api, worker
+coverage:
+	@ $(MAKE) -C api coverage
+	@ $(MAKE) -C worker coverage
+
+.PHONY: vet
+## vet: run vet in api, worker
+vet:
+	@ $(MAKE) -C api vet
+	@ $(MAKE) -C worker vet
+
+.PHONY: fmt
+## fmt: run fmt in api, worker
+fmt:
+	@ $(MAKE) -C api fmt
+	@ $(MAKE) -C worker fmt
+
+.PHONY: tidy
+## tidy: run tidy in api, worker
+tidy:
+	@ $(MAKE) -C api tidy
+	@ $(MAKE) -C worker tidy
+
+.PHONY: build
+## build: run build in worker
+build:
+	@ $(MAKE) -C worker build
+
+.PHONY: run
+## run: run run in worker
+run:
+	@ $(MAKE) -C worker run
+`,
+		},
+		{
+			name:    "no module found when recursive",
+			options: []GenerateOption{WithRecursive(true)},
+			mockClosure: func(m *mockFileSystem) {
+				m.isDirOutput = true
+				m.tree = fstest.MapFS{"some/path/main.go": {}}
+			},
+			expectedError: errors.New("no module found under some/path"),
+		},
+		{
+			name:    "happy path, template followed by presets",
+			options: []GenerateOption{WithTemplate("templates/Makefile.tmpl"), WithPresets(PresetMinimal)},
+			mockClosure: func(m *mockFileSystem) {
+				m.files = map[string][]byte{
+					"templates/Makefile.tmpl": []byte(".PHONY: deploy\n## deploy: deploy {{ .AppName }}\ndeploy:\n\t@ ./deploy.sh\n"),
+					"some/go.mod":             []byte("module github.com/acme/widget\n"),
+				}
+				m.isNotExistOutput = true
+			},
+			expectedContent: ".PHONY: deploy\n## deploy: deploy widget\ndeploy:\n\t@ ./deploy.sh\n\n" + minimalMakefile,
+		},
+		{
+			name: "happy path, template with values",
+			options: []GenerateOption{
+				WithTemplate("templates/Makefile.tmpl"),
+				WithValues(map[string]any{"registry": "ghcr.io/acme", "team": "payments"}),
+			},
+			mockClosure: func(m *mockFileSystem) {
+				m.files = map[string][]byte{
+					"templates/Makefile.tmpl": []byte("IMAGE ?= {{ .Values.registry }}/{{ .AppName }}\nTEAM ?= {{ .Values.team }}\n"),
+				}
+				m.isNotExistOutput = true
+			},
+			expectedContent: "IMAGE ?= ghcr.io/acme/app\nTEAM ?= payments\n",
+		},
+		{
+			name:    "template not found",
+			options: []GenerateOption{WithTemplate("templates/Makefile.tmpl")},
+			mockClosure: func(m *mockFileSystem) {
+				m.files = map[string][]byte{}
+			},
+			expectedError: errors.New("reading template at templates/Makefile.tmpl: file does not exist"),
+		},
+		{
+			name:        "happy path, minimal flavor",
+			options:     []GenerateOption{WithFlavor(FlavorMinimal)},
+			mockClosure: func(m *mockFileSystem) {},
+			expectedContent: `.PHONY: help
+## help: shows this help message
+help:
+	@ awk 'BEGIN { print "Usage: make [target]\n" } /^##@/ { printf "\n%s\n", substr($$0, 5); next } /^##/ { line = substr($$0, 3); sub(/^ +/, "", line); i = index(line, ":"); if (i > 1) { desc = substr(line, i + 1); sub(/^ +/, "", desc); printf "  %-24s %s\n", substr(line, 1, i - 1), desc } }' $(MAKEFILE_LIST)
+`,
+		},
+		{
+			name:            "happy path, flavor followed by presets",
+			options:         []GenerateOption{WithFlavor(FlavorStandard), WithPresets(PresetHelp)},
+			mockClosure:     func(m *mockFileSystem) {},
+			expectedContent: minimalMakefile,
+		},
+		{
+			name:          "unknown flavor",
+			options:       []GenerateOption{WithFlavor("unknown")},
+			mockClosure:   func(m *mockFileSystem) {},
+			expectedError: errors.New(`unknown flavor "unknown"`),
+		},
+		{
+			name:          "unknown dialect",
+			options:       []GenerateOption{WithDialect("unknown")},
+			mockClosure:   func(m *mockFileSystem) {},
+			expectedError: errors.New(`unknown dialect "unknown"`),
+		},
+		{
+			name:          "posix dialect with Windows variants",
+			options:       []GenerateOption{WithDialect(DialectPOSIX), WithWindows(true)},
+			mockClosure:   func(m *mockFileSystem) {},
+			expectedError: errors.New("the posix dialect does not support Windows recipe variants"),
+		},
+		{
+			name:          "posix dialect with info help",
+			options:       []GenerateOption{WithDialect(DialectPOSIX), WithHelpStyle(HelpStyleInfo)},
+			mockClosure:   func(m *mockFileSystem) {},
+			expectedError: errors.New("the posix dialect does not support the info help style"),
+		},
+		{
+			name:          "posix dialect with env file",
+			options:       []GenerateOption{WithDialect(DialectPOSIX), WithEnvFile(".env")},
+			mockClosure:   func(m *mockFileSystem) {},
+			expectedError: errors.New("the posix dialect does not support loading an environment file"),
+		},
+		{
+			name:          "parallel and not parallel",
+			options:       []GenerateOption{WithParallel(ParallelAuto), WithNotParallel(true)},
+			mockClosure:   func(m *mockFileSystem) {},
+			expectedError: errors.New("a Makefile can't run its targets both in parallel and one at a time"),
+		},
+		{
+			name:          "invalid number of jobs",
+			options:       []GenerateOption{WithParallel("0")},
+			mockClosure:   func(m *mockFileSystem) {},
+			expectedError: errors.New(`invalid number of jobs "0", want a positive number or auto`),
+		},
+		{
+			name:          "posix dialect with strict prologue",
+			options:       []GenerateOption{WithDialect(DialectPOSIX), WithStrict(true)},
+			mockClosure:   func(m *mockFileSystem) {},
+			expectedError: errors.New("the posix dialect does not support configuring the shell and make flags"),
+		},
+		{
+			name:          "invalid make flag",
+			options:       []GenerateOption{WithMakeFlags("no-builtin-rules")},
+			mockClosure:   func(m *mockFileSystem) {},
+			expectedError: errors.New(`invalid make flag "no-builtin-rules", want a flag like --no-builtin-rules`),
+		},
+		{
+			name:          "posix dialect in parallel",
+			options:       []GenerateOption{WithDialect(DialectPOSIX), WithParallel("2")},
+			mockClosure:   func(m *mockFileSystem) {},
+			expectedError: errors.New("the posix dialect does not support running the targets in parallel by default"),
+		},
+		{
+			name:          "invalid recipe prefix",
+			options:       []GenerateOption{WithRecipePrefix(">>")},
+			mockClosure:   func(m *mockFileSystem) {},
+			expectedError: errors.New(`invalid recipe prefix ">>", want a single printable character`),
+		},
+		{
+			name:          "bmake dialect with recipe prefix",
+			options:       []GenerateOption{WithDialect(DialectBMake), WithRecipePrefix(">")},
+			mockClosure:   func(m *mockFileSystem) {},
+			expectedError: errors.New("the bmake dialect does not support a custom recipe prefix"),
+		},
+		{
+			name:          "unknown parameter",
+			options:       []GenerateOption{WithParameter("platforms", "linux/amd64")},
+			mockClosure:   func(m *mockFileSystem) {},
+			expectedError: errors.New(`unknown parameter "platforms"`),
+		},
+		{
+			name:          "unknown preset",
+			options:       []GenerateOption{WithPresets("minimal,unknown")},
+			mockClosure:   func(m *mockFileSystem) {},
+			expectedError: errors.New(`unknown preset "unknown"`),
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			m := new(mockFileSystem)
+			tc.mockClosure(m)
+			fsProvider = m
+			templateProcessorProvider = htmlTemplateProcessor{}
+			err := Generate("some/path", tc.options...)
+			if err != nil {
+				if tc.expectedError == nil {
+					t.Fatalf("expected no error, got %v", err)
+				}
+				require.Equal(t, tc.expectedError.Error(), err.Error())
+			} else {
+				if tc.expectedError != nil {
+					t.Fatalf("expected error to be %v, got nil", tc.expectedError)
+				}
+				require.Equal(t, tc.expectedContent, string(m.writtenData))
+			}
+		})
+	}
+}
+
+func TestGenerateFragments(t *testing.T) {
+	testCases := []struct {
+		name            string
+		options         []GenerateOption
+		mockClosure     func(m *mockFileSystem)
+		expectedWritten map[string]string
+		expectedError   error
+	}{
+		{
+			name:        "happy path",
+			options:     []GenerateOption{WithFragments("make"), WithPresets("go-library,lint"), WithOverwrite(true)},
+			mockClosure: func(m *mockFileSystem) {},
+			expectedWritten: map[string]string{
+				"some/make/minimal.mk": minimalMakefile,
+				"some/make/go-library.mk": `.PHONY: vet
+## vet: run go vet
+vet:
+	@ go vet ./...
+
+.PHONY: fmt
+## fmt: format the source code
+fmt:
+	@ go fmt ./...
+
+.PHONY: tidy
+## tidy: add missing and remove unused modules
+tidy:
+	@ go mod tidy
+`,
+				"some/make/lint.mk": `GOLANGCI_LINT_VERSION ?= v1.59.1
+GOLANGCI_LINT ?= $(shell go env GOPATH)/bin/golangci-lint
+
+.PHONY: install-golangci-lint
+## install-golangci-lint: install golangci-lint
+install-golangci-lint:
+	@ go install github.com/golangci/golangci-lint/cmd/golangci-lint@$(GOLANGCI_LINT_VERSION)
+
+.PHONY: lint
+## lint: run golangci-lint, installing it if needed
+lint:
+	@ test -x $(GOLANGCI_LINT) || $(MAKE) install-golangci-lint
+	@ $(GOLANGCI_LINT) run ./...
+`,
+				"some/path": "include make/minimal.mk\ninclude make/go-library.mk\ninclude make/lint.mk\n",
+			},
+		},
+		{
+			name:    "happy path, existing fragments are kept",
+			options: []GenerateOption{WithFragments("make")},
+			mockClosure: func(m *mockFileSystem) {
+				m.files = map[string][]byte{
+					"some/make/minimal.mk": []byte("help:\n"),
+				}
+				m.isNotExistOutput = true
+			},
+			expectedWritten: map[string]string{
+				"some/path": "include make/minimal.mk\n",
+			},
+		},
+		{
+			name:        "happy path, bmake dialect",
+			options:     []GenerateOption{WithFragments("make"), WithPresets("go-library"), WithDialect(DialectBMake), WithOverwrite(true)},
+			mockClosure: func(m *mockFileSystem) {},
+			expectedWritten: map[string]string{
+				"some/make/minimal.mk": strings.Replace(minimalMakefile, "$(MAKEFILE_LIST)", "Makefile", 1),
+				"some/make/go-library.mk": `.PHONY: vet
+## vet: run go vet
+vet:
+	@ go vet ./...
+
+.PHONY: fmt
+## fmt: format the source code
+fmt:
+	@ go fmt ./...
+
+.PHONY: tidy
+## tidy: add missing and remove unused modules
+tidy:
+	@ go mod tidy
+`,
+				"some/path": ".include \"make/minimal.mk\"\n.include \"make/go-library.mk\"\n",
+			},
+		},
+		{
+			name:    "error when creating fragments directory",
+			options: []GenerateOption{WithFragments("make")},
+			mockClosure: func(m *mockFileSystem) {
+				m.mkdirErr = errors.New("mkdir error")
+			},
+			expectedError: errors.New("creating fragments directory some/make: mkdir error"),
+		},
+		{
+			name:    "error when writing fragment",
+			options: []GenerateOption{WithFragments("make"), WithOverwrite(true)},
+			mockClosure: func(m *mockFileSystem) {
+				m.writeFileErr = errors.New("write error")
+			},
+			expectedError: errors.New("writing fragment at some/make/minimal.mk: write error"),
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			m := new(mockFileSystem)
+			tc.mockClosure(m)
+			fsProvider = m
+			err := Generate("some/path", tc.options...)
+			if err != nil {
+				if tc.expectedError == nil {
+					t.Fatalf("expected no error, got %v", err)
+				}
+				require.Equal(t, tc.expectedError.Error(), err.Error())
+			} else {
+				if tc.expectedError != nil {
+					t.Fatalf("expected error to be %v, got nil", tc.expectedError)
+				}
+				written := make(map[string]string)
+				for name, data := range m.written {
+					written[name] = string(data)
+				}
+				require.Equal(t, tc.expectedWritten, written)
+			}
+		})
+	}
+}
+
+func TestRenderSections(t *testing.T) {
+	targets := []Target{
+		{Name: "help"},
+		{Name: "build", Section: "Build"},
+		{Name: "run", Section: "Build"},
+		{Name: "test", Section: "Test"},
+	}
+	content := render([]Variable{{Name: "GO", Value: "go"}}, targets)
+	require.Equal(t, "GO ?= go\n\nhelp:\n\n##@ Build\n\nbuild:\n\nrun:\n\n##@ Test\n\ntest:\n", content)
+	require.Equal(t, []Target{
+		{Name: "help", Line: 3},
+		{Name: "build", Section: "Build", Line: 7},
+		{Name: "run", Section: "Build", Line: 9},
+		{Name: "test", Section: "Test", Line: 13},
+	}, parseTargets(content))
+}
+
+func TestRenderOSConditionals(t *testing.T) {
+	variables := []Variable{
+		{Name: "EXE", OSValues: map[string]string{OSWindows: ".exe"}},
+		{Name: "OPEN", Value: "xdg-open", OSValues: map[string]string{OSWindows: "start", OSDarwin: "open"}},
+	}
+	targets := []Target{
+		{
+			Name:      "clean",
+			Recipe:    []string{"rm -rf bin"},
+			OSRecipes: map[string][]string{OSWindows: {"if exist bin rmdir /S /Q bin"}},
+			Phony:     true,
+		},
+	}
+	content := render(variables, targets)
+	require.Equal(t, `ifeq ($(OS),Windows_NT)
+DETECTED_OS := Windows
+else
+UNAME_S := $(shell uname -s)
+DETECTED_OS := $(UNAME_S)
+endif
+
+ifeq ($(DETECTED_OS),Windows)
+EXE ?= .exe
+else
+EXE ?=
+endif
+ifeq ($(DETECTED_OS),Darwin)
+OPEN ?= open
+else ifeq ($(DETECTED_OS),Windows)
+OPEN ?= start
+else
+OPEN ?= xdg-open
+endif
+
+.PHONY: clean
+clean:
+ifeq ($(DETECTED_OS),Windows)
+	if exist bin rmdir /S /Q bin
+else
+	rm -rf bin
+endif
+`, content)
+	require.Empty(t, lintMakefile(content))
+	require.Equal(t, []Target{
+		{Name: "clean", Recipe: []string{"if exist bin rmdir /S /Q bin", "rm -rf bin"}, Phony: true, Line: 22},
+	}, parseTargets(content))
+}
+
+func TestRenderDeterministic(t *testing.T) {
+	variables := []Variable{
+		{Name: "GO", Value: "go"},
+		{Name: "OPEN", Value: "xdg-open", OSValues: map[string]string{OSWindows: "start", OSDarwin: "open", OSLinux: "xdg-open"}},
+	}
+	targets := []Target{
+		{
+			Name:         "build",
+			Description:  "build the binary",
+			Dependencies: []string{"vet", "generate"},
+			Recipe:       []string{"$(GO) build ./...", `@ echo "done"`},
+			OSRecipes:    map[string][]string{OSWindows: {"$(GO) build -o app.exe ./..."}, OSDarwin: {"$(GO) build -o app ./..."}},
+			Phony:        true,
+		},
+	}
+	// Same model, with extra spacing.
+	spacedVariables := []Variable{
+		{Name: "GO", Value: "  go "},
+		{Name: "OPEN", Value: "xdg-open\t", OSValues: map[string]string{OSLinux: "xdg-open ", OSDarwin: " open", OSWindows: "start"}},
+	}
+	spacedTargets := []Target{
+		{
+			Name:         "build",
+			Description:  " build the binary  ",
+			Dependencies: []string{"vet  generate", ""},
+			Recipe:       []string{"$(GO) build ./...  ", "@ echo \"done\"\t"},
+			OSRecipes:    map[string][]string{OSDarwin: {"$(GO) build -o app ./... "}, OSWindows: {"$(GO) build -o app.exe ./...\r"}},
+			Phony:        true,
+		},
+	}
+	expected := render(variables, targets)
+	for i := 0; i < 20; i++ {
+		require.Equal(t, expected, render(variables, targets))
+		require.Equal(t, expected, render(spacedVariables, spacedTargets))
+	}
+	require.Equal(t, "  go ", spacedVariables[0].Value, "the model must not be modified")
+	require.Equal(t, []string{`echo a\ `}, trimRecipe([]string{`echo a\ `}))
+}
+
+func TestGeneratePrepend(t *testing.T) {
+	testCases := []struct {
+		name            string
+		existing        string
+		symlink         bool
+		expectedContent string
+		expectedError   string
+	}{
+		{
+			name:            "happy path",
+			existing:        "\nbuild:\n\tgo build\n",
+			expectedContent: minimalMakefile + "\nbuild:\n\tgo build\n",
+		},
+		{
+			name:            "happy path, symbolic link",
+			existing:        "\nbuild:\n",
+			symlink:         true,
+			expectedContent: minimalMakefile + "\nbuild:\n",
+		},
+		{
+			name:          "not a Makefile",
+			existing:      "\x7fELF\x00\x00",
+			expectedError: "reading Makefile at %s: not a Makefile",
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			fsProvider = osFileSystem{}
+			dir := t.TempDir()
+			path := filepath.Join(dir, "Makefile")
+			file := path
+			if tc.symlink {
+				file = filepath.Join(dir, "Makefile.real")
+				require.NoError(t, os.Symlink(file, path))
+			}
+			require.NoError(t, os.WriteFile(file, []byte(tc.existing), 0600))
+			err := Generate(path, WithPresets(PresetMinimal))
+			if tc.expectedError != "" {
+				require.EqualError(t, err, strings.ReplaceAll(tc.expectedError, "%s", path))
+				content, err := os.ReadFile(file)
+				require.NoError(t, err)
+				require.Equal(t, tc.existing, string(content), "the Makefile must be left as it is")
+			} else {
+				require.NoError(t, err)
+				content, err := os.ReadFile(path)
+				require.NoError(t, err)
+				require.Equal(t, tc.expectedContent, string(content))
+				fi, err := os.Stat(file)
+				require.NoError(t, err)
+				require.Equal(t, os.FileMode(0600), fi.Mode().Perm())
+			}
+			entries, err := os.ReadDir(dir)
+			require.NoError(t, err)
+			require.Len(t, entries, map[bool]int{false: 1, true: 2}[tc.symlink], "no temporary file must be left")
+		})
+	}
+}
+
+// BenchmarkGeneratePrepend generates a Makefile before existing ones of
+// growing sizes. The memory allocated per operation stays the same, as the
+// existing content is streamed.
+func BenchmarkGeneratePrepend(b *testing.B) {
+	fsProvider = osFileSystem{}
+	line := "\t@ echo 'a recipe line of an existing target'\n"
+	for _, size := range []int{1 << 10, 1 << 20, 16 << 20} {
+		b.Run(fmt.Sprintf("%dKiB", size>>10), func(b *testing.B) {
+			path := filepath.Join(b.TempDir(), "Makefile")
+			existing := []byte("existing:\n" + strings.Repeat(line, size/len(line)))
+			b.SetBytes(int64(len(existing)))
+			b.ReportAllocs()
+			b.ResetTimer()
+			for i := 0; i < b.N; i++ {
+				b.StopTimer()
+				if err := os.WriteFile(path, existing, 0644); err != nil {
+					b.Fatal(err)
+				}
+				b.StartTimer()
+				if err := Generate(path, WithPresets(PresetMinimal)); err != nil {
+					b.Fatal(err)
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,271 @@
+// Copyright (c) 2023 Tiago Melo. All rights reserved.
+// Use of this source code is governed by the MIT License that can be found in
+// the LICENSE file.
+
+package mfile
+
+import (
+	"errors"
+	"fmt"
+	"regexp"
+	"slices"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// workflow is the part of a GitHub Actions workflow converted to a
+// Makefile.
+type workflow struct {
+	Name string    `yaml:"name"`
+	Env  yaml.Node `yaml:"env"`
+	Jobs yaml.Node `yaml:"jobs"`
+}
+
+// workflowJob is a job of a GitHub Actions workflow.
+type workflowJob struct {
+	Name     string         `yaml:"name"`
+	Needs    stringList     `yaml:"needs"`
+	Env      yaml.Node      `yaml:"env"`
+	Defaults workflowRun    `yaml:"defaults"`
+	Strategy jobStrategy    `yaml:"strategy"`
+	Steps    []workflowStep `yaml:"steps"`
+}
+
+// workflowRun holds the defaults of the run steps of a job.
+type workflowRun struct {
+	Run struct {
+		WorkingDirectory string `yaml:"working-directory"`
+	} `yaml:"run"`
+}
+
+// jobStrategy holds the matrix of a job.
+type jobStrategy struct {
+	Matrix yaml.Node `yaml:"matrix"`
+}
+
+// workflowStep is a step of a job.
+type workflowStep struct {
+	Name             string    `yaml:"name"`
+	Uses             string    `yaml:"uses"`
+	Run              string    `yaml:"run"`
+	Shell            string    `yaml:"shell"`
+	WorkingDirectory string    `yaml:"working-directory"`
+	Env              yaml.Node `yaml:"env"`
+}
+
+// stringList is a list of strings that may also be declared as a single
+// string, like the needs of a job.
+type stringList []string
+
+func (l *stringList) UnmarshalYAML(node *yaml.Node) error {
+	if node.Kind == yaml.ScalarNode {
+		*l = []string{node.Value}
+		return nil
+	}
+	return node.Decode((*[]string)(l))
+}
+
+// workflowExpression matches the ${{ ... }} expressions of a workflow.
+var workflowExpression = regexp.MustCompile(`\$\{\{\s*(.*?)\s*\}\}`)
+
+// workflowContextValue matches the expressions reading a value of the
+// env, matrix, inputs, vars or secrets contexts, which become make
+// variables.
+var workflowContextValue = regexp.MustCompile(`^(?:env|matrix|inputs|vars|secrets)\.([A-Za-z_][A-Za-z0-9_-]*)$`)
+
+// importWorkflow converts the jobs of a GitHub Actions workflow into
+// targets running their run steps, so that CI steps can be run locally.
+// The needs of a job become dependencies, and each step is announced with
+// its name. The env of the workflow become exported variables, the env
+// and the first value of the matrix of a job target-specific ones, and
+// ${{ env.NAME }} like expressions references to them. Steps using
+// actions are logged as warnings, as are expressions make has no
+// equivalent for.
+func importWorkflow(content []byte, _ *importOptions) ([]Variable, []Target, error) {
+	var wf workflow
+	if err := yaml.Unmarshal(content, &wf); err != nil {
+		return nil, nil, fmt.Errorf("parsing workflow: %w", err)
+	}
+	if wf.Jobs.Kind != yaml.MappingNode {
+		return nil, nil, errors.New("no job found")
+	}
+	variables := workflowEnv(&wf.Env)
+	var targets []Target
+	for i := 0; i+1 < len(wf.Jobs.Content); i += 2 {
+		id, node := wf.Jobs.Content[i].Value, wf.Jobs.Content[i+1]
+		var job workflowJob
+		if err := node.Decode(&job); err != nil {
+			return nil, nil, fmt.Errorf("parsing job %s: %w", id, err)
+		}
+		t := Target{Name: importedName(id), Phony: true, Variables: workflowEnv(&job.Env)}
+		t.Description = "run the steps of the " + id + " job"
+		if job.Name != "" {
+			t.Description = "run the steps of the " + job.Name + " job"
+		}
+		for _, need := range job.Needs {
+			t.Dependencies = append(t.Dependencies, importedName(need))
+		}
+		t.Variables = append(t.Variables, workflowMatrix(&job.Strategy.Matrix)...)
+		for _, step := range job.Steps {
+			if step.Run == "" {
+				if step.Uses != "" && !strings.HasPrefix(step.Uses, "actions/checkout@") {
+					logger.Warn("workflow step not converted", "job", id, "uses", step.Uses)
+				}
+				continue
+			}
+			if step.Shell != "" && step.Shell != "bash" && step.Shell != "sh" {
+				logger.Warn("workflow step shell not supported", "job", id, "step", step.Name, "shell", step.Shell)
+				continue
+			}
+			if step.Name != "" {
+				t.Recipe = append(t.Recipe, fmt.Sprintf(`@ echo "==> %s"`, escapeDollars(strings.ReplaceAll(step.Name, `"`, `\"`))))
+			}
+			t.Recipe = append(t.Recipe, workflowCommand(step, job.Defaults.Run.WorkingDirectory))
+		}
+		targets = append(targets, t)
+	}
+	return variables, targets, nil
+}
+
+// workflowCommand returns the recipe line running the given run step, in
+// the given default working directory, with the env of the step. Like
+// GitHub Actions does, multi-line scripts stop at the first failure.
+func workflowCommand(step workflowStep, dir string) string {
+	var setup []string
+	if step.WorkingDirectory != "" {
+		dir = step.WorkingDirectory
+	}
+	if dir != "" {
+		setup = append(setup, "cd "+workflowValue(dir))
+	}
+	if env := workflowEnv(&step.Env); len(env) > 0 {
+		assignments := make([]string, len(env))
+		for i, v := range env {
+			assignments[i] = v.Name + "=" + shellQuote(v.Value)
+		}
+		setup = append(setup, "export "+strings.Join(assignments, " "))
+	}
+	script := strings.TrimSpace(step.Run)
+	if strings.Contains(script, "\n") {
+		setup = append([]string{"set -e"}, setup...)
+		return strings.Join(append(setup, workflowValue(joinShellLines(script))), "; ")
+	}
+	return strings.Join(append(setup, workflowValue(script)), " && ")
+}
+
+// workflowEnv returns the exported variables declared by the given env
+// mapping.
+func workflowEnv(env *yaml.Node) []Variable {
+	if env.Kind != yaml.MappingNode {
+		return nil
+	}
+	var variables []Variable
+	for i := 0; i+1 < len(env.Content); i += 2 {
+		variables = append(variables, Variable{Name: env.Content[i].Value, Operator: "?=", Value: workflowValue(env.Content[i+1].Value), Export: true})
+	}
+	return variables
+}
+
+// workflowMatrix returns the variables holding the first value of each
+// dimension of the given matrix.
+func workflowMatrix(matrix *yaml.Node) []Variable {
+	if matrix.Kind != yaml.MappingNode {
+		return nil
+	}
+	var variables []Variable
+	for i := 0; i+1 < len(matrix.Content); i += 2 {
+		name, values := matrix.Content[i].Value, matrix.Content[i+1]
+		if values.Kind != yaml.SequenceNode || len(values.Content) == 0 || values.Content[0].Kind != yaml.ScalarNode {
+			continue
+		}
+		variables = append(variables, Variable{Name: name, Operator: "?=", Value: workflowValue(values.Content[0].Value)})
+	}
+	return variables
+}
+
+// workflowValue returns the given workflow value with its dollar signs
+// escaped and its ${{ env.NAME }} like expressions turned into $(NAME).
+// Other expressions are logged as warnings and dropped, since the shell
+// cannot evaluate them.
+func workflowValue(value string) string {
+	var sb strings.Builder
+	last := 0
+	for _, loc := range workflowExpression.FindAllStringSubmatchIndex(value, -1) {
+		sb.WriteString(escapeDollars(value[last:loc[0]]))
+		expr := value[loc[2]:loc[3]]
+		if m := workflowContextValue.FindStringSubmatch(expr); m != nil {
+			sb.WriteString("$(" + m[1] + ")")
+		} else {
+			logger.Warn("workflow expression not converted", "expression", expr)
+		}
+		last = loc[1]
+	}
+	sb.WriteString(escapeDollars(value[last:]))
+	return sb.String()
+}
+
+// invalidJobIDChars matches the characters of target names GitHub Actions
+// does not accept in job ids.
+var invalidJobIDChars = regexp.MustCompile(`[^A-Za-z0-9_-]+`)
+
+// exportWorkflow converts the Makefile into a GitHub Actions workflow,
+// run on pushes and pull requests, whose jobs check out the repository,
+// set up Go with the version of go.mod when the recipes use it, and run
+// make. If the Makefile declares a ci target, a single job runs it;
+// otherwise, each of its lint, vet, test and build targets becomes a job,
+// needing the jobs of its dependencies. The help comment of a target
+// names its job.
+func exportWorkflow(_ []Variable, targets []Target) ([]byte, error) {
+	names, byName, err := ciTargets(targets)
+	if err != nil {
+		return nil, err
+	}
+	jobs := yamlMapping()
+	for _, name := range names {
+		t := byName[name]
+		job := yamlMapping()
+		if t.Description != "" {
+			job.Content = append(job.Content, yamlString("name"), yamlString(t.Description))
+		}
+		var needs []*yaml.Node
+		for _, d := range t.Dependencies {
+			if d != name && slices.Contains(names, d) {
+				needs = append(needs, yamlString(workflowJobID(d)))
+			}
+		}
+		if len(needs) > 0 {
+			job.Content = append(job.Content, yamlString("needs"), yamlSequence(needs...))
+		}
+		steps := yamlSequence(yamlMapping(yamlString("uses"), yamlString("actions/checkout@v4")))
+		if usesGo(byName, name, make(map[string]bool)) {
+			steps.Content = append(steps.Content, yamlMapping(
+				yamlString("uses"), yamlString("actions/setup-go@v5"),
+				yamlString("with"), yamlMapping(yamlString("go-version-file"), yamlString("go.mod")),
+			))
+		}
+		steps.Content = append(steps.Content, yamlMapping(
+			yamlString("name"), yamlString("make "+name),
+			yamlString("run"), yamlString("make "+name),
+		))
+		job.Content = append(job.Content,
+			yamlString("runs-on"), yamlString("ubuntu-latest"),
+			yamlString("steps"), steps,
+		)
+		jobs.Content = append(jobs.Content, yamlString(workflowJobID(name)), job)
+	}
+	doc := yamlMapping(
+		yamlString("name"), yamlString("CI"),
+		yamlString("on"), yamlMapping(
+			yamlString("push"), yamlMapping(yamlString("branches"), yamlSequence(yamlString("main"))),
+			yamlString("pull_request"), yamlMapping(),
+		),
+		yamlString("jobs"), jobs,
+	)
+	return encodeYAML(doc)
+}
+
+// workflowJobID returns the id of the job running the given target.
+func workflowJobID(name string) string {
+	return invalidJobIDChars.ReplaceAllString(name, "-")
+}
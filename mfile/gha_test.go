@@ -0,0 +1,225 @@
+// Copyright (c) 2023 Tiago Melo. All rights reserved.
+// Use of this source code is governed by the MIT License that can be found in
+// the LICENSE file.
+
+package mfile
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestImportWorkflow(t *testing.T) {
+	testCases := []struct {
+		name              string
+		content           string
+		expectedVariables []Variable
+		expectedTargets   []Target
+		expectedError     error
+	}{
+		{
+			name: "happy path",
+			content: `name: CI
+on: [push]
+env:
+  GOFLAGS: -mod=mod
+jobs:
+  lint:
+    name: Lint code
+    runs-on: ubuntu-latest
+    steps:
+      - uses: actions/checkout@v4
+      - uses: actions/setup-go@v5
+      - name: Vet
+        run: go vet ./...
+      - name: Format
+        shell: pwsh
+        run: Get-Item
+  test:
+    needs: lint
+    strategy:
+      matrix:
+        go: ["1.21", "1.22"]
+    env:
+      CGO_ENABLED: "0"
+    defaults:
+      run:
+        working-directory: src
+    steps:
+      - name: Test "all"
+        run: |
+          echo go ${{ matrix.go }} ${{ github.sha }}
+          go test -count=1 $PKGS
+        env:
+          PKGS: ./...
+      - run: echo ${{ env.CGO_ENABLED }}
+        working-directory: ${{ inputs.dir }}
+`,
+			expectedVariables: []Variable{{Name: "GOFLAGS", Operator: "?=", Value: "-mod=mod", Export: true}},
+			expectedTargets: []Target{
+				{
+					Name:        "lint",
+					Description: "run the steps of the Lint code job",
+					Recipe:      []string{`@ echo "==> Vet"`, "go vet ./..."},
+					Phony:       true,
+				},
+				{
+					Name:         "test",
+					Description:  "run the steps of the test job",
+					Dependencies: []string{"lint"},
+					Recipe: []string{
+						`@ echo "==> Test \"all\""`,
+						"set -e; cd src; export PKGS=./...; echo go $(go) ; go test -count=1 $$PKGS",
+						"cd $(dir) && echo $(CGO_ENABLED)",
+					},
+					Variables: []Variable{
+						{Name: "CGO_ENABLED", Operator: "?=", Value: "0", Export: true},
+						{Name: "go", Operator: "?=", Value: "1.21"},
+					},
+					Phony: true,
+				},
+			},
+		},
+		{
+			name: "needs list",
+			content: `jobs:
+  deploy:
+    needs: [build, test]
+    steps:
+      - run: ./deploy.sh
+`,
+			expectedTargets: []Target{
+				{
+					Name:         "deploy",
+					Description:  "run the steps of the deploy job",
+					Dependencies: []string{"build", "test"},
+					Recipe:       []string{"./deploy.sh"},
+					Phony:        true,
+				},
+			},
+		},
+		{
+			name:          "no job",
+			content:       "name: CI\n",
+			expectedError: errors.New("no job found"),
+		},
+		{
+			name:          "invalid YAML",
+			content:       "jobs: [",
+			expectedError: errors.New("parsing workflow: yaml: line 1: did not find expected node content"),
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			variables, targets, err := importWorkflow([]byte(tc.content), nil)
+			if err != nil {
+				if tc.expectedError == nil {
+					t.Fatalf("expected no error, got %v", err)
+				}
+				require.Equal(t, tc.expectedError.Error(), err.Error())
+			} else {
+				if tc.expectedError != nil {
+					t.Fatalf("expected error to be %v, got nil", tc.expectedError)
+				}
+				require.Equal(t, tc.expectedVariables, variables)
+				require.Equal(t, tc.expectedTargets, targets)
+			}
+		})
+	}
+}
+
+func TestExportWorkflow(t *testing.T) {
+	testCases := []struct {
+		name            string
+		targets         []Target
+		expectedContent string
+		expectedError   error
+	}{
+		{
+			name: "happy path, ci target",
+			targets: []Target{
+				helpTarget,
+				{Name: "test", Recipe: []string{"go test ./..."}, Phony: true},
+				{Name: "ci", Description: "run the CI checks", Dependencies: []string{"test"}, Phony: true},
+			},
+			expectedContent: `name: CI
+on:
+  push:
+    branches:
+      - main
+  pull_request: {}
+jobs:
+  ci:
+    name: run the CI checks
+    runs-on: ubuntu-latest
+    steps:
+      - uses: actions/checkout@v4
+      - uses: actions/setup-go@v5
+        with:
+          go-version-file: go.mod
+      - name: make ci
+        run: make ci
+`,
+		},
+		{
+			name: "happy path, a job per target",
+			targets: []Target{
+				{Name: "build", Dependencies: []string{"lint"}, Recipe: []string{"docker build ."}, Phony: true},
+				{Name: "lint", Description: "run linters", Recipe: []string{"@ $(GO) vet ./..."}, Phony: true},
+				{Name: "clean", Recipe: []string{"rm -rf bin"}, Phony: true},
+			},
+			expectedContent: `name: CI
+on:
+  push:
+    branches:
+      - main
+  pull_request: {}
+jobs:
+  lint:
+    name: run linters
+    runs-on: ubuntu-latest
+    steps:
+      - uses: actions/checkout@v4
+      - uses: actions/setup-go@v5
+        with:
+          go-version-file: go.mod
+      - name: make lint
+        run: make lint
+  build:
+    needs:
+      - lint
+    runs-on: ubuntu-latest
+    steps:
+      - uses: actions/checkout@v4
+      - uses: actions/setup-go@v5
+        with:
+          go-version-file: go.mod
+      - name: make build
+        run: make build
+`,
+		},
+		{
+			name:          "no CI target",
+			targets:       []Target{{Name: "clean", Recipe: []string{"rm -rf bin"}}},
+			expectedError: errors.New("no ci, lint, vet, test or build target found"),
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			content, err := exportWorkflow(nil, tc.targets)
+			if err != nil {
+				if tc.expectedError == nil {
+					t.Fatalf("expected no error, got %v", err)
+				}
+				require.Equal(t, tc.expectedError.Error(), err.Error())
+			} else {
+				if tc.expectedError != nil {
+					t.Fatalf("expected error to be %v, got nil", tc.expectedError)
+				}
+				require.Equal(t, tc.expectedContent, string(content))
+			}
+		})
+	}
+}
@@ -0,0 +1,79 @@
+// Copyright (c) 2023 Tiago Melo. All rights reserved.
+// Use of this source code is governed by the MIT License that can be found in
+// the LICENSE file.
+
+package mfile
+
+import (
+	"slices"
+
+	"gopkg.in/yaml.v3"
+)
+
+// gitLabGoImage is the image of the jobs whose recipes run the go command.
+const gitLabGoImage = "golang:latest"
+
+// exportGitLabCI converts the Makefile into a GitLab CI configuration
+// whose jobs run make. The targets annotated with a "## ci-stage: stage"
+// comment become jobs of that stage, in the order the stages are first
+// used. Without annotations, the ci target, or else the lint, vet, test
+// and build targets, become jobs of the test stage, build of the build
+// one. Jobs need the jobs of their dependencies, and use a Go image when
+// their recipes run go.
+func exportGitLabCI(_ []Variable, targets []Target) ([]byte, error) {
+	var (
+		names  []string
+		stages []string
+		stage  = make(map[string]string)
+		byName = make(map[string]Target)
+	)
+	for _, t := range targets {
+		byName[t.Name] = t
+		if t.CIStage != "" {
+			names = append(names, t.Name)
+			stage[t.Name] = t.CIStage
+		}
+	}
+	if len(names) == 0 {
+		var err error
+		if names, byName, err = ciTargets(targets); err != nil {
+			return nil, err
+		}
+		for _, name := range names {
+			stage[name] = "test"
+			if name == "build" {
+				stage[name] = "build"
+			}
+		}
+	}
+	for _, name := range names {
+		if !slices.Contains(stages, stage[name]) {
+			stages = append(stages, stage[name])
+		}
+	}
+	doc := yamlMapping()
+	seq := yamlSequence()
+	for _, s := range stages {
+		seq.Content = append(seq.Content, yamlString(s))
+	}
+	doc.Content = append(doc.Content, yamlString("stages"), seq)
+	for _, name := range names {
+		t := byName[name]
+		job := yamlMapping(yamlString("stage"), yamlString(stage[name]))
+		if usesGo(byName, name, make(map[string]bool)) {
+			job.Content = append(job.Content, yamlString("image"), yamlString(gitLabGoImage))
+		}
+		var needs []*yaml.Node
+		for _, d := range t.Dependencies {
+			if d != name && slices.Contains(names, d) {
+				needs = append(needs, yamlString(d))
+			}
+		}
+		if len(needs) > 0 {
+			job.Content = append(job.Content, yamlString("needs"), yamlSequence(needs...))
+		}
+		job.Content = append(job.Content, yamlString("script"), yamlSequence(yamlString("make "+name)))
+		doc.Content = append(doc.Content, yamlString(name), job)
+	}
+	return encodeYAML(doc)
+}
@@ -0,0 +1,93 @@
+// Copyright (c) 2023 Tiago Melo. All rights reserved.
+// Use of this source code is governed by the MIT License that can be found in
+// the LICENSE file.
+
+package mfile
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestExportGitLabCI(t *testing.T) {
+	testCases := []struct {
+		name            string
+		targets         []Target
+		expectedContent string
+		expectedError   error
+	}{
+		{
+			name: "happy path, CI stages",
+			targets: []Target{
+				{Name: "lint", Recipe: []string{"golangci-lint run"}, CIStage: "check"},
+				{Name: "test", Dependencies: []string{"lint"}, Recipe: []string{"go test ./..."}, CIStage: "test"},
+				{Name: "build", Recipe: []string{"docker build ."}},
+				{Name: "vet", Recipe: []string{"go vet ./..."}, CIStage: "check"},
+			},
+			expectedContent: `stages:
+  - check
+  - test
+lint:
+  stage: check
+  script:
+    - make lint
+test:
+  stage: test
+  image: golang:latest
+  needs:
+    - lint
+  script:
+    - make test
+vet:
+  stage: check
+  image: golang:latest
+  script:
+    - make vet
+`,
+		},
+		{
+			name: "happy path, without CI stages",
+			targets: []Target{
+				helpTarget,
+				{Name: "build", Recipe: []string{"docker build ."}},
+				{Name: "test", Recipe: []string{"go test ./..."}},
+			},
+			expectedContent: `stages:
+  - test
+  - build
+test:
+  stage: test
+  image: golang:latest
+  script:
+    - make test
+build:
+  stage: build
+  script:
+    - make build
+`,
+		},
+		{
+			name:          "no CI target",
+			targets:       []Target{{Name: "clean", Recipe: []string{"rm -rf bin"}}},
+			expectedError: errors.New("no ci, lint, vet, test or build target found"),
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			content, err := exportGitLabCI(nil, tc.targets)
+			if err != nil {
+				if tc.expectedError == nil {
+					t.Fatalf("expected no error, got %v", err)
+				}
+				require.Equal(t, tc.expectedError.Error(), err.Error())
+			} else {
+				if tc.expectedError != nil {
+					t.Fatalf("expected error to be %v, got nil", tc.expectedError)
+				}
+				require.Equal(t, tc.expectedContent, string(content))
+			}
+		})
+	}
+}
@@ -0,0 +1,60 @@
+// Copyright (c) 2023 Tiago Melo. All rights reserved.
+// Use of this source code is governed by the MIT License that can be found in
+// the LICENSE file.
+
+package mfile
+
+import (
+	"regexp"
+	"strings"
+)
+
+// RecipeMatch is a recipe line matching a search.
+type RecipeMatch struct {
+	Targets []string // Targets whose recipe has the line, more than one for rules declaring several.
+	Line    int      // 1-based line number.
+	Text    string   // The line, without the recipe prefix and indentation.
+}
+
+// Grep parses the Makefile at the given path and returns the recipe lines
+// matching the given pattern, in order, with the targets running them.
+func Grep(path string, pattern *regexp.Regexp) ([]RecipeMatch, error) {
+	content, err := readMakefile(mkFilePath(path))
+	if err != nil {
+		return nil, err
+	}
+	var (
+		matches []RecipeMatch
+		current []string
+		prefix  = "\t"
+	)
+	lines := strings.Split(content, "\n")
+	for i := 0; i < len(lines); i++ {
+		line := strings.TrimRight(lines[i], "\r")
+		if strings.HasPrefix(line, prefix) {
+			text := strings.TrimLeft(strings.TrimPrefix(line, prefix), " \t")
+			if len(current) > 0 && pattern.MatchString(text) {
+				matches = append(matches, RecipeMatch{Targets: current, Line: i + 1, Text: text})
+			}
+			continue
+		}
+		for strings.HasSuffix(line, "\\") && i+1 < len(lines) {
+			i++
+			line = strings.TrimSuffix(line, "\\") + " " + strings.TrimSpace(lines[i])
+		}
+		if isConditional(line) {
+			// Conditionals may wrap recipe lines, which then still
+			// belong to the current rule.
+			continue
+		}
+		current = nil
+		if p, ok := parseRecipePrefix(line); ok {
+			prefix = p
+			continue
+		}
+		if names, _, ok := parseRule(line); ok && !strings.HasPrefix(names[0], ".") {
+			current = names
+		}
+	}
+	return matches, nil
+}
@@ -0,0 +1,87 @@
+// Copyright (c) 2023 Tiago Melo. All rights reserved.
+// Use of this source code is governed by the MIT License that can be found in
+// the LICENSE file.
+
+package mfile
+
+import (
+	"errors"
+	"regexp"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestGrep(t *testing.T) {
+	const content = `IMAGE ?= app
+
+## image: build the image
+image:
+	docker build -t $(IMAGE) .
+
+## release: push the image
+release publish: image
+ifeq ($(CI),true)
+	docker push $(IMAGE)
+else
+	@ echo "not pushing"
+endif
+
+.RECIPEPREFIX = >
+deploy: release
+> docker push $(IMAGE):latest
+`
+	testCases := []struct {
+		name            string
+		content         string
+		pattern         string
+		expectedMatches []RecipeMatch
+		expectedError   error
+	}{
+		{
+			name:    "happy path",
+			content: content,
+			pattern: `docker push`,
+			expectedMatches: []RecipeMatch{
+				{Targets: []string{"release", "publish"}, Line: 10, Text: "docker push $(IMAGE)"},
+				{Targets: []string{"deploy"}, Line: 17, Text: "docker push $(IMAGE):latest"},
+			},
+		},
+		{
+			name:    "happy path, regular expression",
+			content: content,
+			pattern: `^docker (build|push) -t`,
+			expectedMatches: []RecipeMatch{
+				{Targets: []string{"image"}, Line: 5, Text: "docker build -t $(IMAGE) ."},
+			},
+		},
+		{
+			name:    "no match",
+			content: content,
+			pattern: `kubectl`,
+		},
+		{
+			name:          "invalid Makefile",
+			content:       "\x00",
+			pattern:       `docker`,
+			expectedError: errors.New("reading Makefile at Makefile: not a Makefile"),
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			fsProvider = &mockFileSystem{file: []byte(tc.content)}
+			matches, err := Grep("Makefile", regexp.MustCompile(tc.pattern))
+			if err != nil {
+				if tc.expectedError == nil {
+					t.Fatalf("expected no error, got %v", err)
+				}
+				require.Equal(t, tc.expectedError.Error(), err.Error())
+			} else {
+				if tc.expectedError != nil {
+					t.Fatalf("expected error to be %v, got nil", tc.expectedError)
+				}
+				require.Equal(t, tc.expectedMatches, matches)
+			}
+		})
+	}
+}
@@ -0,0 +1,114 @@
+// Copyright (c) 2023 Tiago Melo. All rights reserved.
+// Use of this source code is governed by the MIT License that can be found in
+// the LICENSE file.
+
+package mfile
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+)
+
+// Implementations of the help target.
+const (
+	// HelpStyleAWK lists the "## name: description" comments of the
+	// Makefile with awk, which behaves the same everywhere, grouped under
+	// the "##@ Section" comments. The default.
+	HelpStyleAWK = "awk"
+
+	// HelpStyleSed lists the "## name: description" comments of the
+	// Makefile with sed and column, which is missing on minimal images,
+	// leaving out the "##@ Section" comments.
+	HelpStyleSed = "sed"
+
+	// HelpStyleInfo lists the targets known when the Makefile is generated
+	// with $(info), grouped by section, without running any command.
+	// Targets added afterwards are not listed.
+	HelpStyleInfo = "info"
+)
+
+// helpRecipes are the recipes of the help target, by style. The info
+// style is computed from the targets of the Makefile.
+var helpRecipes = map[string][]string{
+	HelpStyleAWK: {
+		`@ awk 'BEGIN { print "Usage: make [target]\n" } /^##@/ { printf "\n%s\n", substr($$0, 5); next } /^##/ { line = substr($$0, 3); sub(/^ +/, "", line); i = index(line, ":"); if (i > 1) { desc = substr(line, i + 1); sub(/^ +/, "", desc); printf "  %-24s %s\n", substr(line, 1, i - 1), desc } }' $(MAKEFILE_LIST)`,
+	},
+	HelpStyleSed: {
+		`@ echo "Usage: make [target]\n"`,
+		`@ sed -n '/^##@/d; s/^##//p' ${MAKEFILE_LIST} | column -t -s ':' |  sed -e 's/^/ /'`,
+	},
+}
+
+// HelpStyles returns the implementations of the help target.
+func HelpStyles() []string {
+	return []string{HelpStyleAWK, HelpStyleSed, HelpStyleInfo}
+}
+
+// applyHelpStyle makes the built-in help target among the given ones use
+// the given style. Help targets declared otherwise are left as they are.
+func applyHelpStyle(style string, targets []Target) error {
+	if style == "" {
+		style = HelpStyleAWK
+	}
+	if !slices.Contains(HelpStyles(), style) {
+		return fmt.Errorf("unknown help style %q", style)
+	}
+	for i, t := range targets {
+		if t.Name != helpTarget.Name || !slices.Equal(t.Recipe, helpTarget.Recipe) {
+			continue
+		}
+		if style == HelpStyleInfo {
+			targets[i].Recipe = infoHelpRecipe(targets)
+		} else {
+			targets[i].Recipe = helpRecipes[style]
+		}
+	}
+	return nil
+}
+
+// infoHelpRecipe returns a help recipe listing the given targets that
+// have a description with $(info), grouped by section.
+func infoHelpRecipe(targets []Target) []string {
+	width := 0
+	for _, t := range targets {
+		if t.Description != "" {
+			width = max(width, len(t.Name))
+		}
+	}
+	recipe := []string{"$(info Usage: make [target])", "$(info )"}
+	section := ""
+	for _, t := range targets {
+		if t.Description == "" {
+			continue
+		}
+		if ts := targetSection(t); ts != section {
+			recipe = append(recipe, "$(info )", "$(info "+ts+")")
+			section = ts
+		}
+		line := fmt.Sprintf("%-*s  %s", width, t.Name, t.Description)
+		recipe = append(recipe, "$(info "+strings.ReplaceAll(line, "$", "$$")+")")
+	}
+	return append(recipe, "@ :")
+}
+
+// isBuiltinHelp reports whether the given target is the help target as
+// generated, in any style and dialect.
+func isBuiltinHelp(t Target) bool {
+	if t.Name != helpTarget.Name || len(t.Recipe) == 0 {
+		return false
+	}
+	for _, recipe := range helpRecipes {
+		_, dialectTargets := toDialect(DialectPOSIX, nil, []Target{{Recipe: recipe}})
+		if slices.Equal(t.Recipe, recipe) || slices.Equal(t.Recipe, dialectTargets[0].Recipe) {
+			return true
+		}
+	}
+	last := len(t.Recipe) - 1
+	for _, line := range t.Recipe[:last] {
+		if !strings.HasPrefix(line, "$(info ") {
+			return false
+		}
+	}
+	return t.Recipe[last] == "@ :"
+}
@@ -0,0 +1,126 @@
+// Copyright (c) 2023 Tiago Melo. All rights reserved.
+// Use of this source code is governed by the MIT License that can be found in
+// the LICENSE file.
+
+package mfile
+
+import (
+	"errors"
+	"os/exec"
+	"regexp"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestApplyHelpStyle(t *testing.T) {
+	customHelp := Target{Name: "help", Recipe: []string{"@ cat README.md"}}
+	build := Target{Name: "build", Description: "build $(BINARY_NAME)", Recipe: []string{"@ go build"}}
+	bare := Target{Name: "bare-target"}
+	testCases := []struct {
+		name            string
+		style           string
+		targets         []Target
+		expectedRecipes [][]string
+		expectedError   error
+	}{
+		{
+			name:            "default style",
+			targets:         []Target{helpTarget, build},
+			expectedRecipes: [][]string{helpRecipes[HelpStyleAWK], build.Recipe},
+		},
+		{
+			name:            "sed style",
+			style:           HelpStyleSed,
+			targets:         []Target{helpTarget, build},
+			expectedRecipes: [][]string{helpRecipes[HelpStyleSed], build.Recipe},
+		},
+		{
+			name:    "info style",
+			style:   HelpStyleInfo,
+			targets: []Target{helpTarget, bare, build},
+			expectedRecipes: [][]string{
+				{
+					"$(info Usage: make [target])",
+					"$(info )",
+					"$(info help   shows this help message)",
+					"$(info build  build $$(BINARY_NAME))",
+					"@ :",
+				},
+				nil,
+				build.Recipe,
+			},
+		},
+		{
+			name:  "info style with sections",
+			style: HelpStyleInfo,
+			targets: []Target{
+				helpTarget,
+				{Name: "build", Description: "build it", Section: "Build"},
+				{Name: "test", Description: "test it", Section: "Test"},
+			},
+			expectedRecipes: [][]string{
+				{
+					"$(info Usage: make [target])",
+					"$(info )",
+					"$(info help   shows this help message)",
+					"$(info )",
+					"$(info Build)",
+					"$(info build  build it)",
+					"$(info )",
+					"$(info Test)",
+					"$(info test   test it)",
+					"@ :",
+				},
+				nil,
+				nil,
+			},
+		},
+		{
+			name:            "custom help target is kept",
+			style:           HelpStyleSed,
+			targets:         []Target{customHelp},
+			expectedRecipes: [][]string{customHelp.Recipe},
+		},
+		{
+			name:          "unknown style",
+			style:         "unknown",
+			targets:       []Target{helpTarget},
+			expectedError: errors.New(`unknown help style "unknown"`),
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			targets := append([]Target(nil), tc.targets...)
+			err := applyHelpStyle(tc.style, targets)
+			if err != nil {
+				if tc.expectedError == nil {
+					t.Fatalf("expected no error, got %v", err)
+				}
+				require.Equal(t, tc.expectedError.Error(), err.Error())
+			} else {
+				if tc.expectedError != nil {
+					t.Fatalf("expected error to be %v, got nil", tc.expectedError)
+				}
+				var recipes [][]string
+				for _, target := range targets {
+					recipes = append(recipes, target.Recipe)
+				}
+				require.Equal(t, tc.expectedRecipes, recipes)
+			}
+		})
+	}
+}
+
+func TestHelpStyleSedSections(t *testing.T) {
+	if _, err := exec.LookPath("sed"); err != nil {
+		t.Skip("sed is not installed")
+	}
+	script := regexp.MustCompile(`sed -n '([^']*)'`).FindStringSubmatch(helpRecipes[HelpStyleSed][1])[1]
+	cmd := exec.Command("sed", "-n", script)
+	cmd.Stdin = strings.NewReader("##@ Build\n\n.PHONY: build\n## build: build it\nbuild:\n")
+	out, err := cmd.Output()
+	require.NoError(t, err)
+	require.Equal(t, " build: build it\n", string(out))
+}
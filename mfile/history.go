@@ -0,0 +1,272 @@
+// Copyright (c) 2023 Tiago Melo. All rights reserved.
+// Use of this source code is governed by the MIT License that can be found in
+// the LICENSE file.
+
+package mfile
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// HistoryFile is the audit log of the changes made to the Makefiles of a
+// directory, relative to it, see RecordChange.
+const HistoryFile = ".gomakefile/history.jsonl"
+
+// SnapshotsDir is the directory holding the contents of the Makefiles of a
+// directory before their recorded changes, relative to it, named after
+// their checksum. Undo restores them.
+const SnapshotsDir = ".gomakefile/snapshots"
+
+// HistoryEntry is a change of a Makefile recorded in its audit log.
+type HistoryEntry struct {
+	Time     time.Time `json:"time"`
+	Command  string    `json:"command"`        // Command making the change, like "gomakefile addtarget -t lint".
+	User     string    `json:"user,omitempty"` // User running the command.
+	Makefile string    `json:"makefile"`       // Name of the Makefile, like Makefile or Makefile.ci.
+
+	// BeforeChecksum is the SHA-256 checksum of the Makefile before the
+	// change, empty if it did not exist.
+	BeforeChecksum string `json:"beforeChecksum"`
+
+	// AfterChecksum is the SHA-256 checksum of the Makefile after the
+	// change, empty if it was removed.
+	AfterChecksum string `json:"afterChecksum"`
+
+	DiffHash string `json:"diffHash"`         // SHA-256 hash of the unified diff of the change.
+	Undone   int    `json:"undone,omitempty"` // Number of operations the change reverted, see Undo.
+}
+
+// HistoryEnabled reports whether the Makefile at the given path has an
+// audit log, see RecordChange.
+func HistoryEnabled(path string) bool {
+	_, err := fsProvider.Stat(historyPath(mkFilePath(path)))
+	return err == nil
+}
+
+// RecordChange appends the change of the Makefile at the given path from
+// the given content, nil if it did not exist, to its current one, to the
+// audit log of its directory, see HistoryFile, creating it if needed.
+// The log is append-only, one JSON entry per line, so that teams can trace
+// what changed the Makefile, and when. The given content is kept in
+// SnapshotsDir, so that the change can be undone. The Command and User of
+// the given entry are kept, and its Time, if set; the other fields are set
+// from the change. Nothing is recorded if the Makefile is unchanged.
+func RecordChange(path string, before []byte, entry HistoryEntry) error {
+	makeFilePath := mkFilePath(path)
+	after, err := fsProvider.ReadFile(makeFilePath)
+	if err != nil {
+		if !fsProvider.IsNotExist(err) {
+			return &Error{Op: "reading Makefile", Path: makeFilePath, Err: err}
+		}
+		after = nil
+	}
+	if (before == nil) == (after == nil) && string(before) == string(after) {
+		return nil
+	}
+	if entry.Time.IsZero() {
+		entry.Time = time.Now().UTC()
+	}
+	entry.Makefile = filepath.Base(makeFilePath)
+	if before != nil {
+		entry.BeforeChecksum = checksum(before)
+		if err := writeSnapshot(makeFilePath, before); err != nil {
+			return err
+		}
+	}
+	if after != nil {
+		entry.AfterChecksum = checksum(after)
+	}
+	entry.DiffHash = checksum([]byte(lineDiff("a/"+entry.Makefile, "b/"+entry.Makefile, string(before), string(after))))
+	line, err := json.Marshal(entry)
+	if err != nil {
+		return err
+	}
+	logPath := historyPath(makeFilePath)
+	if err := fsProvider.MkdirAll(filepath.Dir(logPath), 0755); err != nil {
+		return &Error{Op: "creating audit log", Path: logPath, Err: err}
+	}
+	file, err := fsProvider.OpenFile(logPath, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0644)
+	if err != nil {
+		return &Error{Op: "opening audit log", Path: logPath, Err: err}
+	}
+	defer file.Close()
+	if _, err := file.Write(append(line, '\n')); err != nil {
+		return &Error{Op: "writing audit log", Path: logPath, Err: err}
+	}
+	logger.Debug("recorded change", "path", logPath, "makefile", entry.Makefile, "checksum", entry.AfterChecksum)
+	return nil
+}
+
+// History returns the changes of the Makefile at the given path recorded
+// in the audit log of its directory, oldest first. It returns no entries
+// if there is no log, see RecordChange.
+func History(path string) ([]HistoryEntry, error) {
+	makeFilePath := mkFilePath(path)
+	logPath := historyPath(makeFilePath)
+	content, err := fsProvider.ReadFile(logPath)
+	if err != nil {
+		if fsProvider.IsNotExist(err) {
+			return nil, nil
+		}
+		return nil, &Error{Op: "reading audit log", Path: logPath, Err: err}
+	}
+	name := filepath.Base(makeFilePath)
+	var entries []HistoryEntry
+	for i, line := range strings.Split(string(content), "\n") {
+		if strings.TrimSpace(line) == "" {
+			continue
+		}
+		var e HistoryEntry
+		if err := json.Unmarshal([]byte(line), &e); err != nil {
+			return nil, &Error{Op: "reading audit log", Path: logPath, Err: fmt.Errorf("line %d: %w", i+1, err)}
+		}
+		if e.Makefile == name {
+			entries = append(entries, e)
+		}
+	}
+	return entries, nil
+}
+
+// RestorePoints returns the recorded changes of the Makefile at the given
+// path that Undo can revert, newest first. The changes reverted by an
+// earlier undo are left out, and so are the ones recorded before their
+// snapshot, see SnapshotsDir, along with the older ones.
+func RestorePoints(path string) ([]HistoryEntry, error) {
+	makeFilePath := mkFilePath(path)
+	entries, err := History(makeFilePath)
+	if err != nil {
+		return nil, err
+	}
+	return restorePoints(makeFilePath, entries), nil
+}
+
+// restorePoints returns the given recorded changes of the Makefile at the
+// given path that can be undone, newest first.
+func restorePoints(makeFilePath string, entries []HistoryEntry) []HistoryEntry {
+	var (
+		points []HistoryEntry
+		undone int
+	)
+	for i := len(entries) - 1; i >= 0; i-- {
+		e := entries[i]
+		switch {
+		case e.Undone > 0:
+			undone += e.Undone
+			continue
+		case undone > 0:
+			undone--
+			continue
+		}
+		if e.BeforeChecksum != "" {
+			if _, err := fsProvider.Stat(snapshotPath(makeFilePath, e.BeforeChecksum)); err != nil {
+				break
+			}
+		}
+		points = append(points, e)
+	}
+	return points
+}
+
+// Undo reverts the last n operations recorded for the Makefile at the
+// given path, see RestorePoints, restoring its content from before the
+// oldest of them, or removing it if that operation created it. It
+// returns the operations reverted, newest first. Unless force, it fails if
+// the Makefile changed since its last recorded operation, as those changes
+// would be lost. The undo is itself recorded, with the Command and User of
+// the given entry, see RecordChange.
+func Undo(path string, n int, force bool, entry HistoryEntry) ([]HistoryEntry, error) {
+	makeFilePath := mkFilePath(path)
+	if n < 1 {
+		return nil, fmt.Errorf("invalid number of operations to undo %d, want at least 1", n)
+	}
+	entries, err := History(makeFilePath)
+	if err != nil {
+		return nil, err
+	}
+	points := restorePoints(makeFilePath, entries)
+	if n > len(points) {
+		return nil, &Error{Op: "undoing", Path: makeFilePath, Err: fmt.Errorf("%d operation(s) to undo, only %d recorded", n, len(points))}
+	}
+	current, err := fsProvider.ReadFile(makeFilePath)
+	if err != nil {
+		if !fsProvider.IsNotExist(err) {
+			return nil, &Error{Op: "reading Makefile", Path: makeFilePath, Err: err}
+		}
+		current = nil
+	}
+	var currentChecksum string
+	if current != nil {
+		currentChecksum = checksum(current)
+	}
+	if !force && currentChecksum != entries[len(entries)-1].AfterChecksum {
+		return nil, &Error{Op: "undoing", Path: makeFilePath, Err: errors.New("the Makefile changed since its last recorded operation")}
+	}
+	restored := points[n-1]
+	if restored.BeforeChecksum == "" {
+		if current != nil {
+			if err := fsProvider.Remove(makeFilePath); err != nil {
+				return nil, &Error{Op: "removing Makefile", Path: makeFilePath, Err: err}
+			}
+		}
+	} else {
+		snapshot := snapshotPath(makeFilePath, restored.BeforeChecksum)
+		content, err := fsProvider.ReadFile(snapshot)
+		if err != nil {
+			return nil, &Error{Op: "reading snapshot", Path: snapshot, Err: err}
+		}
+		// Written atomically, so that an interrupted undo doesn't leave
+		// a truncated Makefile.
+		if err := fsProvider.WriteFileFrom(makeFilePath, bytes.NewReader(content), 0644); err != nil {
+			return nil, &Error{Op: "writing Makefile", Path: makeFilePath, Err: err}
+		}
+	}
+	entry.Undone = n
+	if err := RecordChange(makeFilePath, current, entry); err != nil {
+		return nil, err
+	}
+	logger.Debug("undone operations", "path", makeFilePath, "operations", n, "checksum", restored.BeforeChecksum)
+	return points[:n], nil
+}
+
+// writeSnapshot keeps the given content of the Makefile at the given path
+// in SnapshotsDir, unless it is already there.
+func writeSnapshot(makeFilePath string, content []byte) error {
+	snapshot := snapshotPath(makeFilePath, checksum(content))
+	if _, err := fsProvider.Stat(snapshot); err == nil {
+		return nil
+	}
+	if err := fsProvider.MkdirAll(filepath.Dir(snapshot), 0755); err != nil {
+		return &Error{Op: "creating snapshot", Path: snapshot, Err: err}
+	}
+	if err := fsProvider.WriteFile(snapshot, content, 0644); err != nil {
+		return &Error{Op: "writing snapshot", Path: snapshot, Err: err}
+	}
+	return nil
+}
+
+// snapshotPath returns the path of the snapshot of the Makefile at the
+// given path with the given checksum.
+func snapshotPath(makeFilePath, sum string) string {
+	return filepath.Join(filepath.Dir(makeFilePath), SnapshotsDir, sum)
+}
+
+// historyPath returns the path of the audit log of the Makefile at the
+// given path.
+func historyPath(makeFilePath string) string {
+	return filepath.Join(filepath.Dir(makeFilePath), HistoryFile)
+}
+
+// checksum returns the hex-encoded SHA-256 checksum of the given content.
+func checksum(content []byte) string {
+	sum := sha256.Sum256(content)
+	return hex.EncodeToString(sum[:])
+}
@@ -0,0 +1,233 @@
+// Copyright (c) 2023 Tiago Melo. All rights reserved.
+// Use of this source code is governed by the MIT License that can be found in
+// the LICENSE file.
+
+package mfile
+
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestRecordChange(t *testing.T) {
+	now := time.Date(2023, 11, 5, 10, 0, 0, 0, time.UTC)
+	testCases := []struct {
+		name            string
+		makefile        string
+		before          []byte
+		after           string
+		expectedEntries []HistoryEntry
+	}{
+		{
+			name:     "happy path",
+			makefile: "Makefile",
+			before:   []byte("build:\n"),
+			after:    "build:\n\ttest:\n",
+			expectedEntries: []HistoryEntry{{
+				Time:           now,
+				Command:        "gomakefile addtarget -t test",
+				User:           "gopher",
+				Makefile:       "Makefile",
+				BeforeChecksum: checksum([]byte("build:\n")),
+				AfterChecksum:  checksum([]byte("build:\n\ttest:\n")),
+				DiffHash:       checksum([]byte(lineDiff("a/Makefile", "b/Makefile", "build:\n", "build:\n\ttest:\n"))),
+			}},
+		},
+		{
+			name:     "happy path, new Makefile",
+			makefile: "Makefile.ci",
+			after:    "build:\n",
+			expectedEntries: []HistoryEntry{{
+				Time:          now,
+				Command:       "gomakefile addtarget -t test",
+				User:          "gopher",
+				Makefile:      "Makefile.ci",
+				AfterChecksum: checksum([]byte("build:\n")),
+				DiffHash:      checksum([]byte(lineDiff("a/Makefile.ci", "b/Makefile.ci", "", "build:\n"))),
+			}},
+		},
+		{
+			name:     "unchanged Makefile",
+			makefile: "Makefile",
+			before:   []byte("build:\n"),
+			after:    "build:\n",
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			fsProvider = osFileSystem{}
+			dir := t.TempDir()
+			path := filepath.Join(dir, tc.makefile)
+			require.NoError(t, os.WriteFile(path, []byte(tc.after), 0644))
+			require.False(t, HistoryEnabled(path))
+			entry := HistoryEntry{Time: now, Command: "gomakefile addtarget -t test", User: "gopher"}
+			require.NoError(t, RecordChange(path, tc.before, entry))
+			require.Equal(t, tc.expectedEntries != nil, HistoryEnabled(path))
+			file, err := os.Open(filepath.Join(dir, HistoryFile))
+			if tc.expectedEntries == nil {
+				require.True(t, os.IsNotExist(err))
+				return
+			}
+			require.NoError(t, err)
+			defer file.Close()
+			var entries []HistoryEntry
+			scanner := bufio.NewScanner(file)
+			for scanner.Scan() {
+				var e HistoryEntry
+				require.NoError(t, json.Unmarshal(scanner.Bytes(), &e))
+				entries = append(entries, e)
+			}
+			require.Equal(t, tc.expectedEntries, entries)
+		})
+	}
+}
+
+func TestUndo(t *testing.T) {
+	versions := []string{"build:\n", "build:\ntest:\n", "build:\ntest:\nlint:\n"}
+	testCases := []struct {
+		name             string
+		steps            []int
+		force            bool
+		edit             string
+		expectedContent  *string
+		expectedReverted []string
+		expectedPoints   []string
+		expectedError    string
+	}{
+		{
+			name:             "happy path",
+			steps:            []int{1},
+			expectedContent:  &versions[1],
+			expectedReverted: []string{"op 3"},
+			expectedPoints:   []string{"op 2", "op 1"},
+		},
+		{
+			name:             "happy path, several operations",
+			steps:            []int{2},
+			expectedContent:  &versions[0],
+			expectedReverted: []string{"op 3", "op 2"},
+			expectedPoints:   []string{"op 1"},
+		},
+		{
+			name:             "happy path, successive undos",
+			steps:            []int{1, 1},
+			expectedContent:  &versions[0],
+			expectedReverted: []string{"op 2"},
+			expectedPoints:   []string{"op 1"},
+		},
+		{
+			name:             "happy path, removing the created Makefile",
+			steps:            []int{3},
+			expectedReverted: []string{"op 3", "op 2", "op 1"},
+		},
+		{
+			name:             "happy path, forced after an edit",
+			steps:            []int{1},
+			force:            true,
+			edit:             "edited:\n",
+			expectedContent:  &versions[1],
+			expectedReverted: []string{"op 3"},
+			expectedPoints:   []string{"op 2", "op 1"},
+		},
+		{
+			name:          "too many operations",
+			steps:         []int{4},
+			expectedError: "undoing at %s: 4 operation(s) to undo, only 3 recorded",
+		},
+		{
+			name:          "edited since the last operation",
+			steps:         []int{1},
+			edit:          "edited:\n",
+			expectedError: "undoing at %s: the Makefile changed since its last recorded operation",
+		},
+		{
+			name:          "invalid number of operations",
+			steps:         []int{0},
+			expectedError: "invalid number of operations to undo 0, want at least 1",
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			fsProvider = atomicMakefileFileSystem{}
+			path := filepath.Join(t.TempDir(), "Makefile")
+			var before []byte
+			for i, v := range versions {
+				require.NoError(t, os.WriteFile(path, []byte(v), 0644))
+				require.NoError(t, RecordChange(path, before, HistoryEntry{Command: fmt.Sprintf("op %d", i+1)}))
+				before = []byte(v)
+			}
+			if tc.edit != "" {
+				require.NoError(t, os.WriteFile(path, []byte(tc.edit), 0644))
+			}
+			var (
+				reverted []HistoryEntry
+				err      error
+			)
+			for _, n := range tc.steps {
+				reverted, err = Undo(path, n, tc.force, HistoryEntry{Command: "gomakefile undo"})
+			}
+			if tc.expectedError != "" {
+				require.EqualError(t, err, strings.ReplaceAll(tc.expectedError, "%s", path))
+				return
+			}
+			require.NoError(t, err)
+			require.Equal(t, tc.expectedReverted, commands(reverted))
+			content, err := os.ReadFile(path)
+			if tc.expectedContent == nil {
+				require.True(t, os.IsNotExist(err))
+			} else {
+				require.NoError(t, err)
+				require.Equal(t, *tc.expectedContent, string(content))
+			}
+			points, err := RestorePoints(path)
+			require.NoError(t, err)
+			require.Equal(t, tc.expectedPoints, commands(points))
+		})
+	}
+}
+
+func TestRestorePointsWithoutSnapshot(t *testing.T) {
+	fsProvider = osFileSystem{}
+	dir := t.TempDir()
+	path := filepath.Join(dir, "Makefile")
+	require.NoError(t, os.WriteFile(path, []byte("build:\n"), 0644))
+	require.NoError(t, RecordChange(path, []byte("old:\n"), HistoryEntry{Command: "op 1"}))
+	require.NoError(t, os.WriteFile(path, []byte("build:\ntest:\n"), 0644))
+	require.NoError(t, RecordChange(path, []byte("build:\n"), HistoryEntry{Command: "op 2"}))
+	require.NoError(t, os.Remove(filepath.Join(dir, SnapshotsDir, checksum([]byte("old:\n")))))
+	points, err := RestorePoints(path)
+	require.NoError(t, err)
+	require.Equal(t, []string{"op 2"}, commands(points))
+}
+
+// atomicMakefileFileSystem is an osFileSystem failing to write Makefiles
+// other than atomically, with WriteFileFrom.
+type atomicMakefileFileSystem struct {
+	osFileSystem
+}
+
+func (atomicMakefileFileSystem) WriteFile(name string, data []byte, perm fs.FileMode) error {
+	if filepath.Base(name) == makefileName {
+		return errors.New("Makefile not written atomically")
+	}
+	return osFileSystem{}.WriteFile(name, data, perm)
+}
+
+// commands returns the commands of the given history entries.
+func commands(entries []HistoryEntry) []string {
+	var c []string
+	for _, e := range entries {
+		c = append(c, e.Command)
+	}
+	return c
+}
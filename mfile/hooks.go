@@ -0,0 +1,75 @@
+// Copyright (c) 2023 Tiago Melo. All rights reserved.
+// Use of this source code is governed by the MIT License that can be found in
+// the LICENSE file.
+
+package mfile
+
+import (
+	"errors"
+	"fmt"
+	"os/exec"
+	"path/filepath"
+	"strings"
+)
+
+// hookMarker is the line marking the hooks written by InstallHook, which
+// are replaced without WithOverwrite.
+const hookMarker = "# Installed by gomakefile hooks install."
+
+// preCommitHook is the git pre-commit hook checking the staged Makefiles:
+// each is linted, and verified when a spec sits next to it.
+const preCommitHook = `#!/bin/sh
+` + hookMarker + `
+# Checks the staged Makefiles with gomakefile lint and, when a
+# makefile.yaml spec sits next to them, gomakefile verify.
+makefiles=$(git diff --cached --name-only --diff-filter=ACMR | grep -E '(^|/)(GNUmakefile|makefile|Makefile|[^/]*\.mk)$')
+[ -z "$makefiles" ] && exit 0
+status=0
+for makefile in $makefiles; do
+	gomakefile lint -p "$makefile" || status=1
+	dir=$(dirname "$makefile")
+	case "$makefile" in
+	*Makefile | *makefile)
+		if [ -f "$dir/` + SpecFileName + `" ]; then
+			gomakefile verify -p "$makefile" --spec "$dir/` + SpecFileName + `" || status=1
+		fi
+		;;
+	esac
+done
+exit $status
+`
+
+// InstallHook writes a git pre-commit hook to the repository holding the
+// given directory, checking the staged Makefiles with the lint command
+// of gomakefile and, when a spec sits next to them, its verify command.
+// A pre-commit hook not written by InstallHook is only replaced when
+// overwrite is set. It returns the path of the hook.
+func InstallHook(dir string, overwrite bool) (string, error) {
+	out, err := exec.Command("git", "-C", dir, "rev-parse", "--git-path", "hooks/pre-commit").Output()
+	if err != nil {
+		var exitErr *exec.ExitError
+		if errors.As(err, &exitErr) {
+			return "", fmt.Errorf("finding the git repository of %s: %s", dir, strings.TrimSpace(string(exitErr.Stderr)))
+		}
+		return "", fmt.Errorf("finding the git repository of %s: %w", dir, err)
+	}
+	hookPath := strings.TrimSpace(string(out))
+	if !filepath.IsAbs(hookPath) {
+		hookPath = filepath.Join(dir, hookPath)
+	}
+	content, err := fsProvider.ReadFile(hookPath)
+	if err != nil && !fsProvider.IsNotExist(err) {
+		return "", &Error{Op: "reading hook", Path: hookPath, Err: err}
+	}
+	if err == nil && !overwrite && !strings.Contains(string(content), hookMarker) {
+		return "", fmt.Errorf("installing hook %s: a pre-commit hook not written by gomakefile already exists", hookPath)
+	}
+	if err := fsProvider.MkdirAll(filepath.Dir(hookPath), 0755); err != nil {
+		return "", fmt.Errorf("creating directory %s: %w", filepath.Dir(hookPath), err)
+	}
+	if err := fsProvider.WriteFile(hookPath, []byte(preCommitHook), 0755); err != nil {
+		return "", &Error{Op: "writing hook", Path: hookPath, Err: err}
+	}
+	logger.Debug("wrote hook", "path", hookPath)
+	return hookPath, nil
+}
@@ -0,0 +1,78 @@
+// Copyright (c) 2023 Tiago Melo. All rights reserved.
+// Use of this source code is governed by the MIT License that can be found in
+// the LICENSE file.
+
+package mfile
+
+import (
+	"errors"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestInstallHook(t *testing.T) {
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skip("git not installed")
+	}
+	testCases := []struct {
+		name          string
+		existingHook  string
+		overwrite     bool
+		expectedError func(hookPath string) error
+	}{
+		{
+			name: "happy path",
+		},
+		{
+			name:         "happy path, hook installed before",
+			existingHook: preCommitHook,
+		},
+		{
+			name:         "happy path, other hook replaced",
+			existingHook: "#!/bin/sh\nexit 0\n",
+			overwrite:    true,
+		},
+		{
+			name:         "other hook",
+			existingHook: "#!/bin/sh\nexit 0\n",
+			expectedError: func(hookPath string) error {
+				return errors.New("installing hook " + hookPath + ": a pre-commit hook not written by gomakefile already exists")
+			},
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			fsProvider = osFileSystem{}
+			dir := t.TempDir()
+			require.NoError(t, exec.Command("git", "init", "-q", dir).Run())
+			expectedPath := filepath.Join(dir, ".git", "hooks", "pre-commit")
+			if tc.existingHook != "" {
+				require.NoError(t, os.WriteFile(expectedPath, []byte(tc.existingHook), 0755))
+			}
+			path, err := InstallHook(dir, tc.overwrite)
+			if err != nil {
+				if tc.expectedError == nil {
+					t.Fatalf("expected no error, got %v", err)
+				}
+				require.Equal(t, tc.expectedError(expectedPath).Error(), err.Error())
+			} else {
+				if tc.expectedError != nil {
+					t.Fatalf("expected error to be %v, got nil", tc.expectedError(expectedPath))
+				}
+				require.Equal(t, expectedPath, path)
+				content, err := os.ReadFile(path)
+				require.NoError(t, err)
+				require.Equal(t, preCommitHook, string(content))
+			}
+		})
+	}
+
+	t.Run("not a git repository", func(t *testing.T) {
+		_, err := InstallHook(t.TempDir(), false)
+		require.ErrorContains(t, err, "not a git repository")
+	})
+}
@@ -0,0 +1,242 @@
+// Copyright (c) 2023 Tiago Melo. All rights reserved.
+// Use of this source code is governed by the MIT License that can be found in
+// the LICENSE file.
+
+package mfile
+
+import (
+	"fmt"
+	"regexp"
+	"slices"
+	"sort"
+	"strings"
+)
+
+// Formats of the task runner and CI files targets are imported from, or
+// exported to.
+const (
+	// FormatTaskfile is the Taskfile.yml of Task (https://taskfile.dev).
+	FormatTaskfile = "taskfile"
+
+	// FormatJust is the justfile of just (https://just.systems).
+	FormatJust = "just"
+
+	// FormatNPM is the package.json of npm, whose scripts are imported.
+	FormatNPM = "npm"
+
+	// FormatGHA is a GitHub Actions workflow.
+	FormatGHA = "gha"
+
+	// FormatGitLabCI is the .gitlab-ci.yml of GitLab CI.
+	FormatGitLabCI = "gitlab-ci"
+
+	// FormatVSCode is the .vscode/tasks.json of VS Code.
+	FormatVSCode = "vscode"
+)
+
+// Policies applied when an imported target is already declared in the
+// Makefile.
+const (
+	// ConflictError makes the import fail. The default.
+	ConflictError = "error"
+
+	// ConflictSkip keeps the declared target and skips the imported one.
+	ConflictSkip = "skip"
+
+	// ConflictRename imports the target prefixed with the format, like
+	// npm-build.
+	ConflictRename = "rename"
+)
+
+// importOptions holds the options of Import.
+type importOptions struct {
+	conflict string
+	inline   bool
+}
+
+// ImportOption configures how targets are imported.
+type ImportOption func(*importOptions)
+
+// WithConflict selects the policy applied when an imported target is
+// already declared in the Makefile: ConflictError, ConflictSkip or
+// ConflictRename. Defaults to ConflictError.
+func WithConflict(policy string) ImportOption {
+	return func(o *importOptions) {
+		o.conflict = policy
+	}
+}
+
+// WithInline makes the imported targets run the commands of the imported
+// scripts themselves, instead of calling the tool running them, for the
+// formats supporting both, like FormatNPM.
+func WithInline(inline bool) ImportOption {
+	return func(o *importOptions) {
+		o.inline = inline
+	}
+}
+
+// importer converts the content of a file of some format into the
+// variables and targets of a Makefile.
+type importer func(content []byte, o *importOptions) ([]Variable, []Target, error)
+
+// importers holds the importer of each format.
+var importers = map[string]importer{
+	FormatTaskfile: importTaskfile,
+	FormatJust:     importJustfile,
+	FormatNPM:      importNPM,
+	FormatGHA:      importWorkflow,
+}
+
+// ImportFormats returns the formats targets can be imported from.
+func ImportFormats() []string {
+	formats := make([]string, 0, len(importers))
+	for name := range importers {
+		formats = append(formats, name)
+	}
+	sort.Strings(formats)
+	return formats
+}
+
+// Import converts the file of the given format, see ImportFormats, at the
+// given source path into variables and targets, and appends them to the
+// Makefile at the specified path. If the Makefile does not exist, it is
+// created with a help target first. Imported targets already declared in
+// the Makefile are handled according to WithConflict. It returns the
+// imported targets.
+func Import(path, format, source string, opts ...ImportOption) ([]Target, error) {
+	o := &importOptions{conflict: ConflictError}
+	for _, opt := range opts {
+		opt(o)
+	}
+	if !slices.Contains([]string{ConflictError, ConflictSkip, ConflictRename}, o.conflict) {
+		return nil, fmt.Errorf("unknown conflict policy %q", o.conflict)
+	}
+	imp, ok := importers[format]
+	if !ok {
+		return nil, fmt.Errorf("unknown import format %q", format)
+	}
+	logger.Debug("reading import source", "path", source, "format", format)
+	sourceContent, err := fsProvider.ReadFile(source)
+	if err != nil {
+		return nil, fmt.Errorf("reading %s: %w", source, err)
+	}
+	variables, targets, err := imp(sourceContent, o)
+	if err != nil {
+		return nil, fmt.Errorf("importing %s: %w", source, err)
+	}
+	makeFilePath := mkFilePath(path)
+	content, err := fsProvider.ReadFile(makeFilePath)
+	if err != nil {
+		if !fsProvider.IsNotExist(err) {
+			return nil, &Error{Op: "reading Makefile", Path: makeFilePath, Err: err}
+		}
+		all := targets
+		if !slices.ContainsFunc(targets, func(t Target) bool { return t.Name == helpTarget.Name }) {
+			all = append([]Target{helpTarget}, targets...)
+		}
+		content := render(variables, all)
+		if err := fsProvider.WriteFile(makeFilePath, []byte(content), 0644); err != nil {
+			return nil, &Error{Op: "writing Makefile", Path: makeFilePath, Err: err}
+		}
+		logger.Debug("wrote Makefile", "path", makeFilePath, "bytes", len(content))
+		return targets, nil
+	}
+	if !isText(content) {
+		return nil, &Error{Op: "reading Makefile", Path: makeFilePath, Err: ErrNotAMakefile}
+	}
+	if targets, err = resolveConflicts(makeFilePath, parseTargets(string(content)), targets, format, o.conflict); err != nil {
+		return nil, err
+	}
+	if len(targets) == 0 {
+		logger.Debug("no target to import", "path", makeFilePath)
+		return nil, nil
+	}
+	file, err := openMakefile(makeFilePath, path)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+	cw := &countingWriter{w: file}
+	w := &recipePrefixWriter{w: cw, prefix: recipePrefixAt(string(content))}
+	if _, err := w.Write([]byte("\n" + render(variables, targets))); err != nil {
+		return nil, &Error{Op: "writing Makefile", Path: makeFilePath, Err: err}
+	}
+	logger.Debug("appended imported targets", "path", makeFilePath, "targets", len(targets), "bytes", cw.n)
+	return targets, nil
+}
+
+// resolveConflicts returns the given imported targets without, or
+// renaming, the ones already declared in the existing targets of the
+// Makefile at the given path, according to the given policy. Renamed targets are prefixed with the given format,
+// and the dependencies and $(MAKE) calls of the other imported targets
+// follow them.
+func resolveConflicts(makeFilePath string, existing, imported []Target, format, policy string) ([]Target, error) {
+	declared := make(map[string]bool)
+	for _, t := range existing {
+		declared[t.Name] = true
+	}
+	renamed := make(map[string]string)
+	var targets []Target
+	for _, t := range imported {
+		if !declared[t.Name] {
+			targets = append(targets, t)
+			continue
+		}
+		switch policy {
+		case ConflictSkip:
+			logger.Warn("skipping imported target already declared", "target", t.Name)
+			continue
+		case ConflictRename:
+			name := format + "-" + t.Name
+			if declared[name] {
+				return nil, &Error{Op: fmt.Sprintf("adding target %s, renamed %s", t.Name, name), Path: makeFilePath, Err: ErrTargetExists}
+			}
+			logger.Warn("renaming imported target already declared", "target", t.Name, "name", name)
+			renamed[t.Name] = name
+			t.Name = name
+			targets = append(targets, t)
+		default:
+			return nil, &Error{Op: "adding target " + t.Name, Path: makeFilePath, Err: ErrTargetExists}
+		}
+	}
+	if len(renamed) == 0 {
+		return targets, nil
+	}
+	for i, t := range targets {
+		deps := make([]string, len(t.Dependencies))
+		for j, d := range t.Dependencies {
+			if name, ok := renamed[d]; ok {
+				d = name
+			}
+			deps[j] = d
+		}
+		recipe := make([]string, len(t.Recipe))
+		for j, line := range t.Recipe {
+			if rest, ok := strings.CutPrefix(line, "$(MAKE) "); ok && renamed[rest] != "" {
+				line = "$(MAKE) " + renamed[rest]
+			} else if rest, ok := strings.CutPrefix(line, "@ $(MAKE) "); ok && renamed[rest] != "" {
+				line = "@ $(MAKE) " + renamed[rest]
+			}
+			recipe[j] = line
+		}
+		targets[i].Dependencies, targets[i].Recipe = deps, recipe
+	}
+	return targets, nil
+}
+
+// invalidTargetChars matches the characters of imported task names that
+// make does not accept in target names.
+var invalidTargetChars = regexp.MustCompile(`[:\s#=%;$]+`)
+
+// importedName returns the target name for the given imported task name,
+// replacing the characters make does not accept, like the colons of
+// namespaced tasks, with dashes.
+func importedName(name string) string {
+	return invalidTargetChars.ReplaceAllString(name, "-")
+}
+
+// escapeDollars escapes the dollar signs of the given shell command, so
+// that make passes them to the shell.
+func escapeDollars(command string) string {
+	return strings.ReplaceAll(command, "$", "$$")
+}
@@ -0,0 +1,151 @@
+// Copyright (c) 2023 Tiago Melo. All rights reserved.
+// Use of this source code is governed by the MIT License that can be found in
+// the LICENSE file.
+
+package mfile
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestImport(t *testing.T) {
+	const taskfile = "version: '3'\ntasks:\n  build:\n    desc: build it\n    cmds:\n      - go build ./...\n"
+	testCases := []struct {
+		name            string
+		format          string
+		options         []ImportOption
+		mockClosure     func(m *mockFileSystem)
+		expectedWritten string
+		expectedContent string
+		expectedError   error
+	}{
+		{
+			name:   "happy path, new Makefile",
+			format: FormatTaskfile,
+			mockClosure: func(m *mockFileSystem) {
+				delete(m.files, "path/to/Makefile")
+			},
+			expectedWritten: render(nil, []Target{helpTarget, {Name: "build", Description: "build it", Recipe: []string{"go build ./..."}, Phony: true}}),
+		},
+		{
+			name:            "happy path, existing Makefile",
+			format:          FormatTaskfile,
+			mockClosure:     func(m *mockFileSystem) {},
+			expectedContent: "\n.PHONY: build\n## build: build it\nbuild:\n\tgo build ./...\n",
+		},
+		{
+			name:   "happy path, recipe prefix of the Makefile",
+			format: FormatTaskfile,
+			mockClosure: func(m *mockFileSystem) {
+				m.files["path/to/Makefile"] = []byte(".RECIPEPREFIX = >\ntest:\n>go test ./...\n")
+			},
+			expectedContent: "\n.PHONY: build\n## build: build it\nbuild:\n>go build ./...\n",
+		},
+		{
+			name:   "target already exists",
+			format: FormatTaskfile,
+			mockClosure: func(m *mockFileSystem) {
+				m.files["path/to/Makefile"] = []byte("build:\n")
+			},
+			expectedError: errors.New("adding target build at path/to/Makefile: target already exists"),
+		},
+		{
+			name:    "happy path, conflicting target skipped",
+			format:  FormatTaskfile,
+			options: []ImportOption{WithConflict(ConflictSkip)},
+			mockClosure: func(m *mockFileSystem) {
+				m.files["Taskfile.yml"] = []byte("tasks:\n  test: go test ./...\n  ci:\n    deps: [test]\n")
+			},
+			expectedContent: "\n.PHONY: ci\nci: test\n",
+		},
+		{
+			name:    "happy path, conflicting target renamed",
+			format:  FormatTaskfile,
+			options: []ImportOption{WithConflict(ConflictRename)},
+			mockClosure: func(m *mockFileSystem) {
+				m.files["Taskfile.yml"] = []byte("tasks:\n  test: go test ./...\n  ci:\n    deps: [test]\n    cmds: [task: test]\n")
+			},
+			expectedContent: "\n.PHONY: taskfile-test\ntaskfile-test:\n\tgo test ./...\n\n.PHONY: ci\nci: taskfile-test\n\t$(MAKE) taskfile-test\n",
+		},
+		{
+			name:    "renamed target already exists",
+			format:  FormatTaskfile,
+			options: []ImportOption{WithConflict(ConflictRename)},
+			mockClosure: func(m *mockFileSystem) {
+				m.files["path/to/Makefile"] = []byte("build:\ntaskfile-build:\n")
+			},
+			expectedError: errors.New("adding target build, renamed taskfile-build at path/to/Makefile: target already exists"),
+		},
+		{
+			name:          "unknown conflict policy",
+			format:        FormatTaskfile,
+			options:       []ImportOption{WithConflict("merge")},
+			mockClosure:   func(m *mockFileSystem) {},
+			expectedError: errors.New(`unknown conflict policy "merge"`),
+		},
+		{
+			name:          "unknown format",
+			format:        "unknown",
+			mockClosure:   func(m *mockFileSystem) {},
+			expectedError: errors.New(`unknown import format "unknown"`),
+		},
+		{
+			name:   "source not found",
+			format: FormatTaskfile,
+			mockClosure: func(m *mockFileSystem) {
+				delete(m.files, "Taskfile.yml")
+			},
+			expectedError: errors.New("reading Taskfile.yml: file does not exist"),
+		},
+		{
+			name:   "invalid source",
+			format: FormatTaskfile,
+			mockClosure: func(m *mockFileSystem) {
+				m.files["Taskfile.yml"] = []byte("version: '3'\n")
+			},
+			expectedError: errors.New("importing Taskfile.yml: no task found"),
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			file, err := os.OpenFile(filepath.Join(t.TempDir(), "Makefile"), os.O_CREATE|os.O_APPEND|os.O_RDWR, 0644)
+			require.NoError(t, err)
+			m := &mockFileSystem{
+				openFile: file,
+				files: map[string][]byte{
+					"path/to/Makefile": []byte("test:\n\tgo test ./...\n"),
+					"Taskfile.yml":     []byte(taskfile),
+				},
+			}
+			tc.mockClosure(m)
+			m.isNotExistOutput = m.files["path/to/Makefile"] == nil
+			fsProvider = m
+			_, err = Import("path/to/Makefile", tc.format, "Taskfile.yml", tc.options...)
+			if err != nil {
+				if tc.expectedError == nil {
+					t.Fatalf("expected no error, got %v", err)
+				}
+				require.Equal(t, tc.expectedError.Error(), err.Error())
+			} else {
+				if tc.expectedError != nil {
+					t.Fatalf("expected error to be %v, got nil", tc.expectedError)
+				}
+				require.Equal(t, tc.expectedWritten, string(m.writtenData))
+				content, err := os.ReadFile(file.Name())
+				require.NoError(t, err)
+				require.Equal(t, tc.expectedContent, string(content))
+			}
+		})
+	}
+}
+
+func TestImportedName(t *testing.T) {
+	require.Equal(t, "docker-build", importedName("docker:build"))
+	require.Equal(t, "db-migrate-up", importedName("db:migrate up"))
+	require.Equal(t, "build", importedName("build"))
+}
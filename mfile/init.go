@@ -0,0 +1,227 @@
+// Copyright (c) 2023 Tiago Melo. All rights reserved.
+// Use of this source code is governed by the MIT License that can be found in
+// the LICENSE file.
+
+package mfile
+
+import (
+	"fmt"
+	"path/filepath"
+	"strings"
+)
+
+// gitignoreEntries are the artifacts of the generated targets, added to
+// the .gitignore file by Init.
+var gitignoreEntries = []string{"/bin/", "/dist/", "/coverage.out"}
+
+// gitignoreHeader is the comment preceding the entries added by Init.
+const gitignoreHeader = "# Build artifacts of the Makefile targets."
+
+// toolsFile is the tools.go stub written by Init, where the tools the
+// module depends on are tracked, which the tools preset installs.
+const toolsFile = `//go:build tools
+
+// Package tools tracks the tools the module depends on, so that go.mod
+// pins their versions. Import them below, then run go mod tidy.
+package tools
+
+import (
+	// _ "golang.org/x/vuln/cmd/govulncheck"
+)
+`
+
+// golangciConfig is the .golangci.yml stub written by Init, picked up by
+// the lint target.
+const golangciConfig = `# Configuration of golangci-lint, run by make lint.
+# See https://golangci-lint.run/usage/configuration/.
+run:
+  timeout: 5m
+
+linters:
+  enable:
+    - errcheck
+    - gofmt
+    - govet
+    - ineffassign
+    - staticcheck
+    - unused
+`
+
+type initOptions struct {
+	generate  []GenerateOption
+	gitignore bool
+	tools     bool
+	golangci  bool
+}
+
+// InitOption configures how a project is bootstrapped by Init.
+type InitOption func(*initOptions)
+
+// WithGenerateOptions sets the options the Makefile is generated with.
+func WithGenerateOptions(opts ...GenerateOption) InitOption {
+	return func(o *initOptions) {
+		o.generate = append(o.generate, opts...)
+	}
+}
+
+// WithGitignore makes Init add the artifacts of the generated targets,
+// like the bin directory, to the .gitignore file, creating it if needed.
+// Entries already present are not added again.
+func WithGitignore() InitOption {
+	return func(o *initOptions) {
+		o.gitignore = true
+	}
+}
+
+// WithToolsFile makes Init write a tools.go stub, tracking the tools the
+// module depends on. An existing tools.go is kept as it is.
+func WithToolsFile() InitOption {
+	return func(o *initOptions) {
+		o.tools = true
+	}
+}
+
+// WithGolangciConfig makes Init write a .golangci.yml stub and add the
+// lint preset, whose lint target runs golangci-lint with it. An existing
+// configuration of golangci-lint is kept as it is.
+func WithGolangciConfig() InitOption {
+	return func(o *initOptions) {
+		o.golangci = true
+	}
+}
+
+// Init bootstraps the project at the given path, a directory or the path
+// of its Makefile: it generates its Makefile, like Generate, then writes
+// the supporting files selected by the given options next to it. It
+// returns the paths of the files it wrote or changed, the Makefile first.
+func Init(path string, opts ...InitOption) ([]string, error) {
+	o := new(initOptions)
+	for _, opt := range opts {
+		opt(o)
+	}
+	generateOpts := o.generate
+	if o.golangci {
+		g := new(generateOptions)
+		for _, opt := range generateOpts {
+			opt(g)
+		}
+		if len(g.presets) == 0 && g.flavor == "" && g.template == "" {
+			// Keep the default content, which the lint preset would
+			// otherwise replace.
+			generateOpts = append(generateOpts, WithPresets(PresetMinimal))
+		}
+		generateOpts = append(generateOpts, WithPresets(PresetLint))
+	}
+	makeFilePath := mkFilePath(path)
+	if err := Generate(makeFilePath, generateOpts...); err != nil {
+		return nil, err
+	}
+	dir := filepath.Dir(makeFilePath)
+	written := []string{makeFilePath}
+	if o.golangci {
+		path, err := writeGolangciConfig(dir)
+		if err != nil {
+			return nil, err
+		}
+		if path != "" {
+			written = append(written, path)
+		}
+	}
+	if o.tools {
+		path := filepath.Join(dir, "tools.go")
+		ok, err := writeFileIfNotExist(path, toolsFile)
+		if err != nil {
+			return nil, err
+		}
+		if ok {
+			written = append(written, path)
+		}
+	}
+	if o.gitignore {
+		path := filepath.Join(dir, ".gitignore")
+		ok, err := addGitignoreEntries(path, gitignoreEntries)
+		if err != nil {
+			return nil, err
+		}
+		if ok {
+			written = append(written, path)
+		}
+	}
+	return written, nil
+}
+
+// writeGolangciConfig writes the .golangci.yml stub to the given
+// directory, unless it already holds a configuration of golangci-lint.
+// It returns the path of the written file, if any.
+func writeGolangciConfig(dir string) (string, error) {
+	for _, name := range golangciFiles {
+		if _, err := fsProvider.Stat(filepath.Join(dir, name)); err == nil {
+			logger.Debug("kept existing file", "path", filepath.Join(dir, name))
+			return "", nil
+		}
+	}
+	path := filepath.Join(dir, golangciFiles[0])
+	if _, err := writeFileIfNotExist(path, golangciConfig); err != nil {
+		return "", err
+	}
+	return path, nil
+}
+
+// writeFileIfNotExist writes the given content to the file at the given
+// path, unless it exists, and reports whether it did.
+func writeFileIfNotExist(path, content string) (bool, error) {
+	_, err := fsProvider.Stat(path)
+	if err == nil {
+		logger.Debug("kept existing file", "path", path)
+		return false, nil
+	}
+	if !fsProvider.IsNotExist(err) {
+		return false, fmt.Errorf("checking %s: %w", path, err)
+	}
+	if err := fsProvider.WriteFile(path, []byte(content), 0644); err != nil {
+		return false, fmt.Errorf("writing %s: %w", path, err)
+	}
+	logger.Debug("wrote file", "path", path, "bytes", len(content))
+	return true, nil
+}
+
+// addGitignoreEntries appends the given entries missing from the
+// .gitignore file at the given path, creating it if needed, and reports
+// whether it changed it. Entries match regardless of their leading and
+// trailing slashes.
+func addGitignoreEntries(path string, entries []string) (bool, error) {
+	content, err := fsProvider.ReadFile(path)
+	if err != nil && !fsProvider.IsNotExist(err) {
+		return false, fmt.Errorf("reading %s: %w", path, err)
+	}
+	existing := make(map[string]bool)
+	for _, line := range strings.Split(string(content), "\n") {
+		existing[strings.Trim(strings.TrimSpace(line), "/")] = true
+	}
+	var missing []string
+	for _, e := range entries {
+		if !existing[strings.Trim(e, "/")] {
+			missing = append(missing, e)
+		}
+	}
+	if len(missing) == 0 {
+		return false, nil
+	}
+	var sb strings.Builder
+	sb.Write(content)
+	if len(content) > 0 {
+		if !strings.HasSuffix(string(content), "\n") {
+			sb.WriteString("\n")
+		}
+		sb.WriteString("\n")
+	}
+	sb.WriteString(gitignoreHeader + "\n")
+	for _, e := range missing {
+		sb.WriteString(e + "\n")
+	}
+	if err := fsProvider.WriteFile(path, []byte(sb.String()), 0644); err != nil {
+		return false, fmt.Errorf("writing %s: %w", path, err)
+	}
+	logger.Debug("updated file", "path", path, "entries", len(missing))
+	return true, nil
+}
@@ -0,0 +1,107 @@
+// Copyright (c) 2023 Tiago Melo. All rights reserved.
+// Use of this source code is governed by the MIT License that can be found in
+// the LICENSE file.
+
+package mfile
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestInit(t *testing.T) {
+	testCases := []struct {
+		name              string
+		existingFiles     map[string]string
+		opts              []InitOption
+		expectedWritten   []string
+		expectedFiles     map[string]string
+		expectedTargets   []string
+		unexpectedTargets []string
+	}{
+		{
+			name:            "Makefile only",
+			expectedWritten: []string{"Makefile"},
+			expectedTargets: []string{"help", "test", "coverage"},
+		},
+		{
+			name:            "all supporting files",
+			opts:            []InitOption{WithGitignore(), WithToolsFile(), WithGolangciConfig()},
+			expectedWritten: []string{"Makefile", ".golangci.yml", "tools.go", ".gitignore"},
+			expectedFiles: map[string]string{
+				".golangci.yml": golangciConfig,
+				"tools.go":      toolsFile,
+				".gitignore":    "# Build artifacts of the Makefile targets.\n/bin/\n/dist/\n/coverage.out\n",
+			},
+			expectedTargets: []string{"help", "test", "coverage", "lint"},
+		},
+		{
+			name:              "golangci-lint config with presets",
+			opts:              []InitOption{WithGolangciConfig(), WithGenerateOptions(WithPresets(PresetHelp))},
+			expectedWritten:   []string{"Makefile", ".golangci.yml"},
+			expectedTargets:   []string{"help", "lint"},
+			unexpectedTargets: []string{"test"},
+		},
+		{
+			name: "existing files kept",
+			existingFiles: map[string]string{
+				".golangci.yaml": "run:\n  timeout: 1m\n",
+				"tools.go":       "package tools\n",
+				".gitignore":     "bin\n/dist/\n/coverage.out",
+			},
+			opts:            []InitOption{WithGitignore(), WithToolsFile(), WithGolangciConfig()},
+			expectedWritten: []string{"Makefile"},
+			expectedFiles: map[string]string{
+				".golangci.yaml": "run:\n  timeout: 1m\n",
+				"tools.go":       "package tools\n",
+				".gitignore":     "bin\n/dist/\n/coverage.out",
+			},
+			expectedTargets: []string{"lint"},
+		},
+		{
+			name:            "missing .gitignore entries added",
+			existingFiles:   map[string]string{".gitignore": "*.log\n/bin/"},
+			opts:            []InitOption{WithGitignore()},
+			expectedWritten: []string{"Makefile", ".gitignore"},
+			expectedFiles: map[string]string{
+				".gitignore": "*.log\n/bin/\n\n# Build artifacts of the Makefile targets.\n/dist/\n/coverage.out\n",
+			},
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			fsProvider = osFileSystem{}
+			dir := t.TempDir()
+			for name, content := range tc.existingFiles {
+				require.NoError(t, os.WriteFile(filepath.Join(dir, name), []byte(content), 0644))
+			}
+			written, err := Init(dir, tc.opts...)
+			require.NoError(t, err)
+			var expectedWritten []string
+			for _, name := range tc.expectedWritten {
+				expectedWritten = append(expectedWritten, filepath.Join(dir, name))
+			}
+			require.Equal(t, expectedWritten, written)
+			for name, expected := range tc.expectedFiles {
+				content, err := os.ReadFile(filepath.Join(dir, name))
+				require.NoError(t, err)
+				require.Equal(t, expected, string(content))
+			}
+			targets, err := ListTargets(dir)
+			require.NoError(t, err)
+			names := make(map[string]bool)
+			for _, target := range targets {
+				names[target.Name] = true
+			}
+			for _, name := range tc.expectedTargets {
+				require.True(t, names[name], "missing target %s", name)
+			}
+			for _, name := range tc.unexpectedTargets {
+				require.False(t, names[name], "unexpected target %s", name)
+			}
+		})
+	}
+}
@@ -0,0 +1,330 @@
+// Copyright (c) 2023 Tiago Melo. All rights reserved.
+// Use of this source code is governed by the MIT License that can be found in
+// the LICENSE file.
+
+package mfile
+
+import (
+	"errors"
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+var (
+	// justAssignment matches the variable assignments of a justfile.
+	justAssignment = regexp.MustCompile(`^(export\s+)?([A-Za-z_][A-Za-z0-9_-]*)\s*:=\s*(.*)$`)
+
+	// justAlias matches the aliases of a justfile.
+	justAlias = regexp.MustCompile(`^alias\s+([A-Za-z_][A-Za-z0-9_-]*)\s*:=\s*([A-Za-z_][A-Za-z0-9_-]*)\s*$`)
+
+	// justRecipe matches the first line of the recipes of a justfile.
+	justRecipe = regexp.MustCompile(`^(@?)([A-Za-z_][A-Za-z0-9_-]*)([^:]*):([^=].*|)$`)
+
+	// justParameter matches a parameter of a recipe, with its default value.
+	justParameter = regexp.MustCompile(`([+*]?)(\$?)([A-Za-z_][A-Za-z0-9_-]*)(?:=("(?:[^"\\]|\\.)*"|'[^']*'|\x60[^\x60]*\x60|[^\s"']+))?`)
+
+	// justDependency matches a dependency of a recipe, which may be called
+	// with arguments, or the && separating the dependencies run after it.
+	justDependency = regexp.MustCompile(`\([^)]*\)|&&|[^\s()]+`)
+
+	// justInterpolation matches the {{expression}} interpolations of the
+	// recipe lines.
+	justInterpolation = regexp.MustCompile(`\{\{(.*?)\}\}`)
+)
+
+// importJustfile converts the recipes of a justfile into targets, their
+// dependencies into prerequisites, those run after them into $(MAKE)
+// calls, and their doc comments into help descriptions. Variables become
+// variables, backtick ones $(shell ...) calls, and parameters
+// target-specific variables, set on the command line like in
+// make deploy env=prod; required parameters are checked. Aliases become
+// targets depending on the recipe. Settings and the expressions make has
+// no equivalent for are logged as warnings.
+func importJustfile(content []byte, _ *importOptions) ([]Variable, []Target, error) {
+	var (
+		variables []Variable
+		targets   []Target
+		doc       string
+		private   bool
+	)
+	lines := strings.Split(strings.ReplaceAll(string(content), "\r\n", "\n"), "\n")
+	for i := 0; i < len(lines); i++ {
+		line := lines[i]
+		trimmed := strings.TrimSpace(line)
+		switch {
+		case trimmed == "":
+			doc, private = "", false
+			continue
+		case strings.HasPrefix(line, "#"):
+			doc = strings.TrimSpace(strings.TrimPrefix(line, "#"))
+			continue
+		case strings.HasPrefix(line, "["):
+			for _, attr := range strings.Split(strings.Trim(trimmed, "[]"), ",") {
+				attr = strings.TrimSpace(attr)
+				switch {
+				case attr == "private":
+					private = true
+				case strings.HasPrefix(attr, "doc(") && strings.HasSuffix(attr, ")"):
+					if value, ok := justString(strings.TrimSuffix(strings.TrimPrefix(attr, "doc("), ")")); ok {
+						doc = value
+					}
+				default:
+					logger.Warn("justfile attribute not converted", "line", i+1, "attribute", attr)
+				}
+			}
+			continue
+		case strings.HasPrefix(line, " ") || strings.HasPrefix(line, "\t"):
+			return nil, nil, fmt.Errorf("line %d: unexpected indentation", i+1)
+		case strings.HasPrefix(line, "set ") || strings.HasPrefix(line, "import ") || strings.HasPrefix(line, "mod "):
+			logger.Warn("justfile line not converted", "line", i+1, "content", line)
+			doc, private = "", false
+			continue
+		}
+		if m := justAlias.FindStringSubmatch(line); m != nil {
+			targets = append(targets, Target{Name: importedName(m[1]), Dependencies: []string{importedName(m[2])}, Phony: true})
+			doc, private = "", false
+			continue
+		}
+		if m := justAssignment.FindStringSubmatch(line); m != nil {
+			value, ok := justExpression(m[3])
+			if !ok {
+				logger.Warn("justfile variable not converted", "line", i+1, "variable", m[2])
+				continue
+			}
+			op := "?="
+			if strings.Contains(value, "$(shell ") {
+				op = ":="
+			}
+			variables = append(variables, Variable{Name: m[2], Operator: op, Value: value, Export: m[1] != ""})
+			doc, private = "", false
+			continue
+		}
+		m := justRecipe.FindStringSubmatch(line)
+		if m == nil {
+			logger.Warn("justfile line not converted", "line", i+1, "content", line)
+			continue
+		}
+		lineNumber := i + 1
+		var body []string
+		for i+1 < len(lines) && (strings.TrimSpace(lines[i+1]) == "" || strings.HasPrefix(lines[i+1], " ") || strings.HasPrefix(lines[i+1], "\t")) {
+			i++
+			body = append(body, lines[i])
+		}
+		t, err := justTarget(m[2], m[3], m[4], m[1] == "@", body)
+		if err != nil {
+			return nil, nil, fmt.Errorf("line %d: %w", lineNumber, err)
+		}
+		if !private && !strings.HasPrefix(m[2], "_") {
+			t.Description = doc
+		}
+		targets = append(targets, t)
+		doc, private = "", false
+	}
+	if len(targets) == 0 {
+		return nil, nil, errors.New("no recipe found")
+	}
+	return variables, targets, nil
+}
+
+// justTarget returns the target of the recipe with the given name,
+// parameters, dependencies and body.
+func justTarget(name, params, deps string, quiet bool, body []string) (Target, error) {
+	t := Target{Name: importedName(name), Phony: true}
+	var required []string
+	for _, p := range justParameter.FindAllStringSubmatch(params, -1) {
+		v := Variable{Name: p[3], Operator: "?=", Export: p[2] == "$"}
+		if p[4] != "" {
+			value, ok := justExpression(p[4])
+			if !ok {
+				return Target{}, fmt.Errorf("invalid default value of parameter %s", p[3])
+			}
+			v.Value = value
+		} else if p[1] != "*" {
+			required = append(required, p[3])
+		}
+		t.Variables = append(t.Variables, v)
+	}
+	var after []string
+	for _, d := range justDependency.FindAllString(deps, -1) {
+		if strings.HasPrefix(d, "#") {
+			break
+		}
+		if d == "&&" {
+			after = append(after, "")
+			continue
+		}
+		if strings.HasPrefix(d, "(") {
+			logger.Warn("justfile dependency arguments not converted", "recipe", name, "dependency", d)
+			d = strings.Fields(strings.Trim(d, "()"))[0]
+		}
+		if after != nil {
+			after = append(after, importedName(d))
+			continue
+		}
+		t.Dependencies = append(t.Dependencies, importedName(d))
+	}
+	for _, p := range required {
+		t.Recipe = append(t.Recipe, fmt.Sprintf(`@ test -n "$(%s)" || { echo "%s requires %s=<value>" >&2; exit 1; }`, p, t.Name, p))
+	}
+	t.Recipe = append(t.Recipe, justRecipeLines(body, quiet)...)
+	for _, d := range after {
+		if d != "" {
+			t.Recipe = append(t.Recipe, "@ $(MAKE) "+d)
+		}
+	}
+	return t, nil
+}
+
+// justRecipeLines returns the recipe lines of the given recipe body.
+// Lines continued with a backslash are joined, and shell shebang recipes
+// are joined into a single line; other shebang recipes are not converted.
+func justRecipeLines(body []string, quiet bool) []string {
+	for len(body) > 0 && strings.TrimSpace(body[0]) == "" {
+		body = body[1:]
+	}
+	for len(body) > 0 && strings.TrimSpace(body[len(body)-1]) == "" {
+		body = body[:len(body)-1]
+	}
+	if len(body) == 0 {
+		return nil
+	}
+	indent := body[0][:len(body[0])-len(strings.TrimLeft(body[0], " \t"))]
+	var lines []string
+	for _, l := range body {
+		l = strings.TrimPrefix(l, indent)
+		if n := len(lines); n > 0 && strings.HasSuffix(lines[n-1], "\\") {
+			lines[n-1] = strings.TrimRight(strings.TrimSuffix(lines[n-1], "\\"), " \t") + " " + strings.TrimSpace(l)
+			continue
+		}
+		lines = append(lines, l)
+	}
+	if strings.HasPrefix(lines[0], "#!") {
+		if !strings.HasSuffix(lines[0], "sh") && !strings.Contains(lines[0], "sh ") {
+			logger.Warn("justfile shebang recipe not converted", "shebang", lines[0])
+			return nil
+		}
+		lines = []string{joinShellLines(strings.Join(lines[1:], "\n"))}
+	}
+	var recipe []string
+	for _, l := range lines {
+		if strings.TrimSpace(l) == "" || strings.HasPrefix(strings.TrimSpace(l), "#") {
+			continue
+		}
+		silent := quiet
+		if rest, ok := strings.CutPrefix(l, "@"); ok {
+			l, silent = strings.TrimLeft(rest, " "), !quiet
+		}
+		line := justInterpolate(l)
+		if silent {
+			line = "@ " + line
+		}
+		recipe = append(recipe, line)
+	}
+	return recipe
+}
+
+// justInterpolate returns the given recipe line with its dollar signs
+// escaped and its {{expression}} interpolations converted to make.
+func justInterpolate(line string) string {
+	const braces = "\x00"
+	line = strings.ReplaceAll(line, "{{{{", braces)
+	var sb strings.Builder
+	last := 0
+	for _, loc := range justInterpolation.FindAllStringSubmatchIndex(line, -1) {
+		sb.WriteString(escapeDollars(line[last:loc[0]]))
+		expr := line[loc[2]:loc[3]]
+		if value, ok := justExpression(expr); ok {
+			sb.WriteString(value)
+		} else {
+			logger.Warn("justfile expression not converted", "expression", strings.TrimSpace(expr))
+			sb.WriteString(line[loc[0]:loc[1]])
+		}
+		last = loc[1]
+	}
+	sb.WriteString(escapeDollars(line[last:]))
+	return strings.ReplaceAll(sb.String(), braces, "{{")
+}
+
+// justExpression converts the given justfile expression, made of string
+// literals, backtick commands and variables concatenated with +, into a
+// make value.
+func justExpression(expr string) (string, bool) {
+	var sb strings.Builder
+	expr = strings.TrimSpace(expr)
+	for expr != "" {
+		var term string
+		switch expr[0] {
+		case '"', '\'':
+			end := justStringEnd(expr)
+			if end < 0 {
+				return "", false
+			}
+			value, ok := justString(expr[:end])
+			if !ok {
+				return "", false
+			}
+			term, expr = escapeDollars(value), expr[end:]
+		case '`':
+			end := strings.IndexByte(expr[1:], '`')
+			if end < 0 {
+				return "", false
+			}
+			term, expr = "$(shell "+escapeDollars(expr[1:end+1])+")", expr[end+2:]
+		default:
+			name := expr
+			if i := strings.IndexAny(expr, " +"); i >= 0 {
+				name = expr[:i]
+			}
+			if !justAssignment.MatchString(name + " := x") {
+				return "", false
+			}
+			term, expr = "$("+name+")", expr[len(name):]
+		}
+		sb.WriteString(term)
+		expr = strings.TrimSpace(expr)
+		if expr == "" {
+			break
+		}
+		if expr[0] != '+' {
+			return "", false
+		}
+		expr = strings.TrimSpace(expr[1:])
+	}
+	return sb.String(), true
+}
+
+// justStringEnd returns the index following the string literal the given
+// expression starts with, or -1 if it is not terminated.
+func justStringEnd(expr string) int {
+	quote := expr[0]
+	for i := 1; i < len(expr); i++ {
+		switch expr[i] {
+		case '\\':
+			if quote == '"' {
+				i++
+			}
+		case quote:
+			return i + 1
+		}
+	}
+	return -1
+}
+
+// justString returns the value of the given justfile string literal:
+// double-quoted strings support escape sequences, single-quoted ones
+// are raw.
+func justString(literal string) (string, bool) {
+	if len(literal) < 2 || literal[0] != literal[len(literal)-1] {
+		return "", false
+	}
+	switch literal[0] {
+	case '\'':
+		return literal[1 : len(literal)-1], true
+	case '"':
+		value, err := strconv.Unquote(literal)
+		return value, err == nil
+	}
+	return "", false
+}
@@ -0,0 +1,121 @@
+// Copyright (c) 2023 Tiago Melo. All rights reserved.
+// Use of this source code is governed by the MIT License that can be found in
+// the LICENSE file.
+
+package mfile
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestImportJustfile(t *testing.T) {
+	testCases := []struct {
+		name              string
+		content           string
+		expectedVariables []Variable
+		expectedTargets   []Target
+		expectedError     error
+	}{
+		{
+			name: "happy path",
+			content: `set shell := ["bash", "-c"]
+
+version := "1.0"
+export GOFLAGS := '-mod=mod'
+commit := ` + "`git rev-parse HEAD`" + `
+bin := "bin/" + name
+
+alias b := build
+
+# Build the binary
+build mode="debug" *args: test
+    go build -tags {{mode}} {{ args }} -o {{bin}} -ldflags "-X main.version={{version}}"
+    @echo "$HOME {{{{literal}}"
+
+@test:
+    go test ./...
+    @echo done
+
+# Deploy it
+deploy env $REGION="eu": build && notify
+    ./deploy.sh {{env}} $REGION
+
+[private]
+notify:
+    #!/usr/bin/env bash
+    if [ -n "$SLACK" ]; then
+      ./notify.sh
+    fi
+`,
+			expectedVariables: []Variable{
+				{Name: "version", Operator: "?=", Value: "1.0"},
+				{Name: "GOFLAGS", Operator: "?=", Value: "-mod=mod", Export: true},
+				{Name: "commit", Operator: ":=", Value: "$(shell git rev-parse HEAD)"},
+				{Name: "bin", Operator: "?=", Value: "bin/$(name)"},
+			},
+			expectedTargets: []Target{
+				{Name: "b", Dependencies: []string{"build"}, Phony: true},
+				{
+					Name:         "build",
+					Description:  "Build the binary",
+					Dependencies: []string{"test"},
+					Recipe: []string{
+						`go build -tags $(mode) $(args) -o $(bin) -ldflags "-X main.version=$(version)"`,
+						`@ echo "$$HOME {{literal}}"`,
+					},
+					Phony:     true,
+					Variables: []Variable{{Name: "mode", Operator: "?=", Value: "debug"}, {Name: "args", Operator: "?="}},
+				},
+				{Name: "test", Recipe: []string{"@ go test ./...", "echo done"}, Phony: true},
+				{
+					Name:         "deploy",
+					Description:  "Deploy it",
+					Dependencies: []string{"build"},
+					Recipe: []string{
+						`@ test -n "$(env)" || { echo "deploy requires env=<value>" >&2; exit 1; }`,
+						"./deploy.sh $(env) $$REGION",
+						"@ $(MAKE) notify",
+					},
+					Phony:     true,
+					Variables: []Variable{{Name: "env", Operator: "?="}, {Name: "REGION", Operator: "?=", Value: "eu", Export: true}},
+				},
+				{Name: "notify", Recipe: []string{`if [ -n "$$SLACK" ]; then ./notify.sh; fi`}, Phony: true},
+			},
+		},
+		{
+			name:          "no recipe",
+			content:       "version := \"1.0\"\n",
+			expectedError: errors.New("no recipe found"),
+		},
+		{
+			name:          "unexpected indentation",
+			content:       "  build:\n",
+			expectedError: errors.New("line 1: unexpected indentation"),
+		},
+		{
+			name:          "invalid default value",
+			content:       "build mode=(debug):\n    go build\n",
+			expectedError: errors.New("line 1: invalid default value of parameter mode"),
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			variables, targets, err := importJustfile([]byte(tc.content), nil)
+			if err != nil {
+				if tc.expectedError == nil {
+					t.Fatalf("expected no error, got %v", err)
+				}
+				require.Equal(t, tc.expectedError.Error(), err.Error())
+			} else {
+				if tc.expectedError != nil {
+					t.Fatalf("expected error to be %v, got nil", tc.expectedError)
+				}
+				require.Equal(t, tc.expectedVariables, variables)
+				require.Equal(t, tc.expectedTargets, targets)
+			}
+		})
+	}
+}
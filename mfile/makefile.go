@@ -0,0 +1,87 @@
+// Copyright (c) 2023 Tiago Melo. All rights reserved.
+// Use of this source code is governed by the MIT License that can be found in
+// the LICENSE file.
+
+package mfile
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Makefile is a parsed Makefile, which can be inspected, edited and
+// written back. It keeps the content of the file as it is, comments,
+// blank lines, line endings and ordering included, so that writing an
+// untouched Makefile reproduces it byte for byte, and edits only change
+// the lines they are about.
+type Makefile struct {
+	lines []string // Lines of the Makefile, with their carriage returns, if any.
+}
+
+// Parse reads the Makefile at the given path.
+func Parse(path string) (*Makefile, error) {
+	content, err := readMakefile(mkFilePath(path))
+	if err != nil {
+		return nil, err
+	}
+	return ParseString(content), nil
+}
+
+// GetTarget parses the Makefile at the given path and returns the given
+// target, with the source line range of its first rule, from Line to
+// EndLine. It returns ErrTargetNotFound if the target is not declared.
+func GetTarget(path, name string) (*Target, error) {
+	m, err := Parse(path)
+	if err != nil {
+		return nil, err
+	}
+	t, err := m.Target(name)
+	if err != nil {
+		return nil, &Error{Op: "getting target", Path: mkFilePath(path), Err: err}
+	}
+	return t, nil
+}
+
+// ParseString parses the given Makefile content.
+func ParseString(content string) *Makefile {
+	return &Makefile{lines: strings.Split(content, "\n")}
+}
+
+// String returns the content of the Makefile.
+func (m *Makefile) String() string {
+	return strings.Join(m.lines, "\n")
+}
+
+// Targets returns the targets of the Makefile, see ListTargets.
+func (m *Makefile) Targets() []Target {
+	return parseTargets(m.String())
+}
+
+// Target returns the given target, see GetTarget.
+func (m *Makefile) Target(name string) (*Target, error) {
+	for _, t := range m.Targets() {
+		if t.Name != name {
+			continue
+		}
+		_, _, _, last, _ := ruleBlock(m.lines, name)
+		t.EndLine = last + 1
+		return &t, nil
+	}
+	return nil, fmt.Errorf("looking up target %s: %w", name, ErrTargetNotFound)
+}
+
+// Variables returns the variables of the Makefile, see ListVariables.
+func (m *Makefile) Variables() []Variable {
+	return parseVariables(m.String())
+}
+
+// Write writes the Makefile to the given path.
+func (m *Makefile) Write(path string) error {
+	makeFilePath := mkFilePath(path)
+	content := m.String()
+	if err := fsProvider.WriteFile(makeFilePath, []byte(content), 0644); err != nil {
+		return &Error{Op: "writing Makefile", Path: makeFilePath, Err: err}
+	}
+	logger.Debug("wrote Makefile", "path", makeFilePath, "bytes", len(content))
+	return nil
+}
@@ -0,0 +1,182 @@
+// Copyright (c) 2023 Tiago Melo. All rights reserved.
+// Use of this source code is governed by the MIT License that can be found in
+// the LICENSE file.
+
+package mfile
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseAndWrite(t *testing.T) {
+	testCases := []struct {
+		name          string
+		content       string
+		readFileErr   error
+		writeFileErr  error
+		expectedError error
+	}{
+		{
+			name: "happy path",
+			content: `# Makefile of the widget service.
+
+GO  ?=   go   # the go command
+SRC := $(wildcard *.go) \
+       $(wildcard cmd/*.go)
+
+
+##@ Build
+
+.PHONY: build
+## build: build the binary
+build:   $(SRC)
+	$(GO) build ./...   
+
+	@ echo done
+ifeq ($(OS),Windows_NT)
+	@ echo windows
+endif
+`,
+		},
+		{
+			name:    "happy path, CRLF line endings",
+			content: "## test: run tests\r\ntest:\r\n\tgo test ./...\r\n",
+		},
+		{
+			name:    "happy path, no trailing newline",
+			content: "build:\n\tgo build ./...",
+		},
+		{
+			name:    "happy path, trailing blank lines",
+			content: "build:\n\tgo build ./...\n\n\n",
+		},
+		{
+			name:    "happy path, recipe prefix",
+			content: ".RECIPEPREFIX = >\nbuild:\n>go build ./...\n",
+		},
+		{
+			name:    "happy path, empty Makefile",
+			content: "",
+		},
+		{
+			name:          "error when reading Makefile",
+			readFileErr:   errors.New("read error"),
+			expectedError: errors.New("reading Makefile at Makefile: read error"),
+		},
+		{
+			name:          "error when writing Makefile",
+			content:       "build:\n",
+			writeFileErr:  errors.New("write error"),
+			expectedError: errors.New("writing Makefile at Makefile: write error"),
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			fs := &mockFileSystem{file: []byte(tc.content), readFileErr: tc.readFileErr, writeFileErr: tc.writeFileErr}
+			fsProvider = fs
+			m, err := Parse("Makefile")
+			if err == nil {
+				err = m.Write("Makefile")
+			}
+			if err != nil {
+				if tc.expectedError == nil {
+					t.Fatalf("expected no error, got %v", err)
+				}
+				require.Equal(t, tc.expectedError.Error(), err.Error())
+			} else {
+				if tc.expectedError != nil {
+					t.Fatalf("expected error to be %v, got nil", tc.expectedError)
+				}
+				require.Equal(t, tc.content, string(fs.writtenData))
+			}
+		})
+	}
+}
+
+func TestMakefileTargetsAndVariables(t *testing.T) {
+	m := ParseString("GO ?= go\n\n.PHONY: build\n## build: build the binary\nbuild:\n\t$(GO) build ./...\n")
+	require.Equal(t, []Target{
+		{Name: "build", Description: "build the binary", Recipe: []string{"$(GO) build ./..."}, Phony: true, Line: 5},
+	}, m.Targets())
+	require.Equal(t, []Variable{{Name: "GO", Operator: "?=", Value: "go"}}, m.Variables())
+}
+
+func TestGetTarget(t *testing.T) {
+	testCases := []struct {
+		name           string
+		target         string
+		readFileErr    error
+		expectedTarget *Target
+		expectedError  error
+	}{
+		{
+			name:   "happy path",
+			target: "build",
+			expectedTarget: &Target{
+				Name:         "build",
+				Description:  "build the binary",
+				Dependencies: []string{"vet", "generate"},
+				Recipe:       []string{"go build ./...", "@ echo done"},
+				Phony:        true,
+				Section:      "Build",
+				Line:         6,
+				EndLine:      9,
+			},
+		},
+		{
+			name:   "happy path, empty recipe",
+			target: "vet",
+			expectedTarget: &Target{
+				Name:    "vet",
+				Section: "Build",
+				Line:    11,
+				EndLine: 11,
+			},
+		},
+		{
+			name:          "target not found",
+			target:        "test",
+			expectedError: errors.New("getting target at Makefile: looking up target test: target not found"),
+		},
+		{
+			name:          "error when reading Makefile",
+			target:        "build",
+			readFileErr:   errors.New("read error"),
+			expectedError: errors.New("reading Makefile at Makefile: read error"),
+		},
+	}
+	const content = `##@ Build
+
+.PHONY: build
+## build: build the binary
+# generate comes first
+build: vet \
+       generate
+	go build ./...
+	@ echo done
+
+vet:
+generate:
+	go generate ./...
+`
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			fsProvider = &mockFileSystem{file: []byte(content), readFileErr: tc.readFileErr}
+			target, err := GetTarget("Makefile", tc.target)
+			if err != nil {
+				if tc.expectedError == nil {
+					t.Fatalf("expected no error, got %v", err)
+				}
+				require.Equal(t, tc.expectedError.Error(), err.Error())
+			} else {
+				if tc.expectedError != nil {
+					t.Fatalf("expected error to be %v, got nil", tc.expectedError)
+				}
+				require.Equal(t, tc.expectedTarget, target)
+			}
+		})
+	}
+}
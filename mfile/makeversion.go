@@ -0,0 +1,188 @@
+// Copyright (c) 2023 Tiago Melo. All rights reserved.
+// Use of this source code is governed by the MIT License that can be found in
+// the LICENSE file.
+
+package mfile
+
+import (
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// MakeRequirement is a construct of a Makefile only supported from a
+// given version of GNU make on.
+type MakeRequirement struct {
+	Line      int    // 1-based line number of the construct.
+	Construct string // Construct, like "grouped targets" or ".ONESHELL".
+	Version   string // Version of GNU make introducing the construct, like "4.3".
+}
+
+// makeFunctionVersions are the versions of GNU make introducing its
+// functions, the ones of 3.80 and before being left out.
+var makeFunctionVersions = map[string]string{
+	"abspath":  "3.81",
+	"and":      "3.81",
+	"info":     "3.81",
+	"lastword": "3.81",
+	"or":       "3.81",
+	"realpath": "3.81",
+	"file":     "4.0",
+	"guile":    "4.0",
+	"intcmp":   "4.4",
+	"let":      "4.4",
+}
+
+// makeFunctionCall matches the calls to the functions of
+// makeFunctionVersions.
+var makeFunctionCall = regexp.MustCompile(`\$[({](abspath|and|info|lastword|or|realpath|file|guile|intcmp|let)[ \t]`)
+
+// makeSpecialVersions are the versions of GNU make introducing special
+// targets and variables.
+var makeSpecialVersions = map[string]string{
+	".SECONDEXPANSION": "3.81",
+	".ONESHELL":        "3.82",
+	".RECIPEPREFIX":    "3.82",
+	".SHELLSTATUS":     "4.2",
+	".EXTRA_PREREQS":   "4.3",
+	".NOTINTERMEDIATE": "4.4",
+	".WAIT":            "4.4",
+}
+
+// makeOperatorVersions are the versions of GNU make introducing
+// assignment operators.
+var makeOperatorVersions = map[string]string{
+	"::=":  "4.0",
+	"!=":   "4.0",
+	":::=": "4.4",
+}
+
+// groupedTargets matches the rules declaring grouped targets, with &:.
+var groupedTargets = regexp.MustCompile(`^[^:=#]*&::?`)
+
+// specialName matches the names of makeSpecialVersions.
+var specialName = regexp.MustCompile(`\.(SECONDEXPANSION|ONESHELL|RECIPEPREFIX|SHELLSTATUS|EXTRA_PREREQS|NOTINTERMEDIATE|WAIT)\b`)
+
+// MakeRequirements parses the Makefile at the given path and returns its
+// constructs that older versions of GNU make don't support, like grouped
+// targets, which need GNU make 4.3, or .ONESHELL, which needs 3.82, in
+// the order they are found. Constructs supported by GNU make 3.80, from
+// 2002, are not reported. See RequiredMakeVersion.
+func MakeRequirements(path string) ([]MakeRequirement, error) {
+	return requirementsFile(fsProvider, mkFilePath(path))
+}
+
+// requirementsFile is like MakeRequirements, for the Makefile at the given
+// path of the given file system.
+func requirementsFile(fsys fileSystem, makeFilePath string) ([]MakeRequirement, error) {
+	content, err := readMakefileFrom(fsys, makeFilePath)
+	if err != nil {
+		return nil, err
+	}
+	return makeRequirements(content), nil
+}
+
+// makeRequirements returns the constructs of the given Makefile content
+// that older versions of GNU make don't support.
+func makeRequirements(content string) []MakeRequirement {
+	var requirements []MakeRequirement
+	require := func(lineNumber int, construct, version string) {
+		requirements = append(requirements, MakeRequirement{Line: lineNumber, Construct: construct, Version: version})
+	}
+	prefix := "\t"
+	for i, line := range strings.Split(content, "\n") {
+		line = strings.TrimRight(line, "\r")
+		lineNumber := i + 1
+		if strings.HasPrefix(strings.TrimSpace(line), "#") {
+			continue
+		}
+		// Functions are expanded in recipes too, unlike the rest.
+		for _, m := range makeFunctionCall.FindAllStringSubmatch(line, -1) {
+			require(lineNumber, "$("+m[1]+")", makeFunctionVersions[m[1]])
+		}
+		if strings.HasPrefix(line, prefix) {
+			for _, m := range specialName.FindAllString(line, -1) {
+				if m == ".SHELLSTATUS" {
+					require(lineNumber, m, makeSpecialVersions[m])
+				}
+			}
+			continue
+		}
+		if p, ok := parseRecipePrefix(line); ok {
+			prefix = p
+		}
+		for _, m := range specialName.FindAllString(line, -1) {
+			require(lineNumber, m, makeSpecialVersions[m])
+		}
+		fields := strings.Fields(line)
+		if len(fields) == 0 {
+			continue
+		}
+		switch {
+		case fields[0] == "undefine" || fields[0] == "private":
+			require(lineNumber, fields[0], "3.82")
+		case fields[0] == "override" && len(fields) > 1 && fields[1] == "undefine":
+			require(lineNumber, fields[1], "3.82")
+		case fields[0] == "else" && len(fields) > 1 && isConditional(fields[1]):
+			require(lineNumber, "else "+fields[1], "3.81")
+		}
+		if groupedTargets.MatchString(line) {
+			require(lineNumber, "grouped targets", "4.3")
+		}
+		if v, ok := parseAssignment(line); ok {
+			if version, ok := makeOperatorVersions[v.Operator]; ok {
+				require(lineNumber, v.Operator, version)
+			}
+		}
+	}
+	return requirements
+}
+
+// RequiredMakeVersion returns the version of GNU make the given
+// requirements need, the highest of their versions, or an empty string
+// if there are none.
+func RequiredMakeVersion(requirements []MakeRequirement) string {
+	var required string
+	for _, r := range requirements {
+		if required == "" || CompareMakeVersions(r.Version, required) > 0 {
+			required = r.Version
+		}
+	}
+	return required
+}
+
+// CompareMakeVersions compares the given versions of GNU make, like
+// "4.2.1" and "4.3", returning -1, 0 or +1 whether a is older than, the
+// same as or newer than b.
+func CompareMakeVersions(a, b string) int {
+	as, bs := strings.Split(a, "."), strings.Split(b, ".")
+	for len(as) < len(bs) {
+		as = append(as, "0")
+	}
+	for len(bs) < len(as) {
+		bs = append(bs, "0")
+	}
+	for i := range as {
+		x, _ := strconv.Atoi(as[i])
+		y, _ := strconv.Atoi(bs[i])
+		switch {
+		case x < y:
+			return -1
+		case x > y:
+			return 1
+		}
+	}
+	return 0
+}
+
+// UnsupportedBy returns the given requirements the given version of GNU
+// make doesn't meet.
+func UnsupportedBy(version string, requirements []MakeRequirement) []MakeRequirement {
+	var unsupported []MakeRequirement
+	for _, r := range requirements {
+		if CompareMakeVersions(version, r.Version) < 0 {
+			unsupported = append(unsupported, r)
+		}
+	}
+	return unsupported
+}
@@ -0,0 +1,95 @@
+// Copyright (c) 2023 Tiago Melo. All rights reserved.
+// Use of this source code is governed by the MIT License that can be found in
+// the LICENSE file.
+
+package mfile
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestMakeRequirements(t *testing.T) {
+	testCases := []struct {
+		name                 string
+		content              string
+		expectedRequirements []MakeRequirement
+		expectedVersion      string
+	}{
+		{
+			name:    "portable Makefile",
+			content: "GO ?= go\nSRC := $(wildcard *.go)\n\n# .ONESHELL is not used\nbuild: $(SRC)\n\t$(GO) build -o app\n\t[ \"$$x\" != y ]\n",
+		},
+		{
+			name: "recent constructs",
+			content: `.ONESHELL:
+VERSION != git describe --tags
+ROOT ::= $(abspath .)
+ifdef CI
+else ifdef DEBUG
+endif
+undefine TMP
+
+gen.a gen.b &: gen.in
+	@ $(info generating) ./gen
+`,
+			expectedRequirements: []MakeRequirement{
+				{Line: 1, Construct: ".ONESHELL", Version: "3.82"},
+				{Line: 2, Construct: "!=", Version: "4.0"},
+				{Line: 3, Construct: "$(abspath)", Version: "3.81"},
+				{Line: 3, Construct: "::=", Version: "4.0"},
+				{Line: 5, Construct: "else ifdef", Version: "3.81"},
+				{Line: 7, Construct: "undefine", Version: "3.82"},
+				{Line: 9, Construct: "grouped targets", Version: "4.3"},
+				{Line: 10, Construct: "$(info)", Version: "3.81"},
+			},
+			expectedVersion: "4.3",
+		},
+		{
+			name:    "recipe prefix",
+			content: ".RECIPEPREFIX = >\nall: a .WAIT b\n> @ echo $(let x,1,$(x))\n",
+			expectedRequirements: []MakeRequirement{
+				{Line: 1, Construct: ".RECIPEPREFIX", Version: "3.82"},
+				{Line: 2, Construct: ".WAIT", Version: "4.4"},
+				{Line: 3, Construct: "$(let)", Version: "4.4"},
+			},
+			expectedVersion: "4.4",
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			requirements := makeRequirements(tc.content)
+			require.Equal(t, tc.expectedRequirements, requirements)
+			require.Equal(t, tc.expectedVersion, RequiredMakeVersion(requirements))
+		})
+	}
+}
+
+func TestCompareMakeVersions(t *testing.T) {
+	testCases := []struct {
+		a, b     string
+		expected int
+	}{
+		{a: "4.3", b: "4.3", expected: 0},
+		{a: "4.3", b: "4.3.0", expected: 0},
+		{a: "4.2.1", b: "4.3", expected: -1},
+		{a: "3.81", b: "3.82", expected: -1},
+		{a: "4.10", b: "4.4", expected: 1},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.a+" "+tc.b, func(t *testing.T) {
+			require.Equal(t, tc.expected, CompareMakeVersions(tc.a, tc.b))
+		})
+	}
+}
+
+func TestUnsupportedBy(t *testing.T) {
+	requirements := []MakeRequirement{
+		{Line: 1, Construct: ".ONESHELL", Version: "3.82"},
+		{Line: 9, Construct: "grouped targets", Version: "4.3"},
+	}
+	require.Equal(t, requirements, UnsupportedBy("3.81", requirements))
+	require.Equal(t, requirements[1:], UnsupportedBy("4.2.1", requirements))
+	require.Empty(t, UnsupportedBy("4.3", requirements))
+}
@@ -0,0 +1,246 @@
+// Copyright (c) 2023 Tiago Melo. All rights reserved.
+// Use of this source code is governed by the MIT License that can be found in
+// the LICENSE file.
+
+package mfile
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// TemplateVersion is the version of the content generated by this release
+// of the package, recorded by the managed blocks, see WithManaged. It is
+// bumped whenever the generated content changes.
+const TemplateVersion = 1
+
+// The comment lines delimiting the managed block of a Makefile.
+const (
+	managedBegin = "# gomakefile:begin "
+	managedEnd   = "# gomakefile:end "
+)
+
+// specMigrations migrate the specs recorded by the managed blocks of older
+// template versions, specMigrations[i] migrating the spec of version i+1
+// to version i+2. A release changing the templates in a way the recorded
+// specs must follow, like renaming a preset, bumps TemplateVersion and
+// appends its migration.
+var specMigrations []func(*Spec)
+
+// WithManaged makes Generate wrap the generated content in a managed
+// block, delimited by comments recording the TemplateVersion and the
+// options it was generated with:
+//
+//	# gomakefile:begin version=1 spec={"flavor":"full","presets":["docker"]}
+//	...
+//	# gomakefile:end checksum=3f4592b1c0d8e7a6
+//
+// Upgrade regenerates the block with the templates of later releases,
+// leaving the rest of the Makefile as it is. The presets detected with
+// WithAutoDetect are recorded as presets. It is not supported with
+// WithRecursive and WithFragments.
+func WithManaged(managed bool) GenerateOption {
+	return func(o *generateOptions) {
+		o.managed = managed
+	}
+}
+
+// validateManaged checks that the managed block fits the other options.
+func validateManaged(o *generateOptions) error {
+	switch {
+	case !o.managed:
+		return nil
+	case o.recursive:
+		return errors.New("managed blocks are not supported when generating recursively")
+	case o.fragmentsDir != "":
+		return errors.New("managed blocks are not supported with fragments")
+	}
+	return nil
+}
+
+// spec returns the spec generating the Makefile with the options, from
+// the given presets and parameters, the detected ones included.
+func (o *generateOptions) spec(presets []string, params map[string]string) Spec {
+	return Spec{
+		Flavor:       o.flavor,
+		HelpStyle:    o.helpStyle,
+		Windows:      o.windows,
+		Dialect:      o.dialect,
+		RecipePrefix: o.recipePrefix,
+		Presets:      presets,
+		Parameters:   params,
+		Template:     o.template,
+		Values:       o.values,
+		EnvFile:      o.envFile,
+		Parallel:     o.parallel,
+		NotParallel:  o.notParallel,
+		Strict:       o.strict,
+		Shell:        o.shell,
+		ShellFlags:   o.shellFlags,
+		MakeFlags:    o.makeFlags,
+	}
+}
+
+// managedBlock returns the given generated content wrapped in a managed
+// block recording the given spec.
+func managedBlock(spec Spec, content string) (string, error) {
+	encoded, err := encodeSpec(spec)
+	if err != nil {
+		return "", err
+	}
+	if !strings.HasSuffix(content, "\n") {
+		content += "\n"
+	}
+	return fmt.Sprintf("%sversion=%d spec=%s\n%s%schecksum=%s\n",
+		managedBegin, TemplateVersion, encoded, content, managedEnd, blockChecksum(content)), nil
+}
+
+// blockChecksum returns the checksum of the given content of a managed
+// block, telling whether it was edited by hand.
+func blockChecksum(content string) string {
+	return checksum([]byte(content))[:16]
+}
+
+// encodeSpec returns the given spec as one line of JSON, which ParseSpec
+// reads, leaving out its empty keys.
+func encodeSpec(spec Spec) (string, error) {
+	out, err := yaml.Marshal(spec)
+	if err != nil {
+		return "", err
+	}
+	var keys map[string]any
+	if err := yaml.Unmarshal(out, &keys); err != nil {
+		return "", err
+	}
+	for k, v := range keys {
+		if isEmptyValue(v) {
+			delete(keys, k)
+		}
+	}
+	encoded, err := json.Marshal(keys)
+	if err != nil {
+		return "", err
+	}
+	return string(encoded), nil
+}
+
+// isEmptyValue reports whether the given decoded YAML value is empty.
+func isEmptyValue(v any) bool {
+	switch v := v.(type) {
+	case nil:
+		return true
+	case string:
+		return v == ""
+	case bool:
+		return !v
+	case []any:
+		return len(v) == 0
+	case map[string]any:
+		return len(v) == 0
+	}
+	return false
+}
+
+// managedSection is the managed block of a Makefile.
+type managedSection struct {
+	start, end int    // Indices of its first line and of the line following it.
+	version    int    // Template version it was generated with.
+	spec       *Spec  // Spec it was generated from.
+	checksum   string // Checksum recorded by its end line.
+	content    string // Content between its delimiting lines.
+}
+
+// findManagedSection returns the managed block of the given lines of a
+// Makefile.
+func findManagedSection(lines []string) (*managedSection, error) {
+	var s *managedSection
+	for i, line := range lines {
+		if header, ok := strings.CutPrefix(line, managedBegin); ok {
+			if s != nil {
+				return nil, fmt.Errorf("line %d: more than one managed block", i+1)
+			}
+			s = &managedSection{start: i, end: -1}
+			version, spec, ok := strings.Cut(header, " spec=")
+			v, err := strconv.Atoi(strings.TrimPrefix(version, "version="))
+			if !ok || !strings.HasPrefix(version, "version=") || err != nil {
+				return nil, fmt.Errorf("line %d: invalid managed block header %q", i+1, line)
+			}
+			s.version = v
+			if s.spec, err = parseSpec([]byte(spec)); err != nil {
+				return nil, fmt.Errorf("line %d: invalid managed block spec: %w", i+1, err)
+			}
+			continue
+		}
+		if footer, ok := strings.CutPrefix(line, managedEnd); ok && s != nil && s.end < 0 {
+			s.end = i + 1
+			s.checksum = strings.TrimPrefix(footer, "checksum=")
+			s.content = strings.Join(lines[s.start+1:i], "\n") + "\n"
+		}
+	}
+	switch {
+	case s == nil:
+		return nil, errors.New("no managed block, generate the Makefile with managed blocks to upgrade it")
+	case s.end < 0:
+		return nil, fmt.Errorf("line %d: managed block not closed by a %q line", s.start+1, strings.TrimSpace(managedEnd))
+	}
+	return s, nil
+}
+
+// UpgradeResult is the outcome of Upgrade.
+type UpgradeResult struct {
+	FromVersion int    // Template version the managed block was generated with.
+	ToVersion   int    // Template version it is upgraded to, TemplateVersion.
+	Diff        string // Unified diff of the upgrade, empty if the Makefile is up to date.
+}
+
+// Upgrade regenerates the managed block of the Makefile at the given path,
+// see WithManaged, with the templates of this release, from the options it
+// records, migrated from its template version if older. The content
+// outside the block, like the targets added since, is left as it is.
+// Unless force, it fails if the block was edited by hand, as the edits
+// would be lost. With dryRun, the Makefile is not written.
+func Upgrade(path string, force, dryRun bool) (*UpgradeResult, error) {
+	makeFilePath := mkFilePath(path)
+	content, err := readMakefile(makeFilePath)
+	if err != nil {
+		return nil, err
+	}
+	lines := strings.Split(content, "\n")
+	s, err := findManagedSection(lines)
+	if err != nil {
+		return nil, &Error{Op: "upgrading", Path: makeFilePath, Err: err}
+	}
+	if s.version > TemplateVersion {
+		return nil, &Error{Op: "upgrading", Path: makeFilePath, Err: fmt.Errorf("managed block generated with template version %d, newer than %d, upgrade gomakefile", s.version, TemplateVersion)}
+	}
+	if !force && s.checksum != blockChecksum(s.content) {
+		return nil, &Error{Op: "upgrading", Path: makeFilePath, Err: errors.New("the managed block was edited by hand")}
+	}
+	for v := s.version; v < TemplateVersion; v++ {
+		specMigrations[v-1](s.spec)
+	}
+	capture := &capturingFileSystem{fileSystem: fsProvider, written: make(map[string][]byte)}
+	if err := generate(capture, makeFilePath, append(s.spec.Options(), WithOverwrite(true), WithManaged(true))...); err != nil {
+		return nil, &Error{Op: "upgrading", Path: makeFilePath, Err: err}
+	}
+	block := strings.TrimSuffix(string(capture.written[makeFilePath]), "\n")
+	upgraded := strings.Join(append(append(append([]string{}, lines[:s.start]...), block), lines[s.end:]...), "\n")
+	r := &UpgradeResult{
+		FromVersion: s.version,
+		ToVersion:   TemplateVersion,
+		Diff:        lineDiff(makeFilePath, makeFilePath, content, upgraded),
+	}
+	if dryRun || r.Diff == "" {
+		return r, nil
+	}
+	if err := fsProvider.WriteFileFrom(makeFilePath, strings.NewReader(upgraded), 0644); err != nil {
+		return nil, &Error{Op: "writing Makefile", Path: makeFilePath, Err: err}
+	}
+	logger.Debug("upgraded managed block", "path", makeFilePath, "from", s.version, "to", TemplateVersion)
+	return r, nil
+}
@@ -0,0 +1,159 @@
+// Copyright (c) 2023 Tiago Melo. All rights reserved.
+// Use of this source code is governed by the MIT License that can be found in
+// the LICENSE file.
+
+package mfile
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestWithManaged(t *testing.T) {
+	testCases := []struct {
+		name           string
+		opts           []GenerateOption
+		expectedHeader string
+		expectedError  string
+	}{
+		{
+			name:           "happy path",
+			expectedHeader: `# gomakefile:begin version=1 spec={"dialect":"gnu"}`,
+		},
+		{
+			name:           "happy path, with options",
+			opts:           []GenerateOption{WithFlavor(FlavorFull), WithPresets("docker", "integration"), WithParameter("compose-file", "compose.yaml"), WithStrict(true)},
+			expectedHeader: `# gomakefile:begin version=1 spec={"dialect":"gnu","flavor":"full","parameters":{"compose-file":"compose.yaml"},"presets":["docker","integration"],"strict":true}`,
+		},
+		{
+			name:          "recursive",
+			opts:          []GenerateOption{WithRecursive(true)},
+			expectedError: "managed blocks are not supported when generating recursively",
+		},
+		{
+			name:          "fragments",
+			opts:          []GenerateOption{WithFragments("make")},
+			expectedError: "managed blocks are not supported with fragments",
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			fsProvider = osFileSystem{}
+			path := filepath.Join(t.TempDir(), "Makefile")
+			err := Generate(path, append(tc.opts, WithManaged(true))...)
+			if tc.expectedError != "" {
+				require.EqualError(t, err, tc.expectedError)
+				return
+			}
+			require.NoError(t, err)
+			content, err := os.ReadFile(path)
+			require.NoError(t, err)
+			lines := strings.Split(strings.TrimSuffix(string(content), "\n"), "\n")
+			require.Equal(t, tc.expectedHeader, lines[0])
+			require.True(t, strings.HasPrefix(lines[len(lines)-1], "# gomakefile:end checksum="))
+			r, err := Upgrade(path, false, false)
+			require.NoError(t, err)
+			require.Empty(t, r.Diff)
+		})
+	}
+}
+
+func TestUpgrade(t *testing.T) {
+	generated, err := managedBlock(Spec{Dialect: DialectGNU}, render(nil, []Target{helpTarget}))
+	require.NoError(t, err)
+	fsProvider = osFileSystem{}
+	currentPath := filepath.Join(t.TempDir(), "Makefile")
+	require.NoError(t, Generate(currentPath, WithManaged(true)))
+	content, err := os.ReadFile(currentPath)
+	require.NoError(t, err)
+	current := string(content)
+	edited := strings.Replace(generated, "help:", "help: deps", 1)
+	testCases := []struct {
+		name             string
+		content          string
+		force            bool
+		dryRun           bool
+		expectedContent  string
+		expectedUpgraded bool
+		expectedError    string
+	}{
+		{
+			name:             "happy path",
+			content:          "custom:\n\techo custom\n\n" + generated + "\ndeploy:\n\techo deploy\n",
+			expectedContent:  "custom:\n\techo custom\n\n" + current + "\ndeploy:\n\techo deploy\n",
+			expectedUpgraded: true,
+		},
+		{
+			name:            "happy path, up to date",
+			content:         current + "\ndeploy:\n\techo deploy\n",
+			expectedContent: current + "\ndeploy:\n\techo deploy\n",
+		},
+		{
+			name:             "happy path, dry run",
+			content:          generated,
+			dryRun:           true,
+			expectedContent:  generated,
+			expectedUpgraded: true,
+		},
+		{
+			name:             "happy path, forced over an edited block",
+			content:          edited,
+			force:            true,
+			expectedContent:  current,
+			expectedUpgraded: true,
+		},
+		{
+			name:          "edited block",
+			content:       edited,
+			expectedError: "upgrading at %s: the managed block was edited by hand",
+		},
+		{
+			name:          "newer template version",
+			content:       strings.Replace(generated, "version=1", "version=2", 1),
+			expectedError: "upgrading at %s: managed block generated with template version 2, newer than 1, upgrade gomakefile",
+		},
+		{
+			name:          "no managed block",
+			content:       "build:\n",
+			expectedError: "upgrading at %s: no managed block, generate the Makefile with managed blocks to upgrade it",
+		},
+		{
+			name:          "several managed blocks",
+			content:       generated + generated,
+			expectedError: "upgrading at %s: line 7: more than one managed block",
+		},
+		{
+			name:          "managed block not closed",
+			content:       "# gomakefile:begin version=1 spec={}\nbuild:\n",
+			expectedError: `upgrading at %s: line 1: managed block not closed by a "# gomakefile:end" line`,
+		},
+		{
+			name:          "invalid header",
+			content:       "# gomakefile:begin v1\n# gomakefile:end checksum=\n",
+			expectedError: `upgrading at %s: line 1: invalid managed block header "# gomakefile:begin v1"`,
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			fsProvider = atomicMakefileFileSystem{}
+			path := filepath.Join(t.TempDir(), "Makefile")
+			require.NoError(t, os.WriteFile(path, []byte(tc.content), 0644))
+			r, err := Upgrade(path, tc.force, tc.dryRun)
+			if tc.expectedError != "" {
+				require.EqualError(t, err, strings.ReplaceAll(tc.expectedError, "%s", path))
+				return
+			}
+			require.NoError(t, err)
+			require.Equal(t, 1, r.FromVersion)
+			require.Equal(t, TemplateVersion, r.ToVersion)
+			require.Equal(t, tc.expectedUpgraded, r.Diff != "")
+			content, err := os.ReadFile(path)
+			require.NoError(t, err)
+			require.Equal(t, tc.expectedContent, string(content))
+		})
+	}
+}
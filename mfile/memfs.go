@@ -0,0 +1,178 @@
+// Copyright (c) 2023 Tiago Melo. All rights reserved.
+// Use of this source code is governed by the MIT License that can be found in
+// the LICENSE file.
+
+package mfile
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"time"
+)
+
+// memFileSystem is a fileSystem holding its files in memory, by clean
+// path, the directories being the ones the paths of the files imply. It
+// lets the package work on Makefile content without touching the disk,
+// like in a browser, which has none, see GenerateContent. Opening files
+// for appending is not supported, as it needs an *os.File.
+type memFileSystem struct {
+	files map[string][]byte
+}
+
+// newMemFileSystem returns an in-memory file system holding the given
+// files, by path.
+func newMemFileSystem(files map[string][]byte) *memFileSystem {
+	m := &memFileSystem{files: make(map[string][]byte)}
+	for name, data := range files {
+		m.files[filepath.Clean(name)] = data
+	}
+	return m
+}
+
+func (m *memFileSystem) OpenFile(name string, flag int, perm os.FileMode) (*os.File, error) {
+	return nil, &fs.PathError{Op: "open", Path: name, Err: errors.ErrUnsupported}
+}
+
+func (m *memFileSystem) Stat(name string) (os.FileInfo, error) {
+	name = filepath.Clean(name)
+	if data, ok := m.files[name]; ok {
+		return memFileInfo{name: filepath.Base(name), size: int64(len(data))}, nil
+	}
+	if m.isDir(name) {
+		return memFileInfo{name: filepath.Base(name), dir: true}, nil
+	}
+	return nil, &fs.PathError{Op: "stat", Path: name, Err: fs.ErrNotExist}
+}
+
+func (m *memFileSystem) ReadFile(name string) ([]byte, error) {
+	data, ok := m.files[filepath.Clean(name)]
+	if !ok {
+		return nil, &fs.PathError{Op: "open", Path: name, Err: fs.ErrNotExist}
+	}
+	return slices.Clone(data), nil
+}
+
+func (m *memFileSystem) WriteFile(name string, data []byte, perm fs.FileMode) error {
+	m.files[filepath.Clean(name)] = slices.Clone(data)
+	return nil
+}
+
+func (m *memFileSystem) IsNotExist(err error) bool {
+	return errors.Is(err, fs.ErrNotExist)
+}
+
+func (m *memFileSystem) IsDir(fi fs.FileInfo) bool {
+	return fi.IsDir()
+}
+
+func (m *memFileSystem) ReadDir(name string) ([]os.DirEntry, error) {
+	name = filepath.Clean(name)
+	if !m.isDir(name) {
+		return nil, &fs.PathError{Op: "open", Path: name, Err: fs.ErrNotExist}
+	}
+	children := make(map[string]bool)
+	for path := range m.files {
+		rel, err := filepath.Rel(name, path)
+		if err != nil || rel == "." || strings.HasPrefix(rel, "..") {
+			continue
+		}
+		child, _, nested := strings.Cut(rel, string(filepath.Separator))
+		children[child] = children[child] || nested
+	}
+	entries := make([]os.DirEntry, 0, len(children))
+	for child, dir := range children {
+		info := memFileInfo{name: child, dir: dir}
+		if !dir {
+			info.size = int64(len(m.files[filepath.Join(name, child)]))
+		}
+		entries = append(entries, fs.FileInfoToDirEntry(info))
+	}
+	slices.SortFunc(entries, func(a, b os.DirEntry) int {
+		return strings.Compare(a.Name(), b.Name())
+	})
+	return entries, nil
+}
+
+func (m *memFileSystem) MkdirAll(path string, perm os.FileMode) error {
+	// Directories are implied by the paths of the files.
+	return nil
+}
+
+func (m *memFileSystem) Remove(name string) error {
+	name = filepath.Clean(name)
+	if _, ok := m.files[name]; !ok {
+		return &fs.PathError{Op: "remove", Path: name, Err: fs.ErrNotExist}
+	}
+	delete(m.files, name)
+	return nil
+}
+
+func (m *memFileSystem) Open(name string) (io.ReadCloser, error) {
+	data, err := m.ReadFile(name)
+	if err != nil {
+		return nil, err
+	}
+	return io.NopCloser(bytes.NewReader(data)), nil
+}
+
+func (m *memFileSystem) WriteFileFrom(name string, r io.Reader, perm fs.FileMode) error {
+	data, err := io.ReadAll(r)
+	if err != nil {
+		return err
+	}
+	return m.WriteFile(name, data, perm)
+}
+
+// isDir reports whether the given clean path is a directory, the current
+// one or one holding files.
+func (m *memFileSystem) isDir(name string) bool {
+	if name == "." {
+		return true
+	}
+	for path := range m.files {
+		if strings.HasPrefix(path, name+string(filepath.Separator)) {
+			return true
+		}
+	}
+	return false
+}
+
+// memFileInfo describes a file or a directory of a memFileSystem.
+type memFileInfo struct {
+	name string
+	size int64
+	dir  bool
+}
+
+func (fi memFileInfo) Name() string {
+	return fi.name
+}
+
+func (fi memFileInfo) Size() int64 {
+	return fi.size
+}
+
+func (fi memFileInfo) Mode() fs.FileMode {
+	if fi.dir {
+		return fs.ModeDir | 0755
+	}
+	return 0644
+}
+
+func (fi memFileInfo) ModTime() time.Time {
+	return time.Time{}
+}
+
+func (fi memFileInfo) IsDir() bool {
+	return fi.dir
+}
+
+func (fi memFileInfo) Sys() any {
+	return nil
+}
@@ -0,0 +1,82 @@
+// Copyright (c) 2023 Tiago Melo. All rights reserved.
+// Use of this source code is governed by the MIT License that can be found in
+// the LICENSE file.
+
+package mfile
+
+import (
+	"slices"
+)
+
+// MergeConflict is a target declared with different recipes by some of
+// the merged Makefiles.
+type MergeConflict struct {
+	Target string   // Name of the target.
+	Paths  []string // Paths of the Makefiles declaring it, in order.
+}
+
+// Merge parses the Makefiles at the given paths and returns the content
+// of a Makefile declaring their variables and targets, in order. Targets
+// declared by more than one of them are declared once: like make does,
+// their dependencies are combined, and the recipe is the one of the
+// declaration having one. Targets declared with different recipes are
+// returned as conflicts, declared with the first one. Variables assigned
+// by more than one of them keep their first value. Other content, like
+// include directives and conditionals, is not merged.
+func Merge(paths ...string) ([]byte, []MergeConflict, error) {
+	var (
+		variables []Variable
+		targets   []Target
+		declaring = make(map[string][]string)
+		conflicts []string
+	)
+	for _, path := range paths {
+		makeFilePath := mkFilePath(path)
+		content, err := readMakefile(makeFilePath)
+		if err != nil {
+			return nil, nil, err
+		}
+		for _, v := range parseVariables(content) {
+			i := slices.IndexFunc(variables, func(o Variable) bool { return o.Name == v.Name })
+			if i < 0 {
+				variables = append(variables, v)
+				continue
+			}
+			if o := variables[i]; o.Operator != v.Operator || o.Value != v.Value || o.Export != v.Export {
+				logger.Warn("keeping the first value of a variable assigned differently", "variable", v.Name, "path", makeFilePath)
+			}
+		}
+		for _, t := range parseTargets(content) {
+			t.Line = 0
+			declaring[t.Name] = append(declaring[t.Name], makeFilePath)
+			i := slices.IndexFunc(targets, func(o Target) bool { return o.Name == t.Name })
+			if i < 0 {
+				targets = append(targets, t)
+				continue
+			}
+			o := &targets[i]
+			for _, d := range t.Dependencies {
+				if !slices.Contains(o.Dependencies, d) {
+					o.Dependencies = append(o.Dependencies, d)
+				}
+			}
+			if o.Description == "" {
+				o.Description = t.Description
+			}
+			o.Phony = o.Phony || t.Phony
+			switch {
+			case len(t.Recipe) == 0 || slices.Equal(o.Recipe, t.Recipe):
+			case len(o.Recipe) == 0:
+				o.Recipe = t.Recipe
+			case !slices.Contains(conflicts, t.Name):
+				conflicts = append(conflicts, t.Name)
+			}
+		}
+	}
+	var mergeConflicts []MergeConflict
+	for _, name := range conflicts {
+		mergeConflicts = append(mergeConflicts, MergeConflict{Target: name, Paths: declaring[name]})
+	}
+	logger.Debug("merged Makefiles", "paths", len(paths), "targets", len(targets), "conflicts", len(conflicts))
+	return []byte(render(variables, targets)), mergeConflicts, nil
+}
@@ -0,0 +1,106 @@
+// Copyright (c) 2023 Tiago Melo. All rights reserved.
+// Use of this source code is governed by the MIT License that can be found in
+// the LICENSE file.
+
+package mfile
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestMerge(t *testing.T) {
+	files := map[string][]byte{
+		"base.mk": []byte(`GO ?= go
+.PHONY: build
+## build: build it
+build: gen
+	go build
+## test: run unit tests
+test:
+	go test ./...
+`),
+		"extra.mk": []byte(`GO := go1.22
+DOCKER ?= docker
+build: tools
+## test: run unit tests
+test:
+	go test ./...
+## image: build the image
+image: build
+	$(DOCKER) build .
+`),
+		"race.mk": []byte(`test:
+	go test -race ./...
+`),
+	}
+	testCases := []struct {
+		name              string
+		paths             []string
+		expectedContent   string
+		expectedConflicts []MergeConflict
+		expectedError     error
+	}{
+		{
+			name:  "happy path",
+			paths: []string{"base.mk", "extra.mk"},
+			expectedContent: `GO ?= go
+DOCKER ?= docker
+
+.PHONY: build
+## build: build it
+build: gen tools
+	go build
+
+## test: run unit tests
+test:
+	go test ./...
+
+## image: build the image
+image: build
+	$(DOCKER) build .
+`,
+		},
+		{
+			name:  "conflict",
+			paths: []string{"base.mk", "race.mk"},
+			expectedContent: `GO ?= go
+
+.PHONY: build
+## build: build it
+build: gen
+	go build
+
+## test: run unit tests
+test:
+	go test ./...
+`,
+			expectedConflicts: []MergeConflict{{Target: "test", Paths: []string{"base.mk", "race.mk"}}},
+		},
+		{
+			name:          "Makefile not found",
+			paths:         []string{"base.mk", "missing.mk"},
+			expectedError: errors.New("reading Makefile at missing.mk: file does not exist"),
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			fsProvider = &mockFileSystem{files: files, isNotExistOutput: true}
+			content, conflicts, err := Merge(tc.paths...)
+			if err != nil {
+				if tc.expectedError == nil {
+					t.Fatalf("expected no error, got %v", err)
+				}
+				require.Equal(t, tc.expectedError.Error(), err.Error())
+			} else {
+				if tc.expectedError != nil {
+					t.Fatalf("expected error to be %v, got nil", tc.expectedError)
+				}
+				require.Equal(t, tc.expectedContent, string(content))
+				require.Equal(t, tc.expectedConflicts, conflicts)
+			}
+		})
+	}
+}
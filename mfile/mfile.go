@@ -5,11 +5,13 @@
 package mfile
 
 import (
+	"errors"
+	"fmt"
+	"io"
 	"os"
 	"path/filepath"
+	"slices"
 	"strings"
-
-	"github.com/pkg/errors"
 )
 
 // For ease of unit testing.
@@ -24,182 +26,494 @@ var (
 	templateProcessorProvider templateProcessor = htmlTemplateProcessor{}
 )
 
-// Templates for the content to be added to the Makefile.
-const (
-	generateTemplate = `.PHONY: help
-## help: shows this help message
-help:
-	@ echo "Usage: make [target]\n"
-	@ sed -n 's/^##//p' ${MAKEFILE_LIST} | column -t -s ':' |  sed -e 's/^/ /'
-
-.PHONY: test
-## test: run unit tests
-test:
-	@ go test -v ./... -count=1
-
-.PHONY: coverage
-## coverage: run unit tests and generate coverage report in html format
-coverage:
-	@ go test -coverprofile=coverage.out ./...  && go tool cover -html=coverage.out
-`
-	addTargetTemplate = `
-.PHONY: {{ .TargetName }}
-## {{ .TargetName }}: explain what {{ .TargetName }} does
-{{ .TargetName }}:
-`
-	addTargetWithDependenciesTemplate = `
-.PHONY: {{ .TargetName }}
-## {{ .TargetName }}: explain what {{ .TargetName }} does
-{{ .TargetName }}: {{ .TargetDependencies }}
-`
-	addTargetWithContentAndDependenciesTemplate = `
-.PHONY: {{ .TargetName }}
-## {{ .TargetName }}: explain what {{ .TargetName }} does
-{{ .TargetName }}: {{ .TargetDependencies }}
-	{{ .TargetContent }}
-`
-	addTargetWithContentTemplate = `
-.PHONY: {{ .TargetName }}
-## {{ .TargetName }}: explain what {{ .TargetName }} does
-{{ .TargetName }}:
-	{{ .TargetContent }}
-`
-	makefileName = "Makefile" // Default name for the Makefile.
-)
+const makefileName = "Makefile" // Default name for the Makefile.
 
 // GenerateMakefile creates or updates a Makefile at the specified path.
 // If `overwrite`, the existing Makefile will be overwritten.
 func GenerateMakefile(path string, overwrite bool) error {
-	makeFilePath := mkFilePath(path)
-	content := generateTemplate
-	if !overwrite {
-		existingContent, err := fsProvider.ReadFile(makeFilePath)
-		if err != nil && !fsProvider.IsNotExist(err) {
-			return errors.Wrapf(err, "reading Makefile at %s", makeFilePath)
-		}
-		content = generateTemplate + string(existingContent)
-	}
-	if err := fsProvider.WriteFile(makeFilePath, []byte(content), 0644); err != nil {
-		return errors.Wrapf(err, "writing MakeFile at %s", makeFilePath)
-	}
-	return nil
+	return Generate(path, WithOverwrite(overwrite))
 }
 
 // AddTargetToMakefile appends a custom target to a Makefile.
-// It ensures that the target name does not contain spaces and uses
+// It ensures that the target name is valid, see AddTarget, and uses
 // template processing to format the target addition.
 func AddTargetToMakefile(path, targetName string) error {
-	if containsSpace(targetName) {
-		return errors.New("target name cannot contain space")
-	}
-	file, err := fsProvider.OpenFile(mkFilePath(path), os.O_APPEND|os.O_WRONLY, 0644)
-	if err != nil {
-		return errors.Wrapf(err, "opening %s", path)
+	if err := validateTargetName(targetName, false); err != nil {
+		return err
 	}
-	defer file.Close()
-	tmplExecutor, err := templateProcessorProvider.Parse("target", addTargetTemplate)
-	if err != nil {
-		return errors.Wrap(err, "parsing template")
-	}
-	err = tmplExecutor.Execute(file, map[string]string{"TargetName": targetName})
-	if err != nil {
-		return errors.Wrap(err, "executing template")
-	}
-	return nil
+	return appendTemplate(path, TemplateTarget, map[string]string{"TargetName": targetName}, addTargetOptions{})
 }
 
 // AddTargetWithContentToMakefile appends a custom target to a Makefile,
 // with the specified content.
-// It ensures that the target name does not contain spaces and uses
+// It ensures that the target name is valid, see AddTarget, and uses
 // template processing to format the target addition.
 func AddTargetWithContentToMakefile(path, targetName, targetContent string) error {
-	if containsSpace(targetName) {
-		return errors.New("target name cannot contain space")
-	}
-	file, err := fsProvider.OpenFile(mkFilePath(path), os.O_APPEND|os.O_WRONLY, 0644)
-	if err != nil {
-		return errors.Wrapf(err, "opening %s", path)
-	}
-	defer file.Close()
-	tmplExecutor, err := templateProcessorProvider.Parse("target", addTargetWithContentTemplate)
-	if err != nil {
-		return errors.Wrap(err, "parsing template")
+	if err := validateTargetName(targetName, false); err != nil {
+		return err
 	}
-	err = tmplExecutor.Execute(file, map[string]string{
+	return appendTemplate(path, TemplateTargetWithContent, map[string]string{
 		"TargetName":    targetName,
 		"TargetContent": targetContent,
-	})
-	if err != nil {
-		return errors.Wrap(err, "executing template")
-	}
-	return nil
+	}, addTargetOptions{})
 }
 
 // AddTargetWithDependenciesToMakefile appends a custom target to a Makefile,
 // with the specified dependencies.
-// It ensures that the target name does not contain spaces and uses
+// It ensures that the target name is valid, see AddTarget, and uses
 // template processing to format the target addition.
 func AddTargetWithDependenciesToMakefile(path, targetName string, targetDependencies []string) error {
-	if containsSpace(targetName) {
-		return errors.New("target name cannot contain space")
+	if err := validateTargetName(targetName, false); err != nil {
+		return err
 	}
 	for _, td := range targetDependencies {
-		if containsSpace(td) {
-			return errors.New("target dependency name cannot contain space")
+		if err := validateDependencyName(td); err != nil {
+			return err
 		}
 	}
-	file, err := fsProvider.OpenFile(mkFilePath(path), os.O_APPEND|os.O_WRONLY, 0644)
-	if err != nil {
-		return errors.Wrapf(err, "opening %s", path)
-	}
-	defer file.Close()
-	tmplExecutor, err := templateProcessorProvider.Parse("target", addTargetWithDependenciesTemplate)
-	if err != nil {
-		return errors.Wrap(err, "parsing template")
-	}
-	err = tmplExecutor.Execute(file, map[string]string{
+	return appendTemplate(path, TemplateTargetWithDependencies, map[string]string{
 		"TargetName":         targetName,
 		"TargetDependencies": strings.Join(targetDependencies, " "),
-	})
-	if err != nil {
-		return errors.Wrap(err, "executing template")
-	}
-	return nil
+	}, addTargetOptions{})
 }
 
 // AddTargetWithContentAndDependenciesToMakefile appends a custom target to a Makefile,
 // with the specified content and dependencies.
-// It ensures that the target name does not contain spaces and uses
+// It ensures that the target name is valid, see AddTarget, and uses
 // template processing to format the target addition.
 func AddTargetWithContentAndDependenciesToMakefile(path, targetName, targetContent string, targetDependencies []string) error {
-	if containsSpace(targetName) {
-		return errors.New("target name cannot contain space")
+	if err := validateTargetName(targetName, false); err != nil {
+		return err
 	}
 	for _, td := range targetDependencies {
-		if containsSpace(td) {
-			return errors.New("target dependency name cannot contain space")
+		if err := validateDependencyName(td); err != nil {
+			return err
 		}
 	}
-	file, err := fsProvider.OpenFile(mkFilePath(path), os.O_APPEND|os.O_WRONLY, 0644)
+	return appendTemplate(path, TemplateTargetWithContentAndDependencies, map[string]string{
+		"TargetName":         targetName,
+		"TargetDependencies": strings.Join(targetDependencies, " "),
+		"TargetContent":      targetContent,
+	}, addTargetOptions{})
+}
+
+// addTargetOptions holds the options of AddTarget.
+type addTargetOptions struct {
+	content      string
+	dependencies []string
+	aliases      []string
+	namespace    string
+	existsPolicy ExistsPolicy
+	pattern      bool
+	sanitize     bool
+	force        bool
+	stamped      bool
+	stampSources []string
+	stampFile    string
+	stampRecipe  string
+	template     string
+}
+
+// ExistsPolicy is what AddTarget does when the target is already declared.
+type ExistsPolicy int
+
+const (
+	// ErrorIfExists fails with ErrTargetExists. The default.
+	ErrorIfExists ExistsPolicy = iota
+
+	// SkipIfExists leaves the Makefile as it is, so that scaffolding
+	// scripts can be run again.
+	SkipIfExists
+
+	// ReplaceIfExists replaces the target, with its help comment, .PHONY
+	// declaration and recipe, where it is declared, keeping the other
+	// comments above it.
+	ReplaceIfExists
+)
+
+// TargetOption configures how AddTarget adds a target.
+type TargetOption func(*addTargetOptions)
+
+// WithContent sets the recipe of the target.
+func WithContent(content string) TargetOption {
+	return func(o *addTargetOptions) {
+		o.content = content
+	}
+}
+
+// WithDependencies sets the dependencies of the target.
+func WithDependencies(dependencies ...string) TargetOption {
+	return func(o *addTargetOptions) {
+		o.dependencies = append(o.dependencies, dependencies...)
+	}
+}
+
+// WithAliases adds short alias targets, like t for tests, which simply
+// depend on the target and are listed as aliases of it by help.
+func WithAliases(aliases ...string) TargetOption {
+	return func(o *addTargetOptions) {
+		o.aliases = append(o.aliases, aliases...)
+	}
+}
+
+// WithNamespace prefixes the target name with the given namespace, like
+// docker for docker/build.
+func WithNamespace(namespace string) TargetOption {
+	return func(o *addTargetOptions) {
+		o.namespace = namespace
+	}
+}
+
+// WithExistsPolicy sets what to do when the target, or one of its aliases,
+// is already declared in the Makefile.
+func WithExistsPolicy(policy ExistsPolicy) TargetOption {
+	return func(o *addTargetOptions) {
+		o.existsPolicy = policy
+	}
+}
+
+// WithPatternRule adds a pattern rule, like guard-%, whose name must
+// contain a single %, which is not accepted otherwise.
+func WithPatternRule() TargetOption {
+	return func(o *addTargetOptions) {
+		o.pattern = true
+	}
+}
+
+// WithSanitizedName converts the target name, taken as a free-form label,
+// into a valid kebab-case target name, see SanitizeTargetName.
+func WithSanitizedName() TargetOption {
+	return func(o *addTargetOptions) {
+		o.sanitize = true
+	}
+}
+
+// WithForce allows adding, replacing, or removing with RemoveTargetOp,
+// the targets managed by the generator, like help, test and coverage, and
+// the special targets of make, like .PHONY, which fail with
+// ErrReservedTarget otherwise.
+func WithForce() TargetOption {
+	return func(o *addTargetOptions) {
+		o.force = true
+	}
+}
+
+// WithTargetTemplate sets the template the target is added with, instead
+// of the built-in ones, to change the comment style, drop the .PHONY
+// declaration or add annotations, for instance. The template is executed
+// with the TargetName, TargetDependencies and TargetContent values, the
+// latter two being empty when not given, plus the Module and AppName
+// values derived from go.mod. It is checked with ValidateTargetTemplate.
+func WithTargetTemplate(text string) TargetOption {
+	return func(o *addTargetOptions) {
+		o.template = text
+	}
+}
+
+// AddTarget appends a custom target to a Makefile, configured by the
+// given options. The template used depends on whether content and
+// dependencies are given, like with the AddTarget*ToMakefile functions.
+// Target names cannot be empty, start with -, or contain whitespace or
+// the characters make gives a meaning to, like : or #, and can only
+// contain % for pattern rules, see WithPatternRule. An error marked as
+// ErrInvalidTargetName explains why otherwise. Unless forced, see
+// WithForce, targets managed by the generator or special to make are
+// refused with ErrReservedTarget.
+func AddTarget(path, targetName string, opts ...TargetOption) error {
+	name, data, o, err := targetTemplate(targetName, opts)
 	if err != nil {
-		return errors.Wrapf(err, "opening %s", path)
+		return err
 	}
-	defer file.Close()
-	tmplExecutor, err := templateProcessorProvider.Parse("target", addTargetWithContentAndDependenciesTemplate)
+	return appendTemplate(path, name, data, o)
+}
+
+// targetTemplate validates the given target name and options, see
+// AddTarget, and returns the name of the target template to execute, with
+// its data.
+func targetTemplate(targetName string, opts []TargetOption) (string, map[string]string, addTargetOptions, error) {
+	var o addTargetOptions
+	for _, opt := range opts {
+		opt(&o)
+	}
+	if o.sanitize {
+		targetName = SanitizeTargetName(targetName)
+	}
+	if o.namespace != "" {
+		targetName = o.namespace + namespaceSeparator + targetName
+	}
+	if !o.force {
+		for _, name := range append([]string{targetName}, o.aliases...) {
+			if err := checkReserved(name); err != nil {
+				return "", nil, o, err
+			}
+		}
+	}
+	if err := validateTargetName(targetName, o.pattern); err != nil {
+		return "", nil, o, err
+	}
+	if err := validateNamespacedName(targetName); err != nil {
+		return "", nil, o, err
+	}
+	for _, td := range o.dependencies {
+		if err := validateDependencyName(td); err != nil {
+			return "", nil, o, err
+		}
+	}
+	if o.template != "" {
+		if err := ValidateTargetTemplate(o.template); err != nil {
+			return "", nil, o, err
+		}
+	}
+	for i, alias := range o.aliases {
+		if err := validateTargetName(alias, false); err != nil {
+			return "", nil, o, fmt.Errorf("invalid alias %q: %w", alias, err)
+		}
+		switch {
+		case alias == targetName:
+			return "", nil, o, mark(ErrInvalidTargetName, fmt.Errorf("alias %s is the target name", alias))
+		case slices.Contains(o.aliases[:i], alias):
+			return "", nil, o, mark(ErrInvalidTargetName, fmt.Errorf("alias %s given more than once", alias))
+		}
+	}
+	if o.stamped {
+		switch {
+		case o.content == "":
+			return "", nil, o, fmt.Errorf("stamped target %s needs content, the recipe of its stamp file", targetName)
+		case o.pattern:
+			return "", nil, o, errors.New("pattern rules cannot be stamped")
+		}
+		for _, s := range o.stampSources {
+			if isReference(s) {
+				// Like $(shell find . -name '*.go'), expanded by make.
+				continue
+			}
+			if err := validateDependencyName(s); err != nil {
+				return "", nil, o, fmt.Errorf("invalid stamp source %q: %w", s, err)
+			}
+		}
+		o.stampFile, o.stampRecipe = StampFile(targetName), o.content
+		o.content, o.dependencies = "", append(slices.Clone(o.dependencies), o.stampFile)
+	}
+	data := map[string]string{"TargetName": targetName}
+	name := TemplateTarget
+	switch {
+	case o.content != "" && len(o.dependencies) > 0:
+		name = TemplateTargetWithContentAndDependencies
+	case o.content != "":
+		name = TemplateTargetWithContent
+	case len(o.dependencies) > 0:
+		name = TemplateTargetWithDependencies
+	}
+	if o.content != "" {
+		data["TargetContent"] = o.content
+	}
+	if len(o.dependencies) > 0 {
+		data["TargetDependencies"] = strings.Join(o.dependencies, " ")
+	}
+	return name, data, o, nil
+}
+
+// aliasBlock returns the rules of the alias targets of the given target.
+func aliasBlock(targetName string, aliases []string) string {
+	var sb strings.Builder
+	for _, alias := range aliases {
+		fmt.Fprintf(&sb, "\n.PHONY: %s\n## %s: alias of %s\n%s: %s\n", alias, alias, targetName, alias, targetName)
+	}
+	return sb.String()
+}
+
+// appendTemplate executes the target template with the given name, see
+// ResolveTemplate, with the given data and appends the result to the
+// Makefile at the specified path, see addTargetContent.
+func appendTemplate(path, name string, data map[string]string, o addTargetOptions) error {
+	makeFilePath := mkFilePath(path)
+	content, err := readMakefile(makeFilePath)
 	if err != nil {
-		return errors.Wrap(err, "parsing template")
+		return err
 	}
-	err = tmplExecutor.Execute(file, map[string]string{
-		"TargetName":         targetName,
-		"TargetDependencies": strings.Join(targetDependencies, " "),
-		"TargetContent":      targetContent,
-	})
+	kept, block, rest, skipped, err := addTargetContent(fsProvider, makeFilePath, content, name, data, o)
+	if err != nil {
+		return err
+	}
+	if kept != content {
+		// Existing targets are replaced, in place if the target is.
+		if err := fsProvider.WriteFileFrom(makeFilePath, strings.NewReader(kept+block+rest), 0644); err != nil {
+			return &Error{Op: "writing Makefile", Path: makeFilePath, Err: err}
+		}
+		logger.Debug("replaced existing targets", "path", makeFilePath, "target", data["TargetName"])
+		return nil
+	}
+	if skipped {
+		return nil
+	}
+	file, err := openMakefile(makeFilePath, path)
 	if err != nil {
-		return errors.Wrap(err, "executing template")
+		return err
 	}
+	defer file.Close()
+	if block == "" {
+		// The template rendered nothing.
+		return nil
+	}
+	if _, err := io.WriteString(file, block); err != nil {
+		return fmt.Errorf("writing target: %w", err)
+	}
+	logger.Debug("appended target", "path", makeFilePath, "bytes", len(block))
 	return nil
 }
 
+// replacedTargetMarker marks the line a replaced target was declared at.
+const replacedTargetMarker = "\x00replaced target"
+
+// addTargetContent executes the target template with the given name, see
+// ResolveTemplate, with the given data, for the given content of the
+// Makefile at the specified path of the given file system. It returns the
+// content to keep, the block to append to it, unless skipped, and the
+// rest of the content, to write after the block: the target, followed by
+// the rules of the given aliases of the target, if any. Namespaced
+// targets, like docker/build, are preceded by a "##@ docker" section
+// comment, unless the Makefile already ends with that section.
+// What happens if the target or an alias is already declared in the
+// Makefile depends on the exists policy of the given options: with
+// ReplaceIfExists, the content doesn't have them anymore, the block
+// taking the place of the target, after the comments before it, but its
+// help comment, and with SkipIfExists, the target is skipped if it is
+// declared. The rest is empty unless a target is replaced.
+// Besides the given data, templates can use the Module and AppName
+// values derived from go.mod. The template set with WithTargetTemplate,
+// if any, is used instead of the given one.
+func addTargetContent(fsys fileSystem, makeFilePath, content, name string, data map[string]string, o addTargetOptions) (kept, block, rest string, skipped bool, err error) {
+	targetName, aliases := data["TargetName"], o.aliases
+	var (
+		existing []string
+		replaced bool
+	)
+	for _, t := range parseTargets(content) {
+		if t.Name == targetName || slices.Contains(aliases, t.Name) {
+			existing = append(existing, t.Name)
+		}
+	}
+	if o.stampFile != "" {
+		if _, _, _, _, ok := ruleBlock(strings.Split(content, "\n"), o.stampFile); ok {
+			existing = append(existing, o.stampFile)
+		}
+	}
+	if len(existing) > 0 {
+		switch o.existsPolicy {
+		case SkipIfExists:
+			if slices.Contains(existing, targetName) || slices.Contains(existing, o.stampFile) {
+				logger.Debug("target already exists, skipping", "path", makeFilePath, "target", targetName)
+				return content, "", "", true, nil
+			}
+			aliases = slices.DeleteFunc(slices.Clone(aliases), func(alias string) bool {
+				return slices.Contains(existing, alias)
+			})
+		case ReplaceIfExists:
+			m := ParseString(content)
+			if _, at, _, _, ok := ruleBlock(m.lines, targetName); ok {
+				// Marks where the target was, after the comments
+				// before it.
+				m.lines = slices.Insert(m.lines, at, replacedTargetMarker)
+			}
+			for _, name := range existing {
+				m.removeRules(name, false)
+			}
+			content, rest, replaced = strings.Cut(m.String(), replacedTargetMarker+"\n")
+			logger.Debug("removing existing targets", "path", makeFilePath, "targets", strings.Join(existing, ","))
+		default:
+			return "", "", "", false, &Error{Op: "adding target " + existing[0], Path: makeFilePath, Err: ErrTargetExists}
+		}
+	}
+	module, err := modulePath(fsys, filepath.Dir(makeFilePath))
+	if err != nil {
+		return "", "", "", false, err
+	}
+	data["Module"], data["AppName"] = module, appName(module)
+	text, source, err := resolveTemplate(fsys, filepath.Dir(makeFilePath), name)
+	if err != nil {
+		return "", "", "", false, err
+	}
+	if o.template != "" {
+		text, source = o.template, "custom"
+		for _, p := range targetPlaceholders {
+			if _, ok := data[p]; !ok {
+				data[p] = ""
+			}
+		}
+	}
+	logger.Debug("parsing template", "template", name, "source", source)
+	tmplExecutor, err := templateProcessorProvider.Parse("target", text)
+	if err != nil {
+		return "", "", "", false, fmt.Errorf("parsing template: %w", err)
+	}
+	var sb strings.Builder
+	if namespace := targetNamespace(targetName); namespace != "" && !replaced && namespace != lastSection(content) {
+		sb.WriteString("\n" + sectionPrefix + " " + namespace + "\n")
+	}
+	if err := tmplExecutor.Execute(&recipePrefixWriter{w: &sb, prefix: recipePrefixAt(content)}, data); err != nil {
+		return "", "", "", false, fmt.Errorf("executing template: %w", err)
+	}
+	sb.WriteString(aliasBlock(targetName, aliases))
+	if o.stampFile != "" {
+		if _, err := io.WriteString(&recipePrefixWriter{w: &sb, prefix: recipePrefixAt(content)}, stampBlock(o.stampFile, o.stampSources, o.stampRecipe)); err != nil {
+			return "", "", "", false, fmt.Errorf("writing stamp file rule: %w", err)
+		}
+	}
+	block = sb.String()
+	if replaced {
+		// The block follows the comments before the replaced target, or
+		// a blank line.
+		block = strings.TrimLeft(block, "\n")
+		if previous := lastLine(content); previous != "" && !strings.HasPrefix(previous, "#") {
+			block = "\n" + block
+		}
+	}
+	return content, block, rest, false, nil
+}
+
+// lastLine returns the last line of the given content, ending with a
+// newline, or an empty string if there is none.
+func lastLine(content string) string {
+	content = strings.TrimSuffix(content, "\n")
+	return strings.TrimRight(content[strings.LastIndex(content, "\n")+1:], "\r")
+}
+
+// openMakefile opens the Makefile at the given path for appending.
+// Errors mention the path given by the caller.
+func openMakefile(makeFilePath, path string) (*os.File, error) {
+	file, err := fsProvider.OpenFile(makeFilePath, os.O_APPEND|os.O_WRONLY, 0644)
+	if err != nil {
+		if fsProvider.IsNotExist(err) {
+			err = mark(ErrMakefileNotFound, err)
+		}
+		return nil, &Error{Op: "opening Makefile", Path: path, Err: err}
+	}
+	return file, nil
+}
+
+// readMakefile reads the content of the Makefile at the given path,
+// making sure that it exists and that it is a text file.
+func readMakefile(makeFilePath string) (string, error) {
+	return readMakefileFrom(fsProvider, makeFilePath)
+}
+
+// readMakefileFrom is like readMakefile, reading the Makefile from the
+// given file system.
+func readMakefileFrom(fsys fileSystem, makeFilePath string) (string, error) {
+	logger.Debug("reading Makefile", "path", makeFilePath)
+	content, err := fsys.ReadFile(makeFilePath)
+	if err != nil {
+		if fsys.IsNotExist(err) {
+			err = mark(ErrMakefileNotFound, err)
+		}
+		return "", &Error{Op: "reading Makefile", Path: makeFilePath, Err: err}
+	}
+	if !isText(content) {
+		return "", &Error{Op: "reading Makefile", Path: makeFilePath, Err: ErrNotAMakefile}
+	}
+	return string(content), nil
+}
+
 // mkFilePath calculates the full path to the Makefile.
 // It checks if the provided path is a directory and appends the Makefile name to it.
 func mkFilePath(path string) string {
@@ -211,6 +525,33 @@ func mkFilePath(path string) string {
 	return makeFilePath
 }
 
+// recipePrefixWriter writes Makefile content written with tab recipe
+// prefixes to w, with the given recipe prefix instead.
+type recipePrefixWriter struct {
+	w       io.Writer
+	prefix  string
+	midLine bool
+}
+
+func (rw *recipePrefixWriter) Write(p []byte) (int, error) {
+	if rw.prefix == "\t" {
+		return rw.w.Write(p)
+	}
+	out := make([]byte, 0, len(p))
+	for _, b := range p {
+		if b == '\t' && !rw.midLine {
+			out = append(out, rw.prefix...)
+		} else {
+			out = append(out, b)
+		}
+		rw.midLine = b != '\n'
+	}
+	if _, err := rw.w.Write(out); err != nil {
+		return 0, err
+	}
+	return len(p), nil
+}
+
 // containsSpace checks if the given string contains any spaces.
 func containsSpace(s string) bool {
 	return strings.Contains(s, " ")
@@ -5,11 +5,14 @@
 package mfile
 
 import (
+	"bytes"
 	"errors"
 	"io"
 	"io/fs"
 	"os"
+	"path/filepath"
 	"testing"
+	"testing/fstest"
 
 	"github.com/stretchr/testify/require"
 )
@@ -63,7 +66,7 @@ func TestGenerateMakefile(t *testing.T) {
 			mockClosure: func(m *mockFileSystem) {
 				m.writeFileErr = errors.New("write error")
 			},
-			expectedError: errors.New("writing MakeFile at some/path: write error"),
+			expectedError: errors.New("writing Makefile at some/path: write error"),
 		},
 	}
 	for _, tc := range testCases {
@@ -111,13 +114,38 @@ func TestAddTargetToMakefile(t *testing.T) {
 			mockClosure:   func(mfs *mockFileSystem, mtp *mockTemplateProcessor, mte *mockTemplateExecutor) {},
 			expectedError: errors.New("target name cannot contain space"),
 		},
+		{
+			name:       "target already exists",
+			targetName: "test-target",
+			mockClosure: func(mfs *mockFileSystem, mtp *mockTemplateProcessor, mte *mockTemplateExecutor) {
+				mfs.file = []byte("test-target:\n")
+			},
+			expectedError: errors.New("adding target test-target at path/to/Makefile: target already exists"),
+		},
+		{
+			name:       "Makefile does not exist",
+			targetName: "test-target",
+			mockClosure: func(mfs *mockFileSystem, mtp *mockTemplateProcessor, mte *mockTemplateExecutor) {
+				mfs.readFileErr = os.ErrNotExist
+				mfs.isNotExistOutput = true
+			},
+			expectedError: errors.New("reading Makefile at path/to/Makefile: file does not exist"),
+		},
+		{
+			name:       "not a Makefile",
+			targetName: "test-target",
+			mockClosure: func(mfs *mockFileSystem, mtp *mockTemplateProcessor, mte *mockTemplateExecutor) {
+				mfs.file = []byte{0x7f, 'E', 'L', 'F', 0x00}
+			},
+			expectedError: errors.New("reading Makefile at path/to/Makefile: not a Makefile"),
+		},
 		{
 			name:       "error when opening file",
 			targetName: "test-target",
 			mockClosure: func(mfs *mockFileSystem, mtp *mockTemplateProcessor, mte *mockTemplateExecutor) {
 				mfs.openErr = errors.New("open error")
 			},
-			expectedError: errors.New("opening path/to/Makefile: open error"),
+			expectedError: errors.New("opening Makefile at path/to/Makefile: open error"),
 		},
 		{
 			name:       "error when parsing template",
@@ -196,7 +224,7 @@ func TestAddTargetWithContentToMakefile(t *testing.T) {
 			mockClosure: func(mfs *mockFileSystem, mtp *mockTemplateProcessor, mte *mockTemplateExecutor) {
 				mfs.openErr = errors.New("open error")
 			},
-			expectedError: errors.New("opening path/to/Makefile: open error"),
+			expectedError: errors.New("opening Makefile at path/to/Makefile: open error"),
 		},
 		{
 			name:          "error when parsing template",
@@ -284,7 +312,7 @@ func TestAddTargetWithDependenciesToMakefile(t *testing.T) {
 			mockClosure: func(mfs *mockFileSystem, mtp *mockTemplateProcessor, mte *mockTemplateExecutor) {
 				mfs.openErr = errors.New("open error")
 			},
-			expectedError: errors.New("opening path/to/Makefile: open error"),
+			expectedError: errors.New("opening Makefile at path/to/Makefile: open error"),
 		},
 		{
 			name:               "error when parsing template",
@@ -379,7 +407,7 @@ func TestAddTargetWithContentAndDependenciesToMakefile(t *testing.T) {
 			mockClosure: func(mfs *mockFileSystem, mtp *mockTemplateProcessor, mte *mockTemplateExecutor) {
 				mfs.openErr = errors.New("open error")
 			},
-			expectedError: errors.New("opening path/to/Makefile: open error"),
+			expectedError: errors.New("opening Makefile at path/to/Makefile: open error"),
 		},
 		{
 			name:               "error when parsing template",
@@ -428,16 +456,281 @@ func TestAddTargetWithContentAndDependenciesToMakefile(t *testing.T) {
 	}
 }
 
+func TestAddTarget(t *testing.T) {
+	testCases := []struct {
+		name            string
+		targetName      string
+		opts            []TargetOption
+		mockClosure     func(m *mockFileSystem)
+		expectedContent string
+		// expectedWrittenContent is the content the Makefile is
+		// rewritten with before appending the target, if any.
+		expectedWrittenContent string
+		expectedError          error
+	}{
+		{
+			name:            "happy path",
+			targetName:      "lint",
+			mockClosure:     func(m *mockFileSystem) {},
+			expectedContent: "\n.PHONY: lint\n## lint: explain what lint does\nlint:\n",
+		},
+		{
+			name:            "happy path, content and dependencies",
+			targetName:      "tests",
+			opts:            []TargetOption{WithContent("@ go test ./..."), WithDependencies("vet", "build")},
+			mockClosure:     func(m *mockFileSystem) {},
+			expectedContent: "\n.PHONY: tests\n## tests: explain what tests does\ntests: vet build\n\t@ go test ./...\n",
+		},
+		{
+			name:       "happy path, aliases",
+			targetName: "tests",
+			opts:       []TargetOption{WithContent("@ go test ./..."), WithAliases("t", "unit")},
+			mockClosure: func(m *mockFileSystem) {
+				m.files["path/to/Makefile"] = []byte(".RECIPEPREFIX = >\nbuild:\n>go build\n")
+			},
+			expectedContent: "\n.PHONY: tests\n## tests: explain what tests does\ntests:\n>@ go test ./...\n" +
+				"\n.PHONY: t\n## t: alias of tests\nt: tests\n" +
+				"\n.PHONY: unit\n## unit: alias of tests\nunit: tests\n",
+		},
+		{
+			name:            "happy path, namespace",
+			targetName:      "build",
+			opts:            []TargetOption{WithNamespace("docker")},
+			mockClosure:     func(m *mockFileSystem) {},
+			expectedContent: "\n##@ docker\n\n.PHONY: docker/build\n## docker/build: explain what docker/build does\ndocker/build:\n",
+		},
+		{
+			name:       "happy path, namespaced name in the last section",
+			targetName: "docker/push",
+			mockClosure: func(m *mockFileSystem) {
+				m.files["path/to/Makefile"] = []byte("##@ docker\n\ndocker/build:\n")
+			},
+			expectedContent: "\n.PHONY: docker/push\n## docker/push: explain what docker/push does\ndocker/push:\n",
+		},
+		{
+			name:          "empty namespaced name",
+			targetName:    "",
+			opts:          []TargetOption{WithNamespace("docker")},
+			mockClosure:   func(m *mockFileSystem) {},
+			expectedError: errors.New(`invalid namespaced target name "docker/"`),
+		},
+		{
+			name:            "happy path, sanitized name",
+			targetName:      "Run Integration Tests!",
+			opts:            []TargetOption{WithSanitizedName()},
+			mockClosure:     func(m *mockFileSystem) {},
+			expectedContent: "\n.PHONY: run-integration-tests\n## run-integration-tests: explain what run-integration-tests does\nrun-integration-tests:\n",
+		},
+		{
+			name:            "happy path, forced reserved target",
+			targetName:      "coverage",
+			opts:            []TargetOption{WithForce()},
+			mockClosure:     func(m *mockFileSystem) {},
+			expectedContent: "\n.PHONY: coverage\n## coverage: explain what coverage does\ncoverage:\n",
+		},
+		{
+			name:          "managed target",
+			targetName:    "help",
+			mockClosure:   func(m *mockFileSystem) {},
+			expectedError: errors.New("target help is managed by the generator"),
+		},
+		{
+			name:          "managed alias",
+			targetName:    "tests",
+			opts:          []TargetOption{WithAliases("test")},
+			mockClosure:   func(m *mockFileSystem) {},
+			expectedError: errors.New("target test is managed by the generator"),
+		},
+		{
+			name:          "special target",
+			targetName:    ".PHONY",
+			opts:          []TargetOption{WithExistsPolicy(ReplaceIfExists)},
+			mockClosure:   func(m *mockFileSystem) {},
+			expectedError: errors.New(".PHONY is a special target of make"),
+		},
+		{
+			name:          "target name has space",
+			targetName:    "unit tests",
+			mockClosure:   func(m *mockFileSystem) {},
+			expectedError: errors.New("target name cannot contain space"),
+		},
+		{
+			name:          "dependency name has space",
+			targetName:    "tests",
+			opts:          []TargetOption{WithDependencies("go vet")},
+			mockClosure:   func(m *mockFileSystem) {},
+			expectedError: errors.New("target dependency name cannot contain space"),
+		},
+		{
+			name:          "alias has space",
+			targetName:    "tests",
+			opts:          []TargetOption{WithAliases("t t")},
+			mockClosure:   func(m *mockFileSystem) {},
+			expectedError: errors.New(`invalid alias "t t": target name cannot contain space`),
+		},
+		{
+			name:          "alias is the target name",
+			targetName:    "tests",
+			opts:          []TargetOption{WithAliases("tests")},
+			mockClosure:   func(m *mockFileSystem) {},
+			expectedError: errors.New("alias tests is the target name"),
+		},
+		{
+			name:          "alias given more than once",
+			targetName:    "tests",
+			opts:          []TargetOption{WithAliases("t"), WithAliases("t")},
+			mockClosure:   func(m *mockFileSystem) {},
+			expectedError: errors.New("alias t given more than once"),
+		},
+		{
+			name:       "happy path, skip if exists",
+			targetName: "build",
+			opts:       []TargetOption{WithContent("go build ./..."), WithExistsPolicy(SkipIfExists)},
+			mockClosure: func(m *mockFileSystem) {
+				m.files["path/to/Makefile"] = []byte("build:\n\tgo build\n")
+			},
+		},
+		{
+			name:            "happy path, skip existing alias",
+			targetName:      "binary",
+			opts:            []TargetOption{WithAliases("build", "bin"), WithExistsPolicy(SkipIfExists)},
+			mockClosure:     func(m *mockFileSystem) {},
+			expectedContent: "\n.PHONY: binary\n## binary: explain what binary does\nbinary:\n\n.PHONY: bin\n## bin: alias of binary\nbin: binary\n",
+		},
+		{
+			name:       "happy path, replace if exists",
+			targetName: "build",
+			opts:       []TargetOption{WithContent("go build ./..."), WithExistsPolicy(ReplaceIfExists)},
+			mockClosure: func(m *mockFileSystem) {
+				m.files["path/to/Makefile"] = []byte(`GO ?= go
+
+.PHONY: build test
+## build: build it
+# the old way
+build:
+	$(GO) build
+
+## test: run tests
+test: build
+	$(GO) test ./...
+`)
+			},
+			expectedWrittenContent: `GO ?= go
+
+.PHONY: test
+# the old way
+.PHONY: build
+## build: explain what build does
+build:
+	go build ./...
+
+## test: run tests
+test: build
+	$(GO) test ./...
+`,
+		},
+		{
+			name:       "happy path, stamp",
+			targetName: "image",
+			opts:       []TargetOption{WithContent("docker build -t app ."), WithDependencies("vet"), WithStamp("Dockerfile", "$(shell find . -name '*.go')"), WithNamespace("docker")},
+			mockClosure: func(m *mockFileSystem) {
+				m.files["path/to/Makefile"] = []byte(".RECIPEPREFIX = >\nvet:\n>go vet ./...\n")
+			},
+			expectedContent: "\n##@ docker\n\n.PHONY: docker/image\n## docker/image: explain what docker/image does\ndocker/image: vet .docker-image.stamp\n" +
+				"\n.docker-image.stamp: Dockerfile $(shell find . -name '*.go')\n>docker build -t app .\n>@touch $@\n",
+		},
+		{
+			name:       "happy path, replace stamped target",
+			targetName: "image",
+			opts:       []TargetOption{WithContent("docker build ."), WithStamp("Dockerfile"), WithExistsPolicy(ReplaceIfExists)},
+			mockClosure: func(m *mockFileSystem) {
+				m.files["path/to/Makefile"] = []byte("image: .image.stamp\n\n.image.stamp: Dockerfile\n\tdocker build -t app .\n\t@touch $@\n")
+			},
+			expectedWrittenContent: ".PHONY: image\n## image: explain what image does\nimage: .image.stamp\n\n.image.stamp: Dockerfile\n\tdocker build .\n\t@touch $@\n",
+		},
+		{
+			name:          "stamped target without content",
+			targetName:    "image",
+			opts:          []TargetOption{WithStamp("Dockerfile")},
+			mockClosure:   func(m *mockFileSystem) {},
+			expectedError: errors.New("stamped target image needs content, the recipe of its stamp file"),
+		},
+		{
+			name:          "stamp source has colon",
+			targetName:    "image",
+			opts:          []TargetOption{WithContent("docker build ."), WithStamp("a:b")},
+			mockClosure:   func(m *mockFileSystem) {},
+			expectedError: errors.New(`invalid stamp source "a:b": target dependency name cannot contain ':', which separates targets from their dependencies`),
+		},
+		{
+			name:       "stamp file already exists",
+			targetName: "image",
+			opts:       []TargetOption{WithContent("docker build ."), WithStamp("Dockerfile")},
+			mockClosure: func(m *mockFileSystem) {
+				m.files["path/to/Makefile"] = []byte(".image.stamp: Dockerfile\n\tdocker build .\n\ttouch $@\n")
+			},
+			expectedError: errors.New("adding target .image.stamp at path/to/Makefile: target already exists"),
+		},
+		{
+			name:          "alias already exists",
+			targetName:    "binary",
+			opts:          []TargetOption{WithAliases("build")},
+			mockClosure:   func(m *mockFileSystem) {},
+			expectedError: errors.New("adding target build at path/to/Makefile: target already exists"),
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			file, err := os.OpenFile(filepath.Join(t.TempDir(), "Makefile"), os.O_CREATE|os.O_APPEND|os.O_RDWR, 0644)
+			require.NoError(t, err)
+			m := &mockFileSystem{
+				openFile:         file,
+				isNotExistOutput: true,
+				files: map[string][]byte{
+					"path/to/Makefile": []byte("build:\n"),
+				},
+			}
+			tc.mockClosure(m)
+			fsProvider = m
+			templateProcessorProvider = htmlTemplateProcessor{}
+			userHomeDir = func() (string, error) { return "/home/gopher", nil }
+			err = AddTarget("path/to/Makefile", tc.targetName, tc.opts...)
+			if err != nil {
+				if tc.expectedError == nil {
+					t.Fatalf("expected no error, got %v", err)
+				}
+				require.Equal(t, tc.expectedError.Error(), err.Error())
+			} else {
+				if tc.expectedError != nil {
+					t.Fatalf("expected error to be %v, got nil", tc.expectedError)
+				}
+				content, err := os.ReadFile(file.Name())
+				require.NoError(t, err)
+				require.Equal(t, tc.expectedContent, string(content))
+				require.Equal(t, tc.expectedWrittenContent, string(m.writtenData))
+			}
+		})
+	}
+}
+
 type mockFileSystem struct {
 	openFile         *os.File
 	fileInfo         os.FileInfo
 	statErr          error
 	file             []byte
+	files            map[string][]byte
 	openErr          error
 	readFileErr      error
 	writeFileErr     error
+	writtenData      []byte
+	written          map[string][]byte
+	mkdirErr         error
 	isNotExistOutput bool
 	isDirOutput      bool
+	tree             fstest.MapFS
+	readDirErr       error
+	removeErr        error
+	removed          []string
 }
 
 func (m *mockFileSystem) OpenFile(name string, flag int, perm os.FileMode) (*os.File, error) {
@@ -445,25 +738,75 @@ func (m *mockFileSystem) OpenFile(name string, flag int, perm os.FileMode) (*os.
 }
 
 func (m *mockFileSystem) Stat(name string) (os.FileInfo, error) {
+	if m.files != nil {
+		if _, ok := m.files[name]; !ok {
+			return nil, os.ErrNotExist
+		}
+	}
 	return m.fileInfo, m.statErr
 }
 
 func (m *mockFileSystem) ReadFile(name string) ([]byte, error) {
+	if m.files != nil {
+		content, ok := m.files[name]
+		if !ok {
+			return nil, os.ErrNotExist
+		}
+		return content, nil
+	}
 	return m.file, m.readFileErr
 }
 
 func (m *mockFileSystem) WriteFile(name string, data []byte, perm fs.FileMode) error {
+	m.writtenData = data
+	if m.written == nil {
+		m.written = make(map[string][]byte)
+	}
+	m.written[name] = data
 	return m.writeFileErr
 }
 
 func (m *mockFileSystem) IsNotExist(err error) bool {
-	return m.isNotExistOutput
+	return m.isNotExistOutput || (m.tree != nil && errors.Is(err, fs.ErrNotExist))
 }
 
 func (m *mockFileSystem) IsDir(fi fs.FileInfo) bool {
 	return m.isDirOutput
 }
 
+func (m *mockFileSystem) ReadDir(name string) ([]os.DirEntry, error) {
+	if m.tree != nil {
+		return m.tree.ReadDir(name)
+	}
+	return nil, m.readDirErr
+}
+
+func (m *mockFileSystem) MkdirAll(path string, perm os.FileMode) error {
+	return m.mkdirErr
+}
+
+func (m *mockFileSystem) Open(name string) (io.ReadCloser, error) {
+	content, err := m.ReadFile(name)
+	if err != nil {
+		return nil, err
+	}
+	return io.NopCloser(bytes.NewReader(content)), nil
+}
+
+func (m *mockFileSystem) WriteFileFrom(name string, r io.Reader, perm fs.FileMode) error {
+	data, err := io.ReadAll(r)
+	if err != nil {
+		return err
+	}
+	return m.WriteFile(name, data, perm)
+}
+
+func (m *mockFileSystem) Remove(name string) error {
+	m.removed = append(m.removed, name)
+	delete(m.files, name)
+	return m.removeErr
+}
+
 type mockTemplateExecutor struct {
 	err error
 }
@@ -0,0 +1,124 @@
+// Copyright (c) 2023 Tiago Melo. All rights reserved.
+// Use of this source code is governed by the MIT License that can be found in
+// the LICENSE file.
+
+package mfile
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+	"unicode"
+)
+
+// forbiddenTargetChars are the characters make does not accept in the
+// names of the targets added to a Makefile, with the reason why.
+var forbiddenTargetChars = []struct {
+	char   string
+	name   string
+	reason string
+}{
+	{" ", "space", ""},
+	{"\t", "tab", ""},
+	{"\n", "newline", ""},
+	{"\r", "carriage return", ""},
+	{":", "':'", ", which separates targets from their dependencies"},
+	{"#", "'#'", ", which starts a comment"},
+	{"=", "'='", ", which assigns variables"},
+	{";", "';'", ", which starts an inline recipe"},
+	{"$", "'$'", ", which references variables"},
+}
+
+// validateTargetName checks that make accepts the given name as a target
+// name, returning an error marked as ErrInvalidTargetName explaining why
+// otherwise. A % is only accepted for a pattern rule, once.
+func validateTargetName(name string, pattern bool) error {
+	invalid := func(format string, args ...any) error {
+		return mark(ErrInvalidTargetName, fmt.Errorf(format, args...))
+	}
+	if name == "" {
+		return invalid("target name cannot be empty")
+	}
+	for _, c := range forbiddenTargetChars {
+		if strings.Contains(name, c.char) {
+			return invalid("target name cannot contain %s%s", c.name, c.reason)
+		}
+	}
+	if strings.HasPrefix(name, "-") {
+		return invalid("target name cannot start with '-', which make parses as an option")
+	}
+	switch n := strings.Count(name, "%"); {
+	case n > 0 && !pattern:
+		return invalid("target name cannot contain '%%' outside of a pattern rule")
+	case n > 1:
+		return invalid("pattern rule name cannot contain more than one '%%'")
+	case n == 0 && pattern:
+		return invalid("pattern rule name must contain '%%'")
+	}
+	return nil
+}
+
+// validateDependencyName checks that the given dependency name can be
+// written in a rule. Unlike target names, dependencies may reference
+// variables and use %, matching the stem of pattern rules.
+func validateDependencyName(name string) error {
+	for _, c := range forbiddenTargetChars {
+		if c.char != "$" && strings.Contains(name, c.char) {
+			return mark(ErrInvalidTargetName, fmt.Errorf("target dependency name cannot contain %s%s", c.name, c.reason))
+		}
+	}
+	return nil
+}
+
+// SanitizeTargetName converts the given free-form label, like "Run
+// Integration Tests!", into a valid kebab-case target name, like
+// run-integration-tests: letters are lowercased, words, including the
+// ones of camelCase labels, are joined with dashes, and the other
+// characters are dropped. The result is empty if the label has no letter
+// nor digit.
+func SanitizeTargetName(label string) string {
+	var (
+		sb       strings.Builder
+		previous rune
+		dash     bool
+	)
+	for _, r := range label {
+		switch {
+		case unicode.IsLetter(r) || unicode.IsDigit(r):
+			if sb.Len() > 0 && (dash || unicode.IsUpper(r) && (unicode.IsLower(previous) || unicode.IsDigit(previous))) {
+				sb.WriteByte('-')
+			}
+			sb.WriteRune(unicode.ToLower(r))
+			dash = false
+		default:
+			dash = true
+		}
+		previous = r
+	}
+	return sb.String()
+}
+
+// managedTargets are the targets the generator manages, which added
+// targets would shadow.
+var managedTargets = []string{helpTarget.Name, "test", "coverage"}
+
+// specialTargets are the special targets of make, and .DEFAULT_GOAL,
+// which is declared like one.
+var specialTargets = []string{
+	".PHONY", ".SUFFIXES", ".DEFAULT", ".PRECIOUS", ".INTERMEDIATE", ".NOTINTERMEDIATE",
+	".SECONDARY", ".SECONDEXPANSION", ".DELETE_ON_ERROR", ".IGNORE", ".LOW_RESOLUTION_TIME",
+	".SILENT", ".EXPORT_ALL_VARIABLES", ".NOTPARALLEL", ".ONESHELL", ".POSIX",
+	".DEFAULT_GOAL", recipePrefixDirective,
+}
+
+// checkReserved returns an error marked as ErrReservedTarget if the given
+// target name is managed by the generator or is a special target.
+func checkReserved(name string) error {
+	switch {
+	case slices.Contains(managedTargets, name):
+		return mark(ErrReservedTarget, fmt.Errorf("target %s is managed by the generator", name))
+	case slices.Contains(specialTargets, name):
+		return mark(ErrReservedTarget, fmt.Errorf("%s is a special target of make", name))
+	}
+	return nil
+}
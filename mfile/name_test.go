@@ -0,0 +1,86 @@
+// Copyright (c) 2023 Tiago Melo. All rights reserved.
+// Use of this source code is governed by the MIT License that can be found in
+// the LICENSE file.
+
+package mfile
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestValidateTargetName(t *testing.T) {
+	testCases := []struct {
+		name          string
+		targetName    string
+		pattern       bool
+		expectedError error
+	}{
+		{name: "valid name", targetName: "build-linux_amd64.v2"},
+		{name: "namespaced name", targetName: "docker/build"},
+		{name: "pattern rule", targetName: "guard-%", pattern: true},
+		{name: "empty", targetName: "", expectedError: errors.New("target name cannot be empty")},
+		{name: "space", targetName: "unit tests", expectedError: errors.New("target name cannot contain space")},
+		{name: "tab", targetName: "unit\ttests", expectedError: errors.New("target name cannot contain tab")},
+		{name: "newline", targetName: "build\nrm", expectedError: errors.New("target name cannot contain newline")},
+		{name: "colon", targetName: "db:migrate", expectedError: errors.New("target name cannot contain ':', which separates targets from their dependencies")},
+		{name: "hash", targetName: "c#", expectedError: errors.New("target name cannot contain '#', which starts a comment")},
+		{name: "equal sign", targetName: "GO=go", expectedError: errors.New("target name cannot contain '=', which assigns variables")},
+		{name: "semicolon", targetName: "build;rm", expectedError: errors.New("target name cannot contain ';', which starts an inline recipe")},
+		{name: "dollar sign", targetName: "$(BIN)", expectedError: errors.New("target name cannot contain '$', which references variables")},
+		{name: "leading dash", targetName: "-build", expectedError: errors.New("target name cannot start with '-', which make parses as an option")},
+		{name: "percent sign", targetName: "guard-%", expectedError: errors.New("target name cannot contain '%' outside of a pattern rule")},
+		{name: "pattern rule with two percent signs", targetName: "%-%", pattern: true, expectedError: errors.New("pattern rule name cannot contain more than one '%'")},
+		{name: "pattern rule without percent sign", targetName: "guard", pattern: true, expectedError: errors.New("pattern rule name must contain '%'")},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			err := validateTargetName(tc.targetName, tc.pattern)
+			if err != nil {
+				if tc.expectedError == nil {
+					t.Fatalf("expected no error, got %v", err)
+				}
+				require.Equal(t, tc.expectedError.Error(), err.Error())
+				require.ErrorIs(t, err, ErrInvalidTargetName)
+			} else {
+				if tc.expectedError != nil {
+					t.Fatalf("expected error to be %v, got nil", tc.expectedError)
+				}
+			}
+		})
+	}
+}
+
+func TestValidateDependencyName(t *testing.T) {
+	require.NoError(t, validateDependencyName("$(BIN)/%.o"))
+	require.EqualError(t, validateDependencyName("go vet"), "target dependency name cannot contain space")
+	require.EqualError(t, validateDependencyName("a:b"), "target dependency name cannot contain ':', which separates targets from their dependencies")
+}
+
+func TestSanitizeTargetName(t *testing.T) {
+	testCases := []struct {
+		label        string
+		expectedName string
+	}{
+		{label: "Run Integration Tests!", expectedName: "run-integration-tests"},
+		{label: "  build   the binary  ", expectedName: "build-the-binary"},
+		{label: "runIntegrationTests", expectedName: "run-integration-tests"},
+		{label: "db:migrate-up", expectedName: "db-migrate-up"},
+		{label: "Deploy to EU (prod) #2", expectedName: "deploy-to-eu-prod-2"},
+		{label: "build2Docker", expectedName: "build2-docker"},
+		{label: "Café au lait", expectedName: "café-au-lait"},
+		{label: "already-kebab-case", expectedName: "already-kebab-case"},
+		{label: "!!!", expectedName: ""},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.label, func(t *testing.T) {
+			name := SanitizeTargetName(tc.label)
+			require.Equal(t, tc.expectedName, name)
+			if name != "" {
+				require.NoError(t, validateTargetName(name, false))
+			}
+		})
+	}
+}
@@ -0,0 +1,57 @@
+// Copyright (c) 2023 Tiago Melo. All rights reserved.
+// Use of this source code is governed by the MIT License that can be found in
+// the LICENSE file.
+
+package mfile
+
+import (
+	"fmt"
+	"strings"
+)
+
+// namespaceSeparator separates the namespace of a target from its name,
+// like in docker/build.
+const namespaceSeparator = "/"
+
+// targetNamespace returns the namespace of the given target name, like
+// docker for docker/build, or an empty string if it has none.
+func targetNamespace(name string) string {
+	namespace, _, ok := strings.Cut(name, namespaceSeparator)
+	if !ok {
+		return ""
+	}
+	return namespace
+}
+
+// targetSection returns the section the given target is listed under by
+// help: its own section or, for a .PHONY target without one, its
+// namespace, so that namespaced targets are grouped automatically.
+func targetSection(t Target) string {
+	if t.Section != "" || !t.Phony {
+		return t.Section
+	}
+	return targetNamespace(t.Name)
+}
+
+// validateNamespacedName checks that none of the parts of the given
+// namespaced target name, like docker/build, is empty.
+func validateNamespacedName(name string) error {
+	for _, part := range strings.Split(name, namespaceSeparator) {
+		if part == "" {
+			return mark(ErrInvalidTargetName, fmt.Errorf("invalid namespaced target name %q", name))
+		}
+	}
+	return nil
+}
+
+// lastSection returns the section declared last in the given Makefile
+// content, which targets appended to it are listed under by help.
+func lastSection(content string) string {
+	section := ""
+	for _, line := range strings.Split(content, "\n") {
+		if strings.HasPrefix(line, sectionPrefix) {
+			section = strings.TrimSpace(strings.TrimPrefix(strings.TrimRight(line, "\r"), sectionPrefix))
+		}
+	}
+	return section
+}
@@ -0,0 +1,72 @@
+// Copyright (c) 2023 Tiago Melo. All rights reserved.
+// Use of this source code is governed by the MIT License that can be found in
+// the LICENSE file.
+
+package mfile
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestTargetSection(t *testing.T) {
+	testCases := []struct {
+		name            string
+		target          Target
+		expectedSection string
+	}{
+		{
+			name:            "namespaced target",
+			target:          Target{Name: "docker/build", Phony: true},
+			expectedSection: "docker",
+		},
+		{
+			name:            "nested namespaces",
+			target:          Target{Name: "db/migrate/up", Phony: true},
+			expectedSection: "db",
+		},
+		{
+			name:            "explicit section",
+			target:          Target{Name: "docker/build", Phony: true, Section: "Containers"},
+			expectedSection: "Containers",
+		},
+		{
+			name:   "file target",
+			target: Target{Name: "bin/app"},
+		},
+		{
+			name:   "target without namespace",
+			target: Target{Name: "build", Phony: true},
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			require.Equal(t, tc.expectedSection, targetSection(tc.target))
+		})
+	}
+}
+
+func TestRenderNamespacedTargets(t *testing.T) {
+	targets := []Target{
+		{Name: "build", Recipe: []string{"go build"}, Phony: true},
+		{Name: "docker/build", Description: "build the image", Recipe: []string{"docker build ."}, Phony: true},
+		{Name: "docker/push", Recipe: []string{"docker push"}, Phony: true},
+	}
+	expected := `.PHONY: build
+build:
+	go build
+
+##@ docker
+
+.PHONY: docker/build
+## docker/build: build the image
+docker/build:
+	docker build .
+
+.PHONY: docker/push
+docker/push:
+	docker push
+`
+	require.Equal(t, expected, render(nil, targets))
+}
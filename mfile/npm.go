@@ -0,0 +1,104 @@
+// Copyright (c) 2023 Tiago Melo. All rights reserved.
+// Use of this source code is governed by the MIT License that can be found in
+// the LICENSE file.
+
+package mfile
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+// npmRun matches the npm run calls of a script, which become $(MAKE)
+// calls when inlined.
+var npmRun = regexp.MustCompile(`\bnpm run(?:-script)?(?: (?:--silent|-s))? ([A-Za-z0-9_:.-]+)`)
+
+// npmBinPath is the variable putting the binaries of the dependencies
+// in the PATH of inlined scripts, as npm run does.
+var npmBinPath = Variable{Name: "PATH", Operator: ":=", Value: "$(CURDIR)/node_modules/.bin:$(PATH)", Export: true}
+
+// importNPM converts the scripts of a package.json into targets, in the
+// order they are declared, running them with npm run. With WithInline,
+// the targets run the commands of the scripts instead, with the binaries
+// of the dependencies in the PATH; their pre and post scripts become a
+// dependency and a $(MAKE) call, and npm run calls $(MAKE) ones.
+func importNPM(content []byte, o *importOptions) ([]Variable, []Target, error) {
+	var pkg struct {
+		Scripts json.RawMessage `json:"scripts"`
+	}
+	if err := json.Unmarshal(content, &pkg); err != nil {
+		return nil, nil, fmt.Errorf("parsing package.json: %w", err)
+	}
+	names, scripts, err := npmScripts(pkg.Scripts)
+	if err != nil {
+		return nil, nil, err
+	}
+	if len(names) == 0 {
+		return nil, nil, errors.New("no script found")
+	}
+	var (
+		variables []Variable
+		targets   []Target
+	)
+	inline := o != nil && o.inline
+	if inline {
+		variables = append(variables, npmBinPath)
+	}
+	for _, name := range names {
+		t := Target{Name: importedName(name), Description: "run the " + name + " npm script", Phony: true}
+		if !inline {
+			t.Recipe = []string{"npm run " + shellQuote(name)}
+			targets = append(targets, t)
+			continue
+		}
+		if _, ok := scripts["pre"+name]; ok {
+			t.Dependencies = append(t.Dependencies, importedName("pre"+name))
+		}
+		command := npmRun.ReplaceAllStringFunc(escapeDollars(scripts[name]), func(call string) string {
+			fields := strings.Fields(call)
+			return "$(MAKE) " + importedName(fields[len(fields)-1])
+		})
+		t.Recipe = []string{command}
+		if _, ok := scripts["post"+name]; ok {
+			t.Recipe = append(t.Recipe, "@ $(MAKE) "+importedName("post"+name))
+		}
+		targets = append(targets, t)
+	}
+	return variables, targets, nil
+}
+
+// npmScripts returns the names of the scripts of the given scripts
+// object, in the order they are declared, and the scripts by name.
+func npmScripts(raw json.RawMessage) ([]string, map[string]string, error) {
+	if len(raw) == 0 {
+		return nil, nil, nil
+	}
+	dec := json.NewDecoder(bytes.NewReader(raw))
+	if tok, err := dec.Token(); err != nil || tok != json.Delim('{') {
+		return nil, nil, errors.New("scripts must be an object")
+	}
+	var (
+		names   []string
+		scripts = make(map[string]string)
+	)
+	for dec.More() {
+		tok, err := dec.Token()
+		if err != nil {
+			return nil, nil, fmt.Errorf("parsing scripts: %w", err)
+		}
+		name := tok.(string)
+		var script string
+		if err := dec.Decode(&script); err != nil {
+			return nil, nil, fmt.Errorf("parsing script %s: %w", name, err)
+		}
+		if _, ok := scripts[name]; !ok {
+			names = append(names, name)
+		}
+		scripts[name] = script
+	}
+	return names, scripts, nil
+}
@@ -0,0 +1,93 @@
+// Copyright (c) 2023 Tiago Melo. All rights reserved.
+// Use of this source code is governed by the MIT License that can be found in
+// the LICENSE file.
+
+package mfile
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestImportNPM(t *testing.T) {
+	const packageJSON = `{
+	"name": "web",
+	"scripts": {
+		"prebuild": "rm -rf dist",
+		"build": "vite build --base $BASE && npm run -s lint:css",
+		"lint:css": "stylelint 'src/**/*.css'",
+		"postbuild": "echo done"
+	}
+}`
+	testCases := []struct {
+		name              string
+		content           string
+		options           *importOptions
+		expectedVariables []Variable
+		expectedTargets   []Target
+		expectedError     error
+	}{
+		{
+			name:    "happy path, npm run",
+			content: packageJSON,
+			expectedTargets: []Target{
+				{Name: "prebuild", Description: "run the prebuild npm script", Recipe: []string{"npm run prebuild"}, Phony: true},
+				{Name: "build", Description: "run the build npm script", Recipe: []string{"npm run build"}, Phony: true},
+				{Name: "lint-css", Description: "run the lint:css npm script", Recipe: []string{"npm run lint:css"}, Phony: true},
+				{Name: "postbuild", Description: "run the postbuild npm script", Recipe: []string{"npm run postbuild"}, Phony: true},
+			},
+		},
+		{
+			name:              "happy path, inline",
+			content:           packageJSON,
+			options:           &importOptions{inline: true},
+			expectedVariables: []Variable{npmBinPath},
+			expectedTargets: []Target{
+				{Name: "prebuild", Description: "run the prebuild npm script", Recipe: []string{"rm -rf dist"}, Phony: true},
+				{
+					Name:         "build",
+					Description:  "run the build npm script",
+					Dependencies: []string{"prebuild"},
+					Recipe:       []string{"vite build --base $$BASE && $(MAKE) lint-css", "@ $(MAKE) postbuild"},
+					Phony:        true,
+				},
+				{Name: "lint-css", Description: "run the lint:css npm script", Recipe: []string{"stylelint 'src/**/*.css'"}, Phony: true},
+				{Name: "postbuild", Description: "run the postbuild npm script", Recipe: []string{"echo done"}, Phony: true},
+			},
+		},
+		{
+			name:          "no script",
+			content:       `{"name": "web"}`,
+			expectedError: errors.New("no script found"),
+		},
+		{
+			name:          "invalid scripts",
+			content:       `{"scripts": ["build"]}`,
+			expectedError: errors.New("scripts must be an object"),
+		},
+		{
+			name:          "invalid JSON",
+			content:       `{"scripts":`,
+			expectedError: errors.New("parsing package.json: unexpected end of JSON input"),
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			variables, targets, err := importNPM([]byte(tc.content), tc.options)
+			if err != nil {
+				if tc.expectedError == nil {
+					t.Fatalf("expected no error, got %v", err)
+				}
+				require.Equal(t, tc.expectedError.Error(), err.Error())
+			} else {
+				if tc.expectedError != nil {
+					t.Fatalf("expected error to be %v, got nil", tc.expectedError)
+				}
+				require.Equal(t, tc.expectedVariables, variables)
+				require.Equal(t, tc.expectedTargets, targets)
+			}
+		})
+	}
+}
@@ -0,0 +1,93 @@
+// Copyright (c) 2023 Tiago Melo. All rights reserved.
+// Use of this source code is governed by the MIT License that can be found in
+// the LICENSE file.
+
+package mfile
+
+import (
+	"sort"
+	"strings"
+)
+
+// Operating systems variables and recipes can differ for, as detected by
+// the generated Makefile: Windows_NT is reported as Windows, other systems
+// as reported by uname -s.
+const (
+	OSWindows = "Windows"
+	OSLinux   = "Linux"
+	OSDarwin  = "Darwin"
+)
+
+// detectedOSVariable is the variable holding the operating system make runs on.
+const detectedOSVariable = "DETECTED_OS"
+
+// osDetections are the blocks setting detectedOSVariable, by dialect,
+// declared once at the top of Makefiles with OS-specific variables or
+// recipes.
+var osDetections = map[string]string{
+	DialectGNU: `ifeq ($(OS),Windows_NT)
+DETECTED_OS := Windows
+else
+UNAME_S := $(shell uname -s)
+DETECTED_OS := $(UNAME_S)
+endif
+`,
+	DialectBMake: "DETECTED_OS != uname -s\n",
+}
+
+// needsOSDetection reports whether some of the given variables or targets
+// differ per operating system.
+func needsOSDetection(variables []Variable, targets []Target) bool {
+	for _, v := range variables {
+		if len(v.OSValues) > 0 {
+			return true
+		}
+	}
+	for _, t := range targets {
+		if len(t.OSRecipes) > 0 {
+			return true
+		}
+	}
+	return false
+}
+
+// osBlock returns a conditional chain on detectedOSVariable, in the
+// syntax of the given dialect, with the lines of each operating system,
+// sorted by name, followed by the default lines.
+func osBlock[T any](dialect string, byOS map[string]T, def T, lines func(T) []string) string {
+	oses := make([]string, 0, len(byOS))
+	for os := range byOS {
+		oses = append(oses, os)
+	}
+	sort.Strings(oses)
+	var sb strings.Builder
+	for i, os := range oses {
+		switch {
+		case dialect == DialectBMake && i == 0:
+			sb.WriteString(".if ${" + detectedOSVariable + "} == \"" + os + "\"\n")
+		case dialect == DialectBMake:
+			sb.WriteString(".elif ${" + detectedOSVariable + "} == \"" + os + "\"\n")
+		case i == 0:
+			sb.WriteString("ifeq ($(" + detectedOSVariable + ")," + os + ")\n")
+		default:
+			sb.WriteString("else ifeq ($(" + detectedOSVariable + ")," + os + ")\n")
+		}
+		for _, l := range lines(byOS[os]) {
+			sb.WriteString(l + "\n")
+		}
+	}
+	if dialect == DialectBMake {
+		sb.WriteString(".else\n")
+	} else {
+		sb.WriteString("else\n")
+	}
+	for _, l := range lines(def) {
+		sb.WriteString(l + "\n")
+	}
+	if dialect == DialectBMake {
+		sb.WriteString(".endif\n")
+	} else {
+		sb.WriteString("endif\n")
+	}
+	return sb.String()
+}
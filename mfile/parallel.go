@@ -0,0 +1,75 @@
+// Copyright (c) 2023 Tiago Melo. All rights reserved.
+// Use of this source code is governed by the MIT License that can be found in
+// the LICENSE file.
+
+package mfile
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+)
+
+// WaitPrerequisite is the special prerequisite of GNU make 4.4 and POSIX
+// make making make build the prerequisites listed before it before the
+// ones listed after it, even when running jobs in parallel, like in
+// Dependencies: []string{"generate", WaitPrerequisite, "build"}.
+const WaitPrerequisite = ".WAIT"
+
+// ParallelAuto runs one job per CPU, see WithParallel.
+const ParallelAuto = "auto"
+
+// cpuCount is the shell command counting the CPUs, on Linux and macOS.
+const cpuCount = "$(shell nproc 2> /dev/null || sysctl -n hw.ncpu 2> /dev/null || echo 1)"
+
+// WithParallel makes the targets of the Makefile run in parallel by
+// default, adding -j to MAKEFLAGS, with the given number of jobs or, with
+// ParallelAuto, one per CPU. The JOBS variable overrides it, like
+// make JOBS=1 to run them one at a time. It is for GNU make only.
+func WithParallel(jobs string) GenerateOption {
+	return func(o *generateOptions) {
+		o.parallel = jobs
+	}
+}
+
+// WithNotParallel makes the Makefile declare .NOTPARALLEL, so that its
+// targets run one at a time even with make -j, for recipes that are not
+// safe to run in parallel.
+func WithNotParallel(notParallel bool) GenerateOption {
+	return func(o *generateOptions) {
+		o.notParallel = notParallel
+	}
+}
+
+// validateParallel checks that the parallelism options fit together and
+// fit the dialect.
+func validateParallel(o *generateOptions) error {
+	if o.parallel == "" {
+		return nil
+	}
+	if o.notParallel {
+		return errors.New("a Makefile can't run its targets both in parallel and one at a time")
+	}
+	if o.dialect != DialectGNU {
+		return fmt.Errorf("the %s dialect does not support running the targets in parallel by default", o.dialect)
+	}
+	if n, err := strconv.Atoi(o.parallel); o.parallel != ParallelAuto && (err != nil || n < 1) {
+		return fmt.Errorf("invalid number of jobs %q, want a positive number or %s", o.parallel, ParallelAuto)
+	}
+	return nil
+}
+
+// parallelBlock returns the lines setting how the targets of the Makefile
+// run, in parallel or one at a time, or an empty string if it is left to
+// the make command line.
+func parallelBlock(o *generateOptions) string {
+	switch {
+	case o.notParallel:
+		return "# Targets run one at a time, even with make -j.\n.NOTPARALLEL:\n"
+	case o.parallel == ParallelAuto:
+		return "# Targets run in parallel, one job per CPU; run make JOBS=1 to run them\n# one at a time.\nJOBS ?= " + cpuCount + "\nMAKEFLAGS += -j$(JOBS)\n"
+	case o.parallel != "":
+		return "# Targets run in parallel, " + o.parallel + " jobs at a time; run make JOBS=1 to run\n# them one at a time.\nJOBS ?= " + o.parallel + "\nMAKEFLAGS += -j$(JOBS)\n"
+	}
+	return ""
+}
@@ -0,0 +1,22 @@
+// Copyright (c) 2023 Tiago Melo. All rights reserved.
+// Use of this source code is governed by the MIT License that can be found in
+// the LICENSE file.
+
+package mfile
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestRenderWaitPrerequisite(t *testing.T) {
+	content := render(nil, []Target{
+		{Name: "generate", Recipe: []string{"@ go generate ./..."}, Phony: true},
+		{Name: "build", Dependencies: []string{"generate", WaitPrerequisite, "compile"}, Phony: true},
+	})
+	require.Equal(t, ".PHONY: generate\ngenerate:\n\t@ go generate ./...\n\n.PHONY: build\nbuild: generate .WAIT compile\n", content)
+	targets := parseTargets(content)
+	require.Equal(t, []string{"generate", WaitPrerequisite, "compile"}, targets[1].Dependencies)
+	require.Equal(t, []MakeRequirement{{Line: 6, Construct: ".WAIT", Version: "4.4"}}, makeRequirements(content))
+}
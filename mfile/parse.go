@@ -0,0 +1,447 @@
+// Copyright (c) 2023 Tiago Melo. All rights reserved.
+// Use of this source code is governed by the MIT License that can be found in
+// the LICENSE file.
+
+package mfile
+
+import (
+	"bufio"
+	"io"
+	"regexp"
+	"strings"
+)
+
+// sectionPrefix starts the comments declaring the section the targets
+// following them are listed under by help.
+const sectionPrefix = "##@"
+
+// recipePrefixDirective is the special variable of GNU make setting the
+// character recipe lines start with instead of a tab.
+const recipePrefixDirective = ".RECIPEPREFIX"
+
+// Target represents a rule parsed from a Makefile.
+type Target struct {
+	Name         string   // Name of the target.
+	Description  string   // Description taken from the "## name: description" comment.
+	Dependencies []string // Prerequisites of the target.
+	Recipe       []string // Recipe lines, without the leading tab.
+	Phony        bool     // Whether the target is declared as .PHONY.
+	Section      string   // Section the target is listed under by help, from the "##@ Section" comment before it.
+	Line         int      // 1-based line number of the rule.
+	EndLine      int      // 1-based line number of the last line of the rule, recipe included. Only set by GetTarget.
+	CIStage      string   // CI stage the target runs in, from the "## ci-stage: stage" comment before it.
+
+	// OSRecipes holds the recipes run instead of Recipe on the given
+	// operating systems, like OSWindows. It is only used when generating.
+	OSRecipes map[string][]string
+
+	// Variables holds the target-specific variables, set while building
+	// the target only. It is only used when generating, for GNU make.
+	Variables []Variable
+}
+
+// ListOption configures how ListTargets parses a Makefile.
+type ListOption func(*targetScanner)
+
+// WithoutRecipes makes ListTargets leave the recipes of the targets out,
+// which GetTarget loads when needed, sparing their memory on huge
+// Makefiles.
+func WithoutRecipes() ListOption {
+	return func(s *targetScanner) {
+		s.skipRecipes = true
+	}
+}
+
+// ListTargets parses the Makefile at the given path and returns
+// its targets in the order they are declared. The Makefile is streamed
+// line by line rather than read at once.
+func ListTargets(path string, opts ...ListOption) ([]Target, error) {
+	s := newTargetScanner()
+	for _, opt := range opts {
+		opt(s)
+	}
+	if err := scanMakefile(mkFilePath(path), s.scanLine, nil); err != nil {
+		return nil, err
+	}
+	return s.result(), nil
+}
+
+// HasTarget reports whether the Makefile at the given path declares the
+// given target. The Makefile is streamed line by line, and only up to the
+// first rule of the target.
+func HasTarget(path, name string) (bool, error) {
+	s := newTargetScanner()
+	s.skipRecipes = true
+	var found bool
+	err := scanMakefile(mkFilePath(path), s.scanLine, func() bool {
+		_, found = s.index[name]
+		return found
+	})
+	if err != nil {
+		return false, err
+	}
+	return found, nil
+}
+
+// scanMakefile calls scan with each line of the Makefile at the given
+// path, without its line ending, streaming it. It stops once done, if
+// set, returns true, or once the Makefile is not text anymore.
+func scanMakefile(makeFilePath string, scan func(line string), done func() bool) error {
+	logger.Debug("scanning Makefile", "path", makeFilePath)
+	file, err := fsProvider.Open(makeFilePath)
+	if err != nil {
+		if fsProvider.IsNotExist(err) {
+			err = mark(ErrMakefileNotFound, err)
+		}
+		return &Error{Op: "reading Makefile", Path: makeFilePath, Err: err}
+	}
+	defer file.Close()
+	text := &textReader{r: file}
+	r := bufio.NewReader(text)
+	for {
+		line, err := r.ReadString('\n')
+		if err != nil && err != io.EOF {
+			return &Error{Op: "reading Makefile", Path: makeFilePath, Err: err}
+		}
+		scan(strings.TrimSuffix(line, "\n"))
+		if err == io.EOF || done != nil && done() {
+			break
+		}
+	}
+	if text.err != nil {
+		return &Error{Op: "reading Makefile", Path: makeFilePath, Err: text.err}
+	}
+	return nil
+}
+
+// parseTargets extracts the targets declared in the given Makefile content.
+// Special targets (like .PHONY) and variable assignments are skipped.
+// A target declared more than once is reported once, at its first rule.
+func parseTargets(content string) []Target {
+	s := newTargetScanner()
+	for _, line := range strings.Split(content, "\n") {
+		s.scanLine(line)
+	}
+	return s.result()
+}
+
+// targetScanner parses the targets of a Makefile one line at a time, a
+// state machine keeping what the lines to come depend on, like the rules
+// the recipe lines belong to, so that a Makefile can be streamed. See
+// parseTargets.
+type targetScanner struct {
+	skipRecipes  bool // Whether the recipes are left out.
+	targets      []Target
+	index        map[string]int // Index of the targets, by name.
+	phony        map[string]bool
+	descriptions map[string]string
+	current      []int  // Indices of the targets of the rule the recipe lines belong to.
+	section      string // Section of the targets to come.
+	stage        string // CI stage of the next rule.
+	prefix       string // Recipe prefix.
+	lineNumber   int    // Number of lines scanned.
+
+	// continued holds the line continued by the scanned one, which ends
+	// with a backslash, and continuedLine its 1-based line number.
+	continued     *string
+	continuedLine int
+}
+
+// newTargetScanner returns a targetScanner at the start of a Makefile.
+func newTargetScanner() *targetScanner {
+	return &targetScanner{
+		index:        make(map[string]int),
+		phony:        make(map[string]bool),
+		descriptions: make(map[string]string),
+		prefix:       "\t",
+	}
+}
+
+// scanLine parses the next line of the Makefile, without its line ending.
+func (s *targetScanner) scanLine(line string) {
+	s.lineNumber++
+	if s.continued != nil {
+		joined := strings.TrimSuffix(*s.continued, "\\") + " " + strings.TrimSpace(line)
+		if strings.HasSuffix(joined, "\\") {
+			s.continued = &joined
+			return
+		}
+		s.continued = nil
+		s.scanLogicalLine(joined, s.continuedLine)
+		return
+	}
+	line = strings.TrimRight(line, "\r")
+	if strings.HasPrefix(line, s.prefix) {
+		if !s.skipRecipes {
+			for _, ti := range s.current {
+				s.targets[ti].Recipe = append(s.targets[ti].Recipe, strings.TrimPrefix(line, s.prefix))
+			}
+		}
+		return
+	}
+	if strings.HasSuffix(line, "\\") {
+		s.continued, s.continuedLine = &line, s.lineNumber
+		return
+	}
+	s.scanLogicalLine(line, s.lineNumber)
+}
+
+// scanLogicalLine parses the given line, which is not a recipe line, its
+// continuation lines joined, starting at the given 1-based line number.
+func (s *targetScanner) scanLogicalLine(line string, lineNumber int) {
+	if isConditional(line) {
+		// Conditionals may wrap recipe lines, which then still
+		// belong to the current rule.
+		return
+	}
+	s.current = nil
+	if p, ok := parseRecipePrefix(line); ok {
+		s.prefix = p
+		return
+	}
+	if strings.HasPrefix(line, sectionPrefix) {
+		s.section = strings.TrimSpace(strings.TrimPrefix(line, sectionPrefix))
+		return
+	}
+	if m := ciStageAnnotation.FindStringSubmatch(line); m != nil {
+		s.stage = m[1]
+		return
+	}
+	if name, desc, ok := parseDescription(line); ok {
+		s.descriptions[name] = desc
+		return
+	}
+	names, deps, ok := parseRule(line)
+	if !ok {
+		return
+	}
+	if names[0] == ".PHONY" {
+		for _, d := range deps {
+			s.phony[d] = true
+		}
+		return
+	}
+	if strings.HasPrefix(names[0], ".") {
+		return
+	}
+	for _, name := range names {
+		ti, exists := s.index[name]
+		if !exists {
+			s.targets = append(s.targets, Target{Name: name, Line: lineNumber, Section: s.section})
+			ti = len(s.targets) - 1
+			s.index[name] = ti
+		}
+		if s.stage != "" {
+			s.targets[ti].CIStage = s.stage
+		}
+		s.targets[ti].Dependencies = append(s.targets[ti].Dependencies, deps...)
+		s.current = append(s.current, ti)
+	}
+	s.stage = ""
+}
+
+// result returns the targets scanned, with their .PHONY declarations and
+// descriptions, which may follow their rules.
+func (s *targetScanner) result() []Target {
+	if s.continued != nil {
+		s.scanLogicalLine(*s.continued, s.continuedLine)
+		s.continued = nil
+	}
+	for i := range s.targets {
+		s.targets[i].Phony = s.phony[s.targets[i].Name]
+		s.targets[i].Description = s.descriptions[s.targets[i].Name]
+	}
+	return s.targets
+}
+
+// ListVariables parses the Makefile at the given path and returns the
+// variables it assigns, in the order they are first assigned.
+func ListVariables(path string) ([]Variable, error) {
+	content, err := readMakefile(mkFilePath(path))
+	if err != nil {
+		return nil, err
+	}
+	return parseVariables(content), nil
+}
+
+// assignmentOperators are the assignment operators of make, longest first.
+var assignmentOperators = []string{":::=", "::=", ":=", "?=", "+=", "!=", "="}
+
+// parseVariables extracts the variables assigned in the given Makefile
+// content, outside of recipes. A variable assigned more than once is
+// reported once, with its first value, but += appends to it. Special
+// variables, like .DEFAULT_GOAL, and target-specific assignments are
+// skipped.
+func parseVariables(content string) []Variable {
+	var (
+		variables []Variable
+		index     = make(map[string]int)
+		prefix    = "\t"
+	)
+	lines := strings.Split(content, "\n")
+	for i := 0; i < len(lines); i++ {
+		line := strings.TrimRight(lines[i], "\r")
+		if strings.HasPrefix(line, prefix) {
+			continue
+		}
+		for strings.HasSuffix(line, "\\") && i+1 < len(lines) {
+			i++
+			line = strings.TrimRight(strings.TrimSuffix(line, "\\"), " \t") + " " + strings.TrimSpace(lines[i])
+		}
+		if p, ok := parseRecipePrefix(line); ok {
+			prefix = p
+		}
+		v, ok := parseAssignment(line)
+		if !ok {
+			continue
+		}
+		vi, exists := index[v.Name]
+		switch {
+		case !exists:
+			if v.Operator == "+=" {
+				v.Operator = "="
+			}
+			index[v.Name] = len(variables)
+			variables = append(variables, v)
+		case v.Operator == "+=":
+			variables[vi].Value = strings.TrimSpace(variables[vi].Value + " " + v.Value)
+		}
+	}
+	return variables
+}
+
+// parseAssignment parses a variable assignment like "NAME ?= value",
+// optionally preceded by export or override.
+func parseAssignment(line string) (Variable, bool) {
+	if line == "" || strings.HasPrefix(line, "#") || strings.HasPrefix(line, " ") {
+		return Variable{}, false
+	}
+	line = strings.TrimPrefix(line, "override ")
+	line, export := strings.CutPrefix(line, "export ")
+	eq := strings.Index(line, "=")
+	if eq <= 0 {
+		return Variable{}, false
+	}
+	for _, op := range assignmentOperators {
+		start := eq + 1 - len(op)
+		if start <= 0 || line[start:eq+1] != op {
+			continue
+		}
+		name := strings.TrimSpace(line[:start])
+		if name == "" || strings.HasPrefix(name, ".") || strings.ContainsAny(name, " \t:#$()") {
+			return Variable{}, false
+		}
+		value := line[eq+1:]
+		if i := strings.Index(value, " #"); i >= 0 {
+			value = value[:i]
+		}
+		return Variable{Name: name, Operator: op, Value: strings.TrimSpace(value), Export: export}, true
+	}
+	return Variable{}, false
+}
+
+// parseRecipePrefix parses a ".RECIPEPREFIX = >" assignment, returning
+// the recipe prefix it sets. Like make, only the first character of the
+// value is used, and an empty value restores the tab.
+func parseRecipePrefix(line string) (prefix string, ok bool) {
+	rest, ok := strings.CutPrefix(line, recipePrefixDirective)
+	if !ok {
+		return "", false
+	}
+	rest = strings.TrimLeft(rest, " ")
+	for _, op := range []string{"=", ":=", "::="} {
+		if value, ok := strings.CutPrefix(rest, op); ok {
+			value = strings.TrimLeft(value, " \t")
+			if value == "" {
+				return "\t", true
+			}
+			r := []rune(value)[0]
+			return string(r), true
+		}
+	}
+	return "", false
+}
+
+// recipePrefixAt returns the recipe prefix in effect at the end of the
+// given Makefile content.
+func recipePrefixAt(content string) string {
+	prefix := "\t"
+	for _, line := range strings.Split(content, "\n") {
+		if p, ok := parseRecipePrefix(strings.TrimRight(line, "\r")); ok {
+			prefix = p
+		}
+	}
+	return prefix
+}
+
+// isConditional reports whether the given line is a conditional directive,
+// like ifeq or endif, or the .if, .for and .endif directives of bmake.
+func isConditional(line string) bool {
+	fields := strings.Fields(line)
+	if len(fields) == 0 {
+		return false
+	}
+	switch fields[0] {
+	case "ifeq", "ifneq", "ifdef", "ifndef", "else", "endif",
+		".if", ".ifdef", ".ifndef", ".ifmake", ".ifnmake", ".elif", ".elifdef", ".elifndef", ".else", ".endif", ".for", ".endfor":
+		return true
+	}
+	return false
+}
+
+// ciStageAnnotation matches the "## ci-stage: stage" comments selecting the
+// CI stage of the target declared after them. With a single #, they are
+// left out of the help message.
+var ciStageAnnotation = regexp.MustCompile(`^##?\s*ci-stage:\s*([A-Za-z0-9_.-]+)\s*$`)
+
+// parseDescription parses a "## name: description" help comment.
+func parseDescription(line string) (name, description string, ok bool) {
+	if !strings.HasPrefix(line, "##") {
+		return "", "", false
+	}
+	name, description, ok = strings.Cut(strings.TrimPrefix(line, "##"), ":")
+	if !ok {
+		return "", "", false
+	}
+	name = strings.TrimSpace(name)
+	if name == "" || containsSpace(name) {
+		return "", "", false
+	}
+	return name, strings.TrimSpace(description), true
+}
+
+// parseRule parses a rule line like "name: dep1 dep2", returning the target
+// names and their dependencies. Variable assignments, comments and
+// directives are not rules.
+func parseRule(line string) (names, deps []string, ok bool) {
+	if line == "" || strings.HasPrefix(line, "#") || strings.HasPrefix(line, " ") {
+		return nil, nil, false
+	}
+	if i := strings.Index(line, "#"); i >= 0 {
+		line = line[:i]
+	}
+	colon := strings.Index(line, ":")
+	if colon <= 0 {
+		return nil, nil, false
+	}
+	if eq := strings.Index(line, "="); eq >= 0 && eq < colon {
+		return nil, nil, false
+	}
+	rest := line[colon+1:]
+	if strings.HasPrefix(rest, "=") || strings.HasPrefix(rest, ":=") {
+		return nil, nil, false
+	}
+	rest = strings.TrimPrefix(rest, ":")
+	if strings.Contains(rest, "=") {
+		// Target-specific variable assignment.
+		return nil, nil, false
+	}
+	if i := strings.Index(rest, ";"); i >= 0 {
+		rest = rest[:i]
+	}
+	names = strings.Fields(line[:colon])
+	if len(names) == 0 {
+		return nil, nil, false
+	}
+	return names, strings.Fields(rest), true
+}
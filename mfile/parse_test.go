@@ -0,0 +1,335 @@
+// Copyright (c) 2023 Tiago Melo. All rights reserved.
+// Use of this source code is governed by the MIT License that can be found in
+// the LICENSE file.
+
+package mfile
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestListTargets(t *testing.T) {
+	testCases := []struct {
+		name            string
+		mockClosure     func(m *mockFileSystem)
+		opts            []ListOption
+		expectedTargets []Target
+		expectedError   error
+	}{
+		{
+			name: "happy path",
+			mockClosure: func(m *mockFileSystem) {
+				m.file = []byte(minimalMakefile)
+			},
+			expectedTargets: []Target{
+				{
+					Name:        "help",
+					Description: "shows this help message",
+					Recipe:      helpRecipes[HelpStyleAWK],
+					Phony:       true,
+					Line:        3,
+				},
+				{
+					Name:        "test",
+					Description: "run unit tests",
+					Recipe:      []string{"@ go test -v ./... -count=1"},
+					Phony:       true,
+					Line:        8,
+				},
+				{
+					Name:        "coverage",
+					Description: "run unit tests and generate coverage report in html format",
+					Recipe:      []string{"@ go test -coverprofile=coverage.out ./...  && go tool cover -html=coverage.out"},
+					Phony:       true,
+					Line:        13,
+				},
+			},
+		},
+		{
+			name: "happy path, without recipes",
+			mockClosure: func(m *mockFileSystem) {
+				m.file = []byte("build: vet\n\tgo build ./...\n\nvet:\n\tgo vet ./...\n")
+			},
+			opts: []ListOption{WithoutRecipes()},
+			expectedTargets: []Target{
+				{Name: "build", Dependencies: []string{"vet"}, Line: 1},
+				{Name: "vet", Line: 4},
+			},
+		},
+		{
+			name: "not a Makefile",
+			mockClosure: func(m *mockFileSystem) {
+				m.file = []byte("build:\n\x00")
+			},
+			expectedError: errors.New("reading Makefile at some/path: not a Makefile"),
+		},
+		{
+			name: "error when reading file",
+			mockClosure: func(m *mockFileSystem) {
+				m.readFileErr = errors.New("read error")
+			},
+			expectedError: errors.New("reading Makefile at some/path: read error"),
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			m := new(mockFileSystem)
+			tc.mockClosure(m)
+			fsProvider = m
+			targets, err := ListTargets("some/path", tc.opts...)
+			if err != nil {
+				if tc.expectedError == nil {
+					t.Fatalf("expected no error, got %v", err)
+				}
+				require.Equal(t, tc.expectedError.Error(), err.Error())
+			} else {
+				if tc.expectedError != nil {
+					t.Fatalf("expected error to be %v, got nil", tc.expectedError)
+				}
+				require.Equal(t, tc.expectedTargets, targets)
+			}
+		})
+	}
+}
+
+func TestHasTarget(t *testing.T) {
+	content := "VERSION := 1.0\n\nbuild: vet \\\n\tfmt\n\tgo build ./...\n\n.PHONY: deploy\nvet fmt:\n\tgo vet ./...\n"
+	testCases := []struct {
+		name          string
+		mockClosure   func(m *mockFileSystem)
+		target        string
+		expectedFound bool
+		expectedError error
+	}{
+		{
+			name:          "happy path",
+			mockClosure:   func(m *mockFileSystem) { m.file = []byte(content) },
+			target:        "build",
+			expectedFound: true,
+		},
+		{
+			name:          "happy path, rule of several targets",
+			mockClosure:   func(m *mockFileSystem) { m.file = []byte(content) },
+			target:        "fmt",
+			expectedFound: true,
+		},
+		{
+			name:        "happy path, not declared",
+			mockClosure: func(m *mockFileSystem) { m.file = []byte(content) },
+			target:      "deploy",
+		},
+		{
+			name:        "happy path, variable",
+			mockClosure: func(m *mockFileSystem) { m.file = []byte(content) },
+			target:      "VERSION",
+		},
+		{
+			name: "Makefile not found",
+			mockClosure: func(m *mockFileSystem) {
+				m.readFileErr = os.ErrNotExist
+				m.isNotExistOutput = true
+			},
+			target:        "build",
+			expectedError: ErrMakefileNotFound,
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			m := new(mockFileSystem)
+			tc.mockClosure(m)
+			fsProvider = m
+			found, err := HasTarget("some/path", tc.target)
+			if tc.expectedError != nil {
+				require.ErrorIs(t, err, tc.expectedError)
+				return
+			}
+			require.NoError(t, err)
+			require.Equal(t, tc.expectedFound, found)
+		})
+	}
+}
+
+func TestParseTargets(t *testing.T) {
+	testCases := []struct {
+		name            string
+		content         string
+		expectedTargets []Target
+	}{
+		{
+			name:    "variables and special targets are skipped",
+			content: "BIN := app\nVERSION ?= 1.0\n.DEFAULT_GOAL := build\n.PHONY: build\nbuild: ; @ echo $(BIN)\n",
+			expectedTargets: []Target{
+				{Name: "build", Phony: true, Line: 5},
+			},
+		},
+		{
+			name:    "multiple targets and continuation lines",
+			content: "a b: c \\\n\td\n\t@ echo $@\n# a comment\nc:\n",
+			expectedTargets: []Target{
+				{Name: "a", Dependencies: []string{"c", "d"}, Recipe: []string{"@ echo $@"}, Line: 1},
+				{Name: "b", Dependencies: []string{"c", "d"}, Recipe: []string{"@ echo $@"}, Line: 1},
+				{Name: "c", Line: 5},
+			},
+		},
+		{
+			name:    "target-specific variables are not rules",
+			content: "build: GOFLAGS=-mod=vendor\nbuild: deps\n",
+			expectedTargets: []Target{
+				{Name: "build", Dependencies: []string{"deps"}, Line: 2},
+			},
+		},
+		{
+			name:    "sections",
+			content: "help:\n\n##@ Build\n\nbuild:\n\n## run: run it\nrun:\n##@ Test\ntest:\n",
+			expectedTargets: []Target{
+				{Name: "help", Line: 1},
+				{Name: "build", Section: "Build", Line: 5},
+				{Name: "run", Description: "run it", Section: "Build", Line: 8},
+				{Name: "test", Section: "Test", Line: 10},
+			},
+		},
+		{
+			name:    "recipe prefix",
+			content: ".RECIPEPREFIX = >\nbuild:\n>go build\n.RECIPEPREFIX =\ntest:\n\tgo test\n",
+			expectedTargets: []Target{
+				{Name: "build", Recipe: []string{"go build"}, Line: 2},
+				{Name: "test", Recipe: []string{"go test"}, Line: 5},
+			},
+		},
+		{
+			name:    "CI stages",
+			content: ".PHONY: lint\n## lint: run linters\n# ci-stage: check\nlint:\n\n## ci-stage: test\n.PHONY: test\ntest: lint\nclean:\n",
+			expectedTargets: []Target{
+				{Name: "lint", Description: "run linters", Phony: true, Line: 4, CIStage: "check"},
+				{Name: "test", Dependencies: []string{"lint"}, Phony: true, Line: 8, CIStage: "test"},
+				{Name: "clean", Line: 9},
+			},
+		},
+		{
+			name:            "empty content",
+			content:         "",
+			expectedTargets: nil,
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			require.Equal(t, tc.expectedTargets, parseTargets(tc.content))
+		})
+	}
+}
+
+func TestParseVariables(t *testing.T) {
+	testCases := []struct {
+		name              string
+		content           string
+		expectedVariables []Variable
+	}{
+		{
+			name:    "assignments",
+			content: "BIN := app\nVERSION ?= 1.0 # the version\nexport GOFLAGS = -mod=mod\nFILES != ls\nSIMPLE ::= yes\n",
+			expectedVariables: []Variable{
+				{Name: "BIN", Operator: ":=", Value: "app"},
+				{Name: "VERSION", Operator: "?=", Value: "1.0"},
+				{Name: "GOFLAGS", Operator: "=", Value: "-mod=mod", Export: true},
+				{Name: "FILES", Operator: "!=", Value: "ls"},
+				{Name: "SIMPLE", Operator: "::=", Value: "yes"},
+			},
+		},
+		{
+			name:    "first value is kept, += appends",
+			content: "TAGS = a\nTAGS = b\nTAGS += c \\\n\td\nEXTRA += x\n",
+			expectedVariables: []Variable{
+				{Name: "TAGS", Operator: "=", Value: "a c d"},
+				{Name: "EXTRA", Operator: "=", Value: "x"},
+			},
+		},
+		{
+			name:    "recipes, rules and special variables are skipped",
+			content: ".DEFAULT_GOAL := build\nbuild: GOOS = linux\nbuild: ; A=b go build\n\tX=1 go test\n.RECIPEPREFIX = >\ntest:\n>Y=2 go test\n",
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			require.Equal(t, tc.expectedVariables, parseVariables(tc.content))
+		})
+	}
+}
+
+// writeHugeMakefile writes a Makefile of about the given size to a
+// temporary directory, made of targets with long recipes, and returns its
+// path and the name of its last target.
+func writeHugeMakefile(b *testing.B, size int) (string, string) {
+	var sb strings.Builder
+	var last string
+	for i := 0; sb.Len() < size; i++ {
+		last = fmt.Sprintf("target-%d", i)
+		fmt.Fprintf(&sb, ".PHONY: %s\n## %s: a legacy target\n%s: target-%d\n", last, last, last, i+1)
+		for j := 0; j < 20; j++ {
+			fmt.Fprintf(&sb, "\t@ echo 'step %d of %s, with a recipe line long enough to matter'\n", j, last)
+		}
+		sb.WriteString("\n")
+	}
+	path := filepath.Join(b.TempDir(), "Makefile")
+	if err := os.WriteFile(path, []byte(sb.String()), 0644); err != nil {
+		b.Fatal(err)
+	}
+	return path, last
+}
+
+// BenchmarkListTargets compares listing the targets of a multi-megabyte
+// Makefile by streaming it, with and without their recipes, to parsing it
+// whole.
+func BenchmarkListTargets(b *testing.B) {
+	fsProvider = osFileSystem{}
+	path, _ := writeHugeMakefile(b, 8<<20)
+	b.Run("Parse", func(b *testing.B) {
+		b.ReportAllocs()
+		for i := 0; i < b.N; i++ {
+			m, err := Parse(path)
+			if err != nil {
+				b.Fatal(err)
+			}
+			_ = m.Targets()
+		}
+	})
+	b.Run("ListTargets", func(b *testing.B) {
+		b.ReportAllocs()
+		for i := 0; i < b.N; i++ {
+			if _, err := ListTargets(path); err != nil {
+				b.Fatal(err)
+			}
+		}
+	})
+	b.Run("ListTargetsWithoutRecipes", func(b *testing.B) {
+		b.ReportAllocs()
+		for i := 0; i < b.N; i++ {
+			if _, err := ListTargets(path, WithoutRecipes()); err != nil {
+				b.Fatal(err)
+			}
+		}
+	})
+}
+
+// BenchmarkHasTarget looks up the first and the last targets of a
+// multi-megabyte Makefile, the scan stopping at the target.
+func BenchmarkHasTarget(b *testing.B) {
+	fsProvider = osFileSystem{}
+	path, last := writeHugeMakefile(b, 8<<20)
+	for _, name := range []string{"target-0", last} {
+		b.Run(name, func(b *testing.B) {
+			b.ReportAllocs()
+			for i := 0; i < b.N; i++ {
+				if found, err := HasTarget(path, name); err != nil || !found {
+					b.Fatal(found, err)
+				}
+			}
+		})
+	}
+}
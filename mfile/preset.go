@@ -0,0 +1,322 @@
+// Copyright (c) 2023 Tiago Melo. All rights reserved.
+// Use of this source code is governed by the MIT License that can be found in
+// the LICENSE file.
+
+package mfile
+
+import (
+	"fmt"
+	"reflect"
+	"slices"
+	"sort"
+	"strings"
+)
+
+// Variable represents a Makefile variable assignment.
+type Variable struct {
+	Name     string // Name of the variable.
+	Operator string // Assignment operator, like "=", ":=" or "?=". Defaults to "?=".
+	Value    string // Value assigned to the variable.
+	Export   bool   // Whether the variable is exported to the environment of the recipes.
+
+	// OSValues holds the values assigned instead of Value on the given
+	// operating systems, like OSWindows.
+	OSValues map[string]string
+}
+
+// Preset is a named, curated set of variables and targets that can be
+// used when generating a Makefile.
+type Preset struct {
+	Name        string     // Name used to select the preset.
+	Description string     // Short description of the preset.
+	Include     []string   // Presets this one is built on, included before it.
+	Variables   []Variable // Variables declared by the preset.
+	Targets     []Target   // Targets declared by the preset.
+
+	// Parameters holds the generation-time parameters of the preset,
+	// with their default values. They can be set with WithParameter.
+	Parameters map[string]string
+
+	// Func, if set, returns additional variables and targets computed
+	// from the values of the parameters or from the project the Makefile
+	// is generated for. They are declared after Variables and Targets.
+	Func func(ctx PresetContext) ([]Variable, []Target, error)
+}
+
+// PresetContext holds what a Preset.Func can compute its content from.
+type PresetContext struct {
+	Dir     string            // Directory of the Makefile being generated.
+	Params  map[string]string // Values of the parameters.
+	Module  string            // Module path declared by go.mod, if any.
+	AppName string            // Last element of Module, or "app" if there is no go.mod.
+
+	fsys fileSystem // File system holding Dir, see files.
+}
+
+// files returns the file system holding the directory of the Makefile
+// being generated.
+func (ctx PresetContext) files() fileSystem {
+	if ctx.fsys == nil {
+		return fsProvider
+	}
+	return ctx.fsys
+}
+
+// presets holds the registered presets, by name.
+var presets = map[string]Preset{}
+
+func init() {
+	for _, p := range builtinPresets {
+		presets[p.Name] = p
+	}
+}
+
+// RegisterPreset registers a custom preset, so that it can be selected
+// by name. Registering a preset with the name of an existing one replaces it.
+func RegisterPreset(p Preset) error {
+	if p.Name == "" || containsSpace(p.Name) || strings.Contains(p.Name, ",") {
+		return fmt.Errorf("invalid preset name %q", p.Name)
+	}
+	presets[p.Name] = p
+	return nil
+}
+
+// Presets returns the registered presets, sorted by name.
+func Presets() []Preset {
+	list := make([]Preset, 0, len(presets))
+	for _, p := range presets {
+		list = append(list, p)
+	}
+	sort.Slice(list, func(i, j int) bool {
+		return list[i].Name < list[j].Name
+	})
+	return list
+}
+
+// resolution is the result of resolving presets: the merged variables
+// and targets, along with the name of the preset that declared each of
+// them last.
+type resolution struct {
+	presets        []string // Names of the resolved presets, in order.
+	variables      []Variable
+	targets        []Target
+	variableOwners []string
+	targetOwners   []string
+}
+
+// resolvePresets merges the variables and targets of the given presets,
+// and of the presets they include, in order. When two presets declare
+// the same variable or target, the last declaration wins but keeps the
+// position of the first one, unless the presets clash, see
+// clashingPresets. The given parameters override the defaults declared by
+// the presets.
+func resolvePresets(dir string, names []string, params map[string]string) ([]Variable, []Target, error) {
+	r, err := resolve(fsProvider, dir, nil, names, params)
+	if err != nil {
+		return nil, nil, err
+	}
+	return r.variables, r.targets, nil
+}
+
+// resolve is like resolvePresets, but also tells which preset declared
+// each variable and target, the project being read from the given file
+// system. The given base presets, like the ones of a flavor, are resolved
+// first, and their targets can be overridden by the given presets without
+// clashing.
+func resolve(fsys fileSystem, dir string, base, names []string, params map[string]string) (*resolution, error) {
+	var (
+		ordered []Preset
+		visited = make(map[string]bool)
+	)
+	var visit func(name string, stack []string) error
+	visit = func(name string, stack []string) error {
+		for _, s := range stack {
+			if s == name {
+				return fmt.Errorf("preset %q includes itself", name)
+			}
+		}
+		if visited[name] {
+			return nil
+		}
+		p, ok := presets[name]
+		if !ok {
+			return fmt.Errorf("unknown preset %q", name)
+		}
+		for _, inc := range p.Include {
+			if err := visit(inc, append(stack, name)); err != nil {
+				return err
+			}
+		}
+		visited[name] = true
+		ordered = append(ordered, p)
+		return nil
+	}
+	names = slices.Clone(names)
+	for i, name := range names {
+		names[i] = strings.TrimSpace(name)
+	}
+	for _, name := range append(slices.Clone(base), names...) {
+		if err := visit(name, nil); err != nil {
+			return nil, err
+		}
+	}
+	values := make(map[string]string)
+	for _, p := range ordered {
+		for k, v := range p.Parameters {
+			values[k] = v
+		}
+	}
+	for k, v := range params {
+		if _, ok := values[k]; !ok {
+			return nil, fmt.Errorf("unknown parameter %q", k)
+		}
+		values[k] = v
+	}
+	ctx := PresetContext{Dir: dir, Params: values, fsys: fsys}
+	for _, p := range ordered {
+		if p.Func != nil {
+			module, err := modulePath(fsys, dir)
+			if err != nil {
+				return nil, err
+			}
+			ctx.Module, ctx.AppName = module, appName(module)
+			break
+		}
+	}
+	variables := make([][]Variable, len(ordered))
+	targets := make([][]Target, len(ordered))
+	for i, p := range ordered {
+		variables[i], targets[i] = p.Variables, p.Targets
+		if p.Func != nil {
+			vars, tgts, err := p.Func(ctx)
+			if err != nil {
+				return nil, fmt.Errorf("preset %s: %w", p.Name, err)
+			}
+			variables[i] = append(append([]Variable{}, variables[i]...), vars...)
+			targets[i] = append(append([]Target{}, targets[i]...), tgts...)
+		}
+	}
+	namespaced := clashingPresets(ordered, targets, names)
+	var (
+		r        = new(resolution)
+		varIndex = make(map[string]int)
+		tgtIndex = make(map[string]int)
+	)
+	for i, p := range ordered {
+		r.presets = append(r.presets, p.Name)
+		presetVariables, presetTargets := variables[i], targets[i]
+		if namespaced[p.Name] {
+			presetTargets = namespaceTargets(p.Name, presetTargets)
+		}
+		for _, v := range presetVariables {
+			if i, ok := varIndex[v.Name]; ok {
+				r.variables[i], r.variableOwners[i] = v, p.Name
+				continue
+			}
+			varIndex[v.Name] = len(r.variables)
+			r.variables = append(r.variables, v)
+			r.variableOwners = append(r.variableOwners, p.Name)
+		}
+		for _, t := range presetTargets {
+			if i, ok := tgtIndex[t.Name]; ok {
+				r.targets[i], r.targetOwners[i] = t, p.Name
+				continue
+			}
+			tgtIndex[t.Name] = len(r.targets)
+			r.targets = append(r.targets, t)
+			r.targetOwners = append(r.targetOwners, p.Name)
+		}
+	}
+	return r, nil
+}
+
+// clashingPresets returns the names of the given ordered presets whose
+// targets clash with the ones of a preset resolved before them, and must
+// therefore be namespaced, see namespaceTargets: the presets pulled in by
+// different presets of the given names that declare the same target,
+// without one including the other, like the build target of go-cli and
+// node. Declaring the same target, like go and go-library do with vet,
+// or overriding a target of an included preset, or of a base preset,
+// which the given names don't pull in, is not a clash. The given targets are the ones declared
+// by each of the ordered presets.
+func clashingPresets(ordered []Preset, targets [][]Target, names []string) map[string]bool {
+	closures := make(map[string]map[string]bool)
+	for _, name := range names {
+		closures[name] = presetClosure(name)
+	}
+	requester := func(preset string) string {
+		for _, name := range names {
+			if closures[name][preset] {
+				return name
+			}
+		}
+		return ""
+	}
+	type declaration struct {
+		preset string
+		target Target
+	}
+	namespaced := make(map[string]bool)
+	declared := make(map[string]declaration) // By target name.
+	for i, p := range ordered {
+		rp := requester(p.Name)
+		if rp == "" {
+			continue
+		}
+		for _, t := range targets[i] {
+			d, ok := declared[t.Name]
+			if !ok {
+				declared[t.Name] = declaration{preset: p.Name, target: t}
+				continue
+			}
+			ro := requester(d.preset)
+			if ro == rp || closures[rp][d.preset] || closures[ro][p.Name] || reflect.DeepEqual(d.target, t) {
+				declared[t.Name] = declaration{preset: p.Name, target: t}
+				continue
+			}
+			logger.Warn("target declared by two presets, namespacing the targets of the last one", "target", t.Name, "preset", p.Name, "clashes with", ro)
+			namespaced[p.Name] = true
+		}
+	}
+	return namespaced
+}
+
+// presetClosure returns the names of the given preset and of the presets
+// it includes, recursively.
+func presetClosure(name string) map[string]bool {
+	closure := make(map[string]bool)
+	var visit func(name string)
+	visit = func(name string) {
+		if closure[name] {
+			return
+		}
+		closure[name] = true
+		for _, inc := range presets[name].Include {
+			visit(inc)
+		}
+	}
+	visit(name)
+	return closure
+}
+
+// namespaceTargets returns copies of the given targets of the preset with
+// the given name namespaced with it, like node/build, along with their
+// dependencies on each other.
+func namespaceTargets(preset string, targets []Target) []Target {
+	own := make(map[string]bool)
+	for _, t := range targets {
+		own[t.Name] = true
+	}
+	namespaced := make([]Target, len(targets))
+	for i, t := range targets {
+		t.Name = preset + namespaceSeparator + t.Name
+		t.Dependencies = slices.Clone(t.Dependencies)
+		for j, d := range t.Dependencies {
+			if own[d] {
+				t.Dependencies[j] = preset + namespaceSeparator + d
+			}
+		}
+		namespaced[i] = t
+	}
+	return namespaced
+}
@@ -0,0 +1,88 @@
+// Copyright (c) 2023 Tiago Melo. All rights reserved.
+// Use of this source code is governed by the MIT License that can be found in
+// the LICENSE file.
+
+package mfile
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestRegisterPreset(t *testing.T) {
+	testCases := []struct {
+		name          string
+		preset        Preset
+		expectedError error
+	}{
+		{
+			name: "happy path",
+			preset: Preset{
+				Name:    "custom",
+				Include: []string{PresetMinimal},
+				Targets: []Target{{Name: "deploy", Phony: true}},
+			},
+		},
+		{
+			name:          "invalid name",
+			preset:        Preset{Name: "a,b"},
+			expectedError: errors.New(`invalid preset name "a,b"`),
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			defer delete(presets, tc.preset.Name)
+			err := RegisterPreset(tc.preset)
+			if err != nil {
+				if tc.expectedError == nil {
+					t.Fatalf("expected no error, got %v", err)
+				}
+				require.Equal(t, tc.expectedError.Error(), err.Error())
+			} else {
+				if tc.expectedError != nil {
+					t.Fatalf("expected error to be %v, got nil", tc.expectedError)
+				}
+				_, targets, err := resolvePresets(".", []string{tc.preset.Name}, nil)
+				require.NoError(t, err)
+				require.Equal(t, "deploy", targets[len(targets)-1].Name)
+			}
+		})
+	}
+}
+
+func TestResolvePresets(t *testing.T) {
+	presets["loop-a"] = Preset{Name: "loop-a", Include: []string{"loop-b"}}
+	presets["loop-b"] = Preset{Name: "loop-b", Include: []string{"loop-a"}}
+	defer delete(presets, "loop-a")
+	defer delete(presets, "loop-b")
+	_, _, err := resolvePresets(".", []string{"loop-a"}, nil)
+	require.EqualError(t, err, `preset "loop-a" includes itself`)
+}
+
+func TestResolvePresetsClashingTargets(t *testing.T) {
+	presets["clash-a"] = Preset{Name: "clash-a", Targets: []Target{
+		{Name: "build", Recipe: []string{"@ a build"}},
+	}}
+	presets["clash-b"] = Preset{Name: "clash-b", Targets: []Target{
+		{Name: "build", Recipe: []string{"@ b build"}},
+		{Name: "run", Dependencies: []string{"build"}, Recipe: []string{"@ b run"}},
+	}}
+	defer delete(presets, "clash-a")
+	defer delete(presets, "clash-b")
+	_, targets, err := resolvePresets(".", []string{"clash-a", "clash-b"}, nil)
+	require.NoError(t, err)
+	require.Equal(t, []Target{
+		{Name: "build", Recipe: []string{"@ a build"}},
+		{Name: "clash-b/build", Recipe: []string{"@ b build"}},
+		{Name: "clash-b/run", Dependencies: []string{"clash-b/build"}, Recipe: []string{"@ b run"}},
+	}, targets)
+}
+
+func TestResolvePresetsKeepsNames(t *testing.T) {
+	names := []string{" go", "lint "}
+	_, _, err := resolvePresets(".", names, nil)
+	require.NoError(t, err)
+	require.Equal(t, []string{" go", "lint "}, names)
+}
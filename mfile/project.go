@@ -0,0 +1,166 @@
+// Copyright (c) 2023 Tiago Melo. All rights reserved.
+// Use of this source code is governed by the MIT License that can be found in
+// the LICENSE file.
+
+package mfile
+
+import (
+	"fmt"
+	"go/parser"
+	"go/token"
+	"path/filepath"
+	"strconv"
+	"strings"
+)
+
+// goModHasToolDirectives reports whether the go.mod file in the given
+// directory of the given file system declares tool directives, introduced
+// in Go 1.24.
+func goModHasToolDirectives(fsys fileSystem, dir string) (bool, error) {
+	content, err := fsys.ReadFile(filepath.Join(dir, "go.mod"))
+	if err != nil {
+		if fsys.IsNotExist(err) {
+			return false, nil
+		}
+		return false, fmt.Errorf("reading go.mod: %w", err)
+	}
+	for _, line := range strings.Split(string(content), "\n") {
+		fields := strings.Fields(line)
+		if len(fields) > 1 && fields[0] == "tool" {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+// defaultAppName is the application name used when it cannot be derived
+// from go.mod.
+const defaultAppName = "app"
+
+// modulePath returns the module path declared by the go.mod file in the
+// given directory of the given file system, or an empty string if there
+// is no go.mod file.
+func modulePath(fsys fileSystem, dir string) (string, error) {
+	content, err := fsys.ReadFile(filepath.Join(dir, "go.mod"))
+	if err != nil {
+		if fsys.IsNotExist(err) {
+			return "", nil
+		}
+		return "", fmt.Errorf("reading go.mod: %w", err)
+	}
+	for _, line := range strings.Split(string(content), "\n") {
+		line, _, _ = strings.Cut(line, "//")
+		fields := strings.Fields(line)
+		if len(fields) != 2 || fields[0] != "module" {
+			continue
+		}
+		if path, err := strconv.Unquote(fields[1]); err == nil {
+			return path, nil
+		}
+		return fields[1], nil
+	}
+	return "", nil
+}
+
+// appName returns the application name of the given module: the last
+// element of its path, ignoring a major version suffix like "/v2".
+func appName(module string) string {
+	if module == "" {
+		return defaultAppName
+	}
+	elems := strings.Split(module, "/")
+	name := elems[len(elems)-1]
+	if len(elems) > 1 && isMajorVersion(name) {
+		name = elems[len(elems)-2]
+	}
+	return name
+}
+
+// isMajorVersion reports whether the given module path element is a
+// major version suffix, like "v2".
+func isMajorVersion(elem string) bool {
+	if len(elem) < 2 || elem[0] != 'v' {
+		return false
+	}
+	_, err := strconv.Atoi(elem[1:])
+	return err == nil
+}
+
+// cmdBinaries returns the names of the binaries of the project at dir,
+// following the cmd/<name>/main.go layout, sorted by name.
+func cmdBinaries(fsys fileSystem, dir string) ([]string, error) {
+	entries, err := fsys.ReadDir(filepath.Join(dir, "cmd"))
+	if err != nil {
+		if fsys.IsNotExist(err) {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("reading cmd directory: %w", err)
+	}
+	var binaries []string
+	for _, e := range entries {
+		if !e.IsDir() {
+			continue
+		}
+		files, err := fsys.ReadDir(filepath.Join(dir, "cmd", e.Name()))
+		if err != nil {
+			return nil, fmt.Errorf("reading cmd/%s directory: %w", e.Name(), err)
+		}
+		for _, f := range files {
+			if f.Name() == "main.go" && !f.IsDir() {
+				binaries = append(binaries, e.Name())
+				break
+			}
+		}
+	}
+	return binaries, nil
+}
+
+// toolsFilePackages returns the packages imported by the given tools.go
+// file, which by convention pins the versions of the tools a module uses.
+func toolsFilePackages(fsys fileSystem, toolsFile string) ([]string, error) {
+	content, err := fsys.ReadFile(toolsFile)
+	if err != nil {
+		return nil, fmt.Errorf("reading %s: %w", toolsFile, err)
+	}
+	f, err := parser.ParseFile(token.NewFileSet(), toolsFile, content, parser.ImportsOnly)
+	if err != nil {
+		return nil, fmt.Errorf("parsing %s: %w", toolsFile, err)
+	}
+	var packages []string
+	for _, imp := range f.Imports {
+		pkg, err := strconv.Unquote(imp.Path.Value)
+		if err != nil {
+			return nil, fmt.Errorf("parsing %s: %w", toolsFile, err)
+		}
+		packages = append(packages, pkg)
+	}
+	return packages, nil
+}
+
+// fileExists reports whether the given file exists in the given file
+// system.
+func fileExists(fsys fileSystem, path string) bool {
+	_, err := fsys.Stat(path)
+	return err == nil
+}
+
+// lockfiles maps Node.js lockfiles to the package manager that writes them.
+var lockfiles = []struct {
+	file           string
+	packageManager string
+}{
+	{"pnpm-lock.yaml", "pnpm"},
+	{"yarn.lock", "yarn"},
+	{"package-lock.json", "npm"},
+}
+
+// detectPackageManager returns the Node.js package manager used by the
+// project at dir, based on its lockfile. Defaults to npm.
+func detectPackageManager(fsys fileSystem, dir string) string {
+	for _, l := range lockfiles {
+		if fileExists(fsys, filepath.Join(dir, l.file)) {
+			return l.packageManager
+		}
+	}
+	return "npm"
+}
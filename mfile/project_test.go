@@ -0,0 +1,87 @@
+// Copyright (c) 2023 Tiago Melo. All rights reserved.
+// Use of this source code is governed by the MIT License that can be found in
+// the LICENSE file.
+
+package mfile
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestModulePath(t *testing.T) {
+	testCases := []struct {
+		name           string
+		mockClosure    func(m *mockFileSystem)
+		expectedModule string
+		expectedError  error
+	}{
+		{
+			name: "happy path",
+			mockClosure: func(m *mockFileSystem) {
+				m.file = []byte("// a comment\nmodule github.com/acme/widget // the widget\n\ngo 1.21\n")
+			},
+			expectedModule: "github.com/acme/widget",
+		},
+		{
+			name: "happy path, quoted module path",
+			mockClosure: func(m *mockFileSystem) {
+				m.file = []byte("module \"github.com/acme/widget\"\n")
+			},
+			expectedModule: "github.com/acme/widget",
+		},
+		{
+			name: "no go.mod",
+			mockClosure: func(m *mockFileSystem) {
+				m.readFileErr = errors.New("not found")
+				m.isNotExistOutput = true
+			},
+		},
+		{
+			name: "error when reading go.mod",
+			mockClosure: func(m *mockFileSystem) {
+				m.readFileErr = errors.New("read error")
+			},
+			expectedError: errors.New("reading go.mod: read error"),
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			m := new(mockFileSystem)
+			tc.mockClosure(m)
+			fsProvider = m
+			module, err := modulePath(fsProvider, ".")
+			if err != nil {
+				if tc.expectedError == nil {
+					t.Fatalf("expected no error, got %v", err)
+				}
+				require.Equal(t, tc.expectedError.Error(), err.Error())
+			} else {
+				if tc.expectedError != nil {
+					t.Fatalf("expected error to be %v, got nil", tc.expectedError)
+				}
+				require.Equal(t, tc.expectedModule, module)
+			}
+		})
+	}
+}
+
+func TestAppName(t *testing.T) {
+	testCases := []struct {
+		module   string
+		expected string
+	}{
+		{module: "github.com/acme/widget", expected: "widget"},
+		{module: "github.com/acme/widget/v2", expected: "widget"},
+		{module: "widget", expected: "widget"},
+		{module: "v2", expected: "v2"},
+		{module: "", expected: "app"},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.module, func(t *testing.T) {
+			require.Equal(t, tc.expected, appName(tc.module))
+		})
+	}
+}
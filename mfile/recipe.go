@@ -0,0 +1,83 @@
+// Copyright (c) 2023 Tiago Melo. All rights reserved.
+// Use of this source code is governed by the MIT License that can be found in
+// the LICENSE file.
+
+package mfile
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+)
+
+// AppendRecipeLine adds the given command at the end of the recipe of the
+// given target of the Makefile at the given path, see
+// Makefile.AppendRecipeLine.
+func AppendRecipeLine(path, target, line string) error {
+	return editMakefile(path, func(m *Makefile) error {
+		return m.AppendRecipeLine(target, line)
+	})
+}
+
+// PrependRecipeLine adds the given command at the start of the recipe of
+// the given target of the Makefile at the given path, see
+// Makefile.PrependRecipeLine.
+func PrependRecipeLine(path, target, line string) error {
+	return editMakefile(path, func(m *Makefile) error {
+		return m.PrependRecipeLine(target, line)
+	})
+}
+
+// AppendRecipeLine adds the given command at the end of the recipe of the
+// given target, indented with the recipe prefix in effect, a tab unless
+// set otherwise with .RECIPEPREFIX. Commands spanning several lines are
+// added as several recipe lines. It returns ErrTargetNotFound if the
+// target is not declared.
+func (m *Makefile) AppendRecipeLine(target, line string) error {
+	return m.insertRecipeLine(target, line, true)
+}
+
+// PrependRecipeLine adds the given command at the start of the recipe of
+// the given target, see AppendRecipeLine.
+func (m *Makefile) PrependRecipeLine(target, line string) error {
+	return m.insertRecipeLine(target, line, false)
+}
+
+// insertRecipeLine adds the given command at the end of the recipe of the
+// given target if atEnd, or at its start otherwise.
+func (m *Makefile) insertRecipeLine(target, line string, atEnd bool) error {
+	_, _, recipe, last, ok := ruleBlock(m.lines, target)
+	if !ok {
+		return fmt.Errorf("looking up target %s: %w", target, ErrTargetNotFound)
+	}
+	prefix := recipePrefixAt(strings.Join(m.lines[:recipe], "\n"))
+	// Recipe lines end like the rule line, with a carriage return if
+	// the Makefile uses CRLF line endings.
+	lineEnd := ""
+	if strings.HasSuffix(m.lines[recipe-1], "\r") {
+		lineEnd = "\r"
+	}
+	var added []string
+	for _, l := range strings.Split(strings.TrimRight(line, "\r\n"), "\n") {
+		added = append(added, prefix+strings.TrimRight(l, "\r")+lineEnd)
+	}
+	at := recipe
+	if atEnd {
+		at = last + 1
+	}
+	m.lines = slices.Insert(m.lines, at, added...)
+	return nil
+}
+
+// editMakefile parses the Makefile at the given path, edits it with the
+// given function and writes it back.
+func editMakefile(path string, edit func(m *Makefile) error) error {
+	m, err := Parse(path)
+	if err != nil {
+		return err
+	}
+	if err := edit(m); err != nil {
+		return fmt.Errorf("editing %s: %w", mkFilePath(path), err)
+	}
+	return m.Write(path)
+}
@@ -0,0 +1,142 @@
+// Copyright (c) 2023 Tiago Melo. All rights reserved.
+// Use of this source code is governed by the MIT License that can be found in
+// the LICENSE file.
+
+package mfile
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestAppendRecipeLine(t *testing.T) {
+	testCases := []struct {
+		name            string
+		content         string
+		target          string
+		line            string
+		expectedContent string
+		expectedError   error
+	}{
+		{
+			name:            "happy path",
+			content:         "## build: build the binary\nbuild:\n\tgo vet ./...\n\n## test: run tests\ntest:\n\tgo test ./...\n",
+			target:          "build",
+			line:            "go build ./...",
+			expectedContent: "## build: build the binary\nbuild:\n\tgo vet ./...\n\tgo build ./...\n\n## test: run tests\ntest:\n\tgo test ./...\n",
+		},
+		{
+			name:            "happy path, empty recipe",
+			content:         "build: vet\n\ntest:\n",
+			target:          "build",
+			line:            "go build ./...",
+			expectedContent: "build: vet\n\tgo build ./...\n\ntest:\n",
+		},
+		{
+			name:            "happy path, several lines",
+			content:         "build:\n\tgo vet ./...",
+			target:          "build",
+			line:            "go build ./...\n@ echo done\n",
+			expectedContent: "build:\n\tgo vet ./...\n\tgo build ./...\n\t@ echo done",
+		},
+		{
+			name:            "happy path, recipe prefix",
+			content:         ".RECIPEPREFIX = >\nbuild:\n>go vet ./...\n",
+			target:          "build",
+			line:            "go build ./...",
+			expectedContent: ".RECIPEPREFIX = >\nbuild:\n>go vet ./...\n>go build ./...\n",
+		},
+		{
+			name:            "happy path, CRLF line endings",
+			content:         "build:\r\n\tgo vet ./...\r\n",
+			target:          "build",
+			line:            "go build ./...",
+			expectedContent: "build:\r\n\tgo vet ./...\r\n\tgo build ./...\r\n",
+		},
+		{
+			name:          "target not found",
+			content:       "build:\n",
+			target:        "test",
+			line:          "go test ./...",
+			expectedError: errors.New("editing Makefile: looking up target test: target not found"),
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			fs := &mockFileSystem{file: []byte(tc.content)}
+			fsProvider = fs
+			err := AppendRecipeLine("Makefile", tc.target, tc.line)
+			if err != nil {
+				if tc.expectedError == nil {
+					t.Fatalf("expected no error, got %v", err)
+				}
+				require.Equal(t, tc.expectedError.Error(), err.Error())
+			} else {
+				if tc.expectedError != nil {
+					t.Fatalf("expected error to be %v, got nil", tc.expectedError)
+				}
+				require.Equal(t, tc.expectedContent, string(fs.writtenData))
+			}
+		})
+	}
+}
+
+func TestPrependRecipeLine(t *testing.T) {
+	testCases := []struct {
+		name            string
+		content         string
+		target          string
+		line            string
+		expectedContent string
+		expectedError   error
+	}{
+		{
+			name:            "happy path",
+			content:         "GO ?= go\n\nbuild: vet\n\t$(GO) build ./...\n",
+			target:          "build",
+			line:            "@ echo building",
+			expectedContent: "GO ?= go\n\nbuild: vet\n\t@ echo building\n\t$(GO) build ./...\n",
+		},
+		{
+			name:            "happy path, rule spanning several lines",
+			content:         "build: vet \\\n\tlint\n\tgo build ./...\n",
+			target:          "build",
+			line:            "@ echo building",
+			expectedContent: "build: vet \\\n\tlint\n\t@ echo building\n\tgo build ./...\n",
+		},
+		{
+			name:            "happy path, target among others",
+			content:         "build test: vet\n\tgo $@ ./...\n",
+			target:          "test",
+			line:            "@ echo $@",
+			expectedContent: "build test: vet\n\t@ echo $@\n\tgo $@ ./...\n",
+		},
+		{
+			name:          "target not found",
+			content:       "build:\n",
+			target:        "test",
+			line:          "go test ./...",
+			expectedError: errors.New("editing Makefile: looking up target test: target not found"),
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			fs := &mockFileSystem{file: []byte(tc.content)}
+			fsProvider = fs
+			err := PrependRecipeLine("Makefile", tc.target, tc.line)
+			if err != nil {
+				if tc.expectedError == nil {
+					t.Fatalf("expected no error, got %v", err)
+				}
+				require.Equal(t, tc.expectedError.Error(), err.Error())
+			} else {
+				if tc.expectedError != nil {
+					t.Fatalf("expected error to be %v, got nil", tc.expectedError)
+				}
+				require.Equal(t, tc.expectedContent, string(fs.writtenData))
+			}
+		})
+	}
+}
@@ -0,0 +1,206 @@
+// Copyright (c) 2023 Tiago Melo. All rights reserved.
+// Use of this source code is governed by the MIT License that can be found in
+// the LICENSE file.
+
+package mfile
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+)
+
+// Files of the template registry, in the user cache directory.
+const (
+	registryDir  = "gomakefile/templates" // Relative to the user cache directory.
+	registryFile = "registry.json"        // Index of the registered templates.
+)
+
+// For ease of unit testing.
+var (
+	// userCacheDir returns the cache directory of the user.
+	userCacheDir = os.UserCacheDir
+
+	// now returns the current time.
+	now = time.Now
+)
+
+// RegisteredTemplate is a named template kept in the local cache, so that
+// it can be used without fetching it on every run.
+type RegisteredTemplate struct {
+	Name      string    `json:"name"`      // Name used to select the template.
+	Source    string    `json:"source"`    // Source the template is fetched from, with its ref.
+	FetchedAt time.Time `json:"fetchedAt"` // When the template was last fetched.
+}
+
+// Templates returns the registered templates, sorted by name.
+func Templates() ([]RegisteredTemplate, error) {
+	dir, err := registryPath()
+	if err != nil {
+		return nil, err
+	}
+	registry, err := readRegistry(fsProvider, dir)
+	if err != nil {
+		return nil, err
+	}
+	list := make([]RegisteredTemplate, 0, len(registry))
+	for _, t := range registry {
+		list = append(list, t)
+	}
+	sort.Slice(list, func(i, j int) bool {
+		return list[i].Name < list[j].Name
+	})
+	return list, nil
+}
+
+// AddTemplate fetches the template at the given source and registers it
+// in the local cache with the given name. See WithTemplate for the
+// supported sources; a ref pins the version of a template from git.
+func AddTemplate(name, source string) error {
+	if name == "" || containsSpace(name) || strings.ContainsAny(name, `/\.:`) {
+		return fmt.Errorf("invalid template name %q", name)
+	}
+	dir, err := registryPath()
+	if err != nil {
+		return err
+	}
+	registry, err := readRegistry(fsProvider, dir)
+	if err != nil {
+		return err
+	}
+	if _, ok := registry[name]; ok {
+		return fmt.Errorf("template %s is already registered", name)
+	}
+	return cacheTemplate(dir, registry, RegisteredTemplate{Name: name, Source: source})
+}
+
+// UpdateTemplates fetches again the registered templates with the given
+// names, or all of them if no name is given, and updates the local cache.
+func UpdateTemplates(names ...string) error {
+	dir, err := registryPath()
+	if err != nil {
+		return err
+	}
+	registry, err := readRegistry(fsProvider, dir)
+	if err != nil {
+		return err
+	}
+	if len(names) == 0 {
+		for name := range registry {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+	}
+	for _, name := range names {
+		t, ok := registry[name]
+		if !ok {
+			return fmt.Errorf("template %s is not registered", name)
+		}
+		if err := cacheTemplate(dir, registry, t); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// cacheTemplate fetches the given template, writes it to the cache
+// directory and records it in the registry.
+func cacheTemplate(dir string, registry map[string]RegisteredTemplate, t RegisteredTemplate) error {
+	content, err := fetchTemplate(fsProvider, t.Source)
+	if err != nil {
+		return err
+	}
+	if err := fsProvider.MkdirAll(dir, 0755); err != nil {
+		return fmt.Errorf("creating template cache %s: %w", dir, err)
+	}
+	path := filepath.Join(dir, t.Name+".tmpl")
+	if err := fsProvider.WriteFile(path, []byte(content), 0644); err != nil {
+		return &Error{Op: "writing template", Path: path, Err: err}
+	}
+	t.FetchedAt = now().UTC()
+	registry[t.Name] = t
+	logger.Debug("cached template", "name", t.Name, "source", t.Source, "path", path)
+	return writeRegistry(dir, registry)
+}
+
+// cachedTemplate returns the content of the registered template with the
+// given name, and whether it is registered, reading the cache from the
+// given file system.
+func cachedTemplate(fsys fileSystem, name string) (string, bool, error) {
+	if strings.ContainsAny(name, `/\.:`) {
+		return "", false, nil
+	}
+	dir, err := registryPath()
+	if err != nil {
+		return "", false, err
+	}
+	registry, err := readRegistry(fsys, dir)
+	if err != nil {
+		return "", false, err
+	}
+	if _, ok := registry[name]; !ok {
+		return "", false, nil
+	}
+	path := filepath.Join(dir, name+".tmpl")
+	content, err := fsys.ReadFile(path)
+	if err != nil {
+		return "", false, &Error{Op: "reading template", Path: path, Err: err}
+	}
+	logger.Debug("using cached template", "name", name, "path", path)
+	return string(content), true, nil
+}
+
+// registryPath returns the directory of the template registry.
+func registryPath() (string, error) {
+	cache, err := userCacheDir()
+	if err != nil {
+		return "", fmt.Errorf("locating template cache: %w", err)
+	}
+	return filepath.Join(cache, registryDir), nil
+}
+
+// readRegistry reads the registry in the given directory of the given
+// file system. A missing registry is empty.
+func readRegistry(fsys fileSystem, dir string) (map[string]RegisteredTemplate, error) {
+	registry := make(map[string]RegisteredTemplate)
+	path := filepath.Join(dir, registryFile)
+	content, err := fsys.ReadFile(path)
+	if err != nil {
+		if fsys.IsNotExist(err) {
+			return registry, nil
+		}
+		return nil, &Error{Op: "reading template registry", Path: path, Err: err}
+	}
+	var list []RegisteredTemplate
+	if err := json.Unmarshal(content, &list); err != nil {
+		return nil, fmt.Errorf("parsing template registry %s: %w", path, err)
+	}
+	for _, t := range list {
+		registry[t.Name] = t
+	}
+	return registry, nil
+}
+
+// writeRegistry writes the given registry in the given directory.
+func writeRegistry(dir string, registry map[string]RegisteredTemplate) error {
+	list := make([]RegisteredTemplate, 0, len(registry))
+	for _, t := range registry {
+		list = append(list, t)
+	}
+	sort.Slice(list, func(i, j int) bool {
+		return list[i].Name < list[j].Name
+	})
+	content, err := json.MarshalIndent(list, "", "  ")
+	if err != nil {
+		return fmt.Errorf("encoding template registry: %w", err)
+	}
+	path := filepath.Join(dir, registryFile)
+	if err := fsProvider.WriteFile(path, append(content, '\n'), 0644); err != nil {
+		return &Error{Op: "writing template registry", Path: path, Err: err}
+	}
+	return nil
+}
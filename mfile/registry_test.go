@@ -0,0 +1,69 @@
+// Copyright (c) 2023 Tiago Melo. All rights reserved.
+// Use of this source code is governed by the MIT License that can be found in
+// the LICENSE file.
+
+package mfile
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestTemplateRegistry(t *testing.T) {
+	fsProvider = osFileSystem{}
+	cache := t.TempDir()
+	userCacheDir = func() (string, error) { return cache, nil }
+	fetchedAt := time.Date(2024, 5, 1, 10, 0, 0, 0, time.UTC)
+	now = func() time.Time { return fetchedAt }
+	defer func() {
+		userCacheDir = os.UserCacheDir
+		now = time.Now
+	}()
+
+	source := filepath.Join(t.TempDir(), "Makefile.tmpl")
+	require.NoError(t, os.WriteFile(source, []byte("build:\n"), 0644))
+
+	list, err := Templates()
+	require.NoError(t, err)
+	require.Empty(t, list)
+
+	require.NoError(t, AddTemplate("service", source))
+	require.Equal(t, errors.New("template service is already registered").Error(), AddTemplate("service", source).Error())
+	require.Equal(t, errors.New(`invalid template name "my/service"`).Error(), AddTemplate("my/service", source).Error())
+
+	list, err = Templates()
+	require.NoError(t, err)
+	require.Equal(t, []RegisteredTemplate{{Name: "service", Source: source, FetchedAt: fetchedAt}}, list)
+
+	content, err := loadTemplate(fsProvider, "service")
+	require.NoError(t, err)
+	require.Equal(t, "build:\n", content)
+
+	// The cached template is used, even if the source changes, until it is updated.
+	require.NoError(t, os.WriteFile(source, []byte("deploy:\n"), 0644))
+	content, err = loadTemplate(fsProvider, "service")
+	require.NoError(t, err)
+	require.Equal(t, "build:\n", content)
+
+	fetchedAt = fetchedAt.Add(time.Hour)
+	require.NoError(t, UpdateTemplates())
+	content, err = loadTemplate(fsProvider, "service")
+	require.NoError(t, err)
+	require.Equal(t, "deploy:\n", content)
+	list, err = Templates()
+	require.NoError(t, err)
+	require.Equal(t, []RegisteredTemplate{{Name: "service", Source: source, FetchedAt: fetchedAt}}, list)
+
+	require.Equal(t, "template unknown is not registered", UpdateTemplates("unknown").Error())
+
+	// The cached template is used even when its source is gone.
+	require.NoError(t, os.Remove(source))
+	content, err = loadTemplate(fsProvider, "service")
+	require.NoError(t, err)
+	require.Equal(t, "deploy:\n", content)
+}
@@ -0,0 +1,66 @@
+// Copyright (c) 2023 Tiago Melo. All rights reserved.
+// Use of this source code is governed by the MIT License that can be found in
+// the LICENSE file.
+
+package mfile
+
+import (
+	"slices"
+	"strings"
+)
+
+// removeTarget removes the rules of the given target, with the comments
+// before them and its recipes, its help comments and its .PHONY
+// declaration. Rules declaring other targets too are kept for them, only
+// the given target being removed from them.
+func (m *Makefile) removeTarget(target string) {
+	m.removeRules(target, true)
+}
+
+// removeRules removes the rules of the given target like removeTarget,
+// along with the comments before them only if comments is true. Its help
+// comments are removed either way.
+func (m *Makefile) removeRules(target string, comments bool) {
+	removed := make([]bool, len(m.lines))
+	for searched := 0; ; {
+		first, rule, recipe, last, ok := ruleBlock(m.lines[searched:], target)
+		if !ok {
+			break
+		}
+		first, rule, recipe, last = first+searched, rule+searched, recipe+searched, last+searched
+		searched = last + 1
+		names, _, _ := parseRule(strings.Join(m.lines[rule:recipe], " "))
+		if len(names) > 1 {
+			line := m.lines[rule]
+			colon := strings.Index(line, ":")
+			m.lines[rule] = strings.TrimLeft(removeWord(line[:colon], target), " \t") + line[colon:]
+			continue
+		}
+		for i := first; i <= last; i++ {
+			// .PHONY declarations and help comments are handled below,
+			// as they may be about other targets.
+			if i < rule && (!comments || strings.HasPrefix(m.lines[i], ".PHONY:") || strings.HasPrefix(m.lines[i], "##")) {
+				continue
+			}
+			removed[i] = true
+		}
+	}
+	for i, line := range m.lines {
+		trimmed := strings.TrimRight(line, "\r")
+		if name, _, ok := parseDescription(trimmed); ok && name == target {
+			removed[i] = true
+			continue
+		}
+		names, deps, ok := parseRule(trimmed)
+		if !ok || names[0] != ".PHONY" || !slices.Contains(deps, target) {
+			continue
+		}
+		if len(deps) == 1 {
+			removed[i] = true
+			continue
+		}
+		colon := strings.Index(line, ":") + 1
+		m.lines[i] = line[:colon] + removeWord(line[colon:], target)
+	}
+	m.lines = dropLines(m.lines, removed)
+}
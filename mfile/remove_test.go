@@ -0,0 +1,58 @@
+// Copyright (c) 2023 Tiago Melo. All rights reserved.
+// Use of this source code is governed by the MIT License that can be found in
+// the LICENSE file.
+
+package mfile
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestRemoveTarget(t *testing.T) {
+	testCases := []struct {
+		name            string
+		content         string
+		target          string
+		expectedContent string
+	}{
+		{
+			name:            "target block",
+			content:         "GO ?= go\n\n.PHONY: build\n## build: build it\nbuild:\n\t$(GO) build\n\ntest:\n\t$(GO) test\n",
+			target:          "build",
+			expectedContent: "GO ?= go\n\ntest:\n\t$(GO) test\n",
+		},
+		{
+			name:            "several rules",
+			content:         "build: vet\n\nbuild:\n\tgo build\n\ntest:\n",
+			target:          "build",
+			expectedContent: "test:\n",
+		},
+		{
+			name:            "rule declaring other targets",
+			content:         ".PHONY: build test\nbuild test:\n\tgo $@ ./...\n",
+			target:          "build",
+			expectedContent: ".PHONY: test\ntest:\n\tgo $@ ./...\n",
+		},
+		{
+			name:            "help comments elsewhere",
+			content:         "## build: build it\n## test: test it\n\nbuild:\n\ntest:\n",
+			target:          "build",
+			expectedContent: "## test: test it\n\ntest:\n",
+		},
+		{
+			name:            "target not declared",
+			content:         "test:\n",
+			target:          "build",
+			expectedContent: "test:\n",
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			m := ParseString(tc.content)
+			m.removeTarget(tc.target)
+			require.Equal(t, tc.expectedContent, m.String())
+		})
+	}
+}
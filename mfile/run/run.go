@@ -0,0 +1,173 @@
+// Copyright (c) 2023 Tiago Melo. All rights reserved.
+// Use of this source code is governed by the MIT License that can be found in
+// the LICENSE file.
+
+// Package run runs the targets of a Makefile with make, capturing their
+// output, so that Go tools generating Makefiles with package mfile can
+// also execute their targets.
+package run
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"regexp"
+	"time"
+)
+
+// makefileName is the name of the Makefile run when the path is a
+// directory.
+const makefileName = "Makefile"
+
+// waitDelay is how long Target waits for the output of make to be closed
+// once ctx is done and make killed.
+const waitDelay = time.Second
+
+// Result is the outcome of running a target.
+type Result struct {
+	Stdout   []byte // Captured standard output of make.
+	Stderr   []byte // Captured standard error of make.
+	ExitCode int    // Exit status of make; 0 when the target succeeded.
+}
+
+// options holds the options of Target.
+type options struct {
+	env    []string
+	stdout io.Writer
+	stderr io.Writer
+	make   string
+	args   []string
+}
+
+// Option configures how a target is run.
+type Option func(*options)
+
+// WithEnv adds the given KEY=VALUE variables to the environment make runs
+// in, which otherwise is the one of the current process.
+func WithEnv(env ...string) Option {
+	return func(o *options) {
+		o.env = append(o.env, env...)
+	}
+}
+
+// WithStdout streams the standard output of make to the given writer
+// while it runs. It is captured in the Result either way.
+func WithStdout(w io.Writer) Option {
+	return func(o *options) {
+		o.stdout = w
+	}
+}
+
+// WithStderr streams the standard error of make to the given writer
+// while it runs. It is captured in the Result either way.
+func WithStderr(w io.Writer) Option {
+	return func(o *options) {
+		o.stderr = w
+	}
+}
+
+// WithMake selects the make program to run, like gmake or bmake.
+// Defaults to make.
+func WithMake(program string) Option {
+	return func(o *options) {
+		o.make = program
+	}
+}
+
+// WithArgs passes extra arguments to make, like -j4 or VERSION=1.2.0.
+func WithArgs(args ...string) Option {
+	return func(o *options) {
+		o.args = append(o.args, args...)
+	}
+}
+
+// Target runs the given target of the Makefile at the specified path,
+// which may also be the directory holding it, with make, from the
+// directory of the Makefile. It returns the captured output and exit
+// status of make: a target failing is not an error, the ExitCode of the
+// Result tells it. An error is returned when make cannot be run, or
+// ctx is done before it exits, in which case make is killed.
+func Target(ctx context.Context, path, target string, opts ...Option) (*Result, error) {
+	o := &options{make: "make"}
+	for _, opt := range opts {
+		opt(o)
+	}
+	makeFilePath := filepath.Clean(path)
+	if fi, err := os.Stat(makeFilePath); err == nil && fi.IsDir() {
+		makeFilePath = filepath.Join(makeFilePath, makefileName)
+	}
+	args := append([]string{"-f", filepath.Base(makeFilePath)}, o.args...)
+	if target != "" {
+		args = append(args, target)
+	}
+	cmd := exec.CommandContext(ctx, o.make, args...)
+	cmd.Dir = filepath.Dir(makeFilePath)
+	// The commands run by make may outlive it when it is killed, keeping
+	// its output open.
+	cmd.WaitDelay = waitDelay
+	if len(o.env) > 0 {
+		cmd.Env = append(os.Environ(), o.env...)
+	}
+	var stdout, stderr bytes.Buffer
+	cmd.Stdout, cmd.Stderr = teeWriter(&stdout, o.stdout), teeWriter(&stderr, o.stderr)
+	err := cmd.Run()
+	result := &Result{Stdout: stdout.Bytes(), Stderr: stderr.Bytes()}
+	if ctxErr := ctx.Err(); ctxErr != nil {
+		return result, fmt.Errorf("running target %s of %s: %w", target, makeFilePath, ctxErr)
+	}
+	var exitErr *exec.ExitError
+	if errors.As(err, &exitErr) {
+		result.ExitCode = exitErr.ExitCode()
+		return result, nil
+	}
+	if err != nil {
+		return result, fmt.Errorf("running target %s of %s: %w", target, makeFilePath, err)
+	}
+	return result, nil
+}
+
+// teeWriter returns a writer writing to buf and, if not nil, to w.
+func teeWriter(buf *bytes.Buffer, w io.Writer) io.Writer {
+	if w == nil {
+		return buf
+	}
+	return io.MultiWriter(buf, w)
+}
+
+// gnuMakeVersion matches the version in the output of make --version.
+var gnuMakeVersion = regexp.MustCompile(`^GNU Make ([0-9]+(?:\.[0-9]+)*)`)
+
+// MakeVersion returns the version of the installed GNU make, like "4.3",
+// from the output of make --version. Only the WithMake and WithEnv
+// options are used. An error is returned when make cannot be run, or is
+// not GNU make, like bmake.
+func MakeVersion(ctx context.Context, opts ...Option) (string, error) {
+	o := &options{make: "make"}
+	for _, opt := range opts {
+		opt(o)
+	}
+	cmd := exec.CommandContext(ctx, o.make, "--version")
+	if len(o.env) > 0 {
+		cmd.Env = append(os.Environ(), o.env...)
+	}
+	out, err := cmd.Output()
+	if err != nil {
+		return "", fmt.Errorf("getting the version of %s: %w", o.make, err)
+	}
+	return parseMakeVersion(o.make, string(out))
+}
+
+// parseMakeVersion returns the version of GNU make from the given output
+// of make --version, run with the given program.
+func parseMakeVersion(program, output string) (string, error) {
+	m := gnuMakeVersion.FindStringSubmatch(output)
+	if m == nil {
+		return "", fmt.Errorf("getting the version of %s: not GNU make", program)
+	}
+	return m[1], nil
+}
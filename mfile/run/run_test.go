@@ -0,0 +1,167 @@
+// Copyright (c) 2023 Tiago Melo. All rights reserved.
+// Use of this source code is governed by the MIT License that can be found in
+// the LICENSE file.
+
+package run
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+const makefile = `.PHONY: greet
+greet:
+	@ echo "hello $(NAME) $$GREETING"
+	@ echo oops >&2
+
+.PHONY: fail
+fail:
+	@ exit 3
+
+.PHONY: sleep
+sleep:
+	@ sleep 5
+`
+
+func TestTarget(t *testing.T) {
+	if _, err := exec.LookPath("make"); err != nil {
+		t.Skip("make not installed")
+	}
+	dir := t.TempDir()
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "Makefile"), []byte(makefile), 0644))
+	var streamed bytes.Buffer
+	testCases := []struct {
+		name             string
+		path             string
+		target           string
+		options          []Option
+		timeout          time.Duration
+		expectedStdout   string
+		expectedStderr   string
+		expectedExitCode int
+		expectedError    error
+	}{
+		{
+			name:           "happy path",
+			path:           dir,
+			target:         "greet",
+			options:        []Option{WithEnv("GREETING=hi"), WithArgs("NAME=gopher"), WithStdout(&streamed)},
+			expectedStdout: "hello gopher hi\n",
+			expectedStderr: "oops\n",
+		},
+		{
+			name:           "happy path, Makefile path",
+			path:           filepath.Join(dir, "Makefile"),
+			target:         "greet",
+			expectedStdout: "hello  \n",
+			expectedStderr: "oops\n",
+		},
+		{
+			name:             "target failing",
+			path:             dir,
+			target:           "fail",
+			expectedStderr:   "Error 3",
+			expectedExitCode: 2,
+		},
+		{
+			name:          "make not found",
+			path:          dir,
+			target:        "greet",
+			options:       []Option{WithMake("no-such-make")},
+			expectedError: errors.New(`running target greet of ` + filepath.Join(dir, "Makefile") + `: exec: "no-such-make": executable file not found in $PATH`),
+		},
+		{
+			name:          "context done",
+			path:          dir,
+			target:        "sleep",
+			timeout:       100 * time.Millisecond,
+			expectedError: errors.New("running target sleep of " + filepath.Join(dir, "Makefile") + ": context deadline exceeded"),
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			ctx := context.Background()
+			if tc.timeout > 0 {
+				var cancel context.CancelFunc
+				ctx, cancel = context.WithTimeout(ctx, tc.timeout)
+				defer cancel()
+			}
+			result, err := Target(ctx, tc.path, tc.target, tc.options...)
+			if err != nil {
+				if tc.expectedError == nil {
+					t.Fatalf("expected no error, got %v", err)
+				}
+				require.Equal(t, tc.expectedError.Error(), err.Error())
+			} else {
+				if tc.expectedError != nil {
+					t.Fatalf("expected error to be %v, got nil", tc.expectedError)
+				}
+				require.Equal(t, tc.expectedStdout, string(result.Stdout))
+				require.Contains(t, string(result.Stderr), tc.expectedStderr)
+				require.Equal(t, tc.expectedExitCode, result.ExitCode)
+			}
+		})
+	}
+	require.Equal(t, "hello gopher hi\n", streamed.String())
+}
+
+func TestParseMakeVersion(t *testing.T) {
+	testCases := []struct {
+		name            string
+		output          string
+		expectedVersion string
+		expectedError   error
+	}{
+		{
+			name:            "GNU make",
+			output:          "GNU Make 4.3\nBuilt for x86_64-pc-linux-gnu\n",
+			expectedVersion: "4.3",
+		},
+		{
+			name:            "GNU make with patch version",
+			output:          "GNU Make 4.2.1\nBuilt for x86_64-pc-linux-gnu\n",
+			expectedVersion: "4.2.1",
+		},
+		{
+			name:          "not GNU make",
+			output:        "bmake: unknown option -- -\n",
+			expectedError: errors.New("getting the version of make: not GNU make"),
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			version, err := parseMakeVersion("make", tc.output)
+			if err != nil {
+				if tc.expectedError == nil {
+					t.Fatalf("expected no error, got %v", err)
+				}
+				require.Equal(t, tc.expectedError.Error(), err.Error())
+			} else {
+				if tc.expectedError != nil {
+					t.Fatalf("expected error to be %v, got nil", tc.expectedError)
+				}
+				require.Equal(t, tc.expectedVersion, version)
+			}
+		})
+	}
+}
+
+func TestMakeVersion(t *testing.T) {
+	if _, err := exec.LookPath("make"); err != nil {
+		t.Skip("make not installed")
+	}
+	version, err := MakeVersion(context.Background())
+	require.NoError(t, err)
+	require.Regexp(t, `^[0-9]+(\.[0-9]+)+$`, version)
+
+	_, err = MakeVersion(context.Background(), WithMake("no-such-make"))
+	require.EqualError(t, err, `getting the version of no-such-make: exec: "no-such-make": executable file not found in $PATH`)
+}
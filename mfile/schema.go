@@ -0,0 +1,285 @@
+// Copyright (c) 2023 Tiago Melo. All rights reserved.
+// Use of this source code is governed by the MIT License that can be found in
+// the LICENSE file.
+
+package mfile
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// jsonSchema is the subset of JSON Schema describing the spec files, which
+// validateNode checks YAML nodes against.
+type jsonSchema struct {
+	Schema      string                 `json:"$schema,omitempty"`
+	Title       string                 `json:"title,omitempty"`
+	Description string                 `json:"description,omitempty"`
+	Type        schemaTypes            `json:"type,omitempty"`
+	Enum        []string               `json:"enum,omitempty"`
+	Pattern     string                 `json:"pattern,omitempty"`
+	Minimum     *int                   `json:"minimum,omitempty"`
+	Items       *jsonSchema            `json:"items,omitempty"`
+	Properties  map[string]*jsonSchema `json:"properties,omitempty"`
+
+	// AdditionalProperties is the schema of the keys of an object not in
+	// Properties, or false if there can't be any.
+	AdditionalProperties any `json:"additionalProperties,omitempty"`
+
+	noun string // What the values are, like "flavor", for error messages.
+	hint string // What the values matching Pattern are, for error messages.
+
+	// names are the values of the comma-separated list a string holds,
+	// like "go,lint", which are checked one by one instead of Pattern.
+	names []string
+}
+
+// schemaTypes are the JSON types a value can have, marshaled as a string
+// when there is only one.
+type schemaTypes []string
+
+func (t schemaTypes) MarshalJSON() ([]byte, error) {
+	if len(t) == 1 {
+		return json.Marshal(t[0])
+	}
+	return json.Marshal([]string(t))
+}
+
+// SpecSchema returns the JSON Schema of the spec files, see Spec, so that
+// editors can complete and check them, like with a
+// "# yaml-language-server: $schema=makefile.schema.json" comment. It
+// lists the flavors, help styles, dialects and presets known to the
+// package, the registered presets included. Specs are checked against it
+// when read, see ValidateSpec.
+func SpecSchema() ([]byte, error) {
+	out, err := json.MarshalIndent(specSchema(), "", "  ")
+	if err != nil {
+		return nil, err
+	}
+	return append(out, '\n'), nil
+}
+
+// specSchema returns the JSON Schema of the spec files.
+func specSchema() *jsonSchema {
+	str := func(description string) *jsonSchema {
+		return &jsonSchema{Type: schemaTypes{"string"}, Description: description}
+	}
+	boolean := func(description string) *jsonSchema {
+		return &jsonSchema{Type: schemaTypes{"boolean"}, Description: description}
+	}
+	enum := func(noun, description string, values []string) *jsonSchema {
+		return &jsonSchema{Type: schemaTypes{"string"}, Description: description, Enum: values, noun: noun}
+	}
+	// list is like enum, for a comma-separated list of the values.
+	list := func(noun, description string, values []string) *jsonSchema {
+		quoted := make([]string, len(values))
+		for i, v := range values {
+			quoted[i] = regexp.QuoteMeta(v)
+		}
+		value := "(" + strings.Join(quoted, "|") + ")"
+		return &jsonSchema{
+			Type:        schemaTypes{"string"},
+			Description: description,
+			Pattern:     `^ *` + value + `( *, *` + value + `)* *$`,
+			noun:        noun,
+			names:       values,
+		}
+	}
+	var flavorNames, presetNames []string
+	for _, f := range Flavors() {
+		flavorNames = append(flavorNames, f.Name)
+	}
+	for _, p := range Presets() {
+		presetNames = append(presetNames, p.Name)
+	}
+	one := 1
+	return &jsonSchema{
+		Schema:      "https://json-schema.org/draft/2020-12/schema",
+		Title:       "gomakefile spec",
+		Description: "Declares how the Makefile of a project is generated, as the flags of gomakefile generate do.",
+		Type:        schemaTypes{"object"},
+		Properties: map[string]*jsonSchema{
+			"flavor":        enum("flavor", "Base skeleton of the Makefile, the presets are added to. Defaults to standard.", flavorNames),
+			"help-style":    enum("help style", "Implementation of the help target. Defaults to awk.", HelpStyles()),
+			"windows":       boolean("Make the Makefile work under Windows too."),
+			"dialect":       enum("dialect", "Dialect of make the Makefile targets. Defaults to gnu.", Dialects()),
+			"recipe-prefix": {Type: schemaTypes{"string"}, Description: "Character starting the recipe lines instead of a tab, like >, declared with .RECIPEPREFIX (GNU make only).", Pattern: `^[^\s#$]$`, hint: "a single printable character"},
+			"presets": {
+				Type:        schemaTypes{"array"},
+				Description: "Presets to generate the Makefile from.",
+				Items:       list("preset", "Preset name, or comma-separated preset names, like go,lint.", presetNames),
+			},
+			"parameters": {
+				Type:                 schemaTypes{"object"},
+				Description:          "Parameters of the presets, by name.",
+				AdditionalProperties: &jsonSchema{Type: schemaTypes{"string", "number", "boolean"}},
+			},
+			"auto":     boolean("Add the presets detected from the project."),
+			"template": str("Template to generate the Makefile from: a file, a directory, a URL, a git repository directory like github.com/org/repo//dir?ref=v1.0.0, or a registered template name. A relative path is resolved from the directory of the spec file."),
+			"values": {
+				Type:        schemaTypes{"object"},
+				Description: "Values the template can use as {{ .Values.<key> }}.",
+			},
+			"fragments": str("Directory to write each preset to, as a fragment file included by the Makefile."),
+			"env-file":  str("Environment file to load, if it exists, exporting its variables to the recipes."),
+			"parallel": {
+				Type:        schemaTypes{"string", "integer"},
+				Description: "Number of jobs to run the targets with by default, or auto, for one per CPU (GNU make only).",
+				Pattern:     `^([1-9][0-9]*|auto)$`,
+				Minimum:     &one,
+				hint:        "a positive number or auto",
+			},
+			"not-parallel": boolean("Declare .NOTPARALLEL, so that the targets run one at a time even with make -j."),
+			"strict":       boolean("Start the Makefile with a strict prologue (GNU make only)."),
+			"shell":        str("Shell the recipes run with, declared as SHELL, like /bin/bash (GNU make only)."),
+			"shell-flags":  str("Flags of the shell the recipes run with, declared as .SHELLFLAGS (GNU make only)."),
+			"make-flags": {
+				Type:        schemaTypes{"array"},
+				Description: "Flags added to MAKEFLAGS, like --no-builtin-rules (GNU make only).",
+				Items:       &jsonSchema{Type: schemaTypes{"string"}},
+			},
+			"managed": boolean("Wrap the generated content in a managed block, which gomakefile upgrade regenerates."),
+		},
+		AdditionalProperties: false,
+	}
+}
+
+// SpecError is a violation of the schema of the spec files, see
+// SpecSchema, located in the spec.
+type SpecError struct {
+	Line   int    // 1-based line of the offending key or value.
+	Column int    // 1-based column of the offending key or value.
+	Field  string // Path of the offending key, like "presets[1]" or "parameters.registry".
+	Reason string // What is wrong, like `unknown preset "dockr"`.
+}
+
+func (e *SpecError) Error() string {
+	if e.Field == "" {
+		return fmt.Sprintf("line %d, column %d: %s", e.Line, e.Column, e.Reason)
+	}
+	return fmt.Sprintf("line %d, column %d: %s: %s", e.Line, e.Column, e.Field, e.Reason)
+}
+
+// ValidateSpec checks the given spec, held in YAML or in JSON, against the
+// schema of the spec files, see SpecSchema, and returns its violations,
+// in the order they are found. It fails if the spec is not valid YAML.
+func ValidateSpec(content []byte) ([]*SpecError, error) {
+	var doc yaml.Node
+	if err := yaml.Unmarshal(content, &doc); err != nil {
+		return nil, err
+	}
+	if len(doc.Content) == 0 {
+		// Empty, or made of comments.
+		return nil, nil
+	}
+	var violations []*SpecError
+	validateNode(specSchema(), doc.Content[0], "", &violations)
+	return violations, nil
+}
+
+// validateNode checks the given YAML node, at the given field path,
+// against the given schema, adding its violations to the given ones.
+func validateNode(s *jsonSchema, n *yaml.Node, field string, violations *[]*SpecError) {
+	violate := func(n *yaml.Node, field, reason string, args ...any) {
+		*violations = append(*violations, &SpecError{Line: n.Line, Column: n.Column, Field: field, Reason: fmt.Sprintf(reason, args...)})
+	}
+	if n.Kind == yaml.AliasNode {
+		n = n.Alias
+	}
+	typ := nodeType(n)
+	if len(s.Type) > 0 && !slices.Contains(s.Type, typ) && !(typ == "integer" && slices.Contains(s.Type, "number")) {
+		violate(n, field, "got %s, want %s", typ, orList(s.Type))
+		return
+	}
+	switch typ {
+	case "string":
+		if len(s.Enum) > 0 && !slices.Contains(s.Enum, n.Value) {
+			violate(n, field, "unknown %s %q, want %s", s.noun, n.Value, orList(s.Enum))
+		}
+		switch {
+		case len(s.names) > 0:
+			for _, name := range strings.Split(n.Value, ",") {
+				if name = strings.TrimSpace(name); !slices.Contains(s.names, name) {
+					violate(n, field, "unknown %s %q, want %s", s.noun, name, orList(s.names))
+				}
+			}
+		case s.Pattern != "" && !regexp.MustCompile(s.Pattern).MatchString(n.Value):
+			violate(n, field, "invalid value %q, want %s", n.Value, s.hint)
+		}
+	case "integer":
+		if v, err := strconv.Atoi(n.Value); s.Minimum != nil && err == nil && v < *s.Minimum {
+			violate(n, field, "invalid value %d, want %s", v, s.hint)
+		}
+	case "array":
+		if s.Items == nil {
+			return
+		}
+		for i, item := range n.Content {
+			validateNode(s.Items, item, fmt.Sprintf("%s[%d]", field, i), violations)
+		}
+	case "object":
+		for i := 0; i+1 < len(n.Content); i += 2 {
+			key, value := n.Content[i], n.Content[i+1]
+			keyField := key.Value
+			if field != "" {
+				keyField = field + "." + key.Value
+			}
+			if ps, ok := s.Properties[key.Value]; ok {
+				validateNode(ps, value, keyField, violations)
+				continue
+			}
+			switch additional := s.AdditionalProperties.(type) {
+			case bool:
+				violate(key, keyField, "unknown key")
+			case *jsonSchema:
+				validateNode(additional, value, keyField, violations)
+			}
+		}
+	}
+}
+
+// nodeType returns the JSON type of the value held by the given YAML node.
+func nodeType(n *yaml.Node) string {
+	switch n.Kind {
+	case yaml.MappingNode:
+		return "object"
+	case yaml.SequenceNode:
+		return "array"
+	}
+	switch n.ShortTag() {
+	case "!!null":
+		return "null"
+	case "!!bool":
+		return "boolean"
+	case "!!int":
+		return "integer"
+	case "!!float":
+		return "number"
+	}
+	return "string"
+}
+
+// orList returns the given values as a list, like "a, b or c".
+func orList(values []string) string {
+	if len(values) < 2 {
+		return strings.Join(values, "")
+	}
+	return strings.Join(values[:len(values)-1], ", ") + " or " + values[len(values)-1]
+}
+
+// specErrors returns the given violations as one error, or nil if there
+// are none.
+func specErrors(violations []*SpecError) error {
+	errs := make([]error, len(violations))
+	for i, v := range violations {
+		errs[i] = v
+	}
+	return errors.Join(errs...)
+}
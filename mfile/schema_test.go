@@ -0,0 +1,134 @@
+// Copyright (c) 2023 Tiago Melo. All rights reserved.
+// Use of this source code is governed by the MIT License that can be found in
+// the LICENSE file.
+
+package mfile
+
+import (
+	"encoding/json"
+	"errors"
+	"os"
+	"path/filepath"
+	"reflect"
+	"regexp"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestSpecSchema(t *testing.T) {
+	out, err := SpecSchema()
+	require.NoError(t, err)
+	var schema struct {
+		Type                 string `json:"type"`
+		AdditionalProperties bool   `json:"additionalProperties"`
+		Properties           map[string]struct {
+			Type  any      `json:"type"`
+			Enum  []string `json:"enum"`
+			Items struct {
+				Pattern string `json:"pattern"`
+			} `json:"items"`
+		} `json:"properties"`
+	}
+	require.NoError(t, json.Unmarshal(out, &schema))
+	require.Equal(t, "object", schema.Type)
+	require.False(t, schema.AdditionalProperties)
+	// Every key of the spec files is described.
+	var keys []string
+	specType := reflect.TypeOf(Spec{})
+	for i := 0; i < specType.NumField(); i++ {
+		keys = append(keys, strings.Split(specType.Field(i).Tag.Get("yaml"), ",")[0])
+	}
+	for _, key := range keys {
+		require.Contains(t, schema.Properties, key)
+	}
+	require.Len(t, schema.Properties, len(keys))
+	require.Equal(t, "boolean", schema.Properties["windows"].Type)
+	require.Equal(t, []any{"string", "integer"}, schema.Properties["parallel"].Type)
+	require.Equal(t, []string{"minimal", "standard", "library", "full"}, schema.Properties["flavor"].Enum)
+	require.Equal(t, Dialects(), schema.Properties["dialect"].Enum)
+	// Presets can be listed one per item, or comma-separated.
+	presets := regexp.MustCompile(schema.Properties["presets"].Items.Pattern)
+	require.True(t, presets.MatchString("docker"))
+	require.True(t, presets.MatchString("go, lint"))
+	require.False(t, presets.MatchString("dockr"))
+	require.False(t, presets.MatchString("go,dockr"))
+}
+
+func TestValidateSpec(t *testing.T) {
+	testCases := []struct {
+		name               string
+		content            string
+		expectedViolations []string
+		expectedError      error
+	}{
+		{
+			name:    "happy path",
+			content: "flavor: full\npresets: [docker, 'go-cli,lint']\nparameters:\n  registry: ghcr.io/acme\n  port: 8080\nvalues:\n  team: {name: platform}\nparallel: 4\nrecipe-prefix: '>'\nmanaged: true\n",
+		},
+		{
+			name:    "happy path, json",
+			content: `{"flavor": "library", "parallel": "auto", "make-flags": ["--no-builtin-rules"]}`,
+		},
+		{
+			name:    "happy path, empty spec",
+			content: "# nothing yet\n",
+		},
+		{
+			name:    "violations",
+			content: "flavour: full\nhelp-style: fancy\npresets:\n  - docker\n  - go, dockr\nwindows: yes\nparameters:\n  registry: [ghcr.io]\nparallel: 0\nrecipe-prefix: '>>'\n",
+			expectedViolations: []string{
+				"line 1, column 1: flavour: unknown key",
+				`line 2, column 13: help-style: unknown help style "fancy", want awk, sed or info`,
+				`line 5, column 5: presets[1]: unknown preset "dockr", want ` + orList(presetNames()),
+				"line 6, column 10: windows: got string, want boolean",
+				"line 8, column 13: parameters.registry: got array, want string, number or boolean",
+				"line 9, column 11: parallel: invalid value 0, want a positive number or auto",
+				`line 10, column 16: recipe-prefix: invalid value ">>", want a single printable character`,
+			},
+		},
+		{
+			name:               "not an object",
+			content:            "- flavor: full\n",
+			expectedViolations: []string{"line 1, column 1: got array, want object"},
+		},
+		{
+			name:          "invalid yaml",
+			content:       "flavor: [full\n",
+			expectedError: errors.New("yaml: line 1: did not find expected ',' or ']'"),
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			violations, err := ValidateSpec([]byte(tc.content))
+			if tc.expectedError != nil {
+				require.EqualError(t, err, tc.expectedError.Error())
+				return
+			}
+			require.NoError(t, err)
+			var messages []string
+			for _, v := range violations {
+				messages = append(messages, v.Error())
+			}
+			require.Equal(t, tc.expectedViolations, messages)
+		})
+	}
+}
+
+// presetNames returns the names of the presets, in order.
+func presetNames() []string {
+	var names []string
+	for _, p := range Presets() {
+		names = append(names, p.Name)
+	}
+	return names
+}
+
+func TestSpecSchemaPublished(t *testing.T) {
+	out, err := SpecSchema()
+	require.NoError(t, err)
+	published, err := os.ReadFile(filepath.Join("..", "makefile.schema.json"))
+	require.NoError(t, err)
+	require.Equal(t, string(out), string(published), "makefile.schema.json is out of date, run make schema")
+}
@@ -0,0 +1,107 @@
+// Copyright (c) 2023 Tiago Melo. All rights reserved.
+// Use of this source code is governed by the MIT License that can be found in
+// the LICENSE file.
+
+package mfile
+
+import (
+	"fmt"
+	"strings"
+)
+
+// The shell and flags of the strict prologue, see WithStrict.
+const (
+	StrictShell      = "/bin/bash"
+	StrictShellFlags = "-eu -o pipefail -c"
+)
+
+// StrictMakeFlags are the make flags of the strict prologue, see
+// WithStrict.
+var StrictMakeFlags = []string{"--warn-undefined-variables", "--no-builtin-rules"}
+
+// WithStrict makes the Makefile start with a prologue giving it a strict
+// behavior: recipes run with bash, failing on errors, unset variables and
+// failed pipes, and make warns about undefined variables and drops its
+// builtin rules:
+//
+//	SHELL := /bin/bash
+//	.SHELLFLAGS := -eu -o pipefail -c
+//	MAKEFLAGS += --warn-undefined-variables --no-builtin-rules
+//
+// WithShell, WithShellFlags and WithMakeFlags override the lines of the
+// prologue. It is for GNU make only.
+func WithStrict(strict bool) GenerateOption {
+	return func(o *generateOptions) {
+		o.strict = strict
+	}
+}
+
+// WithShell makes the recipes of the Makefile run with the given shell,
+// declaring SHELL in its prologue, like /bin/bash. It is for GNU make
+// only.
+func WithShell(shell string) GenerateOption {
+	return func(o *generateOptions) {
+		o.shell = shell
+	}
+}
+
+// WithShellFlags makes the recipes of the Makefile run with the given
+// shell flags, declaring .SHELLFLAGS in its prologue, like
+// "-eu -o pipefail -c". It is for GNU make only.
+func WithShellFlags(flags string) GenerateOption {
+	return func(o *generateOptions) {
+		o.shellFlags = flags
+	}
+}
+
+// WithMakeFlags adds the given flags to MAKEFLAGS in the prologue of the
+// Makefile, like --no-builtin-rules. It is for GNU make only.
+func WithMakeFlags(flags ...string) GenerateOption {
+	return func(o *generateOptions) {
+		o.makeFlags = append(o.makeFlags, flags...)
+	}
+}
+
+// validateShell checks that the prologue options fit the dialect.
+func validateShell(o *generateOptions) error {
+	if !o.strict && o.shell == "" && o.shellFlags == "" && len(o.makeFlags) == 0 {
+		return nil
+	}
+	if o.dialect != DialectGNU {
+		return fmt.Errorf("the %s dialect does not support configuring the shell and make flags", o.dialect)
+	}
+	for _, f := range o.makeFlags {
+		if !strings.HasPrefix(f, "-") {
+			return fmt.Errorf("invalid make flag %q, want a flag like --no-builtin-rules", f)
+		}
+	}
+	return nil
+}
+
+// shellBlock returns the prologue of the Makefile configuring the shell
+// and make flags, or an empty string if there is none.
+func shellBlock(o *generateOptions) string {
+	shell, shellFlags, makeFlags := o.shell, o.shellFlags, o.makeFlags
+	if o.strict {
+		if shell == "" {
+			shell = StrictShell
+		}
+		if shellFlags == "" {
+			shellFlags = StrictShellFlags
+		}
+		if len(makeFlags) == 0 {
+			makeFlags = StrictMakeFlags
+		}
+	}
+	var b strings.Builder
+	if shell != "" {
+		b.WriteString("SHELL := " + shell + "\n")
+	}
+	if shellFlags != "" {
+		b.WriteString(".SHELLFLAGS := " + shellFlags + "\n")
+	}
+	if len(makeFlags) > 0 {
+		b.WriteString("MAKEFLAGS += " + strings.Join(makeFlags, " ") + "\n")
+	}
+	return b.String()
+}
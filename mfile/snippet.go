@@ -0,0 +1,103 @@
+// Copyright (c) 2023 Tiago Melo. All rights reserved.
+// Use of this source code is governed by the MIT License that can be found in
+// the LICENSE file.
+
+package mfile
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// Directories snippets are looked up in.
+const (
+	localSnippetsDir = ".makefile-snippets"   // Relative to the Makefile directory.
+	userSnippetsDir  = ".gomakefile/snippets" // Relative to the user home directory.
+	snippetExt       = ".mk"                  // Extension of snippet files.
+)
+
+// userHomeDir returns the home directory of the user. For ease of unit testing.
+var userHomeDir = os.UserHomeDir
+
+// AddSnippetToMakefile appends a snippet, a named fragment of Makefile
+// declaring reusable targets, to a Makefile.
+// The snippet is read from <name>.mk in the .makefile-snippets directory
+// next to the Makefile or, if not found there, in ~/.gomakefile/snippets.
+// It is executed as a template, with the Module and AppName values
+// derived from go.mod. It fails if any target of the snippet is already
+// declared in the Makefile.
+func AddSnippetToMakefile(path, name string) error {
+	if name == "" || containsSpace(name) || strings.ContainsAny(name, `/\`) {
+		return fmt.Errorf("invalid snippet name %q", name)
+	}
+	makeFilePath := mkFilePath(path)
+	content, err := readMakefile(makeFilePath)
+	if err != nil {
+		return err
+	}
+	dir := filepath.Dir(makeFilePath)
+	snippet, snippetPath, err := readSnippet(dir, name)
+	if err != nil {
+		return err
+	}
+	logger.Debug("parsing template", "template", snippetPath)
+	tmplExecutor, err := templateProcessorProvider.Parse(name, snippet)
+	if err != nil {
+		return &Error{Op: "parsing snippet", Path: snippetPath, Err: err}
+	}
+	module, err := modulePath(fsProvider, dir)
+	if err != nil {
+		return err
+	}
+	var buf bytes.Buffer
+	if err := tmplExecutor.Execute(&buf, map[string]string{"Module": module, "AppName": appName(module)}); err != nil {
+		return fmt.Errorf("executing snippet %s: %w", snippetPath, err)
+	}
+	existing := make(map[string]bool)
+	for _, t := range parseTargets(content) {
+		existing[t.Name] = true
+	}
+	for _, t := range parseTargets(buf.String()) {
+		if existing[t.Name] {
+			return &Error{Op: "adding target " + t.Name, Path: makeFilePath, Err: ErrTargetExists}
+		}
+	}
+	file, err := openMakefile(makeFilePath, path)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+	cw := &countingWriter{w: file}
+	w := &recipePrefixWriter{w: cw, prefix: recipePrefixAt(content)}
+	if _, err := w.Write(append([]byte("\n"), buf.Bytes()...)); err != nil {
+		return &Error{Op: "writing Makefile", Path: makeFilePath, Err: err}
+	}
+	logger.Debug("appended snippet", "path", makeFilePath, "snippet", snippetPath, "bytes", cw.n)
+	return nil
+}
+
+// readSnippet returns the content and the path of the snippet with the
+// given name, looking it up in the local snippets directory of the given
+// Makefile directory, then in the user snippets directory.
+func readSnippet(dir, name string) (string, string, error) {
+	dirs := []string{filepath.Join(dir, localSnippetsDir)}
+	if home, err := userHomeDir(); err == nil {
+		dirs = append(dirs, filepath.Join(home, userSnippetsDir))
+	}
+	for _, d := range dirs {
+		snippetPath := filepath.Join(d, name+snippetExt)
+		logger.Debug("looking up snippet", "path", snippetPath)
+		content, err := fsProvider.ReadFile(snippetPath)
+		if err != nil {
+			if fsProvider.IsNotExist(err) {
+				continue
+			}
+			return "", "", &Error{Op: "reading snippet", Path: snippetPath, Err: err}
+		}
+		return string(content), snippetPath, nil
+	}
+	return "", "", fmt.Errorf("looking up snippet %s in %s: %w", name, strings.Join(dirs, ", "), ErrSnippetNotFound)
+}
@@ -0,0 +1,111 @@
+// Copyright (c) 2023 Tiago Melo. All rights reserved.
+// Use of this source code is governed by the MIT License that can be found in
+// the LICENSE file.
+
+package mfile
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestAddSnippetToMakefile(t *testing.T) {
+	testCases := []struct {
+		name            string
+		snippet         string
+		mockClosure     func(m *mockFileSystem)
+		expectedContent string
+		expectedError   error
+	}{
+		{
+			name:    "happy path, local snippet",
+			snippet: "deploy",
+			mockClosure: func(m *mockFileSystem) {
+				m.files["path/to/.makefile-snippets/deploy.mk"] = []byte(".PHONY: deploy\n## deploy: deploy {{ .AppName }}\ndeploy:\n\t@ ./deploy.sh {{ .Module }}\n")
+				m.files["/home/gopher/.gomakefile/snippets/deploy.mk"] = []byte("deploy:\n")
+			},
+			expectedContent: "\n.PHONY: deploy\n## deploy: deploy widget\ndeploy:\n\t@ ./deploy.sh github.com/acme/widget\n",
+		},
+		{
+			name:    "happy path, user snippet",
+			snippet: "release",
+			mockClosure: func(m *mockFileSystem) {
+				m.files["/home/gopher/.gomakefile/snippets/release.mk"] = []byte("release:\n\t@ goreleaser release\n")
+			},
+			expectedContent: "\nrelease:\n\t@ goreleaser release\n",
+		},
+		{
+			name:    "happy path, recipe prefix of the Makefile",
+			snippet: "release",
+			mockClosure: func(m *mockFileSystem) {
+				m.files["path/to/Makefile"] = []byte(".RECIPEPREFIX = >\nbuild:\n>go build\n")
+				m.files["/home/gopher/.gomakefile/snippets/release.mk"] = []byte("release:\n\t@ goreleaser release\n\t@ echo \"\tdone\"\n")
+			},
+			expectedContent: "\nrelease:\n>@ goreleaser release\n>@ echo \"\tdone\"\n",
+		},
+		{
+			name:          "invalid snippet name",
+			snippet:       "../deploy",
+			mockClosure:   func(m *mockFileSystem) {},
+			expectedError: errors.New(`invalid snippet name "../deploy"`),
+		},
+		{
+			name:          "snippet not found",
+			snippet:       "deploy",
+			mockClosure:   func(m *mockFileSystem) {},
+			expectedError: errors.New("looking up snippet deploy in path/to/.makefile-snippets, /home/gopher/.gomakefile/snippets: snippet not found"),
+		},
+		{
+			name:    "target already exists",
+			snippet: "build",
+			mockClosure: func(m *mockFileSystem) {
+				m.files["path/to/.makefile-snippets/build.mk"] = []byte("build:\n\t@ go build\n")
+			},
+			expectedError: errors.New("adding target build at path/to/Makefile: target already exists"),
+		},
+		{
+			name:    "invalid template",
+			snippet: "deploy",
+			mockClosure: func(m *mockFileSystem) {
+				m.files["path/to/.makefile-snippets/deploy.mk"] = []byte("deploy:\n\t@ {{ end }}\n")
+			},
+			expectedError: errors.New("parsing snippet at path/to/.makefile-snippets/deploy.mk: template: deploy:2: unexpected {{end}}"),
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			file, err := os.OpenFile(filepath.Join(t.TempDir(), "Makefile"), os.O_CREATE|os.O_APPEND|os.O_RDWR, 0644)
+			require.NoError(t, err)
+			m := &mockFileSystem{
+				openFile:         file,
+				isNotExistOutput: true,
+				files: map[string][]byte{
+					"path/to/Makefile": []byte("build:\n"),
+					"path/to/go.mod":   []byte("module github.com/acme/widget\n"),
+				},
+			}
+			tc.mockClosure(m)
+			fsProvider = m
+			templateProcessorProvider = htmlTemplateProcessor{}
+			userHomeDir = func() (string, error) { return "/home/gopher", nil }
+			err = AddSnippetToMakefile("path/to/Makefile", tc.snippet)
+			if err != nil {
+				if tc.expectedError == nil {
+					t.Fatalf("expected no error, got %v", err)
+				}
+				require.Equal(t, tc.expectedError.Error(), err.Error())
+			} else {
+				if tc.expectedError != nil {
+					t.Fatalf("expected error to be %v, got nil", tc.expectedError)
+				}
+				content, err := os.ReadFile(file.Name())
+				require.NoError(t, err)
+				require.Equal(t, tc.expectedContent, string(content))
+			}
+		})
+	}
+}
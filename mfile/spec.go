@@ -0,0 +1,121 @@
+// Copyright (c) 2023 Tiago Melo. All rights reserved.
+// Use of this source code is governed by the MIT License that can be found in
+// the LICENSE file.
+
+package mfile
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"path/filepath"
+
+	"gopkg.in/yaml.v3"
+)
+
+// SpecFileName is the name of the spec file declaring how the Makefile
+// of a project is generated.
+const SpecFileName = "makefile.yaml"
+
+// Spec declares how a Makefile is generated, as the flags of the generate
+// command do, so that it can be regenerated from it.
+type Spec struct {
+	Flavor       string            `yaml:"flavor"`        // See WithFlavor.
+	HelpStyle    string            `yaml:"help-style"`    // See WithHelpStyle.
+	Windows      bool              `yaml:"windows"`       // See WithWindows.
+	Dialect      string            `yaml:"dialect"`       // See WithDialect.
+	RecipePrefix string            `yaml:"recipe-prefix"` // See WithRecipePrefix.
+	Presets      []string          `yaml:"presets"`       // See WithPresets.
+	Parameters   map[string]string `yaml:"parameters"`    // See WithParameter.
+	Auto         bool              `yaml:"auto"`          // See WithAutoDetect.
+	Template     string            `yaml:"template"`      // See WithTemplate.
+	Values       map[string]any    `yaml:"values"`        // See WithValues.
+	Fragments    string            `yaml:"fragments"`     // See WithFragments.
+	EnvFile      string            `yaml:"env-file"`      // See WithEnvFile.
+	Parallel     string            `yaml:"parallel"`      // See WithParallel.
+	NotParallel  bool              `yaml:"not-parallel"`  // See WithNotParallel.
+	Strict       bool              `yaml:"strict"`        // See WithStrict.
+	Shell        string            `yaml:"shell"`         // See WithShell.
+	ShellFlags   string            `yaml:"shell-flags"`   // See WithShellFlags.
+	MakeFlags    []string          `yaml:"make-flags"`    // See WithMakeFlags.
+	Managed      bool              `yaml:"managed"`       // See WithManaged.
+}
+
+// ReadSpec reads the spec held by the YAML file at the given path.
+// Unknown keys are errors, to catch typos. A template path relative to
+// the spec file is resolved from its directory.
+func ReadSpec(path string) (*Spec, error) {
+	content, err := fsProvider.ReadFile(path)
+	if err != nil {
+		return nil, &Error{Op: "reading spec", Path: path, Err: err}
+	}
+	spec, err := parseSpec(content)
+	if err != nil {
+		return nil, &Error{Op: "parsing spec", Path: path, Err: err}
+	}
+	if spec.Template != "" && !filepath.IsAbs(spec.Template) {
+		if local := filepath.Join(filepath.Dir(path), spec.Template); fileExists(fsProvider, local) {
+			spec.Template = local
+		}
+	}
+	return spec, nil
+}
+
+// ParseSpec parses the given spec, held in YAML, or in JSON, which YAML
+// accepts too, like {"flavor": "full", "presets": ["docker"]}. Unknown
+// keys are errors, as with ReadSpec, but a relative template path is
+// left as it is.
+func ParseSpec(content []byte) (*Spec, error) {
+	spec, err := parseSpec(content)
+	if err != nil {
+		return nil, fmt.Errorf("parsing spec: %w", err)
+	}
+	return spec, nil
+}
+
+// parseSpec decodes the given spec, failing on the violations of the
+// schema of the spec files, like unknown keys, see ValidateSpec.
+func parseSpec(content []byte) (*Spec, error) {
+	violations, err := ValidateSpec(content)
+	if err != nil {
+		return nil, err
+	}
+	if len(violations) > 0 {
+		return nil, specErrors(violations)
+	}
+	spec := new(Spec)
+	dec := yaml.NewDecoder(bytes.NewReader(content))
+	dec.KnownFields(true)
+	if err := dec.Decode(spec); err != nil && err != io.EOF {
+		return nil, err
+	}
+	return spec, nil
+}
+
+// Options returns the options generating the Makefile the spec declares.
+func (s *Spec) Options() []GenerateOption {
+	opts := []GenerateOption{
+		WithFlavor(s.Flavor),
+		WithHelpStyle(s.HelpStyle),
+		WithWindows(s.Windows),
+		WithDialect(s.Dialect),
+		WithRecipePrefix(s.RecipePrefix),
+		WithPresets(s.Presets...),
+		WithAutoDetect(s.Auto),
+		WithTemplate(s.Template),
+		WithValues(s.Values),
+		WithFragments(s.Fragments),
+		WithEnvFile(s.EnvFile),
+		WithParallel(s.Parallel),
+		WithNotParallel(s.NotParallel),
+		WithStrict(s.Strict),
+		WithShell(s.Shell),
+		WithShellFlags(s.ShellFlags),
+		WithMakeFlags(s.MakeFlags...),
+		WithManaged(s.Managed),
+	}
+	for name, value := range s.Parameters {
+		opts = append(opts, WithParameter(name, value))
+	}
+	return opts
+}
@@ -0,0 +1,134 @@
+// Copyright (c) 2023 Tiago Melo. All rights reserved.
+// Use of this source code is governed by the MIT License that can be found in
+// the LICENSE file.
+
+package mfile
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestReadSpec(t *testing.T) {
+	testCases := []struct {
+		name          string
+		files         map[string][]byte
+		expectedSpec  *Spec
+		expectedError error
+	}{
+		{
+			name: "happy path",
+			files: map[string][]byte{
+				"project/makefile.yaml": []byte(`flavor: full
+help-style: sed
+presets: [go-cli, docker]
+parameters:
+  registry: ghcr.io/acme
+template: Makefile.tmpl
+values:
+  team: platform
+parallel: 4
+strict: true
+make-flags: [--no-builtin-rules]
+`),
+				"project/Makefile.tmpl": []byte("build:\n"),
+			},
+			expectedSpec: &Spec{
+				Flavor:     "full",
+				HelpStyle:  "sed",
+				Presets:    []string{"go-cli", "docker"},
+				Parameters: map[string]string{"registry": "ghcr.io/acme"},
+				Template:   "project/Makefile.tmpl",
+				Values:     map[string]any{"team": "platform"},
+				Parallel:   "4",
+				Strict:     true,
+				MakeFlags:  []string{"--no-builtin-rules"},
+			},
+		},
+		{
+			name:         "happy path, remote template",
+			files:        map[string][]byte{"project/makefile.yaml": []byte("template: github.com/acme/templates//go\n")},
+			expectedSpec: &Spec{Template: "github.com/acme/templates//go"},
+		},
+		{
+			name:         "happy path, empty spec",
+			files:        map[string][]byte{"project/makefile.yaml": []byte("# nothing yet\n")},
+			expectedSpec: &Spec{},
+		},
+		{
+			name:          "unknown key",
+			files:         map[string][]byte{"project/makefile.yaml": []byte("flavr: full\n")},
+			expectedError: errors.New("parsing spec at project/makefile.yaml: line 1, column 1: flavr: unknown key"),
+		},
+		{
+			name:          "spec not found",
+			files:         map[string][]byte{},
+			expectedError: errors.New("reading spec at project/makefile.yaml: file does not exist"),
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			fsProvider = &mockFileSystem{files: tc.files}
+			spec, err := ReadSpec("project/makefile.yaml")
+			if err != nil {
+				if tc.expectedError == nil {
+					t.Fatalf("expected no error, got %v", err)
+				}
+				require.Equal(t, tc.expectedError.Error(), err.Error())
+			} else {
+				if tc.expectedError != nil {
+					t.Fatalf("expected error to be %v, got nil", tc.expectedError)
+				}
+				require.Equal(t, tc.expectedSpec, spec)
+			}
+		})
+	}
+}
+
+func TestParseSpec(t *testing.T) {
+	testCases := []struct {
+		name          string
+		content       string
+		expectedSpec  *Spec
+		expectedError error
+	}{
+		{
+			name:         "happy path, yaml",
+			content:      "flavor: library\nhelp-style: sed\n",
+			expectedSpec: &Spec{Flavor: "library", HelpStyle: "sed"},
+		},
+		{
+			name:    "happy path, json",
+			content: `{"flavor": "full", "presets": ["docker"], "parameters": {"registry": "ghcr.io/acme"}, "template": "Makefile.tmpl"}`,
+			expectedSpec: &Spec{
+				Flavor:     "full",
+				Presets:    []string{"docker"},
+				Parameters: map[string]string{"registry": "ghcr.io/acme"},
+				Template:   "Makefile.tmpl",
+			},
+		},
+		{
+			name:          "unknown key",
+			content:       `{"flavr": "full"}`,
+			expectedError: errors.New("parsing spec: line 1, column 2: flavr: unknown key"),
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			spec, err := ParseSpec([]byte(tc.content))
+			if err != nil {
+				if tc.expectedError == nil {
+					t.Fatalf("expected no error, got %v", err)
+				}
+				require.Equal(t, tc.expectedError.Error(), err.Error())
+			} else {
+				if tc.expectedError != nil {
+					t.Fatalf("expected error to be %v, got nil", tc.expectedError)
+				}
+				require.Equal(t, tc.expectedSpec, spec)
+			}
+		})
+	}
+}
@@ -0,0 +1,65 @@
+// Copyright (c) 2023 Tiago Melo. All rights reserved.
+// Use of this source code is governed by the MIT License that can be found in
+// the LICENSE file.
+
+package mfile
+
+import (
+	"strings"
+)
+
+// stampExt is the extension of the stamp files, see WithStamp.
+const stampExt = ".stamp"
+
+// WithStamp makes the target an expensive step, like building a docker
+// image, skipped when its inputs haven't changed, with the stamp-file
+// idiom: the content of the target becomes the recipe of its stamp file,
+// see StampFile, which is touched once the recipe succeeds and depends on
+// the given sources, like Dockerfile or $(shell find . -name '*.go'). The
+// target itself depends on its stamp file, so that make only runs the
+// recipe again when a source is newer than the stamp file:
+//
+//	.PHONY: docker-build
+//	## docker-build: explain what docker-build does
+//	docker-build: .docker-build.stamp
+//
+//	.docker-build.stamp: Dockerfile go.sum
+//		docker build -t app .
+//		@touch $@
+//
+// The target must have content, and can't be a pattern rule.
+func WithStamp(sources ...string) TargetOption {
+	return func(o *addTargetOptions) {
+		o.stamped = true
+		o.stampSources = append(o.stampSources, sources...)
+	}
+}
+
+// StampFile returns the name of the stamp file of the given target, see
+// WithStamp, like .docker-build.stamp for docker-build or docker/build.
+func StampFile(targetName string) string {
+	return "." + strings.ReplaceAll(targetName, namespaceSeparator, "-") + stampExt
+}
+
+// stampBlock returns the rule of the given stamp file, depending on the
+// given sources and touching it once the given recipe succeeds.
+func stampBlock(stampFile string, sources []string, recipe string) string {
+	var sb strings.Builder
+	sb.WriteString("\n" + stampFile + ":")
+	for _, s := range sources {
+		sb.WriteString(" " + s)
+	}
+	sb.WriteString("\n")
+	for _, line := range strings.Split(recipe, "\n") {
+		sb.WriteString("\t" + line + "\n")
+	}
+	sb.WriteString("\t@touch $@\n")
+	return sb.String()
+}
+
+// isReference reports whether the given stamp source is a variable or
+// function reference, like $(SOURCES), whose value may hold spaces.
+func isReference(source string) bool {
+	return strings.HasPrefix(source, "$(") && strings.HasSuffix(source, ")") ||
+		strings.HasPrefix(source, "${") && strings.HasSuffix(source, "}")
+}
@@ -0,0 +1,144 @@
+// Copyright (c) 2023 Tiago Melo. All rights reserved.
+// Use of this source code is governed by the MIT License that can be found in
+// the LICENSE file.
+
+package mfile
+
+import (
+	"regexp"
+	"slices"
+	"strings"
+)
+
+// Statistics summarizes the size and complexity of a Makefile.
+type Statistics struct {
+	Targets             int      // Number of targets declared.
+	Variables           int      // Number of variables assigned.
+	LongestRecipe       string   // Target with the most recipe lines, if any has one.
+	LongestRecipeLength int      // Number of lines of the recipe of LongestRecipe.
+	DeepestChain        []string // Longest dependency chain between targets, from the target depending on the next ones.
+	Undocumented        []string // Targets without a help comment, in order.
+	Tools               []string // External commands run by the recipes, sorted.
+}
+
+// Stats parses the Makefile at the given path and returns its statistics:
+// the number of targets and variables, the longest recipe, the deepest
+// dependency chain, the targets without a help comment, and the external
+// commands the recipes run. The commands run through a variable, like
+// $(GO), are reported by the value of the variable when it is a single
+// word; shell builtins and the scripts of the project are not reported.
+func Stats(path string) (*Statistics, error) {
+	content, err := readMakefile(mkFilePath(path))
+	if err != nil {
+		return nil, err
+	}
+	targets, variables := parseTargets(content), parseVariables(content)
+	s := &Statistics{Targets: len(targets), Variables: len(variables)}
+	values := make(map[string]string)
+	for _, v := range variables {
+		values[v.Name] = v.Value
+	}
+	byName := make(map[string]Target)
+	for _, t := range targets {
+		byName[t.Name] = t
+	}
+	chains := make(map[string][]string)
+	for _, t := range targets {
+		if t.Description == "" {
+			s.Undocumented = append(s.Undocumented, t.Name)
+		}
+		if len(t.Recipe) > s.LongestRecipeLength {
+			s.LongestRecipe, s.LongestRecipeLength = t.Name, len(t.Recipe)
+		}
+		if chain := dependencyChain(byName, t.Name, chains, make(map[string]bool)); len(chain) > len(s.DeepestChain) {
+			s.DeepestChain = chain
+		}
+		for _, tool := range recipeTools(t.Recipe, values) {
+			if !slices.Contains(s.Tools, tool) {
+				s.Tools = append(s.Tools, tool)
+			}
+		}
+	}
+	slices.Sort(s.Tools)
+	return s, nil
+}
+
+// dependencyChain returns the longest dependency chain between the given
+// targets starting at the named one, memoized in chains. Circular
+// dependencies are not followed.
+func dependencyChain(targets map[string]Target, name string, chains map[string][]string, visiting map[string]bool) []string {
+	if chain, ok := chains[name]; ok {
+		return chain
+	}
+	visiting[name] = true
+	var longest []string
+	for _, d := range targets[name].Dependencies {
+		if _, ok := targets[d]; !ok || visiting[d] {
+			continue
+		}
+		if chain := dependencyChain(targets, d, chains, visiting); len(chain) > len(longest) {
+			longest = chain
+		}
+	}
+	visiting[name] = false
+	chains[name] = append([]string{name}, longest...)
+	return chains[name]
+}
+
+// shellCommandSeparator matches the operators separating the commands of
+// a shell command line.
+var shellCommandSeparator = regexp.MustCompile(`&&|\|\||[;|]`)
+
+// shellBuiltins are the shell builtins and keywords, which are not
+// external tools.
+var shellBuiltins = []string{
+	".", ":", "[", "[[", "case", "cd", "command", "do", "done", "echo", "elif", "else", "esac", "eval",
+	"exec", "exit", "export", "false", "fi", "for", "if", "local", "printf", "read", "return", "set",
+	"shift", "source", "test", "then", "trap", "true", "type", "unset", "wait", "while",
+}
+
+// makeVariableReference matches a reference to a make variable, like
+// $(GO) or ${GO}.
+var makeVariableReference = regexp.MustCompile(`^\$[({]([A-Za-z_][A-Za-z0-9_]*)[)}]$`)
+
+// recipeTools returns the external commands run by the given recipe,
+// resolving the variables referenced as commands with the given values.
+func recipeTools(recipe []string, values map[string]string) []string {
+	var tools []string
+	script := strings.Join(recipe, "\n")
+	script = strings.ReplaceAll(script, "\\\n", " ")
+	for _, line := range strings.Split(script, "\n") {
+		line = strings.TrimLeft(line, " \t@-+")
+		for _, command := range shellCommandSeparator.Split(line, -1) {
+			fields := strings.Fields(strings.TrimLeft(strings.TrimSpace(command), "({"))
+			// Skip the environment assignments, whose values may span
+			// several fields, like VERSION=$$(git describe).
+			for len(fields) > 0 && (strings.Contains(fields[0], "=") && !strings.HasPrefix(fields[0], "$") || fields[0] == "!") {
+				open := strings.Count(fields[0], "(") - strings.Count(fields[0], ")")
+				fields = fields[1:]
+				for ; open > 0 && len(fields) > 0; fields = fields[1:] {
+					open += strings.Count(fields[0], "(") - strings.Count(fields[0], ")")
+				}
+			}
+			if len(fields) == 0 {
+				continue
+			}
+			tool := strings.Trim(fields[0], `"'`)
+			if m := makeVariableReference.FindStringSubmatch(tool); m != nil {
+				switch value := strings.TrimSpace(values[m[1]]); {
+				case m[1] == "MAKE":
+					tool = "make"
+				case value != "" && !containsSpace(value) && !strings.Contains(value, "$"):
+					tool = value
+				}
+			}
+			if tool == "" || strings.Contains(tool, "$") || strings.HasPrefix(tool, ".") || strings.HasPrefix(tool, "-") || slices.Contains(shellBuiltins, tool) {
+				continue
+			}
+			if !slices.Contains(tools, tool) {
+				tools = append(tools, tool)
+			}
+		}
+	}
+	return tools
+}
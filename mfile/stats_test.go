@@ -0,0 +1,87 @@
+// Copyright (c) 2023 Tiago Melo. All rights reserved.
+// Use of this source code is governed by the MIT License that can be found in
+// the LICENSE file.
+
+package mfile
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestStats(t *testing.T) {
+	testCases := []struct {
+		name          string
+		content       string
+		expectedStats *Statistics
+		expectedError error
+	}{
+		{
+			name: "happy path",
+			content: `GO ?= go
+LINTER = golangci-lint run
+export CGO_ENABLED = 0
+
+## build: build the binary
+build: generate
+	@ $(GO) build -o bin/app ./cmd/app
+
+generate: tools
+	$(GO) generate ./... && \
+		buf generate
+	cd proto; protoc --version | grep -q 3 || echo "protoc 3 required"
+
+tools: build
+
+## release: release the binary
+release: build
+	-docker build -t app . 2>&1
+	VERSION=$$(git describe) goreleaser release
+	./scripts/notify.sh
+	$(LINTER) ./...
+	$(MAKE) clean
+
+clean:
+	rm -rf bin
+`,
+			expectedStats: &Statistics{
+				Targets:             5,
+				Variables:           3,
+				LongestRecipe:       "release",
+				LongestRecipeLength: 5,
+				DeepestChain:        []string{"release", "build", "generate", "tools"},
+				Undocumented:        []string{"generate", "tools", "clean"},
+				Tools:               []string{"buf", "docker", "go", "goreleaser", "grep", "make", "protoc", "rm"},
+			},
+		},
+		{
+			name:          "happy path, empty Makefile",
+			content:       "",
+			expectedStats: &Statistics{},
+		},
+		{
+			name:          "invalid Makefile",
+			content:       "\x00",
+			expectedError: errors.New("reading Makefile at Makefile: not a Makefile"),
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			fsProvider = &mockFileSystem{file: []byte(tc.content)}
+			stats, err := Stats("Makefile")
+			if err != nil {
+				if tc.expectedError == nil {
+					t.Fatalf("expected no error, got %v", err)
+				}
+				require.Equal(t, tc.expectedError.Error(), err.Error())
+			} else {
+				if tc.expectedError != nil {
+					t.Fatalf("expected error to be %v, got nil", tc.expectedError)
+				}
+				require.Equal(t, tc.expectedStats, stats)
+			}
+		})
+	}
+}
@@ -0,0 +1,237 @@
+// Copyright (c) 2023 Tiago Melo. All rights reserved.
+// Use of this source code is governed by the MIT License that can be found in
+// the LICENSE file.
+
+package mfile
+
+import (
+	"path/filepath"
+	"slices"
+	"strings"
+)
+
+// removablePresets are the presets whose targets Sync removes when what
+// they are detected from is gone from the project, like the docker one
+// when the Dockerfile is removed.
+var removablePresets = []string{
+	PresetDocker, PresetIntegration, PresetProto, PresetMigrations,
+	PresetTerraform, PresetNode, PresetRust, PresetTools,
+}
+
+// SyncResult is the summary of the changes made by Sync.
+type SyncResult struct {
+	Added   []string // Targets added, generated by the presets detected from the project.
+	Removed []string // Targets removed, as what they were generated for is gone from the project.
+	Updated []string // Targets whose dependencies were updated.
+
+	// Modified holds the targets left as they are although they differ
+	// from what the presets generate, as they were presumably edited by
+	// hand.
+	Modified []string
+}
+
+// Changed reports whether Sync changed the Makefile.
+func (r *SyncResult) Changed() bool {
+	return len(r.Added) > 0 || len(r.Removed) > 0 || len(r.Updated) > 0
+}
+
+// Sync reconciles the Makefile at the given path with the project it
+// sits in, so that it doesn't rot as the project evolves. It runs
+// Detect again, then:
+//   - adds the targets of the detected presets the Makefile is missing,
+//     along with the variables they use, like build-<binary> for a new
+//     binary in the cmd directory;
+//   - removes the targets generated for what is gone from the project,
+//     like the docker targets once the Dockerfile is removed, and drops
+//     them from the dependencies of the other targets;
+//   - adds the dependencies the presets now give to the targets, like
+//     build depending on the new build-<binary> target.
+//
+// Only the targets whose recipe is still the one the presets generate
+// are updated or removed; the others are reported as Modified and left
+// as they are. With dryRun, the Makefile is not written.
+func Sync(path string, dryRun bool) (*SyncResult, error) {
+	makeFilePath := mkFilePath(path)
+	m, err := Parse(makeFilePath)
+	if err != nil {
+		return nil, err
+	}
+	dir := filepath.Dir(makeFilePath)
+	detections, err := Detect(dir)
+	if err != nil {
+		return nil, err
+	}
+	var (
+		names  []string
+		params = make(map[string]string)
+	)
+	for _, d := range detections {
+		names = append(names, d.Preset)
+		for k, v := range d.Parameters {
+			params[k] = v
+		}
+	}
+	desired := new(resolution)
+	if len(names) > 0 {
+		if desired, err = resolve(fsProvider, dir, nil, names, params); err != nil {
+			return nil, err
+		}
+	}
+	_, desiredTargets := normalize(nil, desired.targets)
+	wanted := make(map[string]bool)
+	for _, t := range desiredTargets {
+		wanted[t.Name] = true
+	}
+	existing := make(map[string]Target)
+	for _, t := range m.Targets() {
+		existing[t.Name] = t
+	}
+	r := new(SyncResult)
+	for _, t := range staleTargets(dir, names, wanted, existing) {
+		if !sameRecipe(existing[t.Name], t) {
+			r.Modified = append(r.Modified, t.Name)
+			continue
+		}
+		m.removeTarget(t.Name)
+		r.Removed = append(r.Removed, t.Name)
+	}
+	for _, t := range m.Targets() {
+		var updated bool
+		for _, removed := range r.Removed {
+			if slices.Contains(t.Dependencies, removed) {
+				if err := m.RemoveDependency(t.Name, removed); err != nil {
+					return nil, err
+				}
+				updated = true
+			}
+		}
+		if updated {
+			r.Updated = append(r.Updated, t.Name)
+		}
+	}
+	var added []Target
+	for _, t := range desiredTargets {
+		e, ok := existing[t.Name]
+		switch {
+		case !ok:
+			added = append(added, t)
+			r.Added = append(r.Added, t.Name)
+		case t.Name == helpTarget.Name:
+			// The recipe of help depends on its style, and it has
+			// no dependencies.
+		case !sameRecipe(e, t):
+			r.Modified = append(r.Modified, t.Name)
+		default:
+			var updated bool
+			for _, d := range t.Dependencies {
+				if !slices.Contains(e.Dependencies, d) {
+					if err := m.AddDependency(t.Name, d); err != nil {
+						return nil, err
+					}
+					updated = true
+				}
+			}
+			if updated && !slices.Contains(r.Updated, t.Name) {
+				r.Updated = append(r.Updated, t.Name)
+			}
+		}
+	}
+	if len(added) > 0 {
+		m = appendTargets(m, missingVariables(m, desired.variables), added)
+	}
+	if dryRun || !r.Changed() {
+		return r, nil
+	}
+	if err := m.Write(makeFilePath); err != nil {
+		return nil, err
+	}
+	return r, nil
+}
+
+// staleTargets returns the targets of the Makefile generated for what is
+// gone from the project at the given directory: the targets declared by
+// the removable presets not detected anymore, and the build-<binary>
+// targets of the binaries removed from the cmd directory. The given
+// detected presets and wanted targets are the ones Sync keeps.
+func staleTargets(dir string, detected []string, wanted map[string]bool, existing map[string]Target) []Target {
+	var stale []Target
+	seen := make(map[string]bool)
+	add := func(t Target) {
+		if _, ok := existing[t.Name]; ok && !wanted[t.Name] && !seen[t.Name] {
+			seen[t.Name] = true
+			stale = append(stale, normalizeTarget(t))
+		}
+	}
+	for _, name := range removablePresets {
+		if slices.Contains(detected, name) {
+			continue
+		}
+		r, err := resolve(fsProvider, dir, nil, []string{name}, nil)
+		if err != nil {
+			// The preset can't be generated for the project anymore,
+			// like tools without tools.go, so its targets are unknown.
+			continue
+		}
+		for i, t := range r.targets {
+			// Targets of included presets, like guard-%, may be used
+			// by other targets.
+			if r.targetOwners[i] == name {
+				add(t)
+			}
+		}
+	}
+	var binaries []string
+	for name := range existing {
+		if binary, ok := strings.CutPrefix(name, "build-"); ok {
+			binaries = append(binaries, binary)
+		}
+	}
+	slices.Sort(binaries)
+	for _, b := range binaries {
+		add(binaryTarget(b))
+	}
+	return stale
+}
+
+// sameRecipe reports whether the given Makefile target has the recipe of
+// the given generated one.
+func sameRecipe(t, generated Target) bool {
+	return slices.Equal(trimRecipe(t.Recipe), generated.Recipe)
+}
+
+// missingVariables returns the given variables not assigned in the
+// given Makefile.
+func missingVariables(m *Makefile, variables []Variable) []Variable {
+	assigned := make(map[string]bool)
+	for _, v := range m.Variables() {
+		assigned[v.Name] = true
+	}
+	var missing []Variable
+	for _, v := range variables {
+		if !assigned[v.Name] {
+			missing = append(missing, v)
+		}
+	}
+	return missing
+}
+
+// appendTargets returns the given Makefile with the given variables and
+// targets appended, using its recipe prefix.
+func appendTargets(m *Makefile, variables []Variable, targets []Target) *Makefile {
+	content := m.String()
+	s := syntax{dialect: DialectGNU}
+	if prefix := recipePrefixAt(content); prefix != "\t" {
+		s.recipePrefix = prefix
+	}
+	rendered := renderSyntax(s, variables, targets)
+	if s.recipePrefix != "" {
+		rendered = strings.TrimPrefix(rendered, recipePrefixDeclaration(s.recipePrefix)+"\n")
+	}
+	if content != "" && !strings.HasSuffix(content, "\n") {
+		content += "\n"
+	}
+	if content != "" {
+		content += "\n"
+	}
+	return ParseString(content + rendered)
+}
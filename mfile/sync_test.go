@@ -0,0 +1,138 @@
+// Copyright (c) 2023 Tiago Melo. All rights reserved.
+// Use of this source code is governed by the MIT License that can be found in
+// the LICENSE file.
+
+package mfile
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestSync(t *testing.T) {
+	testCases := []struct {
+		name             string
+		presets          []string
+		files            []string
+		change           func(dir string)
+		dryRun           bool
+		expectedResult   *SyncResult
+		expectedTargets  []string
+		expectedBuildDep []string
+	}{
+		{
+			name:            "nothing to sync",
+			presets:         []string{PresetGoCLI, PresetDocker},
+			files:           []string{"go.mod", "Dockerfile", "cmd/api/main.go"},
+			change:          func(dir string) {},
+			expectedResult:  &SyncResult{},
+			expectedTargets: []string{"help", "test", "coverage", "build", "run", "vet", "fmt", "tidy", "build-api", "docker-build", "docker-push", "docker-run"},
+		},
+		{
+			name:    "new binary and proto files",
+			presets: []string{PresetGoCLI},
+			files:   []string{"go.mod", "cmd/api/main.go"},
+			change: func(dir string) {
+				writeProjectFile(t, dir, "cmd/worker/main.go")
+				writeProjectFile(t, dir, "api/v1/api.proto")
+			},
+			expectedResult: &SyncResult{
+				Added:   []string{"build-worker", "install-buf", "proto", "proto-lint"},
+				Updated: []string{"build"},
+			},
+			expectedTargets:  []string{"help", "test", "coverage", "build", "run", "vet", "fmt", "tidy", "build-api", "build-worker", "install-buf", "proto", "proto-lint"},
+			expectedBuildDep: []string{"build-api", "build-worker"},
+		},
+		{
+			name:    "removed binary and docker setup",
+			presets: []string{PresetGoCLI, PresetDocker},
+			files:   []string{"go.mod", "Dockerfile", "cmd/api/main.go", "cmd/worker/main.go"},
+			change: func(dir string) {
+				require.NoError(t, os.Remove(filepath.Join(dir, "Dockerfile")))
+				require.NoError(t, os.RemoveAll(filepath.Join(dir, "cmd", "worker")))
+			},
+			expectedResult: &SyncResult{
+				Removed: []string{"docker-build", "docker-push", "docker-run", "build-worker"},
+				Updated: []string{"build"},
+			},
+			expectedTargets:  []string{"help", "test", "coverage", "build", "run", "vet", "fmt", "tidy", "build-api"},
+			expectedBuildDep: []string{"build-api"},
+		},
+		{
+			name:    "edited targets kept",
+			presets: []string{PresetGoCLI, PresetDocker},
+			files:   []string{"go.mod", "Dockerfile", "cmd/api/main.go"},
+			change: func(dir string) {
+				require.NoError(t, os.Remove(filepath.Join(dir, "Dockerfile")))
+				m, err := Parse(dir)
+				require.NoError(t, err)
+				require.NoError(t, m.AppendRecipeLine("docker-build", "@ docker image prune -f"))
+				require.NoError(t, m.AppendRecipeLine("test", "@ go test -race ./..."))
+				require.NoError(t, m.Write(dir))
+			},
+			expectedResult: &SyncResult{
+				Removed:  []string{"docker-push", "docker-run"},
+				Modified: []string{"docker-build", "test"},
+			},
+			expectedTargets: []string{"help", "test", "coverage", "build", "run", "vet", "fmt", "tidy", "build-api", "docker-build"},
+		},
+		{
+			name:    "dry run",
+			presets: []string{PresetGoCLI},
+			files:   []string{"go.mod", "cmd/api/main.go"},
+			change: func(dir string) {
+				writeProjectFile(t, dir, "cmd/worker/main.go")
+			},
+			dryRun: true,
+			expectedResult: &SyncResult{
+				Added:   []string{"build-worker"},
+				Updated: []string{"build"},
+			},
+			expectedTargets:  []string{"help", "test", "coverage", "build", "run", "vet", "fmt", "tidy", "build-api"},
+			expectedBuildDep: []string{"build-api"},
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			fsProvider = osFileSystem{}
+			dir := t.TempDir()
+			for _, f := range tc.files {
+				writeProjectFile(t, dir, f)
+			}
+			require.NoError(t, Generate(dir, WithPresets(tc.presets...)))
+			tc.change(dir)
+			r, err := Sync(dir, tc.dryRun)
+			require.NoError(t, err)
+			require.Equal(t, tc.expectedResult, r)
+			targets, err := ListTargets(dir)
+			require.NoError(t, err)
+			var names []string
+			for _, target := range targets {
+				names = append(names, target.Name)
+				if target.Name == "build" && tc.expectedBuildDep != nil {
+					require.Equal(t, tc.expectedBuildDep, target.Dependencies)
+				}
+			}
+			require.Equal(t, tc.expectedTargets, names)
+			again, err := Sync(dir, false)
+			require.NoError(t, err)
+			if !tc.dryRun {
+				require.False(t, again.Changed())
+			}
+		})
+	}
+}
+
+// writeProjectFile writes the given project file, relative to dir.
+func writeProjectFile(t *testing.T, dir, name string) {
+	path := filepath.Join(dir, name)
+	require.NoError(t, os.MkdirAll(filepath.Dir(path), 0755))
+	content := ""
+	if name == "go.mod" {
+		content = "module example.com/app\n\ngo 1.21\n"
+	}
+	require.NoError(t, os.WriteFile(path, []byte(content), 0644))
+}
@@ -0,0 +1,79 @@
+// Copyright (c) 2023 Tiago Melo. All rights reserved.
+// Use of this source code is governed by the MIT License that can be found in
+// the LICENSE file.
+
+package mfile
+
+import (
+	"fmt"
+	"strings"
+	"text/template"
+	"text/template/parse"
+)
+
+// targetPlaceholders are the placeholders a custom target template must
+// use, so that no part of the target is silently dropped.
+var targetPlaceholders = []string{"TargetName", "TargetDependencies", "TargetContent"}
+
+// ValidateTargetTemplate checks that the given target template parses,
+// uses the TargetName, TargetDependencies and TargetContent placeholders,
+// and renders a sound Makefile block with sample values.
+func ValidateTargetTemplate(text string) error {
+	tmpl, err := template.New("target").Parse(text)
+	if err != nil {
+		return fmt.Errorf("parsing target template: %w", err)
+	}
+	used := make(map[string]bool)
+	if tmpl.Tree != nil {
+		templateFields(tmpl.Tree.Root, used)
+	}
+	var missing []string
+	for _, p := range targetPlaceholders {
+		if !used[p] {
+			missing = append(missing, "{{ ."+p+" }}")
+		}
+	}
+	if len(missing) > 0 {
+		return fmt.Errorf("invalid target template: missing %s", strings.Join(missing, ", "))
+	}
+	return lintTargetTemplate(tmpl)
+}
+
+// templateFields records the fields used by the given template node and
+// its children in used.
+func templateFields(node parse.Node, used map[string]bool) {
+	switch n := node.(type) {
+	case *parse.ListNode:
+		if n == nil {
+			return
+		}
+		for _, c := range n.Nodes {
+			templateFields(c, used)
+		}
+	case *parse.ActionNode:
+		templateFields(n.Pipe, used)
+	case *parse.PipeNode:
+		if n == nil {
+			return
+		}
+		for _, cmd := range n.Cmds {
+			for _, arg := range cmd.Args {
+				templateFields(arg, used)
+			}
+		}
+	case *parse.FieldNode:
+		if len(n.Ident) > 0 {
+			used[n.Ident[0]] = true
+		}
+	case *parse.IfNode:
+		templateFields(&n.BranchNode, used)
+	case *parse.RangeNode:
+		templateFields(&n.BranchNode, used)
+	case *parse.WithNode:
+		templateFields(&n.BranchNode, used)
+	case *parse.BranchNode:
+		templateFields(n.Pipe, used)
+		templateFields(n.List, used)
+		templateFields(n.ElseList, used)
+	}
+}
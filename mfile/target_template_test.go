@@ -0,0 +1,117 @@
+// Copyright (c) 2023 Tiago Melo. All rights reserved.
+// Use of this source code is governed by the MIT License that can be found in
+// the LICENSE file.
+
+package mfile
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+// customTemplate is a target template without .PHONY, with the
+// description after the rule.
+const customTemplate = `
+{{ .TargetName }}:{{ with .TargetDependencies }} {{ . }}{{ end }} # {{ .AppName }}: explain what {{ .TargetName }} does
+{{- if .TargetContent }}
+	{{ .TargetContent }}
+{{- end }}
+`
+
+func TestValidateTargetTemplate(t *testing.T) {
+	testCases := []struct {
+		name          string
+		template      string
+		expectedError error
+	}{
+		{
+			name:     "happy path",
+			template: customTemplate,
+		},
+		{
+			name:          "missing placeholders",
+			template:      "{{ .TargetName }}:\n",
+			expectedError: errors.New("invalid target template: missing {{ .TargetDependencies }}, {{ .TargetContent }}"),
+		},
+		{
+			name:          "recipe indented with spaces",
+			template:      "{{ .TargetName }}: {{ .TargetDependencies }}\n    {{ .TargetContent }}\n",
+			expectedError: errors.New("invalid target template: line 2: recipe line indented with spaces instead of a tab"),
+		},
+		{
+			name:          "invalid template",
+			template:      "{{ .TargetName }:\n",
+			expectedError: errors.New(`parsing target template: template: target:1: unexpected "}" in operand`),
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			err := ValidateTargetTemplate(tc.template)
+			if err != nil {
+				if tc.expectedError == nil {
+					t.Fatalf("expected no error, got %v", err)
+				}
+				require.Equal(t, tc.expectedError.Error(), err.Error())
+			} else {
+				if tc.expectedError != nil {
+					t.Fatalf("expected error to be %v, got nil", tc.expectedError)
+				}
+			}
+		})
+	}
+}
+
+func TestWithTargetTemplate(t *testing.T) {
+	testCases := []struct {
+		name            string
+		add             func(path string) error
+		expectedContent string
+		expectedError   error
+	}{
+		{
+			name:            "target",
+			add:             func(path string) error { return AddTarget(path, "lint", WithTargetTemplate(customTemplate)) },
+			expectedContent: "\nlint: # widget: explain what lint does\n",
+		},
+		{
+			name: "target with content and dependencies",
+			add: func(path string) error {
+				return AddTarget(path, "lint", WithContent("@ golangci-lint run"), WithDependencies("fmt", "vet"), WithTargetTemplate(customTemplate))
+			},
+			expectedContent: "\nlint: fmt vet # widget: explain what lint does\n\t@ golangci-lint run\n",
+		},
+		{
+			name:          "invalid template",
+			add:           func(path string) error { return AddTarget(path, "lint", WithTargetTemplate("{{ .TargetName }}:\n")) },
+			expectedError: errors.New("invalid target template: missing {{ .TargetDependencies }}, {{ .TargetContent }}"),
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			file, err := os.OpenFile(filepath.Join(t.TempDir(), "Makefile"), os.O_CREATE|os.O_APPEND|os.O_RDWR, 0644)
+			require.NoError(t, err)
+			fsProvider = &mockFileSystem{
+				openFile:         file,
+				isNotExistOutput: true,
+				files: map[string][]byte{
+					"path/to/Makefile": []byte(""),
+					"path/to/go.mod":   []byte("module github.com/acme/widget\n"),
+				},
+			}
+			templateProcessorProvider = htmlTemplateProcessor{}
+			err = tc.add("path/to/Makefile")
+			if tc.expectedError != nil {
+				require.EqualError(t, err, tc.expectedError.Error())
+				return
+			}
+			require.NoError(t, err)
+			content, err := os.ReadFile(file.Name())
+			require.NoError(t, err)
+			require.Equal(t, tc.expectedContent, string(content))
+		})
+	}
+}
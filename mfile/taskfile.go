@@ -0,0 +1,401 @@
+// Copyright (c) 2023 Tiago Melo. All rights reserved.
+// Use of this source code is governed by the MIT License that can be found in
+// the LICENSE file.
+
+package mfile
+
+import (
+	"errors"
+	"fmt"
+	"regexp"
+	"slices"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// taskfile is the part of a Taskfile.yml converted to a Makefile.
+type taskfile struct {
+	Vars   yaml.Node `yaml:"vars"`
+	Silent bool      `yaml:"silent"`
+	Tasks  yaml.Node `yaml:"tasks"`
+}
+
+// task is a task of a Taskfile.yml. It may also be declared as a single
+// command or as a list of commands.
+type task struct {
+	Desc     string     `yaml:"desc"`
+	Deps     []taskCall `yaml:"deps"`
+	Cmds     []taskCmd  `yaml:"cmds"`
+	Cmd      *taskCmd   `yaml:"cmd"`
+	Vars     yaml.Node  `yaml:"vars"`
+	Dir      string     `yaml:"dir"`
+	Silent   bool       `yaml:"silent"`
+	Internal bool       `yaml:"internal"`
+}
+
+// taskKeys are the keys of a task converted to a Makefile; the other
+// ones are reported as not converted.
+var taskKeys = []string{"desc", "summary", "deps", "cmds", "cmd", "vars", "dir", "silent", "internal"}
+
+func (t *task) UnmarshalYAML(node *yaml.Node) error {
+	switch node.Kind {
+	case yaml.ScalarNode:
+		t.Cmds = []taskCmd{{Cmd: node.Value}}
+		return nil
+	case yaml.SequenceNode:
+		return node.Decode(&t.Cmds)
+	}
+	type plain task
+	return node.Decode((*plain)(t))
+}
+
+// taskCall is a call to another task, as a dependency or a command.
+type taskCall struct {
+	Task string    `yaml:"task"`
+	Vars yaml.Node `yaml:"vars"`
+}
+
+func (c *taskCall) UnmarshalYAML(node *yaml.Node) error {
+	if node.Kind == yaml.ScalarNode {
+		c.Task = node.Value
+		return nil
+	}
+	type plain taskCall
+	return node.Decode((*plain)(c))
+}
+
+// taskCmd is a command of a task: a shell command or a call to another task.
+type taskCmd struct {
+	Cmd    string    `yaml:"cmd"`
+	Task   string    `yaml:"task"`
+	Vars   yaml.Node `yaml:"vars"`
+	Silent bool      `yaml:"silent"`
+	Defer  yaml.Node `yaml:"defer"`
+}
+
+func (c *taskCmd) UnmarshalYAML(node *yaml.Node) error {
+	if node.Kind == yaml.ScalarNode {
+		c.Cmd = node.Value
+		return nil
+	}
+	type plain taskCmd
+	return node.Decode((*plain)(c))
+}
+
+// taskTemplateVar matches the {{.NAME}} references to variables in a
+// Taskfile.yml.
+var taskTemplateVar = regexp.MustCompile(`\{\{\s*\.([A-Za-z_][A-Za-z0-9_]*)\s*\}\}`)
+
+// importTaskfile converts the tasks of a Taskfile.yml into targets, their
+// deps into dependencies, their cmds into recipes and their desc into
+// help descriptions. The global vars become variables, and the vars of a
+// task become target-specific variables; {{.NAME}} references become
+// $(NAME) ones. Unsupported keys, like sources or preconditions, are
+// logged as warnings.
+func importTaskfile(content []byte, _ *importOptions) ([]Variable, []Target, error) {
+	var tf taskfile
+	if err := yaml.Unmarshal(content, &tf); err != nil {
+		return nil, nil, fmt.Errorf("parsing Taskfile: %w", err)
+	}
+	variables, err := taskVariables(&tf.Vars, "?=")
+	if err != nil {
+		return nil, nil, err
+	}
+	if tf.Tasks.Kind != yaml.MappingNode {
+		return nil, nil, errors.New("no task found")
+	}
+	var targets []Target
+	for i := 0; i+1 < len(tf.Tasks.Content); i += 2 {
+		name, node := tf.Tasks.Content[i].Value, tf.Tasks.Content[i+1]
+		var tk task
+		if err := node.Decode(&tk); err != nil {
+			return nil, nil, fmt.Errorf("parsing task %s: %w", name, err)
+		}
+		if node.Kind == yaml.MappingNode {
+			for j := 0; j < len(node.Content); j += 2 {
+				if key := node.Content[j].Value; !slices.Contains(taskKeys, key) {
+					logger.Warn("task key not converted", "task", name, "key", key)
+				}
+			}
+		}
+		t := Target{Name: importedName(name), Phony: true}
+		if !tk.Internal {
+			t.Description = tk.Desc
+		}
+		for _, dep := range tk.Deps {
+			t.Dependencies = append(t.Dependencies, importedName(dep.Task))
+		}
+		if t.Variables, err = taskVariables(&tk.Vars, "="); err != nil {
+			return nil, nil, fmt.Errorf("parsing task %s: %w", name, err)
+		}
+		cmds := tk.Cmds
+		if tk.Cmd != nil {
+			cmds = append([]taskCmd{*tk.Cmd}, cmds...)
+		}
+		for _, cmd := range cmds {
+			line, err := taskRecipeLine(cmd)
+			if err != nil {
+				return nil, nil, fmt.Errorf("parsing task %s: %w", name, err)
+			}
+			if line == "" {
+				logger.Warn("task command not converted", "task", name)
+				continue
+			}
+			if tk.Dir != "" && cmd.Task == "" {
+				line = "cd " + tk.Dir + " && " + line
+			}
+			if tf.Silent || tk.Silent || cmd.Silent {
+				line = "@ " + line
+			}
+			t.Recipe = append(t.Recipe, line)
+		}
+		targets = append(targets, t)
+	}
+	return variables, targets, nil
+}
+
+// taskRecipeLine returns the recipe line running the given command, or
+// an empty one if it can't be converted.
+func taskRecipeLine(cmd taskCmd) (string, error) {
+	if cmd.Task != "" {
+		line := "$(MAKE) " + importedName(cmd.Task)
+		args, err := taskVariables(&cmd.Vars, "=")
+		if err != nil {
+			return "", err
+		}
+		for _, v := range args {
+			line += " " + v.Name + "=" + shellQuote(v.Value)
+		}
+		return line, nil
+	}
+	if cmd.Defer.Kind != 0 || strings.TrimSpace(cmd.Cmd) == "" {
+		return "", nil
+	}
+	return taskCommand(joinShellLines(cmd.Cmd)), nil
+}
+
+// joinShellLines joins the lines of the given multi-line shell script
+// into a single line, as each recipe line runs in its own shell.
+func joinShellLines(script string) string {
+	var sb strings.Builder
+	for _, line := range strings.Split(strings.TrimSpace(script), "\n") {
+		line = strings.TrimSpace(line)
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		if sb.Len() > 0 {
+			sb.WriteString(" ")
+		}
+		if rest, ok := strings.CutSuffix(line, "\\"); ok {
+			sb.WriteString(strings.TrimSpace(rest))
+			continue
+		}
+		sb.WriteString(line)
+		if !continuesCommand(line) {
+			sb.WriteString(";")
+		}
+	}
+	return strings.TrimSuffix(sb.String(), ";")
+}
+
+// continuesCommand reports whether the given shell line is continued by
+// the next one without a separator, like a line ending with then or &&.
+func continuesCommand(line string) bool {
+	for _, suffix := range []string{"&&", "||", "|", "{", "(", ";"} {
+		if strings.HasSuffix(line, suffix) {
+			return true
+		}
+	}
+	fields := strings.Fields(line)
+	switch fields[len(fields)-1] {
+	case "then", "do", "else", "in":
+		return true
+	}
+	return false
+}
+
+// taskVariables returns the variables declared by the given vars mapping,
+// assigned with the given operator. Variables set by a shell command, with
+// sh, become $(shell ...) calls.
+func taskVariables(vars *yaml.Node, op string) ([]Variable, error) {
+	if vars.Kind == 0 {
+		return nil, nil
+	}
+	if vars.Kind != yaml.MappingNode {
+		return nil, fmt.Errorf("line %d: vars must be a mapping", vars.Line)
+	}
+	var variables []Variable
+	for i := 0; i+1 < len(vars.Content); i += 2 {
+		name, node := vars.Content[i].Value, vars.Content[i+1]
+		switch node.Kind {
+		case yaml.ScalarNode:
+			variables = append(variables, Variable{Name: name, Operator: op, Value: taskCommand(node.Value)})
+		case yaml.MappingNode:
+			var dynamic struct {
+				Sh string `yaml:"sh"`
+			}
+			if err := node.Decode(&dynamic); err != nil || dynamic.Sh == "" {
+				logger.Warn("variable not converted", "variable", name, "line", node.Line)
+				continue
+			}
+			variables = append(variables, Variable{Name: name, Operator: ":=", Value: "$(shell " + taskCommand(dynamic.Sh) + ")"})
+		default:
+			logger.Warn("variable not converted", "variable", name, "line", node.Line)
+		}
+	}
+	return variables, nil
+}
+
+// taskCommand returns the given Taskfile command, or value, with its
+// dollar signs escaped and its {{.NAME}} references turned into $(NAME).
+func taskCommand(command string) string {
+	command = taskTemplateVar.ReplaceAllString(escapeDollars(command), "$$($1)")
+	if strings.Contains(command, "{{") {
+		logger.Warn("template expression not converted", "command", command)
+	}
+	return command
+}
+
+// shellQuote returns the given value single-quoted if it holds characters
+// the shell would interpret.
+func shellQuote(value string) string {
+	if value != "" && !strings.ContainsAny(value, " \t'\"\\|&;<>()*?!#~`") {
+		return value
+	}
+	return "'" + strings.ReplaceAll(value, "'", `'\''`) + "'"
+}
+
+// makeVariableRef matches the references to make variables, like $(NAME)
+// or ${NAME}.
+var makeVariableRef = regexp.MustCompile(`\$[({]([A-Za-z_][A-Za-z0-9_]*)[)}]`)
+
+// makeToTaskCall matches the recipe lines running a single target of the
+// same Makefile.
+var makeToTaskCall = regexp.MustCompile(`^\$[({]MAKE[)}]\s+([^\s$=-][^\s$=]*)$`)
+
+// exportedCmd is a recipe line converted to a Taskfile command.
+type exportedCmd struct {
+	cmd, task      string
+	silent, ignore bool
+}
+
+// exportTaskfile converts the given variables and targets into a
+// Taskfile.yml: targets become tasks, their dependencies deps, their
+// recipes cmds and their help descriptions desc. Variables set with
+// $(shell ...) become dynamic ones, and $(NAME) references become
+// {{.NAME}} ones. The help target and pattern rules are skipped.
+func exportTaskfile(variables []Variable, targets []Target) ([]byte, error) {
+	doc := yamlMapping(yamlString("version"), yamlString("3"))
+	if len(variables) > 0 {
+		vars := yamlMapping()
+		for _, v := range variables {
+			value := yamlString(taskTemplate(v.Value, ""))
+			if m := shellCall.FindStringSubmatch(v.Value); m != nil && m[0] == v.Value {
+				value = yamlMapping(yamlString("sh"), yamlString(taskTemplate(m[1], "")))
+			} else if v.Operator == "!=" {
+				value = yamlMapping(yamlString("sh"), yamlString(taskTemplate(v.Value, "")))
+			}
+			vars.Content = append(vars.Content, yamlString(v.Name), value)
+		}
+		doc.Content = append(doc.Content, yamlString("vars"), vars)
+	}
+	declared := make(map[string]bool)
+	for _, t := range targets {
+		declared[t.Name] = true
+	}
+	tasks := yamlMapping()
+	for _, t := range targets {
+		if strings.Contains(t.Name, "%") {
+			logger.Warn("pattern rule not exported", "target", t.Name)
+			continue
+		}
+		if isBuiltinHelp(t) {
+			continue
+		}
+		tk := yamlMapping()
+		if t.Description != "" {
+			tk.Content = append(tk.Content, yamlString("desc"), yamlString(t.Description))
+		}
+		var deps []*yaml.Node
+		for _, d := range t.Dependencies {
+			if !declared[d] {
+				logger.Warn("file prerequisite not exported", "target", t.Name, "prerequisite", d)
+				continue
+			}
+			deps = append(deps, yamlString(d))
+		}
+		if len(deps) > 0 {
+			tk.Content = append(tk.Content, yamlString("deps"), yamlSequence(deps...))
+		}
+		var cmds []exportedCmd
+		allSilent := len(t.Recipe) > 0
+		for _, line := range t.Recipe {
+			var cmd exportedCmd
+			line = strings.TrimLeft(line, " \t")
+			for line != "" && strings.ContainsRune("@-+", rune(line[0])) {
+				cmd.silent = cmd.silent || line[0] == '@'
+				cmd.ignore = cmd.ignore || line[0] == '-'
+				line = strings.TrimLeft(line[1:], " \t")
+			}
+			cmd.cmd = line
+			if m := makeToTaskCall.FindStringSubmatch(cmd.cmd); m != nil && declared[m[1]] {
+				cmd.task = m[1]
+			}
+			cmd.cmd = taskTemplate(cmd.cmd, t.Name)
+			allSilent = allSilent && cmd.silent
+			cmds = append(cmds, cmd)
+		}
+		if allSilent {
+			tk.Content = append(tk.Content, yamlString("silent"), yamlBool(true))
+		}
+		if len(cmds) > 0 {
+			seq := yamlSequence()
+			for _, cmd := range cmds {
+				seq.Content = append(seq.Content, cmd.node(allSilent))
+			}
+			tk.Content = append(tk.Content, yamlString("cmds"), seq)
+		}
+		tasks.Content = append(tasks.Content, yamlString(t.Name), tk)
+	}
+	doc.Content = append(doc.Content, yamlString("tasks"), tasks)
+	return encodeYAML(doc)
+}
+
+// node returns the YAML node of the command. Unless silent is set for the
+// whole task, silent commands are marked as such.
+func (c exportedCmd) node(taskSilent bool) *yaml.Node {
+	if c.task != "" {
+		return yamlMapping(yamlString("task"), yamlString(c.task))
+	}
+	if (c.silent && !taskSilent) || c.ignore {
+		n := yamlMapping(yamlString("cmd"), yamlString(c.cmd))
+		if c.silent && !taskSilent {
+			n.Content = append(n.Content, yamlString("silent"), yamlBool(true))
+		}
+		if c.ignore {
+			n.Content = append(n.Content, yamlString("ignore_error"), yamlBool(true))
+		}
+		return n
+	}
+	return yamlString(c.cmd)
+}
+
+// taskTemplate returns the given make recipe line, or value, as a shell
+// command for a Taskfile.yml: $(NAME) references become {{.NAME}} ones,
+// $(shell ...) calls command substitutions, $(MAKE) task, $@ the given
+// target name and $$ a single dollar sign. Other GNU make functions are logged as
+// warnings.
+func taskTemplate(line, target string) string {
+	for _, m := range gnuFunction.FindAllStringSubmatch(line, -1) {
+		if m[1] != "shell" {
+			logger.Warn("make construct not exported", "construct", "$("+m[1]+m[2]+")", "line", line)
+		}
+	}
+	const dollar = "\x00"
+	line = strings.ReplaceAll(line, "$$", dollar)
+	line = shellCall.ReplaceAllString(line, dollar+"($1)")
+	line = strings.NewReplacer("$(MAKE)", "task", "${MAKE}", "task", "$@", target).Replace(line)
+	line = makeVariableRef.ReplaceAllString(line, "{{.$1}}")
+	return strings.ReplaceAll(line, dollar, "$")
+}
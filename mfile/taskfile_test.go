@@ -0,0 +1,171 @@
+// Copyright (c) 2023 Tiago Melo. All rights reserved.
+// Use of this source code is governed by the MIT License that can be found in
+// the LICENSE file.
+
+package mfile
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestImportTaskfile(t *testing.T) {
+	testCases := []struct {
+		name              string
+		content           string
+		expectedVariables []Variable
+		expectedTargets   []Target
+		expectedError     error
+	}{
+		{
+			name: "happy path",
+			content: `version: '3'
+vars:
+  BIN: bin/app
+  COMMIT:
+    sh: git rev-parse --short HEAD
+tasks:
+  generate: go generate ./...
+  lint:
+    - go vet ./...
+    - golangci-lint run
+  build:
+    desc: build the binary
+    deps: [generate, {task: lint}]
+    vars:
+      LDFLAGS: -X main.commit={{.COMMIT}}
+    cmds:
+      - go build -ldflags "{{ .LDFLAGS }}" -o {{.BIN}}
+      - cmd: echo $HOME
+        silent: true
+  docker:push:
+    desc: push the image
+    internal: true
+    dir: deploy
+    cmds:
+      - task: build
+        vars: {BIN: bin/other app}
+      - ./push.sh
+`,
+			expectedVariables: []Variable{
+				{Name: "BIN", Operator: "?=", Value: "bin/app"},
+				{Name: "COMMIT", Operator: ":=", Value: "$(shell git rev-parse --short HEAD)"},
+			},
+			expectedTargets: []Target{
+				{Name: "generate", Recipe: []string{"go generate ./..."}, Phony: true},
+				{Name: "lint", Recipe: []string{"go vet ./...", "golangci-lint run"}, Phony: true},
+				{
+					Name:         "build",
+					Description:  "build the binary",
+					Dependencies: []string{"generate", "lint"},
+					Recipe:       []string{`go build -ldflags "$(LDFLAGS)" -o $(BIN)`, "@ echo $$HOME"},
+					Phony:        true,
+					Variables:    []Variable{{Name: "LDFLAGS", Operator: "=", Value: "-X main.commit=$(COMMIT)"}},
+				},
+				{
+					Name:   "docker-push",
+					Recipe: []string{"$(MAKE) build BIN='bin/other app'", "cd deploy && ./push.sh"},
+					Phony:  true,
+				},
+			},
+		},
+		{
+			name:    "multi-line commands",
+			content: "tasks:\n  check:\n    cmds:\n      - |\n        if [ -f go.mod ]; then\n          go vet ./... &&\n            go test ./...\n        fi\n        echo \\\n          done\n",
+			expectedTargets: []Target{
+				{Name: "check", Recipe: []string{"if [ -f go.mod ]; then go vet ./... && go test ./...; fi; echo done"}, Phony: true},
+			},
+		},
+		{
+			name:          "no task",
+			content:       "version: '3'\n",
+			expectedError: errors.New("no task found"),
+		},
+		{
+			name:          "invalid vars",
+			content:       "vars: [a, b]\ntasks:\n  build: go build\n",
+			expectedError: errors.New("line 1: vars must be a mapping"),
+		},
+		{
+			name:          "invalid YAML",
+			content:       "tasks: [",
+			expectedError: errors.New("parsing Taskfile: yaml: line 1: did not find expected node content"),
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			variables, targets, err := importTaskfile([]byte(tc.content), nil)
+			if err != nil {
+				if tc.expectedError == nil {
+					t.Fatalf("expected no error, got %v", err)
+				}
+				require.Equal(t, tc.expectedError.Error(), err.Error())
+			} else {
+				if tc.expectedError != nil {
+					t.Fatalf("expected error to be %v, got nil", tc.expectedError)
+				}
+				require.Equal(t, tc.expectedVariables, variables)
+				require.Equal(t, tc.expectedTargets, targets)
+			}
+		})
+	}
+}
+
+func TestRenderTargetVariables(t *testing.T) {
+	content := render(nil, []Target{{Name: "build", Variables: []Variable{{Name: "GOOS", Operator: "=", Value: "linux"}}}})
+	require.Equal(t, "build: GOOS = linux\nbuild:\n", content)
+	require.Equal(t, []Target{{Name: "build", Line: 2}}, parseTargets(content))
+
+	content = render([]Variable{{Name: "GOFLAGS", Value: "-mod=mod", Export: true}}, []Target{{Name: "deploy", Variables: []Variable{{Name: "REGION", Value: "eu", Export: true}}}})
+	require.Equal(t, "export GOFLAGS ?= -mod=mod\n\ndeploy: export REGION ?= eu\ndeploy:\n", content)
+	require.Equal(t, []Variable{{Name: "GOFLAGS", Operator: "?=", Value: "-mod=mod", Export: true}}, parseVariables(content))
+}
+
+func TestExportTaskfile(t *testing.T) {
+	variables := []Variable{
+		{Name: "BIN", Operator: "?=", Value: "app"},
+		{Name: "COMMIT", Operator: ":=", Value: "$(shell git rev-parse --short HEAD)"},
+		{Name: "FILES", Operator: "!=", Value: "ls *.go"},
+		{Name: "LDFLAGS", Operator: "=", Value: "-X main.commit=$(COMMIT)"},
+	}
+	targets := []Target{
+		helpTarget,
+		{Name: "build", Description: "build it", Recipe: []string{"@ go build -ldflags '$(LDFLAGS)' -o bin/${BIN}"}},
+		{Name: "%.o", Recipe: []string{"cc -c $<"}},
+		{
+			Name:         "release",
+			Dependencies: []string{"build", "bin/app"},
+			Recipe:       []string{"-@ rm -rf dist", `echo "$$HOME $@ $(shell date)"`, "$(MAKE) build", "@ $(MAKE) -C sub build"},
+		},
+	}
+	content, err := exportTaskfile(variables, targets)
+	require.NoError(t, err)
+	require.Equal(t, `version: "3"
+vars:
+  BIN: app
+  COMMIT:
+    sh: git rev-parse --short HEAD
+  FILES:
+    sh: ls *.go
+  LDFLAGS: -X main.commit={{.COMMIT}}
+tasks:
+  build:
+    desc: build it
+    silent: true
+    cmds:
+      - go build -ldflags '{{.LDFLAGS}}' -o bin/{{.BIN}}
+  release:
+    deps:
+      - build
+    cmds:
+      - cmd: rm -rf dist
+        silent: true
+        ignore_error: true
+      - echo "$HOME release $(date)"
+      - task: build
+      - cmd: task -C sub build
+        silent: true
+`, string(content))
+}
@@ -0,0 +1,84 @@
+// Copyright (c) 2023 Tiago Melo. All rights reserved.
+// Use of this source code is governed by the MIT License that can be found in
+// the LICENSE file.
+
+package mfile
+
+import (
+	"embed"
+	"fmt"
+	"io/fs"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// Names of the built-in templates.
+const (
+	TemplateTarget                           = "target"
+	TemplateTargetWithContent                = "target-with-content"
+	TemplateTargetWithDependencies           = "target-with-dependencies"
+	TemplateTargetWithContentAndDependencies = "target-with-content-and-dependencies"
+)
+
+// Directories the built-in templates can be overridden in.
+const (
+	localTemplatesDir = ".gomakefile/templates" // Relative to the Makefile directory.
+	userTemplatesDir  = ".gomakefile/templates" // Relative to the user home directory.
+	templateExt       = ".tmpl"                 // Extension of template files.
+)
+
+//go:embed templates/*.tmpl
+var builtinTemplates embed.FS
+
+// BuiltinTemplates returns the file system holding the built-in templates,
+// as <name>.tmpl files.
+func BuiltinTemplates() fs.FS {
+	sub, _ := fs.Sub(builtinTemplates, "templates")
+	return sub
+}
+
+// TemplateNames returns the names of the built-in templates, sorted.
+func TemplateNames() []string {
+	entries, _ := fs.ReadDir(builtinTemplates, "templates")
+	var names []string
+	for _, e := range entries {
+		names = append(names, strings.TrimSuffix(e.Name(), templateExt))
+	}
+	sort.Strings(names)
+	return names
+}
+
+// ResolveTemplate returns the text of the template with the given name,
+// for a Makefile in the given directory, along with where it was read from.
+// Built-in templates can be overridden by a <name>.tmpl file in
+// ~/.gomakefile/templates, which can in turn be overridden by one in the
+// .gomakefile/templates directory next to the Makefile.
+func ResolveTemplate(dir, name string) (string, string, error) {
+	return resolveTemplate(fsProvider, dir, name)
+}
+
+// resolveTemplate is like ResolveTemplate, reading the overrides from the
+// given file system.
+func resolveTemplate(fsys fileSystem, dir, name string) (string, string, error) {
+	builtin, err := fs.ReadFile(builtinTemplates, "templates/"+name+templateExt)
+	if err != nil {
+		return "", "", fmt.Errorf("unknown template %q", name)
+	}
+	paths := []string{filepath.Join(dir, localTemplatesDir, name+templateExt)}
+	if home, err := userHomeDir(); err == nil {
+		paths = append(paths, filepath.Join(home, userTemplatesDir, name+templateExt))
+	}
+	for _, path := range paths {
+		content, err := fsys.ReadFile(path)
+		if err != nil {
+			if fsys.IsNotExist(err) {
+				continue
+			}
+			return "", "", &Error{Op: "reading template", Path: path, Err: err}
+		}
+		logger.Debug("using template override", "template", name, "path", path)
+		return string(content), path, nil
+	}
+	return string(builtin), "builtin", nil
+}
@@ -0,0 +1,107 @@
+// Copyright (c) 2023 Tiago Melo. All rights reserved.
+// Use of this source code is governed by the MIT License that can be found in
+// the LICENSE file.
+
+package mfile
+
+import (
+	"errors"
+	"io/fs"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestBuiltinTemplates(t *testing.T) {
+	require.Equal(t, []string{
+		TemplateTarget,
+		TemplateTargetWithContent,
+		TemplateTargetWithContentAndDependencies,
+		TemplateTargetWithDependencies,
+	}, TemplateNames())
+	content, err := fs.ReadFile(BuiltinTemplates(), TemplateTarget+".tmpl")
+	require.NoError(t, err)
+	require.Equal(t, "\n.PHONY: {{ .TargetName }}\n## {{ .TargetName }}: explain what {{ .TargetName }} does\n{{ .TargetName }}:\n", string(content))
+}
+
+func TestResolveTemplate(t *testing.T) {
+	testCases := []struct {
+		name             string
+		template         string
+		mockClosure      func(m *mockFileSystem)
+		expectedTemplate string
+		expectedSource   string
+		expectedError    error
+	}{
+		{
+			name:     "built-in",
+			template: TemplateTargetWithDependencies,
+			mockClosure: func(m *mockFileSystem) {
+				m.files = map[string][]byte{}
+				m.isNotExistOutput = true
+			},
+			expectedTemplate: "\n.PHONY: {{ .TargetName }}\n## {{ .TargetName }}: explain what {{ .TargetName }} does\n{{ .TargetName }}: {{ .TargetDependencies }}\n",
+			expectedSource:   "builtin",
+		},
+		{
+			name:     "user override",
+			template: TemplateTarget,
+			mockClosure: func(m *mockFileSystem) {
+				m.files = map[string][]byte{
+					"/home/gopher/.gomakefile/templates/target.tmpl": []byte("{{ .TargetName }}: # user\n"),
+				}
+				m.isNotExistOutput = true
+			},
+			expectedTemplate: "{{ .TargetName }}: # user\n",
+			expectedSource:   "/home/gopher/.gomakefile/templates/target.tmpl",
+		},
+		{
+			name:     "repo-local override wins",
+			template: TemplateTarget,
+			mockClosure: func(m *mockFileSystem) {
+				m.files = map[string][]byte{
+					"/home/gopher/.gomakefile/templates/target.tmpl": []byte("{{ .TargetName }}: # user\n"),
+					"project/.gomakefile/templates/target.tmpl":      []byte("{{ .TargetName }}: # project\n"),
+				}
+				m.isNotExistOutput = true
+			},
+			expectedTemplate: "{{ .TargetName }}: # project\n",
+			expectedSource:   "project/.gomakefile/templates/target.tmpl",
+		},
+		{
+			name:          "unknown template",
+			template:      "unknown",
+			mockClosure:   func(m *mockFileSystem) {},
+			expectedError: errors.New(`unknown template "unknown"`),
+		},
+		{
+			name:     "error when reading override",
+			template: TemplateTarget,
+			mockClosure: func(m *mockFileSystem) {
+				m.readFileErr = errors.New("read error")
+			},
+			expectedError: errors.New("reading template at project/.gomakefile/templates/target.tmpl: read error"),
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			m := new(mockFileSystem)
+			tc.mockClosure(m)
+			fsProvider = m
+			userHomeDir = func() (string, error) { return "/home/gopher", nil }
+			template, source, err := ResolveTemplate("project", tc.template)
+			if err != nil {
+				if tc.expectedError == nil {
+					t.Fatalf("expected no error, got %v", err)
+				}
+				require.Equal(t, tc.expectedError.Error(), err.Error())
+			} else {
+				if tc.expectedError != nil {
+					t.Fatalf("expected error to be %v, got nil", tc.expectedError)
+				}
+				require.Equal(t, tc.expectedTemplate, template)
+				require.Equal(t, tc.expectedSource, source)
+			}
+		})
+	}
+}
@@ -0,0 +1,198 @@
+// Copyright (c) 2023 Tiago Melo. All rights reserved.
+// Use of this source code is governed by the MIT License that can be found in
+// the LICENSE file.
+
+package mfile
+
+import (
+	"bytes"
+	"fmt"
+	"slices"
+	"strings"
+	"text/template"
+)
+
+// Sample values templates are rendered with when validated.
+const (
+	sampleModule  = "github.com/example/sample"
+	sampleAppName = "sample"
+)
+
+// sampleTargets are the data target templates are rendered with when
+// validated: a target with dependencies and content, and a bare one.
+var sampleTargets = []map[string]string{
+	{
+		"TargetName":         "sample-target",
+		"TargetDependencies": "dep-one dep-two",
+		"TargetContent":      "@ echo sample",
+		"Module":             sampleModule,
+		"AppName":            sampleAppName,
+	},
+	{
+		"TargetName":         "sample-target",
+		"TargetDependencies": "",
+		"TargetContent":      "",
+		"Module":             sampleModule,
+		"AppName":            sampleAppName,
+	},
+}
+
+// ValidateMakefileTemplate checks that the given Makefile template, as
+// used by WithTemplate, parses, renders with sample Module and AppName
+// values and the given values, and that the result is a sound Makefile.
+func ValidateMakefileTemplate(text string, values map[string]any) error {
+	tmpl, err := template.New("Makefile").Parse(text)
+	if err != nil {
+		return fmt.Errorf("parsing template: %w", err)
+	}
+	var buf bytes.Buffer
+	data := map[string]any{"Module": sampleModule, "AppName": sampleAppName, "Values": values}
+	if err := tmpl.Execute(&buf, data); err != nil {
+		return fmt.Errorf("executing template: %w", err)
+	}
+	if issues := lintMakefile(buf.String()); len(issues) > 0 {
+		return fmt.Errorf("invalid template: %s", strings.Join(issues, "; "))
+	}
+	return nil
+}
+
+// lintTargetTemplate renders the given parsed target template with the
+// sample targets and checks that the result is a sound Makefile.
+func lintTargetTemplate(tmpl *template.Template) error {
+	for _, data := range sampleTargets {
+		var buf bytes.Buffer
+		if err := tmpl.Execute(&buf, data); err != nil {
+			return fmt.Errorf("executing target template: %w", err)
+		}
+		if issues := lintMakefile(buf.String()); len(issues) > 0 {
+			return fmt.Errorf("invalid target template: %s", strings.Join(issues, "; "))
+		}
+	}
+	return nil
+}
+
+// LintMakefile returns the problems found in the Makefile at the given
+// path that make would reject or silently misread, like recipe lines
+// indented with spaces, and the constructs the given dialect, see
+// Dialects, does not support.
+func LintMakefile(path, dialect string) ([]string, error) {
+	return lintFile(fsProvider, mkFilePath(path), dialect)
+}
+
+// lintFile is like LintMakefile, for the Makefile at the given path of the
+// given file system.
+func lintFile(fsys fileSystem, makeFilePath, dialect string) ([]string, error) {
+	if dialect != "" && !slices.Contains(Dialects(), dialect) {
+		return nil, fmt.Errorf("unknown dialect %q", dialect)
+	}
+	content, err := readMakefileFrom(fsys, makeFilePath)
+	if err != nil {
+		return nil, err
+	}
+	return append(lintMakefile(content), unsupported(dialect, content)...), nil
+}
+
+// matchesPatternRule reports whether the given name is a target of one of
+// the given declared pattern rules, like print-GOFLAGS for print-%, so
+// that it can be documented on its own.
+func matchesPatternRule(name string, declared map[string]bool) bool {
+	for pattern := range declared {
+		prefix, suffix, ok := strings.Cut(pattern, "%")
+		if ok && len(name) > len(prefix)+len(suffix) && strings.HasPrefix(name, prefix) && strings.HasSuffix(name, suffix) {
+			return true
+		}
+	}
+	return false
+}
+
+// lintMakefile returns the problems found in the given Makefile content
+// that make would reject or silently misread: recipe lines indented with
+// spaces, recipe lines outside of a rule, help comments of undeclared
+// targets and unbalanced conditionals.
+func lintMakefile(content string) []string {
+	var (
+		issues       []string
+		inRule       bool
+		inDefine     bool
+		conditionals int
+		declared     = make(map[string]bool)
+		described    []string
+		describedAt  = make(map[string]int)
+		prefix       = "\t"
+	)
+	lines := strings.Split(content, "\n")
+	for i := 0; i < len(lines); i++ {
+		line := strings.TrimRight(lines[i], "\r")
+		lineNumber := i + 1
+		for strings.HasSuffix(line, "\\") && i+1 < len(lines) {
+			i++
+			line = strings.TrimSuffix(line, "\\") + " " + strings.TrimSpace(lines[i])
+		}
+		directive := strings.Fields(line)
+		if inDefine {
+			if len(directive) > 0 && directive[0] == "endef" {
+				inDefine = false
+			}
+			continue
+		}
+		switch {
+		case strings.TrimSpace(line) == "" || strings.HasPrefix(line, "#"):
+			if name, _, ok := parseDescription(line); ok {
+				if _, seen := describedAt[name]; !seen {
+					described = append(described, name)
+					describedAt[name] = lineNumber
+				}
+			}
+			continue
+		case strings.HasPrefix(line, prefix):
+			if !inRule {
+				issues = append(issues, fmt.Sprintf("line %d: recipe line outside of a rule", lineNumber))
+			}
+			continue
+		case strings.HasPrefix(line, " ") && inRule:
+			issues = append(issues, fmt.Sprintf("line %d: recipe line indented with spaces instead of a tab", lineNumber))
+			continue
+		}
+		if p, ok := parseRecipePrefix(line); ok {
+			prefix, inRule = p, false
+			continue
+		}
+		switch directive[0] {
+		case "define":
+			inDefine, inRule = true, false
+			continue
+		case "ifeq", "ifneq", "ifdef", "ifndef", ".if", ".ifdef", ".ifndef", ".ifmake", ".ifnmake", ".for":
+			conditionals++
+			continue
+		case "else", ".elif", ".elifdef", ".elifndef", ".else":
+			if conditionals == 0 {
+				issues = append(issues, fmt.Sprintf("line %d: %s without conditional", lineNumber, directive[0]))
+			}
+			continue
+		case "endif", ".endif", ".endfor":
+			if conditionals == 0 {
+				issues = append(issues, fmt.Sprintf("line %d: %s without conditional", lineNumber, directive[0]))
+			} else {
+				conditionals--
+			}
+			continue
+		}
+		names, _, ok := parseRule(line)
+		inRule = ok
+		for _, name := range names {
+			declared[name] = true
+		}
+	}
+	if conditionals > 0 {
+		issues = append(issues, "missing endif")
+	}
+	if inDefine {
+		issues = append(issues, "missing endef")
+	}
+	for _, name := range described {
+		if !declared[name] && !matchesPatternRule(name, declared) {
+			issues = append(issues, fmt.Sprintf("line %d: help comment of undeclared target %s", describedAt[name], name))
+		}
+	}
+	return issues
+}
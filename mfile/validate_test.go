@@ -0,0 +1,215 @@
+// Copyright (c) 2023 Tiago Melo. All rights reserved.
+// Use of this source code is governed by the MIT License that can be found in
+// the LICENSE file.
+
+package mfile
+
+import (
+	"errors"
+	"io/fs"
+	"testing"
+	"text/template"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestLintMakefile(t *testing.T) {
+	testCases := []struct {
+		name           string
+		content        string
+		expectedIssues []string
+	}{
+		{
+			name: "sound Makefile",
+			content: `GO ?= go
+
+define BANNER
+    indented lines are fine here
+endef
+
+.PHONY: help
+## help: shows this help message
+help:
+	@ sed -n 's/^##//p' $(MAKEFILE_LIST)
+
+ifeq ($(OS),Windows_NT)
+build:
+	$(GO) build -o app.exe
+else
+build:
+
+	$(GO) build -o app
+endif
+`,
+		},
+		{
+			name:    "recipe indented with spaces",
+			content: "build:\n\tgo vet ./...\n    go build ./...\n",
+			expectedIssues: []string{
+				"line 3: recipe line indented with spaces instead of a tab",
+			},
+		},
+		{
+			name:    "recipe outside of a rule",
+			content: "GO ?= go\n\t$(GO) build\n",
+			expectedIssues: []string{
+				"line 2: recipe line outside of a rule",
+			},
+		},
+		{
+			name:    "recipe prefix",
+			content: ".RECIPEPREFIX := >\nbuild:\n>go build\nGO ?= go\n>$(GO) vet\n",
+			expectedIssues: []string{
+				"line 5: recipe line outside of a rule",
+			},
+		},
+		{
+			name:    "help comment of undeclared target",
+			content: "## test: run tests\ntests:\n\tgo test ./...\n",
+			expectedIssues: []string{
+				"line 1: help comment of undeclared target test",
+			},
+		},
+		{
+			name:    "help comments of pattern rule targets",
+			content: "## print-%: print a variable\n## print-GOFLAGS: print the go flags\n## print-: print nothing\nprint-%:\n\t@ echo '$*=$($*)'\n",
+			expectedIssues: []string{
+				"line 3: help comment of undeclared target print-",
+			},
+		},
+		{
+			name:    "unbalanced conditionals",
+			content: "endif\nifdef CI\ndefine X\n",
+			expectedIssues: []string{
+				"line 1: endif without conditional",
+				"missing endif",
+				"missing endef",
+			},
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			require.Equal(t, tc.expectedIssues, lintMakefile(tc.content))
+		})
+	}
+}
+
+func TestLintBuiltinTemplates(t *testing.T) {
+	for _, name := range TemplateNames() {
+		t.Run(name, func(t *testing.T) {
+			text, err := fs.ReadFile(BuiltinTemplates(), name+templateExt)
+			require.NoError(t, err)
+			require.NoError(t, lintTargetTemplate(template.Must(template.New(name).Parse(string(text)))))
+		})
+	}
+}
+
+func TestValidateMakefileTemplate(t *testing.T) {
+	testCases := []struct {
+		name          string
+		template      string
+		values        map[string]any
+		expectedError error
+	}{
+		{
+			name:     "happy path",
+			template: "IMAGE ?= {{ .Values.registry }}/{{ .AppName }}\n\n## build: build {{ .Module }}\nbuild:\n\tgo build ./...\n",
+			values:   map[string]any{"registry": "ghcr.io/acme"},
+		},
+		{
+			name:          "invalid template",
+			template:      "{{ end }}",
+			expectedError: errors.New("parsing template: template: Makefile:1: unexpected {{end}}"),
+		},
+		{
+			name:          "error when executing",
+			template:      "{{ .Values.registry.host }}",
+			values:        map[string]any{"registry": 1},
+			expectedError: errors.New(`executing template: template: Makefile:1:10: executing "Makefile" at <.Values.registry.host>: can't evaluate field host in type interface {}`),
+		},
+		{
+			name:          "unsound Makefile",
+			template:      "build:\n  go build ./...\n",
+			expectedError: errors.New("invalid template: line 2: recipe line indented with spaces instead of a tab"),
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			err := ValidateMakefileTemplate(tc.template, tc.values)
+			if err != nil {
+				if tc.expectedError == nil {
+					t.Fatalf("expected no error, got %v", err)
+				}
+				require.Equal(t, tc.expectedError.Error(), err.Error())
+			} else {
+				if tc.expectedError != nil {
+					t.Fatalf("expected error to be %v, got nil", tc.expectedError)
+				}
+			}
+		})
+	}
+}
+
+func TestLintMakefileFile(t *testing.T) {
+	testCases := []struct {
+		name           string
+		dialect        string
+		mockClosure    func(m *mockFileSystem)
+		expectedIssues []string
+		expectedError  error
+	}{
+		{
+			name:    "gnu dialect",
+			dialect: DialectGNU,
+			mockClosure: func(m *mockFileSystem) {
+				m.file = []byte("SRC := $(wildcard *.go)\nbuild:\n  go build\n")
+			},
+			expectedIssues: []string{"line 3: recipe line indented with spaces instead of a tab"},
+		},
+		{
+			name:    "posix dialect",
+			dialect: DialectPOSIX,
+			mockClosure: func(m *mockFileSystem) {
+				m.file = []byte("SRC := $(wildcard *.go)\nbuild:\n  go build\n")
+			},
+			expectedIssues: []string{
+				"line 3: recipe line indented with spaces instead of a tab",
+				"line 1: := is GNU make only",
+				"line 1: $(wildcard) is GNU make only",
+			},
+		},
+		{
+			name:          "unknown dialect",
+			dialect:       "unknown",
+			mockClosure:   func(m *mockFileSystem) {},
+			expectedError: errors.New(`unknown dialect "unknown"`),
+		},
+		{
+			name:    "error when reading Makefile",
+			dialect: DialectGNU,
+			mockClosure: func(m *mockFileSystem) {
+				m.readFileErr = errors.New("read error")
+			},
+			expectedError: errors.New("reading Makefile at some/path: read error"),
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			m := new(mockFileSystem)
+			tc.mockClosure(m)
+			fsProvider = m
+			issues, err := LintMakefile("some/path", tc.dialect)
+			if err != nil {
+				if tc.expectedError == nil {
+					t.Fatalf("expected no error, got %v", err)
+				}
+				require.Equal(t, tc.expectedError.Error(), err.Error())
+			} else {
+				if tc.expectedError != nil {
+					t.Fatalf("expected error to be %v, got nil", tc.expectedError)
+				}
+				require.Equal(t, tc.expectedIssues, issues)
+			}
+		})
+	}
+}
@@ -0,0 +1,23 @@
+// Copyright (c) 2023 Tiago Melo. All rights reserved.
+// Use of this source code is governed by the MIT License that can be found in
+// the LICENSE file.
+
+package mfile
+
+import (
+	"gopkg.in/yaml.v3"
+)
+
+// ReadValues reads the template values held by the YAML, or JSON, file
+// at the given path, to be passed to WithValues.
+func ReadValues(path string) (map[string]any, error) {
+	content, err := fsProvider.ReadFile(path)
+	if err != nil {
+		return nil, &Error{Op: "reading values", Path: path, Err: err}
+	}
+	values := make(map[string]any)
+	if err := yaml.Unmarshal(content, &values); err != nil {
+		return nil, &Error{Op: "parsing values", Path: path, Err: err}
+	}
+	return values, nil
+}
@@ -0,0 +1,74 @@
+// Copyright (c) 2023 Tiago Melo. All rights reserved.
+// Use of this source code is governed by the MIT License that can be found in
+// the LICENSE file.
+
+package mfile
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestReadValues(t *testing.T) {
+	testCases := []struct {
+		name           string
+		mockClosure    func(m *mockFileSystem)
+		expectedValues map[string]any
+		expectedError  error
+	}{
+		{
+			name: "happy path",
+			mockClosure: func(m *mockFileSystem) {
+				m.file = []byte("registry: ghcr.io/acme\nteam: payments\nreplicas: 3\nnamespaces:\n  - dev\n  - prod\n")
+			},
+			expectedValues: map[string]any{
+				"registry":   "ghcr.io/acme",
+				"team":       "payments",
+				"replicas":   3,
+				"namespaces": []any{"dev", "prod"},
+			},
+		},
+		{
+			name: "happy path, JSON",
+			mockClosure: func(m *mockFileSystem) {
+				m.file = []byte(`{"registry": "ghcr.io/acme"}`)
+			},
+			expectedValues: map[string]any{"registry": "ghcr.io/acme"},
+		},
+		{
+			name: "error when reading file",
+			mockClosure: func(m *mockFileSystem) {
+				m.readFileErr = errors.New("read error")
+			},
+			expectedError: errors.New("reading values at values.yaml: read error"),
+		},
+		{
+			name: "error when parsing file",
+			mockClosure: func(m *mockFileSystem) {
+				m.file = []byte("- a list\n")
+			},
+			expectedError: errors.New("parsing values at values.yaml: yaml: unmarshal errors:\n  line 1: cannot unmarshal !!seq into map[string]interface {}"),
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			m := new(mockFileSystem)
+			tc.mockClosure(m)
+			fsProvider = m
+			values, err := ReadValues("values.yaml")
+			if err != nil {
+				if tc.expectedError == nil {
+					t.Fatalf("expected no error, got %v", err)
+				}
+				require.Equal(t, tc.expectedError.Error(), err.Error())
+			} else {
+				if tc.expectedError != nil {
+					t.Fatalf("expected error to be %v, got nil", tc.expectedError)
+				}
+				require.Equal(t, tc.expectedValues, values)
+			}
+		})
+	}
+}
@@ -0,0 +1,102 @@
+// Copyright (c) 2023 Tiago Melo. All rights reserved.
+// Use of this source code is governed by the MIT License that can be found in
+// the LICENSE file.
+
+package mfile
+
+import (
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+)
+
+// SetVariable sets the value of the given variable, see
+// Makefile.SetVariable.
+func SetVariable(path, name, value string) error {
+	return editMakefile(path, func(m *Makefile) error {
+		return m.SetVariable(name, value)
+	})
+}
+
+// SetVariable sets the value of the given variable by rewriting its first
+// assignment, keeping its operator, export and trailing comment, or, if
+// it is not assigned, by adding a "name ?= value" assignment after the
+// ones the Makefile starts with, or at its top. Later assignments, like
+// += ones or the OS-specific ones in conditionals, are left as they are.
+func (m *Makefile) SetVariable(name, value string) error {
+	if name == "" || strings.HasPrefix(name, ".") || strings.ContainsAny(name, " \t:#$()=") {
+		return fmt.Errorf("invalid variable name %q", name)
+	}
+	if strings.ContainsAny(value, "\r\n") {
+		return errors.New("variable value cannot span several lines")
+	}
+	// after is the index of the line following the assignments the
+	// Makefile starts with, where a new assignment goes.
+	after, inRules, prefix := 0, false, "\t"
+	for i := 0; i < len(m.lines); i++ {
+		first := i
+		line := strings.TrimRight(m.lines[i], "\r")
+		if strings.HasPrefix(line, prefix) {
+			continue
+		}
+		for strings.HasSuffix(line, "\\") && i+1 < len(m.lines) {
+			i++
+			line = strings.TrimRight(strings.TrimSuffix(line, "\\"), " \t") + " " + strings.TrimSpace(m.lines[i])
+		}
+		if p, ok := parseRecipePrefix(line); ok {
+			prefix = p
+		}
+		v, ok := parseAssignment(line)
+		if !ok {
+			if _, _, rule := parseRule(line); rule {
+				inRules = true
+			}
+			continue
+		}
+		if !inRules {
+			after = i + 1
+		}
+		if v.Name != name || v.Operator == "+=" {
+			continue
+		}
+		m.lines[first] = assignmentLine(m.lines[first], line, value)
+		m.lines = slices.Delete(m.lines, first+1, i+1)
+		return nil
+	}
+	// The assignment ends like the first line, with a carriage return if
+	// the Makefile uses CRLF line endings.
+	lineEnd := ""
+	if len(m.lines) > 0 && strings.HasSuffix(m.lines[0], "\r") {
+		lineEnd = "\r"
+	}
+	added := []string{strings.TrimSpace(name+" ?= "+value) + lineEnd}
+	if after == 0 && len(m.lines) > 0 && strings.TrimSpace(m.lines[0]) != "" {
+		// Separated from the content the Makefile starts with.
+		added = append(added, lineEnd)
+	}
+	m.lines = slices.Insert(m.lines, after, added...)
+	return nil
+}
+
+// assignmentLine returns the given first line of an assignment assigning
+// the given value instead, the whole assignment being the given logical
+// line, continuation lines joined.
+func assignmentLine(first, logical, value string) string {
+	eq := strings.Index(first, "=")
+	head := first[:eq+1]
+	rest := logical[strings.Index(logical, "=")+1:]
+	comment := ""
+	if i := strings.Index(rest, " #"); i >= 0 {
+		comment = " " + strings.TrimSpace(rest[i:])
+	}
+	line := head
+	if value != "" {
+		line += " " + value
+	}
+	line += comment
+	if strings.HasSuffix(first, "\r") {
+		line += "\r"
+	}
+	return line
+}
@@ -0,0 +1,96 @@
+// Copyright (c) 2023 Tiago Melo. All rights reserved.
+// Use of this source code is governed by the MIT License that can be found in
+// the LICENSE file.
+
+package mfile
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestSetVariable(t *testing.T) {
+	testCases := []struct {
+		name            string
+		content         string
+		variable        string
+		value           string
+		expectedContent string
+		expectedError   error
+	}{
+		{
+			name:            "happy path",
+			content:         "GO ?= go\nGOFLAGS := -v # verbose\n\nbuild:\n\t$(GO) build $(GOFLAGS)\n",
+			variable:        "GOFLAGS",
+			value:           "-mod=readonly",
+			expectedContent: "GO ?= go\nGOFLAGS := -mod=readonly # verbose\n\nbuild:\n\t$(GO) build $(GOFLAGS)\n",
+		},
+		{
+			name:            "happy path, exported assignment spanning several lines",
+			content:         "export PKGS = ./cmd/... \\\r\n\t./internal/...\r\nbuild:\r\n",
+			variable:        "PKGS",
+			value:           "./...",
+			expectedContent: "export PKGS = ./...\r\nbuild:\r\n",
+		},
+		{
+			name:            "happy path, empty value",
+			content:         "ARGS = -v\n",
+			variable:        "ARGS",
+			expectedContent: "ARGS =\n",
+		},
+		{
+			name:            "happy path, later appends left as they are",
+			content:         "FLAGS += -a\nFLAGS = -v\nFLAGS += -x\n",
+			variable:        "FLAGS",
+			value:           "-race",
+			expectedContent: "FLAGS += -a\nFLAGS = -race\nFLAGS += -x\n",
+		},
+		{
+			name:            "happy path, not assigned",
+			content:         "GO ?= go\n\nbuild:\n\tCGO_ENABLED=0 $(GO) build\nLATE = 1\n",
+			variable:        "CGO_ENABLED",
+			value:           "0",
+			expectedContent: "GO ?= go\nCGO_ENABLED ?= 0\n\nbuild:\n\tCGO_ENABLED=0 $(GO) build\nLATE = 1\n",
+		},
+		{
+			name:            "happy path, no variables",
+			content:         "# Owned by the platform team.\nbuild:\n",
+			variable:        "GO",
+			value:           "go",
+			expectedContent: "GO ?= go\n\n# Owned by the platform team.\nbuild:\n",
+		},
+		{
+			name:            "happy path, empty Makefile",
+			variable:        "GO",
+			value:           "go",
+			expectedContent: "GO ?= go\n",
+		},
+		{
+			name:          "invalid variable name",
+			content:       "build:\n",
+			variable:      "GO FLAGS",
+			expectedError: errors.New(`invalid variable name "GO FLAGS"`),
+		},
+		{
+			name:          "value spanning several lines",
+			content:       "build:\n",
+			variable:      "GOFLAGS",
+			value:         "-v\n-x",
+			expectedError: errors.New("variable value cannot span several lines"),
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			m := ParseString(tc.content)
+			err := m.SetVariable(tc.variable, tc.value)
+			if tc.expectedError != nil {
+				require.EqualError(t, err, tc.expectedError.Error())
+				return
+			}
+			require.NoError(t, err)
+			require.Equal(t, tc.expectedContent, m.String())
+		})
+	}
+}
@@ -0,0 +1,72 @@
+// Copyright (c) 2023 Tiago Melo. All rights reserved.
+// Use of this source code is governed by the MIT License that can be found in
+// the LICENSE file.
+
+package mfile
+
+import (
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"sort"
+	"strings"
+)
+
+// capturingFileSystem is a fileSystem keeping the files written to it in
+// memory instead of writing them, so that what Generate would write can
+// be compared with what is on disk.
+type capturingFileSystem struct {
+	fileSystem
+	written map[string][]byte
+}
+
+func (c *capturingFileSystem) WriteFile(name string, data []byte, perm fs.FileMode) error {
+	c.written[name] = data
+	return nil
+}
+
+func (c *capturingFileSystem) WriteFileFrom(name string, r io.Reader, perm fs.FileMode) error {
+	data, err := io.ReadAll(r)
+	if err != nil {
+		return err
+	}
+	c.written[name] = data
+	return nil
+}
+
+func (c *capturingFileSystem) MkdirAll(path string, perm os.FileMode) error {
+	return nil
+}
+
+// Verify reports whether the Makefile at the specified path, and its
+// fragments, are the ones the spec at the given path generates, see
+// ReadSpec. It returns the diff turning the files on disk into the
+// generated ones, or an empty string if they are the same, meaning that
+// the Makefile was not edited by hand.
+func Verify(path, specPath string) (string, error) {
+	spec, err := ReadSpec(specPath)
+	if err != nil {
+		return "", err
+	}
+	makeFilePath := mkFilePath(path)
+	disk := fsProvider
+	capture := &capturingFileSystem{fileSystem: disk, written: make(map[string][]byte)}
+	if err := generate(capture, makeFilePath, append(spec.Options(), WithOverwrite(true))...); err != nil {
+		return "", fmt.Errorf("generating from spec %s: %w", specPath, err)
+	}
+	names := make([]string, 0, len(capture.written))
+	for name := range capture.written {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	var sb strings.Builder
+	for _, name := range names {
+		content, err := disk.ReadFile(name)
+		if err != nil && !disk.IsNotExist(err) {
+			return "", fmt.Errorf("reading %s: %w", name, err)
+		}
+		sb.WriteString(lineDiff(name, name, string(content), string(capture.written[name])))
+	}
+	return sb.String(), nil
+}
@@ -0,0 +1,76 @@
+// Copyright (c) 2023 Tiago Melo. All rights reserved.
+// Use of this source code is governed by the MIT License that can be found in
+// the LICENSE file.
+
+package mfile
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestVerify(t *testing.T) {
+	awk := "\t" + helpRecipes[HelpStyleAWK][0] + "\n"
+	testCases := []struct {
+		name          string
+		spec          string
+		edit          func(content string) string
+		expectedDiff  string
+		expectedError func(dir string) error
+	}{
+		{
+			name: "happy path, up to date",
+			spec: "flavor: minimal\n",
+		},
+		{
+			name: "edited by hand",
+			spec: "flavor: minimal\n",
+			edit: func(content string) string {
+				return strings.Replace(content, awk, "\t@ echo edited\n", 1)
+			},
+			expectedDiff: "@@ -1,4 +1,4 @@\n .PHONY: help\n ## help: shows this help message\n help:\n-\t@ echo edited\n+" + awk,
+		},
+		{
+			name: "invalid spec",
+			spec: "flavor: unknown\n",
+			expectedError: func(dir string) error {
+				return errors.New("parsing spec at " + filepath.Join(dir, SpecFileName) + `: line 1, column 9: flavor: unknown flavor "unknown", want minimal, standard, library or full`)
+			},
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			fsProvider = osFileSystem{}
+			dir := t.TempDir()
+			specPath := filepath.Join(dir, SpecFileName)
+			makeFilePath := filepath.Join(dir, "Makefile")
+			require.NoError(t, os.WriteFile(specPath, []byte(tc.spec), 0644))
+			require.NoError(t, Generate(dir, WithFlavor("minimal")))
+			if tc.edit != nil {
+				content, err := os.ReadFile(makeFilePath)
+				require.NoError(t, err)
+				require.NoError(t, os.WriteFile(makeFilePath, []byte(tc.edit(string(content))), 0644))
+			}
+			diff, err := Verify(dir, specPath)
+			if err != nil {
+				if tc.expectedError == nil {
+					t.Fatalf("expected no error, got %v", err)
+				}
+				require.Equal(t, tc.expectedError(dir).Error(), err.Error())
+			} else {
+				if tc.expectedError != nil {
+					t.Fatalf("expected error to be %v, got nil", tc.expectedError(dir))
+				}
+				if tc.expectedDiff != "" {
+					tc.expectedDiff = "--- " + makeFilePath + "\n+++ " + makeFilePath + "\n" + tc.expectedDiff
+				}
+				require.Equal(t, tc.expectedDiff, diff)
+			}
+		})
+	}
+}
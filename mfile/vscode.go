@@ -0,0 +1,67 @@
+// Copyright (c) 2023 Tiago Melo. All rights reserved.
+// Use of this source code is governed by the MIT License that can be found in
+// the LICENSE file.
+
+package mfile
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+)
+
+// vscodeTasks is the .vscode/tasks.json file of VS Code.
+type vscodeTasks struct {
+	Version string       `json:"version"`
+	Tasks   []vscodeTask `json:"tasks"`
+}
+
+// vscodeTask is a task of a .vscode/tasks.json file.
+type vscodeTask struct {
+	Label          string   `json:"label"`
+	Detail         string   `json:"detail,omitempty"`
+	Type           string   `json:"type"`
+	Command        string   `json:"command"`
+	Args           []string `json:"args"`
+	Group          string   `json:"group,omitempty"`
+	ProblemMatcher []string `json:"problemMatcher"`
+}
+
+// exportVSCode converts the Makefile into a VS Code .vscode/tasks.json
+// file, with a task running make for each target. Tasks are labeled with
+// the help comment of their target, or its name, and the build and test
+// targets are put in the build and test groups. The help target and
+// pattern rules are left out.
+func exportVSCode(_ []Variable, targets []Target) ([]byte, error) {
+	doc := vscodeTasks{Version: "2.0.0", Tasks: []vscodeTask{}}
+	labels := make(map[string]bool)
+	for _, t := range targets {
+		if strings.Contains(t.Name, "%") || isBuiltinHelp(t) {
+			continue
+		}
+		task := vscodeTask{
+			Label:          t.Name,
+			Detail:         "make " + t.Name,
+			Type:           "process",
+			Command:        "make",
+			Args:           []string{t.Name},
+			ProblemMatcher: []string{},
+		}
+		if t.Description != "" {
+			task.Label = t.Description
+		}
+		if labels[task.Label] {
+			task.Label += " (" + t.Name + ")"
+		}
+		labels[task.Label] = true
+		if t.Name == "build" || t.Name == "test" {
+			task.Group = t.Name
+		}
+		doc.Tasks = append(doc.Tasks, task)
+	}
+	content, err := json.MarshalIndent(doc, "", "\t")
+	if err != nil {
+		return nil, fmt.Errorf("encoding JSON: %w", err)
+	}
+	return append(content, '\n'), nil
+}
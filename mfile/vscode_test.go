@@ -0,0 +1,83 @@
+// Copyright (c) 2023 Tiago Melo. All rights reserved.
+// Use of this source code is governed by the MIT License that can be found in
+// the LICENSE file.
+
+package mfile
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestExportVSCode(t *testing.T) {
+	testCases := []struct {
+		name            string
+		targets         []Target
+		expectedContent string
+	}{
+		{
+			name: "happy path",
+			targets: []Target{
+				helpTarget,
+				{Name: "build", Description: "build the binary", Recipe: []string{"go build"}},
+				{Name: "%.o", Recipe: []string{"cc -c $<"}},
+				{Name: "lint"},
+				{Name: "vet", Description: "build the binary"},
+			},
+			expectedContent: `{
+	"version": "2.0.0",
+	"tasks": [
+		{
+			"label": "build the binary",
+			"detail": "make build",
+			"type": "process",
+			"command": "make",
+			"args": [
+				"build"
+			],
+			"group": "build",
+			"problemMatcher": []
+		},
+		{
+			"label": "lint",
+			"detail": "make lint",
+			"type": "process",
+			"command": "make",
+			"args": [
+				"lint"
+			],
+			"problemMatcher": []
+		},
+		{
+			"label": "build the binary (vet)",
+			"detail": "make vet",
+			"type": "process",
+			"command": "make",
+			"args": [
+				"vet"
+			],
+			"problemMatcher": []
+		}
+	]
+}
+`,
+		},
+		{
+			name:    "no target",
+			targets: nil,
+			expectedContent: `{
+	"version": "2.0.0",
+	"tasks": []
+}
+`,
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			content, err := exportVSCode(nil, tc.targets)
+			require.NoError(t, err)
+			require.Equal(t, tc.expectedContent, string(content))
+		})
+	}
+}
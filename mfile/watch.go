@@ -0,0 +1,139 @@
+// Copyright (c) 2023 Tiago Melo. All rights reserved.
+// Use of this source code is governed by the MIT License that can be found in
+// the LICENSE file.
+
+package mfile
+
+import (
+	"context"
+	"fmt"
+	"path/filepath"
+	"slices"
+	"strings"
+	"time"
+)
+
+// Watch generates the Makefile at the specified path from the spec at the
+// given path, see ReadSpec, then regenerates it whenever the spec, its
+// local template or the structure of the project, like a new cmd
+// directory or proto file, changes, until ctx is done. Changes are polled
+// at the given interval. The Makefile is overwritten each time, and the
+// given function is called with the diff of each change of its content.
+// Errors after the first generation, like an invalid spec, are logged
+// and the Makefile is left as it is until the next change. The interval
+// must be positive.
+func Watch(ctx context.Context, path, specPath string, interval time.Duration, changed func(diff string)) error {
+	if interval <= 0 {
+		return fmt.Errorf("invalid watch interval %s, want a positive duration", interval)
+	}
+	makeFilePath := mkFilePath(path)
+	fingerprint, err := regenerate(makeFilePath, specPath, changed)
+	if err != nil {
+		return err
+	}
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-ticker.C:
+		}
+		current, err := watchFingerprint(makeFilePath, specPath)
+		if err != nil {
+			logger.Warn("scanning project", "error", err)
+			continue
+		}
+		if current == fingerprint {
+			continue
+		}
+		logger.Debug("change detected", "path", makeFilePath)
+		if fingerprint, err = regenerate(makeFilePath, specPath, changed); err != nil {
+			logger.Warn("regenerating Makefile", "error", err)
+			fingerprint = current
+		}
+	}
+}
+
+// regenerate generates the Makefile at the given path from the spec at
+// the given path, calls changed with the diff of its content if it
+// changed, and returns the fingerprint of what it was generated from.
+func regenerate(makeFilePath, specPath string, changed func(diff string)) (string, error) {
+	spec, err := ReadSpec(specPath)
+	if err != nil {
+		return "", err
+	}
+	old, err := fsProvider.ReadFile(makeFilePath)
+	if err != nil && !fsProvider.IsNotExist(err) {
+		return "", &Error{Op: "reading Makefile", Path: makeFilePath, Err: err}
+	}
+	if err := Generate(makeFilePath, append(spec.Options(), WithOverwrite(true))...); err != nil {
+		return "", err
+	}
+	content, err := fsProvider.ReadFile(makeFilePath)
+	if err != nil {
+		return "", &Error{Op: "reading Makefile", Path: makeFilePath, Err: err}
+	}
+	if diff := lineDiff(makeFilePath, makeFilePath, string(old), string(content)); diff != "" {
+		changed(diff)
+	}
+	// The fingerprint is taken once generated, as generating may add
+	// directories, like the one of the fragments.
+	return watchFingerprint(makeFilePath, specPath)
+}
+
+// watchFingerprint returns a summary of what the Makefile at the given
+// path is generated from, which changes when it must be regenerated: the
+// modification times of the spec, its local template and the go.mod like
+// files at the root of the project, the files at its root, and its
+// directories and proto files.
+func watchFingerprint(makeFilePath, specPath string) (string, error) {
+	var sb strings.Builder
+	files := []string{specPath}
+	if spec, err := ReadSpec(specPath); err == nil && spec.Template != "" && fileExists(fsProvider, spec.Template) {
+		files = append(files, spec.Template)
+	}
+	for _, f := range files {
+		fi, err := fsProvider.Stat(f)
+		if err != nil {
+			return "", fmt.Errorf("reading %s: %w", f, err)
+		}
+		fmt.Fprintf(&sb, "%s %d %d\n", f, fi.ModTime().UnixNano(), fi.Size())
+	}
+	dir := filepath.Dir(makeFilePath)
+	var walk func(rel string) error
+	walk = func(rel string) error {
+		entries, err := fsProvider.ReadDir(filepath.Join(dir, rel))
+		if err != nil {
+			return fmt.Errorf("reading directory %s: %w", filepath.Join(dir, rel), err)
+		}
+		for _, e := range entries {
+			name := filepath.Join(rel, e.Name())
+			switch {
+			case e.IsDir():
+				if skipDir(e.Name()) {
+					continue
+				}
+				sb.WriteString(name + "/\n")
+				if err := walk(name); err != nil {
+					return err
+				}
+			case rel == "" && slices.Contains(moduleFiles, e.Name()):
+				fi, err := e.Info()
+				if err != nil {
+					return fmt.Errorf("reading %s: %w", name, err)
+				}
+				fmt.Fprintf(&sb, "%s %d\n", name, fi.ModTime().UnixNano())
+			case rel == "" && e.Name() != filepath.Base(makeFilePath):
+				sb.WriteString(name + "\n")
+			case filepath.Ext(e.Name()) == ".proto":
+				sb.WriteString(name + "\n")
+			}
+		}
+		return nil
+	}
+	if err := walk(""); err != nil {
+		return "", err
+	}
+	return sb.String(), nil
+}
@@ -0,0 +1,95 @@
+// Copyright (c) 2023 Tiago Melo. All rights reserved.
+// Use of this source code is governed by the MIT License that can be found in
+// the LICENSE file.
+
+package mfile
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestWatch(t *testing.T) {
+	fsProvider = osFileSystem{}
+	dir := t.TempDir()
+	specPath := filepath.Join(dir, SpecFileName)
+	require.NoError(t, os.WriteFile(specPath, []byte("flavor: minimal\n"), 0644))
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	diffs := make(chan string, 10)
+	done := make(chan error)
+	go func() {
+		done <- Watch(ctx, dir, specPath, 10*time.Millisecond, func(diff string) {
+			diffs <- diff
+		})
+	}()
+	next := func() string {
+		select {
+		case diff := <-diffs:
+			return diff
+		case <-time.After(5 * time.Second):
+			t.Fatal("timed out waiting for the Makefile to be regenerated")
+			return ""
+		}
+	}
+	require.Contains(t, next(), "+help:\n")
+
+	require.NoError(t, os.WriteFile(specPath, []byte("flavor: standard\n"), 0644))
+	diff := next()
+	require.Contains(t, diff, "+test:\n")
+	require.NotContains(t, diff, "+help:\n")
+
+	// An invalid spec leaves the Makefile as it is.
+	require.NoError(t, os.WriteFile(specPath, []byte("flavr: minimal\n"), 0644))
+	time.Sleep(100 * time.Millisecond)
+	content, err := os.ReadFile(filepath.Join(dir, "Makefile"))
+	require.NoError(t, err)
+	require.True(t, strings.Contains(string(content), "test:"))
+
+	cancel()
+	require.NoError(t, <-done)
+	require.Empty(t, diffs)
+}
+
+func TestWatchInvalidInterval(t *testing.T) {
+	for _, interval := range []time.Duration{0, -time.Second} {
+		err := Watch(context.Background(), ".", SpecFileName, interval, func(string) {})
+		require.EqualError(t, err, "invalid watch interval "+interval.String()+", want a positive duration")
+	}
+}
+
+func TestWatchFingerprint(t *testing.T) {
+	fsProvider = osFileSystem{}
+	dir := t.TempDir()
+	specPath := filepath.Join(dir, SpecFileName)
+	makeFilePath := filepath.Join(dir, "Makefile")
+	require.NoError(t, os.WriteFile(specPath, []byte("presets: [go-cli]\n"), 0644))
+	fingerprint := func() string {
+		f, err := watchFingerprint(makeFilePath, specPath)
+		require.NoError(t, err)
+		return f
+	}
+	initial := fingerprint()
+
+	require.NoError(t, os.WriteFile(makeFilePath, []byte("build:\n"), 0644))
+	require.Equal(t, initial, fingerprint(), "writing the Makefile is not a change")
+
+	require.NoError(t, os.MkdirAll(filepath.Join(dir, "node_modules", "x"), 0755))
+	require.Equal(t, initial, fingerprint(), "dependency directories are skipped")
+
+	require.NoError(t, os.MkdirAll(filepath.Join(dir, "cmd", "api"), 0755))
+	withCmd := fingerprint()
+	require.NotEqual(t, initial, withCmd, "new directories are changes")
+
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "cmd", "api", "main.go"), []byte("package main\n"), 0644))
+	require.Equal(t, withCmd, fingerprint(), "source files are not changes")
+
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "cmd", "api", "api.proto"), []byte("syntax = \"proto3\";\n"), 0644))
+	require.NotEqual(t, withCmd, fingerprint(), "new proto files are changes")
+}
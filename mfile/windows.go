@@ -0,0 +1,62 @@
+// Copyright (c) 2023 Tiago Melo. All rights reserved.
+// Use of this source code is governed by the MIT License that can be found in
+// the LICENSE file.
+
+package mfile
+
+import (
+	"regexp"
+	"strings"
+)
+
+// windowsRecipeLines are the Windows variants of the recipe lines of the
+// built-in presets relying on a POSIX shell.
+var windowsRecipeLines = map[string]string{
+	"@ ./bin/$(BINARY_NAME)":              `@ bin\$(BINARY_NAME).exe`,
+	"@ PORT=$(PORT) ./bin/$(BINARY_NAME)": `@ set PORT=$(PORT)&& bin\$(BINARY_NAME).exe`,
+	"@ rm -rf $(CLEAN_ARTIFACTS)":         `@ for %f in ($(CLEAN_ARTIFACTS)) do @if exist %f (rmdir /S /Q %f 2> nul || del /Q %f)`,
+	"@ rm -rf $(DISTCLEAN_ARTIFACTS)":     `@ for %f in ($(DISTCLEAN_ARTIFACTS)) do @if exist %f (rmdir /S /Q %f 2> nul || del /Q %f)`,
+}
+
+// goBuildOutput matches the go build recipe lines of the built-in presets,
+// capturing the output path.
+var goBuildOutput = regexp.MustCompile(`^(@ go build -o )(\S+)(.*)$`)
+
+// applyWindows adds a Windows variant, see Target.OSRecipes, to the given
+// targets whose recipe relies on a POSIX shell or builds a binary without
+// the .exe suffix.
+func applyWindows(targets []Target) {
+	for i, t := range targets {
+		if _, ok := t.OSRecipes[OSWindows]; ok {
+			continue
+		}
+		recipe, changed := windowsRecipe(t.Recipe)
+		if !changed {
+			continue
+		}
+		osRecipes := map[string][]string{OSWindows: recipe}
+		for os, r := range t.OSRecipes {
+			osRecipes[os] = r
+		}
+		targets[i].OSRecipes = osRecipes
+	}
+}
+
+// windowsRecipe returns the Windows variant of the given recipe, and
+// whether it differs from it.
+func windowsRecipe(recipe []string) ([]string, bool) {
+	var (
+		variant = make([]string, len(recipe))
+		changed bool
+	)
+	for i, line := range recipe {
+		variant[i] = line
+		if w, ok := windowsRecipeLines[line]; ok {
+			variant[i] = w
+		} else if m := goBuildOutput.FindStringSubmatch(line); m != nil && !strings.HasSuffix(m[2], ".exe") {
+			variant[i] = m[1] + m[2] + ".exe" + m[3]
+		}
+		changed = changed || variant[i] != line
+	}
+	return variant, changed
+}
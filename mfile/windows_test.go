@@ -0,0 +1,62 @@
+// Copyright (c) 2023 Tiago Melo. All rights reserved.
+// Use of this source code is governed by the MIT License that can be found in
+// the LICENSE file.
+
+package mfile
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestApplyWindows(t *testing.T) {
+	testCases := []struct {
+		name              string
+		target            Target
+		expectedOSRecipes map[string][]string
+	}{
+		{
+			name:   "binary gets the .exe suffix",
+			target: Target{Name: "build-api", Recipe: []string{"@ go build -o bin/api ./cmd/api"}},
+			expectedOSRecipes: map[string][]string{
+				OSWindows: {"@ go build -o bin/api.exe ./cmd/api"},
+			},
+		},
+		{
+			name:   "POSIX shell recipe",
+			target: Target{Name: "run", Recipe: []string{"@ ./bin/$(BINARY_NAME)"}},
+			expectedOSRecipes: map[string][]string{
+				OSWindows: {`@ bin\$(BINARY_NAME).exe`},
+			},
+		},
+		{
+			name: "only the lines relying on a POSIX shell change",
+			target: Target{
+				Name:      "distclean",
+				Recipe:    []string{"@ rm -rf $(DISTCLEAN_ARTIFACTS)", "@ go clean -testcache"},
+				OSRecipes: map[string][]string{OSDarwin: {"@ rm -rf $(DISTCLEAN_ARTIFACTS)"}},
+			},
+			expectedOSRecipes: map[string][]string{
+				OSWindows: {`@ for %f in ($(DISTCLEAN_ARTIFACTS)) do @if exist %f (rmdir /S /Q %f 2> nul || del /Q %f)`, "@ go clean -testcache"},
+				OSDarwin:  {"@ rm -rf $(DISTCLEAN_ARTIFACTS)"},
+			},
+		},
+		{
+			name:   "portable recipe",
+			target: Target{Name: "test", Recipe: []string{"@ go test ./..."}},
+		},
+		{
+			name:              "existing Windows variant is kept",
+			target:            Target{Name: "build", Recipe: []string{"@ go build -o bin/app"}, OSRecipes: map[string][]string{OSWindows: {"@ build.cmd"}}},
+			expectedOSRecipes: map[string][]string{OSWindows: {"@ build.cmd"}},
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			targets := []Target{tc.target}
+			applyWindows(targets)
+			require.Equal(t, tc.expectedOSRecipes, targets[0].OSRecipes)
+		})
+	}
+}